@@ -68,6 +68,13 @@ type AutoscalingListenerSpec struct {
 	// +optional
 	Metrics *MetricsConfig `json:"metrics,omitempty"`
 
+	// +optional
+	RunnerResourceRequests corev1.ResourceList `json:"runnerResourceRequests,omitempty"`
+
+	// Prewarm makes the listener follow the prewarm hints on the ephemeral runner set.
+	// +optional
+	Prewarm bool `json:"prewarm,omitempty"`
+
 	// +optional
 	Template *corev1.PodTemplateSpec `json:"template,omitempty"`
 
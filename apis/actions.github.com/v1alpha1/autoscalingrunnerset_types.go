@@ -87,6 +87,11 @@ type AutoscalingRunnerSetSpec struct {
 	// +optional
 	ListenerMetrics *MetricsConfig `json:"listenerMetrics,omitempty"`
 
+	// ListenerPrewarm makes the listener follow the prewarm hints the github webhook server
+	// sets on the ephemeral runner set when jobs are queued for the scale set.
+	// +optional
+	ListenerPrewarm bool `json:"listenerPrewarm,omitempty"`
+
 	// +optional
 	ListenerTemplate *corev1.PodTemplateSpec `json:"listenerTemplate,omitempty"`
 
@@ -118,6 +123,9 @@ type AutoscalingRunnerSetSpec struct {
 	// +optional
 	// +kubebuilder:validation:Minimum:=0
 	MinRunners *int `json:"minRunners,omitempty"`
+
+	// +optional
+	CacheVolumePool *CacheVolumePoolConfig `json:"cacheVolumePool,omitempty"`
 }
 
 type TLSConfig struct {
@@ -294,6 +302,15 @@ type MetricsConfig struct {
 	Gauges map[string]*GaugeMetric `json:"gauges,omitempty"`
 	// +optional
 	Histograms map[string]*HistogramMetric `json:"histograms,omitempty"`
+	// +optional
+	PriceTable *PriceTable `json:"priceTable,omitempty"`
+	// +optional
+	Cardinality *CardinalityConfig `json:"cardinality,omitempty"`
+	// WorkflowJobMetrics enables the github_workflow_job_* metrics, compatible
+	// with the ones exposed by the actions metrics server, recorded from the
+	// messages received by the listener instead of webhooks.
+	// +optional
+	WorkflowJobMetrics bool `json:"workflowJobMetrics,omitempty"`
 }
 
 // CounterMetric holds configuration of a single metric of type Counter
@@ -312,6 +329,57 @@ type HistogramMetric struct {
 	Buckets []float64 `json:"buckets,omitempty"`
 }
 
+// PriceTable holds the prices used to compute the cost of the runner-seconds
+// consumed by jobs. Prices are expressed per hour in an arbitrary currency.
+type PriceTable struct {
+	// +optional
+	PerRunnerHour float64 `json:"perRunnerHour,omitempty"`
+	// +optional
+	PerCPUCoreHour float64 `json:"perCpuCoreHour,omitempty"`
+	// +optional
+	PerMemoryGiBHour float64 `json:"perMemoryGiBHour,omitempty"`
+}
+
+// CardinalityConfig protects the listener metrics against label values with unbounded cardinality
+type CardinalityConfig struct {
+	// MaxLabelValues caps the number of distinct values each label can take per metric.
+	// Values over the limit are folded into the "other" value. Zero means no limit.
+	// +optional
+	// +kubebuilder:validation:Minimum:=0
+	MaxLabelValues int `json:"maxLabelValues,omitempty"`
+	// Allowlist maps a label name to regular expressions matching values that are
+	// always kept and do not count towards MaxLabelValues.
+	// +optional
+	Allowlist map[string][]string `json:"allowlist,omitempty"`
+	// Rewrites are applied in order to label values before the limit is enforced.
+	// +optional
+	Rewrites []LabelRewrite `json:"rewrites,omitempty"`
+}
+
+// LabelRewrite replaces the value of a label matching a regular expression
+type LabelRewrite struct {
+	Label string `json:"label"`
+	Regex string `json:"regex"`
+	// Replacement may reference capture groups of the regex, e.g. ${1}
+	// +optional
+	Replacement string `json:"replacement,omitempty"`
+}
+
+// CacheVolumePoolConfig holds the configuration of a pool of persistent volume claims
+// leased to the runner pods, so that caches written to the volume survive across runners.
+type CacheVolumePoolConfig struct {
+	// VolumeName is the name of the pod volume backed by the leased claim.
+	// It replaces the volume with the same name in the runner pod template, if any,
+	// and falls back to an emptyDir volume when no claim in the pool is free.
+	VolumeName string `json:"volumeName"`
+	// Size is the number of claims kept in the pool.
+	// +kubebuilder:validation:Minimum:=0
+	Size int `json:"size"`
+	// VolumeClaimTemplate is used to create the claims in the pool. Free claims created
+	// from a previous version of the template are deleted and re-created.
+	VolumeClaimTemplate corev1.PersistentVolumeClaimTemplate `json:"volumeClaimTemplate"`
+}
+
 // AutoscalingRunnerSetStatus defines the observed state of AutoscalingRunnerSet
 type AutoscalingRunnerSetStatus struct {
 	// +optional
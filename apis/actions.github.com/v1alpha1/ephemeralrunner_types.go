@@ -126,6 +126,11 @@ type EphemeralRunnerSpec struct {
 	// +optional
 	EphemeralRunnerConfigSecretMetadata *ResourceMeta `json:"ephemeralRunnerConfigSecretMetadata,omitempty"`
 
+	// CacheVolumeName is the name of the pod volume backed by a claim
+	// leased from the cache volume pool of the scale set.
+	// +optional
+	CacheVolumeName string `json:"cacheVolumeName,omitempty"`
+
 	corev1.PodTemplateSpec `json:",inline"`
 }
 
@@ -179,6 +184,14 @@ type EphemeralRunnerStatus struct {
 
 	// +optional
 	JobDisplayName string `json:"jobDisplayName,omitempty"`
+
+	// PodCreatedAt is the time the current runner pod was created.
+	// +optional
+	PodCreatedAt *metav1.Time `json:"podCreatedAt,omitempty"`
+
+	// ReadyAt is the time the current runner pod became ready, i.e. the runner went online.
+	// +optional
+	ReadyAt *metav1.Time `json:"readyAt,omitempty"`
 }
 
 // EphemeralRunnerPhase is the phase of the ephemeral runner.
@@ -0,0 +1,47 @@
+package v1alpha1
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// AnnotationKeyPrewarmHint is the annotation on an EphemeralRunnerSet that holds a PrewarmHint.
+// It is set by the github webhook server on workflow_job queued events and read by the listener.
+const AnnotationKeyPrewarmHint = "actions.github.com/prewarm-hint"
+
+// PrewarmHint is a short-lived hint about jobs that were queued on GitHub for a runner scale set,
+// but might not be reflected in the runner scale set statistics yet.
+// The listener uses it as a temporary floor for the number of runners until it expires.
+// +kubebuilder:object:generate=false
+type PrewarmHint struct {
+	// Runners is the number of jobs queued while the hint was active.
+	Runners int `json:"runners"`
+	// ExpiresAt is the time the hint stops applying.
+	ExpiresAt metav1.Time `json:"expiresAt"`
+}
+
+// ParsePrewarmHint returns the PrewarmHint stored in the annotations, or nil when there is none.
+func ParsePrewarmHint(annotations map[string]string) (*PrewarmHint, error) {
+	v, ok := annotations[AnnotationKeyPrewarmHint]
+	if !ok || v == "" {
+		return nil, nil
+	}
+
+	var hint PrewarmHint
+	if err := json.Unmarshal([]byte(v), &hint); err != nil {
+		return nil, fmt.Errorf("failed to parse %s annotation: %w", AnnotationKeyPrewarmHint, err)
+	}
+
+	return &hint, nil
+}
+
+// Floor returns the number of runners the hint asks for at the given time, which is zero once it expired.
+func (h *PrewarmHint) Floor(now time.Time) int {
+	if h == nil || !now.Before(h.ExpiresAt.Time) {
+		return 0
+	}
+	return h.Runners
+}
@@ -0,0 +1,41 @@
+package v1alpha1_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/actions/actions-runner-controller/apis/actions.github.com/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePrewarmHint(t *testing.T) {
+	t.Parallel()
+
+	hint, err := v1alpha1.ParsePrewarmHint(nil)
+	require.NoError(t, err)
+	assert.Nil(t, hint)
+
+	hint, err = v1alpha1.ParsePrewarmHint(map[string]string{
+		v1alpha1.AnnotationKeyPrewarmHint: `{"runners":3,"expiresAt":"2026-01-01T00:02:00Z"}`,
+	})
+	require.NoError(t, err)
+	require.NotNil(t, hint)
+	assert.Equal(t, 3, hint.Runners)
+
+	expiresAt := time.Date(2026, 1, 1, 0, 2, 0, 0, time.UTC)
+	assert.Equal(t, 3, hint.Floor(expiresAt.Add(-time.Second)))
+	assert.Equal(t, 0, hint.Floor(expiresAt))
+
+	_, err = v1alpha1.ParsePrewarmHint(map[string]string{
+		v1alpha1.AnnotationKeyPrewarmHint: `not json`,
+	})
+	assert.Error(t, err)
+}
+
+func TestPrewarmHintFloorNil(t *testing.T) {
+	t.Parallel()
+
+	var hint *v1alpha1.PrewarmHint
+	assert.Equal(t, 0, hint.Floor(time.Now()))
+}
@@ -113,6 +113,13 @@ func (in *AutoscalingListenerSpec) DeepCopyInto(out *AutoscalingListenerSpec) {
 		*out = new(MetricsConfig)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.RunnerResourceRequests != nil {
+		in, out := &in.RunnerResourceRequests, &out.RunnerResourceRequests
+		*out = make(v1.ResourceList, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val.DeepCopy()
+		}
+	}
 	if in.Template != nil {
 		in, out := &in.Template, &out.Template
 		*out = new(v1.PodTemplateSpec)
@@ -308,6 +315,11 @@ func (in *AutoscalingRunnerSetSpec) DeepCopyInto(out *AutoscalingRunnerSetSpec)
 		*out = new(int)
 		**out = **in
 	}
+	if in.CacheVolumePool != nil {
+		in, out := &in.CacheVolumePool, &out.CacheVolumePool
+		*out = new(CacheVolumePoolConfig)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AutoscalingRunnerSetSpec.
@@ -350,6 +362,58 @@ func (in *AzureKeyVaultConfig) DeepCopy() *AzureKeyVaultConfig {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CacheVolumePoolConfig) DeepCopyInto(out *CacheVolumePoolConfig) {
+	*out = *in
+	in.VolumeClaimTemplate.DeepCopyInto(&out.VolumeClaimTemplate)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CacheVolumePoolConfig.
+func (in *CacheVolumePoolConfig) DeepCopy() *CacheVolumePoolConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(CacheVolumePoolConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CardinalityConfig) DeepCopyInto(out *CardinalityConfig) {
+	*out = *in
+	if in.Allowlist != nil {
+		in, out := &in.Allowlist, &out.Allowlist
+		*out = make(map[string][]string, len(*in))
+		for key, val := range *in {
+			var outVal []string
+			if val == nil {
+				(*out)[key] = nil
+			} else {
+				inVal := (*in)[key]
+				in, out := &inVal, &outVal
+				*out = make([]string, len(*in))
+				copy(*out, *in)
+			}
+			(*out)[key] = outVal
+		}
+	}
+	if in.Rewrites != nil {
+		in, out := &in.Rewrites, &out.Rewrites
+		*out = make([]LabelRewrite, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CardinalityConfig.
+func (in *CardinalityConfig) DeepCopy() *CardinalityConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(CardinalityConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *CounterMetric) DeepCopyInto(out *CounterMetric) {
 	*out = *in
@@ -570,6 +634,14 @@ func (in *EphemeralRunnerStatus) DeepCopyInto(out *EphemeralRunnerStatus) {
 			(*out)[key] = *val.DeepCopy()
 		}
 	}
+	if in.PodCreatedAt != nil {
+		in, out := &in.PodCreatedAt, &out.PodCreatedAt
+		*out = (*in).DeepCopy()
+	}
+	if in.ReadyAt != nil {
+		in, out := &in.ReadyAt, &out.ReadyAt
+		*out = (*in).DeepCopy()
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EphemeralRunnerStatus.
@@ -627,6 +699,21 @@ func (in *HistogramMetric) DeepCopy() *HistogramMetric {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LabelRewrite) DeepCopyInto(out *LabelRewrite) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LabelRewrite.
+func (in *LabelRewrite) DeepCopy() *LabelRewrite {
+	if in == nil {
+		return nil
+	}
+	out := new(LabelRewrite)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *MetricsConfig) DeepCopyInto(out *MetricsConfig) {
 	*out = *in
@@ -678,6 +765,16 @@ func (in *MetricsConfig) DeepCopyInto(out *MetricsConfig) {
 			(*out)[key] = outVal
 		}
 	}
+	if in.PriceTable != nil {
+		in, out := &in.PriceTable, &out.PriceTable
+		*out = new(PriceTable)
+		**out = **in
+	}
+	if in.Cardinality != nil {
+		in, out := &in.Cardinality, &out.Cardinality
+		*out = new(CardinalityConfig)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MetricsConfig.
@@ -690,6 +787,21 @@ func (in *MetricsConfig) DeepCopy() *MetricsConfig {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PriceTable) DeepCopyInto(out *PriceTable) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PriceTable.
+func (in *PriceTable) DeepCopy() *PriceTable {
+	if in == nil {
+		return nil
+	}
+	out := new(PriceTable)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ProxyConfig) DeepCopyInto(out *ProxyConfig) {
 	*out = *in
@@ -85,13 +85,14 @@ type CheckRunSpec struct {
 	Status string   `json:"status,omitempty"`
 
 	// Names is a list of GitHub Actions glob patterns.
-	// Any check_run event whose name matches one of patterns in the list can trigger autoscaling.
+	// Any check_run event whose name matches the patterns in the list can trigger autoscaling.
+	// The patterns are evaluated in order, so that a pattern starting with `!` excludes names matched by the preceding ones.
 	// Note that check_run name seem to equal to the job name you've defined in your actions workflow yaml file.
 	// So it is very likely that you can utilize this to trigger depending on the job.
 	Names []string `json:"names,omitempty"`
 
-	// Repositories is a list of GitHub repositories.
-	// Any check_run event whose repository matches one of repositories in the list can trigger autoscaling.
+	// Repositories is a list of GitHub Actions glob patterns of repositories, either of the name or in the owner/name form.
+	// Any check_run event whose repository matches the patterns in the list can trigger autoscaling.
 	Repositories []string `json:"repositories,omitempty"`
 }
 
@@ -133,7 +134,7 @@ type ScaleTargetRef struct {
 
 type MetricSpec struct {
 	// Type is the type of metric to be used for autoscaling.
-	// It can be TotalNumberOfQueuedAndInProgressWorkflowRuns or PercentageRunnersBusy.
+	// It can be TotalNumberOfQueuedAndInProgressWorkflowRuns, PercentageRunnersBusy or External.
 	Type string `json:"type,omitempty"`
 
 	// RepositoryNames is the list of repository names to be used for calculating the metric.
@@ -170,6 +171,61 @@ type MetricSpec struct {
 	// You can only specify either ScaleDownFactor or ScaleDownAdjustment.
 	// +optional
 	ScaleDownAdjustment int `json:"scaleDownAdjustment,omitempty"`
+
+	// External is the source of the metric when Type is External.
+	// The value of the metric divided by the current number of desired runners is compared
+	// against ScaleUpThreshold and ScaleDownThreshold, the same way as the percentage of busy runners.
+	// +optional
+	External *ExternalMetricSource `json:"external,omitempty"`
+}
+
+// ExternalMetricSource is the source of a metric of the External type.
+// Exactly one of Prometheus and Kubernetes must be specified.
+type ExternalMetricSource struct {
+	// Prometheus reads the metric from a Prometheus-compatible HTTP API.
+	// +optional
+	Prometheus *PrometheusMetricSource `json:"prometheus,omitempty"`
+
+	// Kubernetes reads the metric from the external or custom metrics API of Kubernetes.
+	// +optional
+	Kubernetes *KubernetesMetricSource `json:"kubernetes,omitempty"`
+}
+
+// PrometheusMetricSource reads a metric with an instant query against a Prometheus-compatible HTTP API.
+type PrometheusMetricSource struct {
+	// Address is the base URL of the Prometheus-compatible HTTP API, like http://prometheus.monitoring:9090.
+	// It must be one of the addresses the controller is allowed to query with its --prometheus-address flag.
+	Address string `json:"address"`
+
+	// Query is the PromQL expression to evaluate.
+	// The values of all the returned samples are summed, and an empty result is read as 0.
+	Query string `json:"query"`
+}
+
+// KubernetesMetricSource reads a metric from the external or custom metrics API of Kubernetes,
+// in the namespace of the HorizontalRunnerAutoscaler.
+type KubernetesMetricSource struct {
+	// Name is the name of the metric.
+	Name string `json:"name"`
+
+	// Selector restricts the series of the metric that are read.
+	// The values of all the selected series are summed.
+	// +optional
+	Selector *metav1.LabelSelector `json:"selector,omitempty"`
+
+	// Object is the object the custom metric describes.
+	// The metric is read from the external metrics API when omitted.
+	// +optional
+	Object *MetricObjectReference `json:"object,omitempty"`
+}
+
+// MetricObjectReference refers to the object described by a custom metric.
+type MetricObjectReference struct {
+	// Resource is the plural name of the resource of the object, like services.
+	Resource string `json:"resource"`
+
+	// Name is the name of the object.
+	Name string `json:"name"`
 }
 
 // ScheduledOverride can be used to override a few fields of HorizontalRunnerAutoscalerSpec on schedule.
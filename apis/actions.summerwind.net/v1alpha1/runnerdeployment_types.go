@@ -17,12 +17,17 @@ limitations under the License.
 package v1alpha1
 
 import (
+	"fmt"
+
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/validation/field"
 )
 
 const (
 	AutoscalingMetricTypeTotalNumberOfQueuedAndInProgressWorkflowRuns = "TotalNumberOfQueuedAndInProgressWorkflowRuns"
 	AutoscalingMetricTypePercentageRunnersBusy                        = "PercentageRunnersBusy"
+	AutoscalingMetricTypeExternal                                     = "External"
 )
 
 // RunnerDeploymentSpec defines the desired state of RunnerDeployment
@@ -43,6 +48,124 @@ type RunnerDeploymentSpec struct {
 	// +nullable
 	Selector *metav1.LabelSelector `json:"selector"`
 	Template RunnerTemplate        `json:"template"`
+
+	// Strategy is the strategy used to replace the runners of an outdated template with new ones.
+	// When omitted, all the new runners are created at once, and the outdated runners are removed
+	// once all of them are available.
+	//
+	// +optional
+	Strategy *RunnerDeploymentStrategy `json:"strategy,omitempty"`
+
+	// ProgressDeadlineSeconds is the maximum time in seconds for a rollout to make progress before it is reported
+	// as failed in the Progressing condition. The rollout continues regardless, as busy runners can take a long time to finish their jobs.
+	// Only used by the RollingUpdate strategy. Defaults to 600s.
+	//
+	// +optional
+	ProgressDeadlineSeconds *int32 `json:"progressDeadlineSeconds,omitempty"`
+}
+
+// +kubebuilder:validation:Enum=RollingUpdate
+type RunnerDeploymentStrategyType string
+
+const (
+	// RollingUpdateRunnerDeploymentStrategyType replaces the outdated runners gradually, within the limits of RollingUpdate.
+	RollingUpdateRunnerDeploymentStrategyType RunnerDeploymentStrategyType = "RollingUpdate"
+)
+
+type RunnerDeploymentStrategy struct {
+	// Type of the strategy. Only RollingUpdate is supported.
+	Type RunnerDeploymentStrategyType `json:"type"`
+
+	// RollingUpdate configures the rolling update when Type is RollingUpdate.
+	//
+	// +optional
+	RollingUpdate *RollingUpdateRunnerDeployment `json:"rollingUpdate,omitempty"`
+}
+
+type RollingUpdateRunnerDeployment struct {
+	// MaxSurge is the maximum number of runners, or percentage of the desired runners, that can exist
+	// above the desired number of runners during the rollout.
+	// Outdated runners that are busy running a job count towards it until they finish the job.
+	// Defaults to 25%. Percentages are rounded up.
+	//
+	// +optional
+	MaxSurge *intstr.IntOrString `json:"maxSurge,omitempty"`
+
+	// MaxUnavailable is the maximum number of runners, or percentage of the desired runners, that can be unavailable during the rollout.
+	// Defaults to 25%. Percentages are rounded down. It cannot be 0 when MaxSurge is 0.
+	//
+	// +optional
+	MaxUnavailable *intstr.IntOrString `json:"maxUnavailable,omitempty"`
+}
+
+var defaultRollingUpdateValue = intstr.FromString("25%")
+
+// ResolveRollingUpdate returns the absolute maximum surge and maximum unavailable runners for the desired replicas,
+// the same way as Deployments do. Both are never 0 at the same time, so that the rollout can always progress.
+func (s *RunnerDeploymentStrategy) ResolveRollingUpdate(replicas int) (int, int, error) {
+	maxSurge, maxUnavailable := &defaultRollingUpdateValue, &defaultRollingUpdateValue
+	if s != nil && s.RollingUpdate != nil {
+		if s.RollingUpdate.MaxSurge != nil {
+			maxSurge = s.RollingUpdate.MaxSurge
+		}
+		if s.RollingUpdate.MaxUnavailable != nil {
+			maxUnavailable = s.RollingUpdate.MaxUnavailable
+		}
+	}
+
+	surge, err := intstr.GetScaledValueFromIntOrPercent(maxSurge, replicas, true)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid maxSurge: %w", err)
+	}
+
+	unavailable, err := intstr.GetScaledValueFromIntOrPercent(maxUnavailable, replicas, false)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid maxUnavailable: %w", err)
+	}
+
+	if surge < 0 || unavailable < 0 {
+		return 0, 0, fmt.Errorf("maxSurge and maxUnavailable must not be negative")
+	}
+
+	if surge == 0 && unavailable == 0 {
+		unavailable = 1
+	}
+
+	return surge, unavailable, nil
+}
+
+func (s *RunnerDeploymentSpec) validateStrategy(rootPath *field.Path) field.ErrorList {
+	var errList field.ErrorList
+
+	if s.ProgressDeadlineSeconds != nil && *s.ProgressDeadlineSeconds <= 0 {
+		errList = append(errList, field.Invalid(rootPath.Child("progressDeadlineSeconds"), *s.ProgressDeadlineSeconds, "must be greater than 0"))
+	}
+
+	if s.Strategy == nil || s.Strategy.RollingUpdate == nil {
+		return errList
+	}
+
+	path := rootPath.Child("strategy", "rollingUpdate")
+
+	if s.Strategy.Type != RollingUpdateRunnerDeploymentStrategyType {
+		errList = append(errList, field.Forbidden(path, "may only be specified when type is RollingUpdate"))
+	}
+
+	// Percentages are validated against 100 replicas so that a maxSurge and maxUnavailable of 0 is reported regardless of the replicas.
+	ru := s.Strategy.RollingUpdate
+	surge, err := intstr.GetScaledValueFromIntOrPercent(intstr.ValueOrDefault(ru.MaxSurge, defaultRollingUpdateValue), 100, true)
+	if err != nil || surge < 0 {
+		errList = append(errList, field.Invalid(path.Child("maxSurge"), ru.MaxSurge, "must be a non-negative integer or percentage"))
+	}
+	unavailable, err := intstr.GetScaledValueFromIntOrPercent(intstr.ValueOrDefault(ru.MaxUnavailable, defaultRollingUpdateValue), 100, false)
+	if err != nil || unavailable < 0 {
+		errList = append(errList, field.Invalid(path.Child("maxUnavailable"), ru.MaxUnavailable, "must be a non-negative integer or percentage"))
+	}
+	if surge == 0 && unavailable == 0 {
+		errList = append(errList, field.Invalid(path.Child("maxUnavailable"), ru.MaxUnavailable, "must not be 0 when maxSurge is 0"))
+	}
+
+	return errList
 }
 
 type RunnerDeploymentStatus struct {
@@ -72,6 +195,52 @@ type RunnerDeploymentStatus struct {
 	// Replicas is the total number of replicas
 	// +optional
 	Replicas *int `json:"replicas"`
+
+	// ObservedGeneration is the generation of the RunnerDeployment the status was computed for.
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// Conditions reports the progress of the rollout of the runner template with the RollingUpdate strategy.
+	// +optional
+	// +listType=map
+	// +listMapKey=type
+	Conditions []RunnerDeploymentCondition `json:"conditions,omitempty"`
+}
+
+type RunnerDeploymentConditionType string
+
+const (
+	// RunnerDeploymentProgressing is True while a rollout makes progress or has completed,
+	// and False once it made no progress within ProgressDeadlineSeconds.
+	RunnerDeploymentProgressing RunnerDeploymentConditionType = "Progressing"
+)
+
+// Reasons of the RunnerDeploymentProgressing condition.
+const (
+	RunnerDeploymentReasonNewReplicaSetCreated     = "NewRunnerReplicaSetCreated"
+	RunnerDeploymentReasonReplicaSetUpdated        = "RunnerReplicaSetUpdated"
+	RunnerDeploymentReasonNewReplicaSetAvailable   = "NewRunnerReplicaSetAvailable"
+	RunnerDeploymentReasonProgressDeadlineExceeded = "ProgressDeadlineExceeded"
+)
+
+// RunnerDeploymentCondition mirrors the conditions of Deployments, which have a LastUpdateTime
+// that the progress deadline is computed from.
+type RunnerDeploymentCondition struct {
+	Type   RunnerDeploymentConditionType `json:"type"`
+	Status metav1.ConditionStatus        `json:"status"`
+
+	// LastUpdateTime is the last time the rollout made progress.
+	// +optional
+	LastUpdateTime metav1.Time `json:"lastUpdateTime,omitempty"`
+
+	// +optional
+	LastTransitionTime metav1.Time `json:"lastTransitionTime,omitempty"`
+
+	// +optional
+	Reason string `json:"reason,omitempty"`
+
+	// +optional
+	Message string `json:"message,omitempty"`
 }
 
 // +kubebuilder:object:root=true
@@ -74,6 +74,7 @@ func (*RunnerDeploymentValidator) ValidateDelete(context.Context, *RunnerDeploym
 // Validate validates resource spec.
 func (r *RunnerDeployment) Validate() error {
 	errList := r.Spec.Template.Spec.Validate(field.NewPath("spec", "template", "spec"))
+	errList = append(errList, r.Spec.validateStrategy(field.NewPath("spec"))...)
 
 	if len(errList) > 0 {
 		return apierrors.NewInvalid(r.GroupVersionKind().GroupKind(), r.Name, errList)
@@ -50,6 +50,9 @@ args:
 {{- with .Values.controller.manager.config.runnerMaxConcurrentReconciles }}
   - "--runner-max-concurrent-reconciles={{ . }}"
 {{- end }}
+{{- if .Values.controller.manager.config.runnerEvictionProtection }}
+  - "--runner-eviction-protection"
+{{- end }}
 {{- if .Values.controller.metrics }}
 {{- with .Values.controller.metrics }}
   - "--listener-metrics-addr={{ .listenerAddr }}"
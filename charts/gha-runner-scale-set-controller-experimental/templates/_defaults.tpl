@@ -33,6 +33,14 @@ app.kubernetes.io/instance: {{ .Release.Name }}
 {{- include "gha-controller.name" . }}
 {{- end }}
 
+{{- define "gha-controller.manager-node-cluster-role-name" -}}
+{{- include "gha-controller.name" . }}-node
+{{- end }}
+
+{{- define "gha-controller.manager-node-cluster-role-binding" -}}
+{{- include "gha-controller.name" . }}-node
+{{- end }}
+
 {{- define "gha-controller.manager-single-namespace-role-name" -}}
 {{- include "gha-controller.name" . }}-single-namespace
 {{- end }}
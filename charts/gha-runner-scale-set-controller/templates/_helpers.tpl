@@ -98,6 +98,14 @@ Create the name of the service account to use
 {{- include "gha-runner-scale-set-controller.fullname" . }}
 {{- end }}
 
+{{- define "gha-runner-scale-set-controller.managerNodeClusterRoleName" -}}
+{{- include "gha-runner-scale-set-controller.fullname" . }}-node
+{{- end }}
+
+{{- define "gha-runner-scale-set-controller.managerNodeClusterRoleBinding" -}}
+{{- include "gha-runner-scale-set-controller.fullname" . }}-node
+{{- end }}
+
 {{- define "gha-runner-scale-set-controller.managerSingleNamespaceRoleName" -}}
 {{- include "gha-runner-scale-set-controller.fullname" . }}-single-namespace
 {{- end }}
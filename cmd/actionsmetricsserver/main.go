@@ -24,11 +24,13 @@ import (
 	"net/http"
 	"os"
 	"sync"
+	"time"
 
 	actionsv1alpha1 "github.com/actions/actions-runner-controller/apis/actions.summerwind.net/v1alpha1"
 	"github.com/actions/actions-runner-controller/github"
 	"github.com/actions/actions-runner-controller/logging"
 	"github.com/actions/actions-runner-controller/pkg/actionsmetrics"
+	"github.com/actions/actions-runner-controller/pkg/webhooksecret"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"sigs.k8s.io/controller-runtime/pkg/metrics"
 
@@ -40,6 +42,7 @@ import (
 	_ "k8s.io/client-go/plugin/pkg/client/auth/gcp"
 	_ "k8s.io/client-go/plugin/pkg/client/auth/oidc"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	// +kubebuilder:scaffold:imports
 )
 
@@ -69,9 +72,24 @@ func main() {
 		webhookSecretToken    string
 		webhookSecretTokenEnv string
 
+		webhookSecretTokensFile           string
+		webhookSecretTokensReloadInterval time.Duration
+		rejectUnsignedWebhooks            bool
+
 		logLevel  string
 		logFormat string
 
+		logAnalysisRulesFile           string
+		githubAPICredentialsRoutesFile string
+
+		jobStateStore                  bool
+		jobStateStoreFile              string
+		jobStateStoreConfigMap         string
+		jobStateStoreNamespace         string
+		jobStateStoreTTL               time.Duration
+		jobStateStoreSnapshotInterval  time.Duration
+		logAnalysisRulesReloadInterval time.Duration
+
 		ghClient *github.Client
 	)
 
@@ -88,6 +106,9 @@ func main() {
 	flag.StringVar(&metricsAddr, "metrics-addr", ":8080", "The address the metric endpoint binds to.")
 	flag.StringVar(&logLevel, "log-level", logging.LogLevelDebug, `The verbosity of the logging. Valid values are "debug", "info", "warn", "error". Defaults to "debug".`)
 	flag.StringVar(&webhookSecretToken, "github-webhook-secret-token", "", "The personal access token of GitHub.")
+	flag.StringVar(&webhookSecretTokensFile, "github-webhook-secret-tokens-file", "", "The path of a file with a webhook secret token per line, or of a directory with a token per file like a mounted Secret. The tokens are tried in order before -github-webhook-secret-token, and reloaded whenever they change, so that the webhook secret can be rotated without downtime.")
+	flag.DurationVar(&webhookSecretTokensReloadInterval, "github-webhook-secret-tokens-reload-interval", 30*time.Second, "The interval to check the webhook secret tokens file for changes.")
+	flag.BoolVar(&rejectUnsignedWebhooks, "reject-unsigned-webhooks", false, "Reject the webhook payloads without signature, and every payload while no webhook secret token is configured, instead of accepting them unverified.")
 	flag.StringVar(&c.Token, "github-token", c.Token, "The personal access token of GitHub.")
 	flag.Int64Var(&c.AppID, "github-app-id", c.AppID, "The application ID of GitHub App.")
 	flag.Int64Var(&c.AppInstallationID, "github-app-installation-id", c.AppInstallationID, "The installation ID of GitHub App.")
@@ -98,6 +119,15 @@ func main() {
 	flag.StringVar(&c.BasicauthPassword, "github-basicauth-password", c.BasicauthPassword, "Password for GitHub basic auth to use instead of PAT or GitHub APP in case it's running behind a proxy API")
 	flag.StringVar(&c.RunnerGitHubURL, "runner-github-url", c.RunnerGitHubURL, "GitHub URL to be used by runners during registration")
 	flag.StringVar(&logFormat, "log-format", "text", `The log format. Valid options are "text" and "json". Defaults to "text"`)
+	flag.StringVar(&logAnalysisRulesFile, "log-analysis-rules-file", "", "The path of the YAML file of the rules to analyze the workflow job logs with. The file is reloaded whenever it changes. The built-in rules are used when omitted.")
+	flag.StringVar(&githubAPICredentialsRoutesFile, "github-api-credentials-routes-file", "", "The path of the YAML file that routes the workflow jobs of organizations and enterprises to their own GitHub API credentials to read the job logs with. The jobs matching no route use the credentials specified by the flags and envvars, and only the metrics available from the webhook events are recorded for them when there are none.")
+	flag.BoolVar(&jobStateStore, "job-state-store", false, "Track the times of the events of the in-flight workflow jobs, so that their queue and run durations are observed from the webhook events alone when their logs can't be read.")
+	flag.StringVar(&jobStateStoreFile, "job-state-store-file", "", "The path of the file to snapshot the tracked workflow jobs to, so that they survive restarts. Implies -job-state-store.")
+	flag.StringVar(&jobStateStoreConfigMap, "job-state-store-configmap", "", "The name of the ConfigMap to snapshot the tracked workflow jobs to, so that they survive restarts. Implies -job-state-store.")
+	flag.StringVar(&jobStateStoreNamespace, "job-state-store-namespace", "", "The namespace of the ConfigMap specified by -job-state-store-configmap.")
+	flag.DurationVar(&jobStateStoreTTL, "job-state-store-ttl", actionsmetrics.DefaultJobStateTTL, "How long a workflow job is tracked without any event before it is forgotten.")
+	flag.DurationVar(&jobStateStoreSnapshotInterval, "job-state-store-snapshot-interval", 30*time.Second, "The interval to snapshot the tracked workflow jobs at.")
+	flag.DurationVar(&logAnalysisRulesReloadInterval, "log-analysis-rules-reload-interval", 30*time.Second, "The interval to check the log analysis rules file for changes.")
 
 	flag.Parse()
 
@@ -113,12 +143,30 @@ func main() {
 		webhookSecretToken = webhookSecretTokenEnv
 	}
 
-	if webhookSecretToken == "" {
+	if webhookSecretToken == "" && webhookSecretTokensFile == "" {
 		logger.Info(fmt.Sprintf("-github-webhook-secret-token and %s are missing or empty. Create one following https://docs.github.com/en/developers/webhooks-and-events/securing-your-webhooks and specify it via the flag or the envvar", webhookSecretTokenEnvName))
 	}
 
+	if jobStateStoreFile != "" && jobStateStoreConfigMap != "" {
+		fmt.Fprintln(os.Stderr, "Error: only one of -job-state-store-file and -job-state-store-configmap can be set")
+		os.Exit(1)
+	}
+
+	if jobStateStoreConfigMap != "" && jobStateStoreNamespace == "" {
+		fmt.Fprintln(os.Stderr, "Error: -job-state-store-namespace is required when -job-state-store-configmap is set")
+		os.Exit(1)
+	}
+
 	ctrl.SetLogger(logger)
 
+	var staticWebhookSecrets []webhooksecret.Secret
+	if webhookSecretToken != "" {
+		staticWebhookSecrets = append(staticWebhookSecrets, webhooksecret.Secret{Name: "default", Key: []byte(webhookSecretToken)})
+	}
+
+	webhookSecrets := webhooksecret.NewVerifier("actions-metrics-server", staticWebhookSecrets...)
+	webhookSecrets.RejectUnsigned = rejectUnsignedWebhooks
+
 	// Valid GitHub API credentials is required to call get workflow job logs
 	if len(c.Token) > 0 || (c.AppID > 0 && c.AppInstallationID > 0 && c.AppPrivateKey != "") || (len(c.BasicauthUsername) > 0 && len(c.BasicauthPassword) > 0) {
 		c.Log = &logger
@@ -133,23 +181,98 @@ func main() {
 		logger.Info("GitHub client is not initialized. Runner groups with custom visibility are not supported. If needed, please provide GitHub authentication. This will incur in extra GitHub API calls")
 	}
 
+	var ghClients *actionsmetrics.GitHubClientRouter
+
+	if githubAPICredentialsRoutesFile != "" {
+		c.Log = &logger
+
+		routes, err := actionsmetrics.LoadGitHubClientRoutes(githubAPICredentialsRoutesFile)
+		if err == nil {
+			ghClients, err = actionsmetrics.NewGitHubClientRouter(routes, c)
+		}
+		if err != nil {
+			logger.Error(err, "unable to load the GitHub API credentials routes")
+			os.Exit(1)
+		}
+	}
+
+	var jobStore *actionsmetrics.JobStore
+
+	if jobStateStore || jobStateStoreFile != "" || jobStateStoreConfigMap != "" {
+		var snapshotter actionsmetrics.JobSnapshotter
+
+		switch {
+		case jobStateStoreFile != "":
+			snapshotter = &actionsmetrics.FileJobSnapshotter{Path: jobStateStoreFile}
+		case jobStateStoreConfigMap != "":
+			kubeClient, err := client.New(ctrl.GetConfigOrDie(), client.Options{Scheme: scheme})
+			if err != nil {
+				logger.Error(err, "unable to create kubernetes client")
+				os.Exit(1)
+			}
+
+			snapshotter = &actionsmetrics.ConfigMapJobSnapshotter{
+				Name:      jobStateStoreConfigMap,
+				Namespace: jobStateStoreNamespace,
+				Client:    kubeClient,
+				TTL:       jobStateStoreTTL,
+			}
+		}
+
+		jobStore = actionsmetrics.NewJobStore(ctrl.Log.WithName("workflowjobmetrics-jobstore"), jobStateStoreTTL, snapshotter)
+
+		if err := jobStore.Load(context.Background()); err != nil {
+			logger.Error(err, "unable to load the workflow job states")
+			os.Exit(1)
+		}
+
+		logger.Info("tracking in-flight workflow jobs", "jobs", jobStore.Len())
+	}
+
+	logAnalyzer, err := actionsmetrics.NewLogAnalyzer(actionsmetrics.DefaultLogRules)
+	if err != nil {
+		logger.Error(err, "unable to load the built-in log analysis rules")
+		os.Exit(1)
+	}
+
+	if logAnalysisRulesFile != "" {
+		rules, err := actionsmetrics.LoadLogRules(logAnalysisRulesFile)
+		if err == nil {
+			err = logAnalyzer.SetRules(rules)
+		}
+		if err != nil {
+			logger.Error(err, "unable to load the log analysis rules")
+			os.Exit(1)
+		}
+	}
+
 	eventReader := &actionsmetrics.EventReader{
-		Log:          ctrl.Log.WithName("workflowjobmetrics-eventreader"),
-		GitHubClient: ghClient,
-		Events:       make(chan interface{}, 1024*1024),
+		Log:           ctrl.Log.WithName("workflowjobmetrics-eventreader"),
+		GitHubClient:  ghClient,
+		GitHubClients: ghClients,
+		LogAnalyzer:   logAnalyzer,
+		JobStore:      jobStore,
+		Events:        make(chan interface{}, 1024*1024),
 	}
 
 	webhookServer := &actionsmetrics.WebhookServer{
-		Log:            ctrl.Log.WithName("workflowjobmetrics-webhookserver"),
-		SecretKeyBytes: []byte(webhookSecretToken),
-		GitHubClient:   ghClient,
-		EventHooks:     []actionsmetrics.EventHook{eventReader.HandleWorkflowJobEvent},
+		Log:                   ctrl.Log.WithName("workflowjobmetrics-webhookserver"),
+		Secrets:               webhookSecrets,
+		GitHubClient:          ghClient,
+		WorkflowJobEventHooks: []actionsmetrics.WorkflowJobEventHook{eventReader.HandleWorkflowJobEventOfEnterprise},
 	}
 
 	var wg sync.WaitGroup
 
 	ctx, cancel := context.WithCancel(context.Background())
 
+	if webhookSecretTokensFile != "" {
+		if err := webhookSecrets.Watch(ctx, ctrl.Log.WithName("workflowjobmetrics-webhooksecrets"), webhookSecretTokensFile, webhookSecretTokensReloadInterval, staticWebhookSecrets...); err != nil {
+			logger.Error(err, "unable to load the webhook secret tokens")
+			os.Exit(1)
+		}
+	}
+
 	wg.Add(1)
 	go func() {
 		defer cancel()
@@ -157,6 +280,22 @@ func main() {
 		eventReader.ProcessWorkflowJobEvents(ctx)
 	}()
 
+	if jobStore != nil {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			jobStore.Run(ctx, jobStateStoreSnapshotInterval)
+		}()
+	}
+
+	if logAnalysisRulesFile != "" {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			actionsmetrics.WatchLogRulesFile(ctx, ctrl.Log.WithName("workflowjobmetrics-logrules"), logAnalyzer, logAnalysisRulesFile, logAnalysisRulesReloadInterval)
+		}()
+	}
+
 	// Metrics Server
 
 	metricsHandler := promhttp.HandlerFor(metrics.Registry, promhttp.HandlerOpts{
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+
+	summerwindv1alpha1 "github.com/actions/actions-runner-controller/apis/actions.summerwind.net/v1alpha1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+	"sigs.k8s.io/yaml"
+)
+
+// input is the set of resources read from the manifests to migrate.
+type input struct {
+	runnerDeployments []summerwindv1alpha1.RunnerDeployment
+	autoscalers       []summerwindv1alpha1.HorizontalRunnerAutoscaler
+	// skipped are the resources of the other kinds, which are reported but not migrated.
+	skipped []unstructured.Unstructured
+}
+
+// decode reads the multi-document YAML manifests, including List kinds as returned by kubectl get -o yaml.
+func decode(r io.Reader, in *input) error {
+	reader := utilyaml.NewYAMLReader(bufio.NewReader(r))
+
+	for {
+		doc, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read manifest: %w", err)
+		}
+
+		if len(bytes.TrimSpace(doc)) == 0 {
+			continue
+		}
+
+		if err := decodeObject(doc, in); err != nil {
+			return err
+		}
+	}
+}
+
+func decodeObject(doc []byte, in *input) error {
+	var u unstructured.Unstructured
+	if err := yaml.Unmarshal(doc, &u.Object); err != nil {
+		return fmt.Errorf("failed to parse manifest: %w", err)
+	}
+
+	if len(u.Object) == 0 {
+		return nil
+	}
+
+	if u.IsList() {
+		list, err := u.ToList()
+		if err != nil {
+			return fmt.Errorf("failed to parse list: %w", err)
+		}
+
+		for _, item := range list.Items {
+			b, err := yaml.Marshal(item.Object)
+			if err != nil {
+				return err
+			}
+			if err := decodeObject(b, in); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	gvk := u.GroupVersionKind()
+
+	if gvk.Group != summerwindv1alpha1.GroupVersion.Group {
+		in.skipped = append(in.skipped, u)
+		return nil
+	}
+
+	switch gvk.Kind {
+	case "RunnerDeployment":
+		var rd summerwindv1alpha1.RunnerDeployment
+		if err := yaml.Unmarshal(doc, &rd); err != nil {
+			return fmt.Errorf("failed to parse RunnerDeployment %s: %w", u.GetName(), err)
+		}
+		in.runnerDeployments = append(in.runnerDeployments, rd)
+	case "HorizontalRunnerAutoscaler":
+		var hra summerwindv1alpha1.HorizontalRunnerAutoscaler
+		if err := yaml.Unmarshal(doc, &hra); err != nil {
+			return fmt.Errorf("failed to parse HorizontalRunnerAutoscaler %s: %w", u.GetName(), err)
+		}
+		in.autoscalers = append(in.autoscalers, hra)
+	default:
+		in.skipped = append(in.skipped, u)
+	}
+
+	return nil
+}
@@ -0,0 +1,144 @@
+// arcmigrate converts the RunnerDeployments and HorizontalRunnerAutoscalers of actions-runner-controller
+// into AutoscalingRunnerSets of the gha-runner-scale-set controller.
+//
+// It reads manifests, as written or as returned by kubectl get -o yaml, and writes the AutoscalingRunnerSets
+// to stdout. The fields that have no equivalent, or whose behavior changes, are reported on stderr.
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/yaml"
+)
+
+func main() {
+	opts := defaultOptions()
+	var path string
+	var strict bool
+
+	flag.StringVar(&path, "f", "-", "Path of the manifests to migrate, or - to read them from stdin.")
+	flag.StringVar(&opts.GitHubURL, "github-url", opts.GitHubURL, "URL of GitHub or GitHub Enterprise Server the runners are registered to.")
+	flag.StringVar(&opts.GitHubConfigSecret, "github-config-secret", opts.GitHubConfigSecret, "Secret holding the GitHub credentials, used for the RunnerDeployments that have no githubAPICredentialsFrom.")
+	flag.StringVar(&opts.RunnerImage, "runner-image", opts.RunnerImage, "Runner image replacing the images built for actions-runner-controller runners.")
+	flag.StringVar(&opts.Namespace, "namespace", opts.Namespace, "Namespace of the AutoscalingRunnerSets. Defaults to the namespace of each RunnerDeployment.")
+	flag.BoolVar(&strict, "strict", false, "Exit with a non-zero status when some fields could not be migrated as is.")
+	flag.Parse()
+
+	n, err := run(path, opts, os.Stdin, os.Stdout, os.Stderr)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	if strict && n > 0 {
+		os.Exit(2)
+	}
+}
+
+// run migrates the manifests at path, and returns the number of findings reported.
+func run(path string, opts options, stdin io.Reader, stdout, stderr io.Writer) (int, error) {
+	var in input
+
+	if path == "-" {
+		if err := decode(stdin, &in); err != nil {
+			return 0, err
+		}
+	} else {
+		f, err := os.Open(path)
+		if err != nil {
+			return 0, fmt.Errorf("failed to open manifests: %w", err)
+		}
+		defer f.Close()
+
+		if err := decode(f, &in); err != nil {
+			return 0, err
+		}
+	}
+
+	res := migrate(in, opts)
+
+	out, err := render(res)
+	if err != nil {
+		return 0, err
+	}
+
+	if _, err := stdout.Write(out); err != nil {
+		return 0, fmt.Errorf("failed to write AutoscalingRunnerSets: %w", err)
+	}
+
+	if _, err := io.WriteString(stderr, report(res)); err != nil {
+		return 0, fmt.Errorf("failed to write report: %w", err)
+	}
+
+	return len(res.findings), nil
+}
+
+// render writes the AutoscalingRunnerSets as multi-document YAML, without the empty and read-only fields.
+func render(res result) ([]byte, error) {
+	var buf bytes.Buffer
+
+	for i, ars := range res.sets {
+		obj, err := runtime.DefaultUnstructuredConverter.ToUnstructured(ars)
+		if err != nil {
+			return nil, fmt.Errorf("failed to convert AutoscalingRunnerSet %s/%s: %w", ars.Namespace, ars.Name, err)
+		}
+
+		unstructured.RemoveNestedField(obj, "status")
+		unstructured.RemoveNestedField(obj, "metadata", "creationTimestamp")
+		unstructured.RemoveNestedField(obj, "spec", "template", "metadata", "creationTimestamp")
+		if m, _, _ := unstructured.NestedMap(obj, "spec", "template", "metadata"); len(m) == 0 {
+			unstructured.RemoveNestedField(obj, "spec", "template", "metadata")
+		}
+
+		pruneEmptyResources(obj, "initContainers")
+		pruneEmptyResources(obj, "containers")
+
+		out, err := yaml.Marshal(obj)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal AutoscalingRunnerSet %s/%s: %w", ars.Namespace, ars.Name, err)
+		}
+
+		if i > 0 {
+			buf.WriteString("---\n")
+		}
+		buf.Write(out)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// pruneEmptyResources removes the empty resources of the containers, which are not omitted as they are not pointers.
+func pruneEmptyResources(obj map[string]any, field string) {
+	containers, ok, _ := unstructured.NestedSlice(obj, "spec", "template", "spec", field)
+	if !ok {
+		return
+	}
+
+	for _, c := range containers {
+		m, ok := c.(map[string]any)
+		if !ok {
+			continue
+		}
+		if r, ok := m["resources"].(map[string]any); ok && len(r) == 0 {
+			delete(m, "resources")
+		}
+	}
+
+	_ = unstructured.SetNestedSlice(obj, containers, "spec", "template", "spec", field)
+}
+
+func report(res result) string {
+	var buf bytes.Buffer
+
+	for _, f := range res.findings {
+		fmt.Fprintln(&buf, f.String())
+	}
+
+	return buf.String()
+}
@@ -0,0 +1,304 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	githubv1alpha1 "github.com/actions/actions-runner-controller/apis/actions.github.com/v1alpha1"
+	summerwindv1alpha1 "github.com/actions/actions-runner-controller/apis/actions.summerwind.net/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	defaultGitHubURL   = "https://github.com"
+	defaultRunnerImage = "ghcr.io/actions/actions-runner:latest"
+
+	// summerwindWorkDir is the default work directory of actions-runner-controller runners.
+	summerwindWorkDir = "/runner/_work"
+)
+
+type options struct {
+	// GitHubURL is the URL of GitHub or GitHub Enterprise Server the runners are registered to.
+	GitHubURL string
+	// GitHubConfigSecret is the secret holding the GitHub credentials of the runner scale sets,
+	// used when the RunnerDeployment has no githubAPICredentialsFrom.
+	GitHubConfigSecret string
+	// RunnerImage replaces the runner images that are built for actions-runner-controller runners.
+	RunnerImage string
+	// Namespace overrides the namespace of the runner scale sets when set.
+	Namespace string
+}
+
+func defaultOptions() options {
+	return options{
+		GitHubURL:   defaultGitHubURL,
+		RunnerImage: defaultRunnerImage,
+	}
+}
+
+// finding is a field of a migrated resource that has no equivalent in the AutoscalingRunnerSet,
+// or whose behavior changes.
+type finding struct {
+	// Object is the kind and namespaced name of the resource.
+	Object string
+	// Field is the path of the field within the resource.
+	Field   string
+	Message string
+}
+
+func (f finding) String() string {
+	if f.Field == "" {
+		return fmt.Sprintf("%s: %s", f.Object, f.Message)
+	}
+	return fmt.Sprintf("%s: %s: %s", f.Object, f.Field, f.Message)
+}
+
+type result struct {
+	sets     []*githubv1alpha1.AutoscalingRunnerSet
+	findings []finding
+}
+
+// reporter records the findings of a single resource.
+type reporter struct {
+	object   string
+	findings *[]finding
+}
+
+func (r reporter) report(field, format string, args ...any) {
+	*r.findings = append(*r.findings, finding{
+		Object:  r.object,
+		Field:   field,
+		Message: fmt.Sprintf(format, args...),
+	})
+}
+
+func newReporter(kind, namespace, name string, findings *[]finding) reporter {
+	return reporter{
+		object:   fmt.Sprintf("%s %s/%s", kind, namespace, name),
+		findings: findings,
+	}
+}
+
+// migrate converts the RunnerDeployments, and the HorizontalRunnerAutoscalers that scale them, into AutoscalingRunnerSets.
+func migrate(in input, opts options) result {
+	var res result
+
+	for _, u := range in.skipped {
+		newReporter(u.GetKind(), u.GetNamespace(), u.GetName(), &res.findings).
+			report("", "skipped, only RunnerDeployments and HorizontalRunnerAutoscalers are migrated")
+	}
+
+	rds := append([]summerwindv1alpha1.RunnerDeployment(nil), in.runnerDeployments...)
+	sort.Slice(rds, func(i, j int) bool {
+		return key(rds[i].Namespace, rds[i].Name) < key(rds[j].Namespace, rds[j].Name)
+	})
+
+	deployments := map[string]bool{}
+	for _, rd := range rds {
+		deployments[key(rd.Namespace, rd.Name)] = true
+	}
+
+	autoscalers := map[string]*summerwindv1alpha1.HorizontalRunnerAutoscaler{}
+
+	hras := append([]summerwindv1alpha1.HorizontalRunnerAutoscaler(nil), in.autoscalers...)
+	sort.Slice(hras, func(i, j int) bool {
+		return key(hras[i].Namespace, hras[i].Name) < key(hras[j].Namespace, hras[j].Name)
+	})
+
+	for i := range hras {
+		hra := &hras[i]
+		r := newReporter("HorizontalRunnerAutoscaler", hra.Namespace, hra.Name, &res.findings)
+
+		ref := hra.Spec.ScaleTargetRef
+		if ref.Kind != "" && ref.Kind != "RunnerDeployment" {
+			r.report("spec.scaleTargetRef.kind", "%s is not supported, only RunnerDeployments are migrated", ref.Kind)
+			continue
+		}
+
+		k := key(hra.Namespace, ref.Name)
+
+		if !deployments[k] {
+			r.report("spec.scaleTargetRef.name", "RunnerDeployment %s not found in the manifests", ref.Name)
+			continue
+		}
+
+		if other, ok := autoscalers[k]; ok {
+			r.report("spec.scaleTargetRef.name", "RunnerDeployment %s is already scaled by %s, ignoring this one", ref.Name, other.Name)
+			continue
+		}
+
+		autoscalers[k] = hra
+	}
+
+	for i := range rds {
+		rd := &rds[i]
+
+		ars := migrateRunnerDeployment(rd, autoscalers[key(rd.Namespace, rd.Name)], opts, &res.findings)
+		if ars != nil {
+			res.sets = append(res.sets, ars)
+		}
+	}
+
+	return res
+}
+
+func key(namespace, name string) string {
+	return namespace + "/" + name
+}
+
+func migrateRunnerDeployment(rd *summerwindv1alpha1.RunnerDeployment, hra *summerwindv1alpha1.HorizontalRunnerAutoscaler, opts options, findings *[]finding) *githubv1alpha1.AutoscalingRunnerSet {
+	r := newReporter("RunnerDeployment", rd.Namespace, rd.Name, findings)
+
+	spec := rd.Spec.Template.Spec
+	cfg := spec.RunnerConfig
+
+	configURL, err := githubConfigURL(opts.GitHubURL, cfg)
+	if err != nil {
+		r.report("spec.template.spec", "%v, not migrated", err)
+		return nil
+	}
+
+	namespace := rd.Namespace
+	if opts.Namespace != "" {
+		namespace = opts.Namespace
+	}
+
+	ars := &githubv1alpha1.AutoscalingRunnerSet{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: githubv1alpha1.GroupVersion.String(),
+			Kind:       "AutoscalingRunnerSet",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      rd.Name,
+			Namespace: namespace,
+			Labels:    rd.Labels,
+		},
+		Spec: githubv1alpha1.AutoscalingRunnerSetSpec{
+			GitHubConfigUrl:    configURL,
+			RunnerGroup:        cfg.Group,
+			RunnerScaleSetName: rd.Name,
+		},
+	}
+
+	switch {
+	case cfg.GitHubAPICredentialsFrom != nil && cfg.GitHubAPICredentialsFrom.SecretRef.Name != "":
+		ars.Spec.GitHubConfigSecret = cfg.GitHubAPICredentialsFrom.SecretRef.Name
+	case hra != nil && hra.Spec.GitHubAPICredentialsFrom != nil && hra.Spec.GitHubAPICredentialsFrom.SecretRef.Name != "":
+		ars.Spec.GitHubConfigSecret = hra.Spec.GitHubAPICredentialsFrom.SecretRef.Name
+	case opts.GitHubConfigSecret != "":
+		ars.Spec.GitHubConfigSecret = opts.GitHubConfigSecret
+	default:
+		r.report("spec.template.spec.githubAPICredentialsFrom", "not set, the runners used the controller's credentials; set githubConfigSecret to a secret holding them, or use -github-config-secret")
+	}
+
+	for _, l := range cfg.Labels {
+		if strings.EqualFold(l, "self-hosted") {
+			r.report("spec.template.spec.labels", "runner scale sets have no self-hosted label, update the runs-on of workflows that use it")
+			continue
+		}
+		ars.Spec.RunnerScaleSetLabels = append(ars.Spec.RunnerScaleSetLabels, l)
+	}
+
+	if cfg.Ephemeral != nil && !*cfg.Ephemeral {
+		r.report("spec.template.spec.ephemeral", "runners of runner scale sets are always ephemeral")
+	}
+
+	if cfg.WorkDir != "" && cfg.WorkDir != summerwindWorkDir {
+		r.report("spec.template.spec.workDir", "no equivalent, the work directory is /home/runner/_work")
+	}
+
+	if rd.Spec.Selector != nil {
+		r.report("spec.selector", "no equivalent, the controller manages the labels of the runner pods")
+	}
+
+	migrateScaling(rd, hra, ars, r, findings)
+
+	ars.Spec.Template = migratePodTemplate(rd, opts, r)
+
+	return ars
+}
+
+func githubConfigURL(githubURL string, cfg summerwindv1alpha1.RunnerConfig) (string, error) {
+	base := strings.TrimSuffix(githubURL, "/")
+
+	switch {
+	case cfg.Enterprise != "":
+		return fmt.Sprintf("%s/enterprises/%s", base, cfg.Enterprise), nil
+	case cfg.Organization != "":
+		return fmt.Sprintf("%s/%s", base, cfg.Organization), nil
+	case cfg.Repository != "":
+		return fmt.Sprintf("%s/%s", base, cfg.Repository), nil
+	default:
+		return "", fmt.Errorf("none of enterprise, organization or repository is set")
+	}
+}
+
+// migrateScaling sets the minimum and maximum runners from the HorizontalRunnerAutoscaler,
+// or from the replicas of the RunnerDeployment when it is not autoscaled.
+func migrateScaling(rd *summerwindv1alpha1.RunnerDeployment, hra *summerwindv1alpha1.HorizontalRunnerAutoscaler, ars *githubv1alpha1.AutoscalingRunnerSet, r reporter, findings *[]finding) {
+	if hra == nil {
+		replicas := 1
+		if rd.Spec.Replicas != nil {
+			replicas = *rd.Spec.Replicas
+		}
+
+		ars.Spec.MinRunners = &replicas
+		ars.Spec.MaxRunners = &replicas
+
+		r.report("spec.replicas", "not autoscaled, minRunners and maxRunners are set to %d", replicas)
+
+		return
+	}
+
+	hr := newReporter("HorizontalRunnerAutoscaler", hra.Namespace, hra.Name, findings)
+
+	if min := hra.Spec.MinReplicas; min != nil {
+		v := *min
+		ars.Spec.MinRunners = &v
+	}
+
+	if max := hra.Spec.MaxReplicas; max != nil {
+		v := *max
+		ars.Spec.MaxRunners = &v
+	} else {
+		hr.report("spec.maxReplicas", "not set, the runner scale set has no maximum number of runners")
+	}
+
+	for i, m := range hra.Spec.Metrics {
+		hr.report(fmt.Sprintf("spec.metrics[%d]", i), "%s has no equivalent, runner scale sets scale on the jobs assigned to them", m.Type)
+	}
+
+	for i := range hra.Spec.ScaleUpTriggers {
+		hr.report(fmt.Sprintf("spec.scaleUpTriggers[%d]", i), "no equivalent, runner scale sets scale on the jobs assigned to them")
+	}
+
+	if hra.Spec.ScaleDownDelaySecondsAfterScaleUp != nil {
+		hr.report("spec.scaleDownDelaySecondsAfterScaleOut", "no equivalent, runners are removed once their job completes")
+	}
+
+	for i, o := range hra.Spec.ScheduledOverrides {
+		hr.report(fmt.Sprintf("spec.scheduledOverrides[%d]", i), "no equivalent, patch minRunners to %s %s on a schedule instead", describeMinReplicas(o.MinReplicas), describeSchedule(o))
+	}
+}
+
+func describeMinReplicas(min *int) string {
+	if min == nil {
+		return "its current value"
+	}
+	return fmt.Sprint(*min)
+}
+
+func describeSchedule(o summerwindv1alpha1.ScheduledOverride) string {
+	s := fmt.Sprintf("from %s to %s", o.StartTime.UTC().Format(time.RFC3339), o.EndTime.UTC().Format(time.RFC3339))
+
+	if f := o.RecurrenceRule.Frequency; f != "" {
+		s += fmt.Sprintf(", recurring %s", strings.ToLower(f))
+		if !o.RecurrenceRule.UntilTime.IsZero() {
+			s += fmt.Sprintf(" until %s", o.RecurrenceRule.UntilTime.UTC().Format(time.RFC3339))
+		}
+	}
+
+	return s
+}
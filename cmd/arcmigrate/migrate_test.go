@@ -0,0 +1,89 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/actions/actions-runner-controller/cmd/internal/golden"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func assertGolden(t *testing.T, name string, got []byte) {
+	t.Helper()
+	golden.Assert(t, filepath.Join("testdata", name), got)
+}
+
+func TestRun(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     func(*options)
+		findings int
+	}{
+		{
+			name:     "org-dind",
+			findings: 6,
+		},
+		{
+			name:     "repo-kubernetes",
+			findings: 4,
+		},
+		{
+			name: "enterprise-static",
+			opts: func(o *options) {
+				o.GitHubURL = "https://github.example.com/"
+				o.GitHubConfigSecret = "enterprise-pat"
+				o.Namespace = "arc-runners"
+			},
+			findings: 1,
+		},
+		{
+			name:     "unsupported",
+			findings: 5,
+		},
+		{
+			name:     "missing-credentials",
+			findings: 4,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := defaultOptions()
+			if tt.opts != nil {
+				tt.opts(&opts)
+			}
+
+			var stdout, stderr bytes.Buffer
+			n, err := run(filepath.Join("testdata", tt.name, "input.yaml"), opts, nil, &stdout, &stderr)
+			require.NoError(t, err)
+
+			assertGolden(t, filepath.Join(tt.name, "output.yaml"), stdout.Bytes())
+			assertGolden(t, filepath.Join(tt.name, "report.txt"), stderr.Bytes())
+			assert.Equal(t, tt.findings, n)
+		})
+	}
+}
+
+func TestRunStdin(t *testing.T) {
+	in, err := os.ReadFile(filepath.Join("testdata", "org-dind", "input.yaml"))
+	require.NoError(t, err)
+
+	var stdout, stderr bytes.Buffer
+	_, err = run("-", defaultOptions(), bytes.NewReader(in), &stdout, &stderr)
+	require.NoError(t, err)
+
+	want, err := os.ReadFile(filepath.Join("testdata", "org-dind", "output.yaml"))
+	require.NoError(t, err)
+	assert.Equal(t, string(want), stdout.String())
+}
+
+func TestRunInvalidManifest(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	_, err := run("-", defaultOptions(), strings.NewReader("kind: [RunnerDeployment"), &stdout, &stderr)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to parse manifest")
+}
@@ -0,0 +1,310 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	summerwindv1alpha1 "github.com/actions/actions-runner-controller/apis/actions.summerwind.net/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+)
+
+// The names and paths below match the ones of the gha-runner-scale-set chart.
+const (
+	runnerContainerName = "runner"
+	dindContainerName   = "dind"
+
+	// summerwindDockerContainerName is the name of the dockerd sidecar of actions-runner-controller runners.
+	summerwindDockerContainerName = "docker"
+
+	workVolumeName          = "work"
+	dindSockVolumeName      = "dind-sock"
+	dindExternalsVolumeName = "dind-externals"
+
+	workDir    = "/home/runner/_work"
+	dockerSock = "unix:///var/run/docker.sock"
+
+	runnerHookPath = "/home/runner/k8s/index.js"
+)
+
+// migratePodTemplate converts the runner pod spec of the RunnerDeployment into the pod template of the runner scale set,
+// laying out the docker or kubernetes container mode the same way as the gha-runner-scale-set chart does.
+func migratePodTemplate(rd *summerwindv1alpha1.RunnerDeployment, opts options, r reporter) corev1.PodTemplateSpec {
+	spec := rd.Spec.Template.Spec
+	cfg := spec.RunnerConfig
+
+	tmpl := corev1.PodTemplateSpec{
+		ObjectMeta: metav1.ObjectMeta{
+			Labels:      rd.Spec.Template.Labels,
+			Annotations: rd.Spec.Template.Annotations,
+		},
+		Spec: corev1.PodSpec{
+			RestartPolicy:                 corev1.RestartPolicyNever,
+			Volumes:                       slices.Clone(spec.Volumes),
+			InitContainers:                slices.Clone(spec.InitContainers),
+			NodeSelector:                  spec.NodeSelector,
+			ServiceAccountName:            spec.ServiceAccountName,
+			AutomountServiceAccountToken:  spec.AutomountServiceAccountToken,
+			SecurityContext:               spec.SecurityContext,
+			ImagePullSecrets:              spec.ImagePullSecrets,
+			Affinity:                      spec.Affinity,
+			Tolerations:                   spec.Tolerations,
+			PriorityClassName:             spec.PriorityClassName,
+			TerminationGracePeriodSeconds: spec.TerminationGracePeriodSeconds,
+			HostAliases:                   spec.HostAliases,
+			TopologySpreadConstraints:     spec.TopologySpreadConstraints,
+			RuntimeClassName:              spec.RuntimeClassName,
+			DNSPolicy:                     spec.DnsPolicy,
+			DNSConfig:                     spec.DnsConfig,
+			EnableServiceLinks:            spec.EnableServiceLinks,
+		},
+	}
+
+	if len(spec.EphemeralContainers) > 0 {
+		r.report("spec.template.spec.ephemeralContainers", "no equivalent, ephemeral containers cannot be part of a pod template")
+	}
+
+	// Containers named runner and docker customize the runner and dockerd containers, like they do for actions-runner-controller runners.
+	var runner, docker corev1.Container
+	var extra []corev1.Container
+
+	for _, c := range spec.Containers {
+		switch c.Name {
+		case runnerContainerName:
+			runner = c
+		case summerwindDockerContainerName:
+			docker = c
+		default:
+			extra = append(extra, c)
+		}
+	}
+
+	runner.Name = runnerContainerName
+	runner.Image = migrateRunnerImage(cfg.Image, runner.Image, opts, r)
+	if len(runner.Command) == 0 {
+		runner.Command = []string{"/home/runner/run.sh"}
+	}
+	if spec.ImagePullPolicy != "" {
+		runner.ImagePullPolicy = spec.ImagePullPolicy
+	}
+	runner.Env = append(runner.Env, spec.Env...)
+	runner.EnvFrom = append(runner.EnvFrom, spec.EnvFrom...)
+	runner.VolumeMounts = append(runner.VolumeMounts, spec.VolumeMounts...)
+	if !isEmptyResources(spec.Resources) {
+		runner.Resources = spec.Resources
+	}
+
+	var containers []corev1.Container
+
+	switch {
+	case cfg.ContainerMode == "kubernetes":
+		migrateKubernetesMode(spec, &tmpl.Spec, &runner, r)
+		containers = append(containers, runner)
+	case cfg.ContainerMode != "":
+		r.report("spec.template.spec.containerMode", "%q is not supported", cfg.ContainerMode)
+		containers = append(containers, runner)
+	case cfg.DockerEnabled == nil || *cfg.DockerEnabled:
+		dind := migrateDockerMode(spec, &tmpl.Spec, &runner, docker, r)
+		containers = append(containers, runner, dind)
+	default:
+		migrateWorkVolume(spec, &tmpl.Spec, &runner)
+		containers = append(containers, runner)
+	}
+
+	containers = append(containers, extra...)
+	containers = append(containers, spec.SidecarContainers...)
+
+	tmpl.Spec.Containers = containers
+
+	return tmpl
+}
+
+func migrateRunnerImage(configured, containerImage string, opts options, r reporter) string {
+	image := containerImage
+	if configured != "" {
+		image = configured
+	}
+
+	switch {
+	case image == "":
+		r.report("spec.template.spec.image", "not set, using %s", opts.RunnerImage)
+		return opts.RunnerImage
+	case strings.Contains(image, "summerwind/actions-runner") || strings.Contains(image, "actions-runner-controller/actions-runner"):
+		r.report("spec.template.spec.image", "%s is built for actions-runner-controller runners, replaced with %s; rebuild custom images on top of it", image, opts.RunnerImage)
+		return opts.RunnerImage
+	default:
+		return image
+	}
+}
+
+// migrateDockerMode adds the dind sidecar, and configures the runner container to use it.
+// docker is the container named docker in the RunnerDeployment, if any, used as the base of the dind container.
+func migrateDockerMode(spec summerwindv1alpha1.RunnerSpec, pod *corev1.PodSpec, runner *corev1.Container, docker corev1.Container, r reporter) corev1.Container {
+	cfg := spec.RunnerConfig
+
+	if cfg.DockerdWithinRunnerContainer != nil && *cfg.DockerdWithinRunnerContainer {
+		r.report("spec.template.spec.dockerdWithinRunnerContainer", "no equivalent, dockerd runs in a dind sidecar")
+	}
+
+	pod.InitContainers = append(pod.InitContainers, corev1.Container{
+		Name:    "init-dind-externals",
+		Image:   runner.Image,
+		Command: []string{"cp"},
+		Args:    []string{"-r", "/home/runner/externals/.", "/home/runner/tmpDir/"},
+		VolumeMounts: []corev1.VolumeMount{
+			{Name: dindExternalsVolumeName, MountPath: "/home/runner/tmpDir"},
+		},
+	})
+
+	dind := docker
+	dind.Name = dindContainerName
+	if dind.Image == "" {
+		dind.Image = "docker:dind"
+	}
+	if len(dind.Args) == 0 {
+		dind.Args = []string{"dockerd", "--host=" + dockerSock, "--group=$(DOCKER_GROUP_GID)"}
+		if cfg.DockerMTU != nil {
+			dind.Args = append(dind.Args, fmt.Sprintf("--mtu=%d", *cfg.DockerMTU))
+		}
+		if cfg.DockerRegistryMirror != nil && *cfg.DockerRegistryMirror != "" {
+			dind.Args = append(dind.Args, "--registry-mirror="+*cfg.DockerRegistryMirror)
+		}
+	} else if cfg.DockerMTU != nil || cfg.DockerRegistryMirror != nil {
+		r.report("spec.template.spec.dockerMTU", "not applied, the docker container has its own args")
+	}
+	dind.Env = append(dind.Env, corev1.EnvVar{Name: "DOCKER_GROUP_GID", Value: "123"})
+	dind.Env = append(dind.Env, spec.DockerEnv...)
+	if dind.SecurityContext == nil {
+		dind.SecurityContext = &corev1.SecurityContext{Privileged: ptr.To(true)}
+	}
+	if !isEmptyResources(spec.DockerdContainerResources) {
+		dind.Resources = spec.DockerdContainerResources
+	}
+	dind.VolumeMounts = append(dind.VolumeMounts,
+		corev1.VolumeMount{Name: workVolumeName, MountPath: workDir},
+		corev1.VolumeMount{Name: dindSockVolumeName, MountPath: "/var/run"},
+		corev1.VolumeMount{Name: dindExternalsVolumeName, MountPath: "/home/runner/externals"},
+	)
+	dind.VolumeMounts = append(dind.VolumeMounts, spec.DockerVolumeMounts...)
+
+	runner.Env = appendEnvIfMissing(runner.Env,
+		corev1.EnvVar{Name: "DOCKER_HOST", Value: dockerSock},
+		corev1.EnvVar{Name: "RUNNER_WAIT_FOR_DOCKER_IN_SECONDS", Value: "120"},
+	)
+	runner.VolumeMounts = append(runner.VolumeMounts,
+		corev1.VolumeMount{Name: workVolumeName, MountPath: workDir},
+		corev1.VolumeMount{Name: dindSockVolumeName, MountPath: "/var/run"},
+	)
+
+	if !hasVolume(pod.Volumes, workVolumeName) {
+		pod.Volumes = append(pod.Volumes, workVolume(cfg))
+	}
+	pod.Volumes = append(pod.Volumes,
+		corev1.Volume{
+			Name: dindSockVolumeName,
+			VolumeSource: corev1.VolumeSource{
+				EmptyDir: &corev1.EmptyDirVolumeSource{SizeLimit: cfg.DockerVarRunVolumeSizeLimit},
+			},
+		},
+		corev1.Volume{
+			Name: dindExternalsVolumeName,
+			VolumeSource: corev1.VolumeSource{
+				EmptyDir: &corev1.EmptyDirVolumeSource{},
+			},
+		},
+	)
+
+	return dind
+}
+
+// migrateKubernetesMode configures the runner container to run jobs in separate pods with the container hooks,
+// on a work volume provisioned from the work volume claim template.
+func migrateKubernetesMode(spec summerwindv1alpha1.RunnerSpec, pod *corev1.PodSpec, runner *corev1.Container, r reporter) {
+	runner.Env = appendEnvIfMissing(runner.Env,
+		corev1.EnvVar{Name: "ACTIONS_RUNNER_CONTAINER_HOOKS", Value: runnerHookPath},
+		corev1.EnvVar{
+			Name: "ACTIONS_RUNNER_POD_NAME",
+			ValueFrom: &corev1.EnvVarSource{
+				FieldRef: &corev1.ObjectFieldSelector{FieldPath: "metadata.name"},
+			},
+		},
+		corev1.EnvVar{Name: "ACTIONS_RUNNER_REQUIRE_JOB_CONTAINER", Value: "true"},
+	)
+	runner.VolumeMounts = append(runner.VolumeMounts, corev1.VolumeMount{Name: workVolumeName, MountPath: workDir})
+
+	if pod.ServiceAccountName == "" {
+		r.report("spec.template.spec.serviceAccountName", "not set, the kubernetes container mode needs a service account that can manage pods, jobs and secrets")
+	}
+
+	if hasVolume(pod.Volumes, workVolumeName) {
+		return
+	}
+
+	claim := spec.WorkVolumeClaimTemplate
+	if claim == nil {
+		r.report("spec.template.spec.workVolumeClaimTemplate", "not set, the kubernetes container mode needs a work volume; add a volume named work")
+		return
+	}
+
+	pvcSpec := corev1.PersistentVolumeClaimSpec{
+		AccessModes: claim.AccessModes,
+		Resources:   claim.Resources,
+	}
+	if claim.StorageClassName != "" {
+		pvcSpec.StorageClassName = ptr.To(claim.StorageClassName)
+	}
+
+	pod.Volumes = append(pod.Volumes, corev1.Volume{
+		Name: workVolumeName,
+		VolumeSource: corev1.VolumeSource{
+			Ephemeral: &corev1.EphemeralVolumeSource{
+				VolumeClaimTemplate: &corev1.PersistentVolumeClaimTemplate{Spec: pvcSpec},
+			},
+		},
+	})
+}
+
+// migrateWorkVolume adds a work volume when docker is disabled, only if its size or medium was customized.
+func migrateWorkVolume(spec summerwindv1alpha1.RunnerSpec, pod *corev1.PodSpec, runner *corev1.Container) {
+	cfg := spec.RunnerConfig
+
+	if cfg.VolumeSizeLimit == nil && cfg.VolumeStorageMedium == nil {
+		return
+	}
+
+	if !hasVolume(pod.Volumes, workVolumeName) {
+		pod.Volumes = append(pod.Volumes, workVolume(cfg))
+	}
+	runner.VolumeMounts = append(runner.VolumeMounts, corev1.VolumeMount{Name: workVolumeName, MountPath: workDir})
+}
+
+func workVolume(cfg summerwindv1alpha1.RunnerConfig) corev1.Volume {
+	emptyDir := &corev1.EmptyDirVolumeSource{SizeLimit: cfg.VolumeSizeLimit}
+	if cfg.VolumeStorageMedium != nil {
+		emptyDir.Medium = corev1.StorageMedium(*cfg.VolumeStorageMedium)
+	}
+
+	return corev1.Volume{
+		Name:         workVolumeName,
+		VolumeSource: corev1.VolumeSource{EmptyDir: emptyDir},
+	}
+}
+
+func hasVolume(volumes []corev1.Volume, name string) bool {
+	return slices.ContainsFunc(volumes, func(v corev1.Volume) bool { return v.Name == name })
+}
+
+func appendEnvIfMissing(env []corev1.EnvVar, defaults ...corev1.EnvVar) []corev1.EnvVar {
+	for _, d := range defaults {
+		if !slices.ContainsFunc(env, func(e corev1.EnvVar) bool { return e.Name == d.Name }) {
+			env = append(env, d)
+		}
+	}
+	return env
+}
+
+func isEmptyResources(r corev1.ResourceRequirements) bool {
+	return len(r.Limits) == 0 && len(r.Requests) == 0 && len(r.Claims) == 0
+}
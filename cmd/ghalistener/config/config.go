@@ -9,6 +9,7 @@ import (
 	"net/http"
 	"net/url"
 	"os"
+	"regexp"
 
 	"github.com/actions/actions-runner-controller/apis/actions.github.com/v1alpha1"
 	"github.com/actions/actions-runner-controller/apis/actions.github.com/v1alpha1/appconfig"
@@ -18,6 +19,7 @@ import (
 	"github.com/actions/actions-runner-controller/vault/azurekeyvault"
 	"github.com/actions/scaleset"
 	"golang.org/x/net/http/httpproxy"
+	corev1 "k8s.io/api/core/v1"
 )
 
 const appName = "ghalistener"
@@ -44,6 +46,9 @@ type Config struct {
 	MetricsAddr                 string                  `json:"metrics_addr"`
 	MetricsEndpoint             string                  `json:"metrics_endpoint"`
 	Metrics                     *v1alpha1.MetricsConfig `json:"metrics"`
+	RunnerResourceRequests      corev1.ResourceList     `json:"runner_resource_requests,omitempty"`
+	// Prewarm enables following the prewarm hints on the ephemeral runner set.
+	Prewarm bool `json:"prewarm,omitempty"`
 }
 
 func Read(ctx context.Context, configPath string) (*Config, error) {
@@ -118,6 +123,12 @@ func (c *Config) Validate() error {
 		return fmt.Errorf(`MinRunners "%d" cannot be greater than MaxRunners "%d"`, c.MinRunners, c.MaxRunners)
 	}
 
+	if c.Metrics != nil {
+		if err := validateCardinalityConfig(c.Metrics.Cardinality); err != nil {
+			return fmt.Errorf("metrics cardinality validation failed: %w", err)
+		}
+	}
+
 	if c.VaultType != "" {
 		if err := c.VaultType.Validate(); err != nil {
 			return fmt.Errorf("VaultType validation failed: %w", err)
@@ -136,6 +147,30 @@ func (c *Config) Validate() error {
 	return nil
 }
 
+// validateCardinalityConfig returns an error if any of the regular expressions
+// in the configuration does not compile.
+func validateCardinalityConfig(config *v1alpha1.CardinalityConfig) error {
+	if config == nil {
+		return nil
+	}
+	for label, patterns := range config.Allowlist {
+		for _, pattern := range patterns {
+			if _, err := regexp.Compile(pattern); err != nil {
+				return fmt.Errorf("invalid allowlist pattern %q for label %q: %w", pattern, label, err)
+			}
+		}
+	}
+	for i, rewrite := range config.Rewrites {
+		if rewrite.Label == "" {
+			return fmt.Errorf("rewrite %d: label is required", i)
+		}
+		if _, err := regexp.Compile(rewrite.Regex); err != nil {
+			return fmt.Errorf("rewrite %d: invalid regex %q: %w", i, rewrite.Regex, err)
+		}
+	}
+	return nil
+}
+
 func (c *Config) Logger() (*slog.Logger, error) {
 	return logger.New(c.LogLevel, c.LogFormat)
 }
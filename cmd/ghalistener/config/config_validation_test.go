@@ -3,6 +3,7 @@ package config
 import (
 	"testing"
 
+	"github.com/actions/actions-runner-controller/apis/actions.github.com/v1alpha1"
 	"github.com/actions/actions-runner-controller/apis/actions.github.com/v1alpha1/appconfig"
 	"github.com/actions/actions-runner-controller/vault"
 	"github.com/stretchr/testify/assert"
@@ -24,6 +25,44 @@ func TestConfigValidationMinMax(t *testing.T) {
 	assert.ErrorContains(t, err, `MinRunners "5" cannot be greater than MaxRunners "2"`, "Expected error about MinRunners > MaxRunners")
 }
 
+func TestConfigValidationMetricsCardinality(t *testing.T) {
+	config := &Config{
+		ConfigureURL:                "github.com/some_org/some_repo",
+		EphemeralRunnerSetNamespace: "namespace",
+		EphemeralRunnerSetName:      "deployment",
+		RunnerScaleSetID:            1,
+		AppConfig: &appconfig.AppConfig{
+			Token: "token",
+		},
+		Metrics: &v1alpha1.MetricsConfig{
+			Cardinality: &v1alpha1.CardinalityConfig{
+				Rewrites: []v1alpha1.LabelRewrite{
+					{Label: "job_workflow_target", Regex: "pull/(\\d+"},
+				},
+			},
+		},
+	}
+	err := config.Validate()
+	assert.ErrorContains(t, err, "metrics cardinality validation failed", "Expected error about invalid rewrite regex")
+}
+
+func TestValidateCardinalityConfig(t *testing.T) {
+	assert.NoError(t, validateCardinalityConfig(nil))
+	assert.NoError(t, validateCardinalityConfig(&v1alpha1.CardinalityConfig{
+		Allowlist: map[string][]string{"job_name": {"^build$"}},
+		Rewrites:  []v1alpha1.LabelRewrite{{Label: "job_name", Regex: "^(.*)$"}},
+	}))
+	assert.Error(t, validateCardinalityConfig(&v1alpha1.CardinalityConfig{
+		Allowlist: map[string][]string{"job_name": {"("}},
+	}))
+	assert.Error(t, validateCardinalityConfig(&v1alpha1.CardinalityConfig{
+		Rewrites: []v1alpha1.LabelRewrite{{Regex: ".*"}},
+	}))
+	assert.Error(t, validateCardinalityConfig(&v1alpha1.CardinalityConfig{
+		Rewrites: []v1alpha1.LabelRewrite{{Label: "job_name", Regex: "["}},
+	}))
+}
+
 func TestConfigValidationMissingToken(t *testing.T) {
 	config := &Config{
 		ConfigureURL:                "github.com/some_org/some_repo",
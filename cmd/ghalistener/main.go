@@ -61,6 +61,7 @@ func run(ctx context.Context, config *config.Config) error {
 			ServerAddr:        config.MetricsAddr,
 			ServerEndpoint:    config.MetricsEndpoint,
 			Metrics:           config.Metrics,
+			RunnerResources:   config.RunnerResourceRequests,
 			Logger:            logger.With("component", "metrics exporter"),
 		})
 	}
@@ -90,8 +91,10 @@ func run(ctx context.Context, config *config.Config) error {
 		}
 	}()
 
+	var listenerClient listener.Client = sessionClient
 	var listenerOptions []listener.Option
 	if metricsExporter != nil {
+		listenerClient = metrics.NewMessageRecordingClient(sessionClient, metricsExporter)
 		listenerOptions = append(
 			listenerOptions,
 			listener.WithMetricsRecorder(
@@ -102,7 +105,7 @@ func run(ctx context.Context, config *config.Config) error {
 	}
 
 	listener, err := listener.New(
-		sessionClient,
+		listenerClient,
 		listener.Config{
 			ScaleSetID: config.RunnerScaleSetID,
 			MaxRunners: config.MaxRunners,
@@ -114,6 +117,13 @@ func run(ctx context.Context, config *config.Config) error {
 		return fmt.Errorf("failed to create new listener: %w", err)
 	}
 
+	scalerOptions := []scaler.Option{
+		scaler.WithLogger(logger.With("component", "worker")),
+	}
+	if metricsExporter != nil {
+		scalerOptions = append(scalerOptions, scaler.WithMetricsRecorder(metricsExporter))
+	}
+
 	scaler, err := scaler.New(
 		scaler.Config{
 			EphemeralRunnerSetNamespace: config.EphemeralRunnerSetNamespace,
@@ -121,7 +131,7 @@ func run(ctx context.Context, config *config.Config) error {
 			MaxRunners:                  config.MaxRunners,
 			MinRunners:                  config.MinRunners,
 		},
-		scaler.WithLogger(logger.With("component", "worker")),
+		scalerOptions...,
 	)
 	if err != nil {
 		return fmt.Errorf("failed to create new kubernetes worker: %w", err)
@@ -137,6 +147,14 @@ func run(ctx context.Context, config *config.Config) error {
 		return listnerErr
 	})
 
+	if config.Prewarm {
+		g.Go(func() error {
+			logger.Info("Starting prewarm hint watch")
+			// metricsCtx is also canceled when the listener exits.
+			return scaler.WatchPrewarmHints(metricsCtx)
+		})
+	}
+
 	if metricsExporter != nil {
 		g.Go(func() error {
 			logger.Info("Starting metrics server")
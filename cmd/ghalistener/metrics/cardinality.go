@@ -0,0 +1,146 @@
+package metrics
+
+import (
+	"log/slog"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/actions/actions-runner-controller/apis/actions.github.com/v1alpha1"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// MetricDroppedSeriesTotal counts label values folded into the overflow value
+// because a label reached its cardinality limit for a metric.
+const MetricDroppedSeriesTotal = "gha_dropped_series_total"
+
+// overflowLabelValue is the value used in place of label values over the limit.
+const overflowLabelValue = "other"
+
+type labelRewrite struct {
+	label       string
+	regex       *regexp.Regexp
+	replacement string
+}
+
+// cardinalityLimiter rewrites label values and caps the number of distinct
+// values each label can take per metric.
+type cardinalityLimiter struct {
+	maxLabelValues int
+	allowlist      map[string][]*regexp.Regexp
+	rewrites       []labelRewrite
+	dropped        *prometheus.CounterVec
+
+	mu sync.Mutex
+	// seen holds the distinct values observed per metric and label, at most maxLabelValues each.
+	seen map[string]map[string]map[string]struct{}
+}
+
+// newCardinalityLimiter returns nil when there is nothing to limit.
+// Patterns that do not compile are logged and ignored.
+func newCardinalityLimiter(config *v1alpha1.CardinalityConfig, reg prometheus.Registerer, logger *slog.Logger) *cardinalityLimiter {
+	if config == nil {
+		return nil
+	}
+
+	l := &cardinalityLimiter{
+		maxLabelValues: config.MaxLabelValues,
+		allowlist:      make(map[string][]*regexp.Regexp, len(config.Allowlist)),
+		seen:           make(map[string]map[string]map[string]struct{}),
+	}
+
+	for label, patterns := range config.Allowlist {
+		for _, pattern := range patterns {
+			re, err := regexp.Compile(pattern)
+			if err != nil {
+				logger.Error("invalid allowlist pattern", slog.String("label", label), slog.String("pattern", pattern), slog.String("error", err.Error()))
+				continue
+			}
+			l.allowlist[label] = append(l.allowlist[label], re)
+		}
+	}
+
+	for _, rewrite := range config.Rewrites {
+		re, err := regexp.Compile(rewrite.Regex)
+		if err != nil {
+			logger.Error("invalid rewrite regex", slog.String("label", rewrite.Label), slog.String("regex", rewrite.Regex), slog.String("error", err.Error()))
+			continue
+		}
+		l.rewrites = append(l.rewrites, labelRewrite{
+			label:       rewrite.Label,
+			regex:       re,
+			replacement: rewrite.Replacement,
+		})
+	}
+
+	l.dropped = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Subsystem: githubScaleSetSubsystem,
+			Name:      strings.TrimPrefix(MetricDroppedSeriesTotal, githubScaleSetSubsystemPrefix),
+			Help:      "Total number of label values folded into the \"other\" value because the label reached its cardinality limit for the metric.",
+		},
+		[]string{"metric", "label"},
+	)
+	reg.MustRegister(l.dropped)
+
+	return l
+}
+
+// apply rewrites the labels of the metric in place. Once a label has taken
+// maxLabelValues distinct values for the metric, its new values are folded into
+// the overflow value, except for allowlisted ones. The other labels are kept.
+func (l *cardinalityLimiter) apply(metric string, labels prometheus.Labels) {
+	if l == nil {
+		return
+	}
+
+	for _, rewrite := range l.rewrites {
+		if v, ok := labels[rewrite.label]; ok {
+			labels[rewrite.label] = rewrite.regex.ReplaceAllString(v, rewrite.replacement)
+		}
+	}
+
+	if l.maxLabelValues <= 0 {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	seen, ok := l.seen[metric]
+	if !ok {
+		seen = make(map[string]map[string]struct{})
+		l.seen[metric] = seen
+	}
+
+	for label, value := range labels {
+		if l.allowed(label, value) {
+			continue
+		}
+
+		values, ok := seen[label]
+		if !ok {
+			values = make(map[string]struct{})
+			seen[label] = values
+		}
+		if _, ok := values[value]; ok {
+			continue
+		}
+		if len(values) < l.maxLabelValues {
+			values[value] = struct{}{}
+			continue
+		}
+
+		labels[label] = overflowLabelValue
+		l.dropped.WithLabelValues(metric, label).Inc()
+	}
+}
+
+func (l *cardinalityLimiter) allowed(label, value string) bool {
+	for _, re := range l.allowlist[label] {
+		if re.MatchString(value) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,126 @@
+package metrics
+
+import (
+	"testing"
+
+	"github.com/actions/actions-runner-controller/apis/actions.github.com/v1alpha1"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCardinalityLimiter(t *testing.T) {
+	t.Run("nil config disables the limiter", func(t *testing.T) {
+		l := newCardinalityLimiter(nil, prometheus.NewRegistry(), discardLogger)
+		require.Nil(t, l)
+
+		labels := prometheus.Labels{labelKeyJobName: "build"}
+		l.apply(MetricStartedJobsTotal, labels)
+		assert.Equal(t, "build", labels[labelKeyJobName])
+	})
+
+	t.Run("folds values over the limit", func(t *testing.T) {
+		l := newCardinalityLimiter(&v1alpha1.CardinalityConfig{MaxLabelValues: 2}, prometheus.NewRegistry(), discardLogger)
+		require.NotNil(t, l)
+
+		for _, v := range []string{"a", "b", "a"} {
+			labels := prometheus.Labels{labelKeyJobName: v}
+			l.apply(MetricStartedJobsTotal, labels)
+			assert.Equal(t, v, labels[labelKeyJobName])
+		}
+
+		for range 2 {
+			labels := prometheus.Labels{labelKeyJobName: "c"}
+			l.apply(MetricStartedJobsTotal, labels)
+			assert.Equal(t, overflowLabelValue, labels[labelKeyJobName])
+		}
+
+		// limits are tracked per metric
+		labels := prometheus.Labels{labelKeyJobName: "c"}
+		l.apply(MetricCompletedJobsTotal, labels)
+		assert.Equal(t, "c", labels[labelKeyJobName])
+
+		// every folded value is counted
+		assert.Equal(t, 2.0, testutil.ToFloat64(l.dropped.WithLabelValues(MetricStartedJobsTotal, labelKeyJobName)))
+	})
+
+	t.Run("folds only the labels over the limit", func(t *testing.T) {
+		l := newCardinalityLimiter(&v1alpha1.CardinalityConfig{MaxLabelValues: 2}, prometheus.NewRegistry(), discardLogger)
+
+		for _, v := range []string{"a", "b", "c", "d"} {
+			labels := prometheus.Labels{labelKeyJobName: v, labelKeyRepository: "repo", labelKeyOrganization: "org"}
+			l.apply(MetricStartedJobsTotal, labels)
+			assert.Equal(t, "repo", labels[labelKeyRepository])
+			assert.Equal(t, "org", labels[labelKeyOrganization])
+		}
+
+		labels := prometheus.Labels{labelKeyJobName: "a", labelKeyRepository: "other-repo", labelKeyOrganization: "org"}
+		l.apply(MetricStartedJobsTotal, labels)
+		assert.Equal(t, prometheus.Labels{labelKeyJobName: "a", labelKeyRepository: "other-repo", labelKeyOrganization: "org"}, labels)
+
+		labels = prometheus.Labels{labelKeyJobName: "a", labelKeyRepository: "third-repo", labelKeyOrganization: "org"}
+		l.apply(MetricStartedJobsTotal, labels)
+		assert.Equal(t, prometheus.Labels{labelKeyJobName: "a", labelKeyRepository: overflowLabelValue, labelKeyOrganization: "org"}, labels)
+
+		assert.Equal(t, 2.0, testutil.ToFloat64(l.dropped.WithLabelValues(MetricStartedJobsTotal, labelKeyJobName)))
+		assert.Equal(t, 1.0, testutil.ToFloat64(l.dropped.WithLabelValues(MetricStartedJobsTotal, labelKeyRepository)))
+	})
+
+	t.Run("allowlist is checked per label", func(t *testing.T) {
+		l := newCardinalityLimiter(&v1alpha1.CardinalityConfig{
+			MaxLabelValues: 1,
+			Allowlist: map[string][]string{
+				labelKeyOrganization: {`^org-`},
+			},
+		}, prometheus.NewRegistry(), discardLogger)
+
+		for _, v := range [][2]string{{"org-a", "a"}, {"org-b", "b"}} {
+			labels := prometheus.Labels{labelKeyOrganization: v[0], labelKeyJobName: v[1]}
+			l.apply(MetricStartedJobsTotal, labels)
+			assert.Equal(t, v[0], labels[labelKeyOrganization])
+		}
+
+		labels := prometheus.Labels{labelKeyOrganization: "org-c", labelKeyJobName: "c"}
+		l.apply(MetricStartedJobsTotal, labels)
+		assert.Equal(t, prometheus.Labels{labelKeyOrganization: "org-c", labelKeyJobName: overflowLabelValue}, labels)
+	})
+
+	t.Run("allowlisted values do not count towards the limit", func(t *testing.T) {
+		l := newCardinalityLimiter(&v1alpha1.CardinalityConfig{
+			MaxLabelValues: 1,
+			Allowlist: map[string][]string{
+				labelKeyJobWorkflowTarget: {`^heads/(main|master)$`},
+			},
+		}, prometheus.NewRegistry(), discardLogger)
+
+		for _, v := range []string{"heads/main", "heads/master", "heads/feature", "heads/main"} {
+			labels := prometheus.Labels{labelKeyJobWorkflowTarget: v}
+			l.apply(MetricStartedJobsTotal, labels)
+			assert.Equal(t, v, labels[labelKeyJobWorkflowTarget])
+		}
+
+		labels := prometheus.Labels{labelKeyJobWorkflowTarget: "heads/other"}
+		l.apply(MetricStartedJobsTotal, labels)
+		assert.Equal(t, overflowLabelValue, labels[labelKeyJobWorkflowTarget])
+	})
+
+	t.Run("rewrites are applied before the limit", func(t *testing.T) {
+		l := newCardinalityLimiter(&v1alpha1.CardinalityConfig{
+			MaxLabelValues: 1,
+			Rewrites: []v1alpha1.LabelRewrite{
+				{
+					Label:       labelKeyJobWorkflowTarget,
+					Regex:       `^pull/\d+/(.*)$`,
+					Replacement: "pull/${1}",
+				},
+			},
+		}, prometheus.NewRegistry(), discardLogger)
+
+		for _, v := range []string{"pull/1/merge", "pull/2/merge"} {
+			labels := prometheus.Labels{labelKeyJobWorkflowTarget: v}
+			l.apply(MetricStartedJobsTotal, labels)
+			assert.Equal(t, "pull/merge", labels[labelKeyJobWorkflowTarget])
+		}
+	})
+}
@@ -0,0 +1,53 @@
+package metrics
+
+import (
+	"slices"
+	"sort"
+)
+
+// Kinds of metrics exposed by the listener.
+const (
+	KindCounter   = "counter"
+	KindGauge     = "gauge"
+	KindHistogram = "histogram"
+)
+
+// Definition describes a metric the listener can expose.
+type Definition struct {
+	Name string
+	Kind string
+	Help string
+	// Labels are the labels the metric is exposed with by default.
+	// It is empty when the metric is not enabled by default.
+	Labels []string
+}
+
+// Definitions returns all metrics the listener can expose, sorted by name.
+// It is meant for tooling that needs to stay in sync with the listener,
+// such as dashboard and alert generators.
+func Definitions() []Definition {
+	var defs []Definition
+	for name, help := range metricsHelp.counters {
+		var labels []string
+		if m, ok := defaultMetrics.Counters[name]; ok {
+			labels = slices.Clone(m.Labels)
+		}
+		defs = append(defs, Definition{Name: name, Kind: KindCounter, Help: help, Labels: labels})
+	}
+	for name, help := range metricsHelp.gauges {
+		var labels []string
+		if m, ok := defaultMetrics.Gauges[name]; ok {
+			labels = slices.Clone(m.Labels)
+		}
+		defs = append(defs, Definition{Name: name, Kind: KindGauge, Help: help, Labels: labels})
+	}
+	for name, help := range metricsHelp.histograms {
+		var labels []string
+		if m, ok := defaultMetrics.Histograms[name]; ok {
+			labels = slices.Clone(m.Labels)
+		}
+		defs = append(defs, Definition{Name: name, Kind: KindHistogram, Help: help, Labels: labels})
+	}
+	sort.Slice(defs, func(i, j int) bool { return defs[i].Name < defs[j].Name })
+	return defs
+}
@@ -11,6 +11,7 @@ import (
 	"github.com/actions/scaleset"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
+	corev1 "k8s.io/api/core/v1"
 )
 
 const (
@@ -46,6 +47,16 @@ const (
 	MetricCompletedJobsTotal          = "gha_completed_jobs_total"
 	MetricJobStartupDurationSeconds   = "gha_job_startup_duration_seconds"
 	MetricJobExecutionDurationSeconds = "gha_job_execution_duration_seconds"
+	MetricJobRunnerSecondsTotal       = "gha_job_runner_seconds_total"
+	MetricJobCPUCoreSecondsTotal      = "gha_job_cpu_core_seconds_total"
+	MetricJobMemoryGiBSecondsTotal    = "gha_job_memory_gib_seconds_total"
+	MetricJobCostTotal                = "gha_job_cost_total"
+
+	MetricJobQueuedToAcquiredDurationSeconds         = "gha_job_queued_to_acquired_duration_seconds"
+	MetricJobAcquiredToDesiredPatchDurationSeconds   = "gha_job_acquired_to_desired_patch_duration_seconds"
+	MetricJobDesiredPatchToPodCreatedDurationSeconds = "gha_job_desired_patch_to_pod_created_duration_seconds"
+	MetricJobPodCreatedToRunnerOnlineDurationSeconds = "gha_job_pod_created_to_runner_online_duration_seconds"
+	MetricJobRunnerOnlineToJobStartedDurationSeconds = "gha_job_runner_online_to_job_started_duration_seconds"
 )
 
 type metricsHelpRegistry struct {
@@ -56,8 +67,12 @@ type metricsHelpRegistry struct {
 
 var metricsHelp = metricsHelpRegistry{
 	counters: map[string]string{
-		MetricStartedJobsTotal:   "Total number of jobs started.",
-		MetricCompletedJobsTotal: "Total number of jobs completed.",
+		MetricStartedJobsTotal:         "Total number of jobs started.",
+		MetricCompletedJobsTotal:       "Total number of jobs completed.",
+		MetricJobRunnerSecondsTotal:    "Total runner-seconds consumed by completed jobs.",
+		MetricJobCPUCoreSecondsTotal:   "Total runner-seconds consumed by completed jobs, weighted by the CPU cores requested by the runner pod.",
+		MetricJobMemoryGiBSecondsTotal: "Total runner-seconds consumed by completed jobs, weighted by the memory (in GiB) requested by the runner pod.",
+		MetricJobCostTotal:             "Total cost of completed jobs, computed from the configured price table.",
 	},
 	gauges: map[string]string{
 		MetricAssignedJobs:      "Number of jobs assigned to this scale set.",
@@ -72,6 +87,12 @@ var metricsHelp = metricsHelpRegistry{
 	histograms: map[string]string{
 		MetricJobStartupDurationSeconds:   "Time spent waiting for workflow job to get started on the runner owned by the scale set (in seconds).",
 		MetricJobExecutionDurationSeconds: "Time spent executing workflow jobs by the scale set (in seconds).",
+
+		MetricJobQueuedToAcquiredDurationSeconds:         "Time between the workflow job being queued and being acquired by the scale set (in seconds).",
+		MetricJobAcquiredToDesiredPatchDurationSeconds:   "Time between the workflow job being acquired and the listener scaling up the ephemeral runner set (in seconds).",
+		MetricJobDesiredPatchToPodCreatedDurationSeconds: "Time between the listener scaling up the ephemeral runner set and the runner pod being created (in seconds).",
+		MetricJobPodCreatedToRunnerOnlineDurationSeconds: "Time between the runner pod being created and the runner going online (in seconds).",
+		MetricJobRunnerOnlineToJobStartedDurationSeconds: "Time between the runner going online, or the job being acquired for an already online runner, and the job starting (in seconds).",
 	},
 }
 
@@ -102,9 +123,28 @@ func (e *exporter) startedJobLabels(msg *scaleset.JobStarted) prometheus.Labels
 type Recorder interface {
 	RecordStatic(min, max int)
 	RecordStatistics(stats *scaleset.RunnerScaleSetStatistic)
+	RecordJobAssigned(msg *scaleset.JobAssigned)
 	RecordJobStarted(msg *scaleset.JobStarted)
 	RecordJobCompleted(msg *scaleset.JobCompleted)
 	RecordDesiredRunners(count int)
+	RecordJobStartupTimeline(timeline *JobStartupTimeline)
+}
+
+// JobStartupTimeline holds the points in time a job goes through before it starts
+// on a runner. Zero values are unknown and the phases they delimit are not recorded.
+type JobStartupTimeline struct {
+	// Queued is when the job was queued on GitHub.
+	Queued time.Time
+	// Acquired is when the job was assigned to the scale set.
+	Acquired time.Time
+	// DesiredPatch is when the listener first scaled up the ephemeral runner set after the job was acquired.
+	DesiredPatch time.Time
+	// PodCreated is when the pod of the runner that picked up the job was created.
+	PodCreated time.Time
+	// RunnerOnline is when the runner that picked up the job went online.
+	RunnerOnline time.Time
+	// JobStarted is when the job was assigned to the runner.
+	JobStarted time.Time
 }
 
 type ServerExporter interface {
@@ -123,13 +163,41 @@ type exporter struct {
 	logger         *slog.Logger
 	scaleSetLabels prometheus.Labels
 	*metrics
-	srv *http.Server
+	runner runnerCost
+	// workflowJobs is nil unless the workflow job metrics are enabled.
+	workflowJobs *workflowJobMetrics
+	srv          *http.Server
+}
+
+// runnerCost holds what is needed to attribute the cost of a job to
+// the runner pod that executed it.
+type runnerCost struct {
+	cpuCores   float64
+	memoryGiB  float64
+	priceTable *v1alpha1.PriceTable
+}
+
+func newRunnerCost(requests corev1.ResourceList, priceTable *v1alpha1.PriceTable) runnerCost {
+	return runnerCost{
+		cpuCores:   requests.Cpu().AsApproximateFloat64(),
+		memoryGiB:  float64(requests.Memory().Value()) / (1 << 30),
+		priceTable: priceTable,
+	}
+}
+
+// cost returns the price of running the runner pod for the given number of seconds.
+func (c *runnerCost) cost(seconds float64) float64 {
+	hours := seconds / 3600
+	return hours * (c.priceTable.PerRunnerHour +
+		c.cpuCores*c.priceTable.PerCPUCoreHour +
+		c.memoryGiB*c.priceTable.PerMemoryGiBHour)
 }
 
 type metrics struct {
 	counters   map[string]*counterMetric
 	gauges     map[string]*gaugeMetric
 	histograms map[string]*histogramMetric
+	limiter    *cardinalityLimiter
 }
 
 type counterMetric struct {
@@ -157,6 +225,9 @@ type ExporterConfig struct {
 	ServerEndpoint    string
 	Logger            *slog.Logger
 	Metrics           *v1alpha1.MetricsConfig
+	// RunnerResources are the resources requested by a single runner pod.
+	// They are used to weight the runner-seconds consumed by jobs.
+	RunnerResources corev1.ResourceList
 }
 
 var defaultMetrics = v1alpha1.MetricsConfig{
@@ -180,6 +251,39 @@ var defaultMetrics = v1alpha1.MetricsConfig{
 				labelKeyJobResult,
 			},
 		},
+		MetricJobRunnerSecondsTotal: {
+			Labels: []string{
+				labelKeyEnterprise,
+				labelKeyOrganization,
+				labelKeyRepository,
+				labelKeyJobWorkflowName,
+			},
+		},
+		MetricJobCPUCoreSecondsTotal: {
+			Labels: []string{
+				labelKeyEnterprise,
+				labelKeyOrganization,
+				labelKeyRepository,
+				labelKeyJobWorkflowName,
+			},
+		},
+		MetricJobMemoryGiBSecondsTotal: {
+			Labels: []string{
+				labelKeyEnterprise,
+				labelKeyOrganization,
+				labelKeyRepository,
+				labelKeyJobWorkflowName,
+			},
+		},
+		// Only registered when a price table is configured
+		MetricJobCostTotal: {
+			Labels: []string{
+				labelKeyEnterprise,
+				labelKeyOrganization,
+				labelKeyRepository,
+				labelKeyJobWorkflowName,
+			},
+		},
 	},
 	Gauges: map[string]*v1alpha1.GaugeMetric{
 		MetricAssignedJobs: {
@@ -277,6 +381,56 @@ var defaultMetrics = v1alpha1.MetricsConfig{
 			},
 			Buckets: defaultRuntimeBuckets,
 		},
+		MetricJobQueuedToAcquiredDurationSeconds: {
+			Labels: []string{
+				labelKeyEnterprise,
+				labelKeyOrganization,
+				labelKeyRepository,
+				labelKeyRunnerScaleSetName,
+				labelKeyRunnerScaleSetNamespace,
+			},
+			Buckets: defaultRuntimeBuckets,
+		},
+		MetricJobAcquiredToDesiredPatchDurationSeconds: {
+			Labels: []string{
+				labelKeyEnterprise,
+				labelKeyOrganization,
+				labelKeyRepository,
+				labelKeyRunnerScaleSetName,
+				labelKeyRunnerScaleSetNamespace,
+			},
+			Buckets: defaultRuntimeBuckets,
+		},
+		MetricJobDesiredPatchToPodCreatedDurationSeconds: {
+			Labels: []string{
+				labelKeyEnterprise,
+				labelKeyOrganization,
+				labelKeyRepository,
+				labelKeyRunnerScaleSetName,
+				labelKeyRunnerScaleSetNamespace,
+			},
+			Buckets: defaultRuntimeBuckets,
+		},
+		MetricJobPodCreatedToRunnerOnlineDurationSeconds: {
+			Labels: []string{
+				labelKeyEnterprise,
+				labelKeyOrganization,
+				labelKeyRepository,
+				labelKeyRunnerScaleSetName,
+				labelKeyRunnerScaleSetNamespace,
+			},
+			Buckets: defaultRuntimeBuckets,
+		},
+		MetricJobRunnerOnlineToJobStartedDurationSeconds: {
+			Labels: []string{
+				labelKeyEnterprise,
+				labelKeyOrganization,
+				labelKeyRepository,
+				labelKeyRunnerScaleSetName,
+				labelKeyRunnerScaleSetNamespace,
+			},
+			Buckets: defaultRuntimeBuckets,
+		},
 	},
 }
 
@@ -290,6 +444,13 @@ func (e *ExporterConfig) defaults() {
 	if e.Metrics == nil {
 		defaultMetrics := defaultMetrics
 		e.Metrics = &defaultMetrics
+	} else if len(e.Metrics.Counters) == 0 && len(e.Metrics.Gauges) == 0 && len(e.Metrics.Histograms) == 0 {
+		// A config with only a price table, cardinality limits or workflowJobMetrics keeps the default metrics
+		metrics := *e.Metrics
+		metrics.Counters = defaultMetrics.Counters
+		metrics.Gauges = defaultMetrics.Gauges
+		metrics.Histograms = defaultMetrics.Histograms
+		e.Metrics = &metrics
 	}
 }
 
@@ -299,6 +460,11 @@ func NewExporter(config ExporterConfig) ServerExporter {
 
 	metrics := installMetrics(*config.Metrics, reg, config.Logger)
 
+	var workflowJobs *workflowJobMetrics
+	if config.Metrics.WorkflowJobMetrics {
+		workflowJobs = newWorkflowJobMetrics(reg, metrics.limiter)
+	}
+
 	mux := http.NewServeMux()
 	mux.Handle(
 		config.ServerEndpoint,
@@ -314,7 +480,9 @@ func NewExporter(config ExporterConfig) ServerExporter {
 			labelKeyOrganization:            config.Organization,
 			labelKeyRepository:              config.Repository,
 		},
-		metrics: metrics,
+		metrics:      metrics,
+		runner:       newRunnerCost(config.RunnerResources, config.Metrics.PriceTable),
+		workflowJobs: workflowJobs,
 		srv: &http.Server{
 			Addr:    config.ServerAddr,
 			Handler: mux,
@@ -336,6 +504,7 @@ func installMetrics(config v1alpha1.MetricsConfig, reg *prometheus.Registry, log
 		counters:   make(map[string]*counterMetric, len(config.Counters)),
 		gauges:     make(map[string]*gaugeMetric, len(config.Gauges)),
 		histograms: make(map[string]*histogramMetric, len(config.Histograms)),
+		limiter:    newCardinalityLimiter(config.Cardinality, reg, logger),
 	}
 	for name, cfg := range config.Gauges {
 		help, ok := metricsHelp.gauges[name]
@@ -373,6 +542,14 @@ func installMetrics(config v1alpha1.MetricsConfig, reg *prometheus.Registry, log
 			)
 			continue
 		}
+		if name == MetricJobCostTotal && config.PriceTable == nil {
+			logger.Info(
+				"skipping metric that requires a price table",
+				slog.String("name", name),
+				slog.String("kind", "counter"),
+			)
+			continue
+		}
 		c := prometheus.V2.NewCounterVec(prometheus.CounterVecOpts{
 			CounterOpts: prometheus.CounterOpts{
 				Subsystem: githubScaleSetSubsystem,
@@ -444,10 +621,15 @@ func (e *exporter) setGauge(name string, allLabels prometheus.Labels, val float6
 	for _, label := range m.config.Labels {
 		labels[label] = allLabels[label]
 	}
+	e.limiter.apply(name, labels)
 	m.gauge.With(labels).Set(val)
 }
 
 func (e *exporter) incCounter(name string, allLabels prometheus.Labels) {
+	e.addCounter(name, allLabels, 1)
+}
+
+func (e *exporter) addCounter(name string, allLabels prometheus.Labels, val float64) {
 	m, ok := e.counters[name]
 	if !ok {
 		return
@@ -456,7 +638,8 @@ func (e *exporter) incCounter(name string, allLabels prometheus.Labels) {
 	for _, label := range m.config.Labels {
 		labels[label] = allLabels[label]
 	}
-	m.counter.With(labels).Inc()
+	e.limiter.apply(name, labels)
+	m.counter.With(labels).Add(val)
 }
 
 func (e *exporter) observeHistogram(name string, allLabels prometheus.Labels, val float64) {
@@ -468,6 +651,7 @@ func (e *exporter) observeHistogram(name string, allLabels prometheus.Labels, va
 	for _, label := range m.config.Labels {
 		labels[label] = allLabels[label]
 	}
+	e.limiter.apply(name, labels)
 	m.histogram.With(labels).Observe(val)
 }
 
@@ -484,7 +668,20 @@ func (e *exporter) RecordStatistics(stats *scaleset.RunnerScaleSetStatistic) {
 	e.setGauge(MetricIdleRunners, e.scaleSetLabels, float64(stats.TotalIdleRunners))
 }
 
+// RecordJobAssigned counts the job as queued in the workflow job metrics.
+// Available jobs are not counted, as they are offered to every scale set
+// matching the job labels and can be offered more than once.
+func (e *exporter) RecordJobAssigned(msg *scaleset.JobAssigned) {
+	if e.workflowJobs != nil {
+		e.workflowJobs.recordQueued(msg)
+	}
+}
+
 func (e *exporter) RecordJobStarted(msg *scaleset.JobStarted) {
+	if e.workflowJobs != nil {
+		e.workflowJobs.recordStarted(msg)
+	}
+
 	l := e.startedJobLabels(msg)
 	e.incCounter(MetricStartedJobsTotal, l)
 
@@ -493,26 +690,75 @@ func (e *exporter) RecordJobStarted(msg *scaleset.JobStarted) {
 }
 
 func (e *exporter) RecordJobCompleted(msg *scaleset.JobCompleted) {
+	if e.workflowJobs != nil {
+		e.workflowJobs.recordCompleted(msg)
+	}
+
 	if msg.RunnerAssignTime.IsZero() {
 		return
 	}
 
 	l := e.completedJobLabels(msg)
 	e.incCounter(MetricCompletedJobsTotal, l)
-	e.observeHistogram(MetricJobExecutionDurationSeconds, l, float64(msg.FinishTime.Unix()-msg.RunnerAssignTime.Unix()))
+
+	executionDuration := float64(msg.FinishTime.Unix() - msg.RunnerAssignTime.Unix())
+	e.observeHistogram(MetricJobExecutionDurationSeconds, l, executionDuration)
+	e.recordJobCost(l, executionDuration)
+}
+
+// recordJobCost attributes the runner-seconds consumed by a job, weighted
+// by the resources requested by the runner pod, to the job's labels.
+func (e *exporter) recordJobCost(labels prometheus.Labels, seconds float64) {
+	if seconds <= 0 {
+		return
+	}
+
+	e.addCounter(MetricJobRunnerSecondsTotal, labels, seconds)
+	e.addCounter(MetricJobCPUCoreSecondsTotal, labels, seconds*e.runner.cpuCores)
+	e.addCounter(MetricJobMemoryGiBSecondsTotal, labels, seconds*e.runner.memoryGiB)
+	if e.runner.priceTable != nil {
+		if cost := e.runner.cost(seconds); cost > 0 {
+			e.addCounter(MetricJobCostTotal, labels, cost)
+		}
+	}
 }
 
 func (e *exporter) RecordDesiredRunners(count int) {
 	e.setGauge(MetricDesiredRunners, e.scaleSetLabels, float64(count))
 }
 
+func (e *exporter) RecordJobStartupTimeline(timeline *JobStartupTimeline) {
+	observe := func(name string, from, to time.Time) {
+		if from.IsZero() || to.IsZero() || to.Before(from) {
+			return
+		}
+		e.observeHistogram(name, e.scaleSetLabels, to.Sub(from).Seconds())
+	}
+
+	observe(MetricJobQueuedToAcquiredDurationSeconds, timeline.Queued, timeline.Acquired)
+
+	// A runner that was online before the job was acquired didn't have to be
+	// provisioned for it, so only the time to pick up the job is relevant.
+	if !timeline.RunnerOnline.IsZero() && timeline.RunnerOnline.Before(timeline.Acquired) {
+		observe(MetricJobRunnerOnlineToJobStartedDurationSeconds, timeline.Acquired, timeline.JobStarted)
+		return
+	}
+
+	observe(MetricJobAcquiredToDesiredPatchDurationSeconds, timeline.Acquired, timeline.DesiredPatch)
+	observe(MetricJobDesiredPatchToPodCreatedDurationSeconds, timeline.DesiredPatch, timeline.PodCreated)
+	observe(MetricJobPodCreatedToRunnerOnlineDurationSeconds, timeline.PodCreated, timeline.RunnerOnline)
+	observe(MetricJobRunnerOnlineToJobStartedDurationSeconds, timeline.RunnerOnline, timeline.JobStarted)
+}
+
 type discard struct{}
 
 func (*discard) RecordStatic(int, int)                              {}
 func (*discard) RecordStatistics(*scaleset.RunnerScaleSetStatistic) {}
+func (*discard) RecordJobAssigned(*scaleset.JobAssigned)            {}
 func (*discard) RecordJobStarted(*scaleset.JobStarted)              {}
 func (*discard) RecordJobCompleted(*scaleset.JobCompleted)          {}
 func (*discard) RecordDesiredRunners(int)                           {}
+func (*discard) RecordJobStartupTimeline(*JobStartupTimeline)       {}
 
 var defaultRuntimeBuckets []float64 = []float64{
 	0.01,
@@ -3,11 +3,16 @@ package metrics
 import (
 	"log/slog"
 	"testing"
+	"time"
 
 	"github.com/actions/actions-runner-controller/apis/actions.github.com/v1alpha1"
+	"github.com/actions/scaleset"
 	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 )
 
 var discardLogger = slog.New(slog.DiscardHandler)
@@ -265,3 +270,195 @@ func TestExporterConfigDefaults(t *testing.T) {
 
 	assert.Equal(t, want, config)
 }
+
+func TestRecordJobCost(t *testing.T) {
+	metricsConfig := v1alpha1.MetricsConfig{
+		Counters: map[string]*v1alpha1.CounterMetric{
+			MetricJobRunnerSecondsTotal: {
+				Labels: []string{labelKeyRepository, labelKeyJobWorkflowName},
+			},
+			MetricJobCPUCoreSecondsTotal: {
+				Labels: []string{labelKeyRepository, labelKeyJobWorkflowName},
+			},
+			MetricJobMemoryGiBSecondsTotal: {
+				Labels: []string{labelKeyRepository, labelKeyJobWorkflowName},
+			},
+			MetricJobCostTotal: {
+				Labels: []string{labelKeyRepository, labelKeyJobWorkflowName},
+			},
+		},
+		PriceTable: &v1alpha1.PriceTable{
+			PerRunnerHour:    1,
+			PerCPUCoreHour:   2,
+			PerMemoryGiBHour: 0.5,
+		},
+	}
+
+	exporter, ok := NewExporter(ExporterConfig{
+		Logger:  discardLogger,
+		Metrics: &metricsConfig,
+		RunnerResources: corev1.ResourceList{
+			corev1.ResourceCPU:    resource.MustParse("500m"),
+			corev1.ResourceMemory: resource.MustParse("4Gi"),
+		},
+	}).(*exporter)
+	require.True(t, ok, "expected exporter to be of type *exporter")
+
+	now := time.Now()
+	exporter.RecordJobCompleted(&scaleset.JobCompleted{
+		Result: "succeeded",
+		JobMessageBase: scaleset.JobMessageBase{
+			OwnerName:        "org",
+			RepositoryName:   "repo",
+			JobWorkflowRef:   "org/repo/.github/workflows/build.yml@refs/heads/main",
+			RunnerAssignTime: now.Add(-time.Hour),
+			FinishTime:       now,
+		},
+	})
+
+	labels := prometheus.Labels{
+		labelKeyRepository:      "repo",
+		labelKeyJobWorkflowName: "build",
+	}
+	assert.Equal(t, 3600.0, testutil.ToFloat64(exporter.counters[MetricJobRunnerSecondsTotal].counter.With(labels)))
+	assert.Equal(t, 1800.0, testutil.ToFloat64(exporter.counters[MetricJobCPUCoreSecondsTotal].counter.With(labels)))
+	assert.Equal(t, 14400.0, testutil.ToFloat64(exporter.counters[MetricJobMemoryGiBSecondsTotal].counter.With(labels)))
+	// 1h * (1 + 0.5 cores * 2 + 4GiB * 0.5)
+	assert.Equal(t, 4.0, testutil.ToFloat64(exporter.counters[MetricJobCostTotal].counter.With(labels)))
+}
+
+func TestInstallMetricsSkipsCostWithoutPriceTable(t *testing.T) {
+	metricsConfig := v1alpha1.MetricsConfig{
+		Counters: map[string]*v1alpha1.CounterMetric{
+			MetricJobCostTotal: {
+				Labels: []string{labelKeyRepository},
+			},
+		},
+	}
+
+	got := installMetrics(metricsConfig, prometheus.NewRegistry(), discardLogger)
+	assert.Empty(t, got.counters)
+}
+
+func TestDefaultMetricsWithPriceTable(t *testing.T) {
+	withPrices, ok := NewExporter(ExporterConfig{
+		Logger: discardLogger,
+		Metrics: &v1alpha1.MetricsConfig{
+			PriceTable: &v1alpha1.PriceTable{PerRunnerHour: 1},
+		},
+	}).(*exporter)
+	require.True(t, ok, "expected exporter to be of type *exporter")
+
+	assert.Len(t, withPrices.counters, len(defaultMetrics.Counters))
+	assert.Contains(t, withPrices.counters, MetricJobCostTotal)
+	assert.Len(t, withPrices.gauges, len(defaultMetrics.Gauges))
+	assert.Len(t, withPrices.histograms, len(defaultMetrics.Histograms))
+
+	// Without a price table, the cost is not exposed
+	withoutPrices, ok := NewExporter(ExporterConfig{Logger: discardLogger}).(*exporter)
+	require.True(t, ok, "expected exporter to be of type *exporter")
+
+	assert.NotContains(t, withoutPrices.counters, MetricJobCostTotal)
+	assert.Len(t, withoutPrices.counters, len(defaultMetrics.Counters)-1)
+}
+
+func TestRecordJobStartupTimeline(t *testing.T) {
+	phases := []string{
+		MetricJobQueuedToAcquiredDurationSeconds,
+		MetricJobAcquiredToDesiredPatchDurationSeconds,
+		MetricJobDesiredPatchToPodCreatedDurationSeconds,
+		MetricJobPodCreatedToRunnerOnlineDurationSeconds,
+		MetricJobRunnerOnlineToJobStartedDurationSeconds,
+	}
+
+	newExporter := func(t *testing.T) *exporter {
+		metricsConfig := v1alpha1.MetricsConfig{
+			Histograms: map[string]*v1alpha1.HistogramMetric{},
+		}
+		for _, name := range phases {
+			metricsConfig.Histograms[name] = &v1alpha1.HistogramMetric{
+				Labels: []string{labelKeyRunnerScaleSetName},
+			}
+		}
+
+		e, ok := NewExporter(ExporterConfig{
+			ScaleSetName: "test-scale-set",
+			Logger:       discardLogger,
+			Metrics:      &metricsConfig,
+		}).(*exporter)
+		require.True(t, ok, "expected exporter to be of type *exporter")
+		return e
+	}
+
+	sampleSum := func(t *testing.T, e *exporter, name string) (uint64, float64) {
+		reg := prometheus.NewRegistry()
+		reg.MustRegister(e.histograms[name].histogram)
+		families, err := reg.Gather()
+		require.NoError(t, err)
+		if len(families) == 0 {
+			return 0, 0
+		}
+		h := families[0].GetMetric()[0].GetHistogram()
+		return h.GetSampleCount(), h.GetSampleSum()
+	}
+
+	queued := time.Now().Add(-time.Hour)
+
+	t.Run("cold start records every phase", func(t *testing.T) {
+		e := newExporter(t)
+		e.RecordJobStartupTimeline(&JobStartupTimeline{
+			Queued:       queued,
+			Acquired:     queued.Add(1 * time.Second),
+			DesiredPatch: queued.Add(3 * time.Second),
+			PodCreated:   queued.Add(6 * time.Second),
+			RunnerOnline: queued.Add(10 * time.Second),
+			JobStarted:   queued.Add(15 * time.Second),
+		})
+
+		for i, name := range phases {
+			count, sum := sampleSum(t, e, name)
+			assert.Equal(t, uint64(1), count, name)
+			assert.Equal(t, float64(i+1), sum, name)
+		}
+	})
+
+	t.Run("warm runner only records pickup", func(t *testing.T) {
+		e := newExporter(t)
+		e.RecordJobStartupTimeline(&JobStartupTimeline{
+			Queued:       queued,
+			Acquired:     queued.Add(2 * time.Second),
+			PodCreated:   queued.Add(-time.Minute),
+			RunnerOnline: queued.Add(-30 * time.Second),
+			JobStarted:   queued.Add(5 * time.Second),
+		})
+
+		count, sum := sampleSum(t, e, MetricJobQueuedToAcquiredDurationSeconds)
+		assert.Equal(t, uint64(1), count)
+		assert.Equal(t, 2.0, sum)
+
+		count, sum = sampleSum(t, e, MetricJobRunnerOnlineToJobStartedDurationSeconds)
+		assert.Equal(t, uint64(1), count)
+		assert.Equal(t, 3.0, sum)
+
+		for _, name := range phases[1:4] {
+			count, _ := sampleSum(t, e, name)
+			assert.Equal(t, uint64(0), count, name)
+		}
+	})
+
+	t.Run("unknown timestamps are skipped", func(t *testing.T) {
+		e := newExporter(t)
+		e.RecordJobStartupTimeline(&JobStartupTimeline{
+			Queued:     queued,
+			Acquired:   queued.Add(time.Second),
+			JobStarted: queued.Add(5 * time.Second),
+		})
+
+		count, _ := sampleSum(t, e, MetricJobQueuedToAcquiredDurationSeconds)
+		assert.Equal(t, uint64(1), count)
+		for _, name := range phases[1:] {
+			count, _ := sampleSum(t, e, name)
+			assert.Equal(t, uint64(0), count, name)
+		}
+	})
+}
@@ -78,6 +78,46 @@ func (_c *MockRecorder_RecordDesiredRunners_Call) RunAndReturn(run func(count in
 	return _c
 }
 
+// RecordJobAssigned provides a mock function for the type MockRecorder
+func (_mock *MockRecorder) RecordJobAssigned(msg *scaleset.JobAssigned) {
+	_mock.Called(msg)
+	return
+}
+
+// MockRecorder_RecordJobAssigned_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'RecordJobAssigned'
+type MockRecorder_RecordJobAssigned_Call struct {
+	*mock.Call
+}
+
+// RecordJobAssigned is a helper method to define mock.On call
+//   - msg *scaleset.JobAssigned
+func (_e *MockRecorder_Expecter) RecordJobAssigned(msg interface{}) *MockRecorder_RecordJobAssigned_Call {
+	return &MockRecorder_RecordJobAssigned_Call{Call: _e.mock.On("RecordJobAssigned", msg)}
+}
+
+func (_c *MockRecorder_RecordJobAssigned_Call) Run(run func(msg *scaleset.JobAssigned)) *MockRecorder_RecordJobAssigned_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 *scaleset.JobAssigned
+		if args[0] != nil {
+			arg0 = args[0].(*scaleset.JobAssigned)
+		}
+		run(
+			arg0,
+		)
+	})
+	return _c
+}
+
+func (_c *MockRecorder_RecordJobAssigned_Call) Return() *MockRecorder_RecordJobAssigned_Call {
+	_c.Call.Return()
+	return _c
+}
+
+func (_c *MockRecorder_RecordJobAssigned_Call) RunAndReturn(run func(msg *scaleset.JobAssigned)) *MockRecorder_RecordJobAssigned_Call {
+	_c.Run(run)
+	return _c
+}
+
 // RecordJobCompleted provides a mock function for the type MockRecorder
 func (_mock *MockRecorder) RecordJobCompleted(msg *scaleset.JobCompleted) {
 	_mock.Called(msg)
@@ -158,6 +198,46 @@ func (_c *MockRecorder_RecordJobStarted_Call) RunAndReturn(run func(msg *scalese
 	return _c
 }
 
+// RecordJobStartupTimeline provides a mock function for the type MockRecorder
+func (_mock *MockRecorder) RecordJobStartupTimeline(timeline *JobStartupTimeline) {
+	_mock.Called(timeline)
+	return
+}
+
+// MockRecorder_RecordJobStartupTimeline_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'RecordJobStartupTimeline'
+type MockRecorder_RecordJobStartupTimeline_Call struct {
+	*mock.Call
+}
+
+// RecordJobStartupTimeline is a helper method to define mock.On call
+//   - timeline *JobStartupTimeline
+func (_e *MockRecorder_Expecter) RecordJobStartupTimeline(timeline interface{}) *MockRecorder_RecordJobStartupTimeline_Call {
+	return &MockRecorder_RecordJobStartupTimeline_Call{Call: _e.mock.On("RecordJobStartupTimeline", timeline)}
+}
+
+func (_c *MockRecorder_RecordJobStartupTimeline_Call) Run(run func(timeline *JobStartupTimeline)) *MockRecorder_RecordJobStartupTimeline_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 *JobStartupTimeline
+		if args[0] != nil {
+			arg0 = args[0].(*JobStartupTimeline)
+		}
+		run(
+			arg0,
+		)
+	})
+	return _c
+}
+
+func (_c *MockRecorder_RecordJobStartupTimeline_Call) Return() *MockRecorder_RecordJobStartupTimeline_Call {
+	_c.Call.Return()
+	return _c
+}
+
+func (_c *MockRecorder_RecordJobStartupTimeline_Call) RunAndReturn(run func(timeline *JobStartupTimeline)) *MockRecorder_RecordJobStartupTimeline_Call {
+	_c.Run(run)
+	return _c
+}
+
 // RecordStatic provides a mock function for the type MockRecorder
 func (_mock *MockRecorder) RecordStatic(min int, max int) {
 	_mock.Called(min, max)
@@ -362,6 +442,46 @@ func (_c *MockServerExporter_RecordDesiredRunners_Call) RunAndReturn(run func(co
 	return _c
 }
 
+// RecordJobAssigned provides a mock function for the type MockServerExporter
+func (_mock *MockServerExporter) RecordJobAssigned(msg *scaleset.JobAssigned) {
+	_mock.Called(msg)
+	return
+}
+
+// MockServerExporter_RecordJobAssigned_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'RecordJobAssigned'
+type MockServerExporter_RecordJobAssigned_Call struct {
+	*mock.Call
+}
+
+// RecordJobAssigned is a helper method to define mock.On call
+//   - msg *scaleset.JobAssigned
+func (_e *MockServerExporter_Expecter) RecordJobAssigned(msg interface{}) *MockServerExporter_RecordJobAssigned_Call {
+	return &MockServerExporter_RecordJobAssigned_Call{Call: _e.mock.On("RecordJobAssigned", msg)}
+}
+
+func (_c *MockServerExporter_RecordJobAssigned_Call) Run(run func(msg *scaleset.JobAssigned)) *MockServerExporter_RecordJobAssigned_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 *scaleset.JobAssigned
+		if args[0] != nil {
+			arg0 = args[0].(*scaleset.JobAssigned)
+		}
+		run(
+			arg0,
+		)
+	})
+	return _c
+}
+
+func (_c *MockServerExporter_RecordJobAssigned_Call) Return() *MockServerExporter_RecordJobAssigned_Call {
+	_c.Call.Return()
+	return _c
+}
+
+func (_c *MockServerExporter_RecordJobAssigned_Call) RunAndReturn(run func(msg *scaleset.JobAssigned)) *MockServerExporter_RecordJobAssigned_Call {
+	_c.Run(run)
+	return _c
+}
+
 // RecordJobCompleted provides a mock function for the type MockServerExporter
 func (_mock *MockServerExporter) RecordJobCompleted(msg *scaleset.JobCompleted) {
 	_mock.Called(msg)
@@ -442,6 +562,46 @@ func (_c *MockServerExporter_RecordJobStarted_Call) RunAndReturn(run func(msg *s
 	return _c
 }
 
+// RecordJobStartupTimeline provides a mock function for the type MockServerExporter
+func (_mock *MockServerExporter) RecordJobStartupTimeline(timeline *JobStartupTimeline) {
+	_mock.Called(timeline)
+	return
+}
+
+// MockServerExporter_RecordJobStartupTimeline_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'RecordJobStartupTimeline'
+type MockServerExporter_RecordJobStartupTimeline_Call struct {
+	*mock.Call
+}
+
+// RecordJobStartupTimeline is a helper method to define mock.On call
+//   - timeline *JobStartupTimeline
+func (_e *MockServerExporter_Expecter) RecordJobStartupTimeline(timeline interface{}) *MockServerExporter_RecordJobStartupTimeline_Call {
+	return &MockServerExporter_RecordJobStartupTimeline_Call{Call: _e.mock.On("RecordJobStartupTimeline", timeline)}
+}
+
+func (_c *MockServerExporter_RecordJobStartupTimeline_Call) Run(run func(timeline *JobStartupTimeline)) *MockServerExporter_RecordJobStartupTimeline_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 *JobStartupTimeline
+		if args[0] != nil {
+			arg0 = args[0].(*JobStartupTimeline)
+		}
+		run(
+			arg0,
+		)
+	})
+	return _c
+}
+
+func (_c *MockServerExporter_RecordJobStartupTimeline_Call) Return() *MockServerExporter_RecordJobStartupTimeline_Call {
+	_c.Call.Return()
+	return _c
+}
+
+func (_c *MockServerExporter_RecordJobStartupTimeline_Call) RunAndReturn(run func(timeline *JobStartupTimeline)) *MockServerExporter_RecordJobStartupTimeline_Call {
+	_c.Run(run)
+	return _c
+}
+
 // RecordStatic provides a mock function for the type MockServerExporter
 func (_mock *MockServerExporter) RecordStatic(min int, max int) {
 	_mock.Called(min, max)
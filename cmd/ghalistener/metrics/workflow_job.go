@@ -0,0 +1,199 @@
+package metrics
+
+import (
+	"context"
+	"strings"
+
+	"github.com/actions/scaleset"
+	"github.com/actions/scaleset/listener"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Names of the workflow job metrics. They match the metrics exposed by the
+// actions metrics server from workflow_job webhook events, so that dashboards
+// built for it keep working without receiving webhooks.
+const (
+	MetricGitHubWorkflowJobQueueDurationSeconds = "github_workflow_job_queue_duration_seconds"
+	MetricGitHubWorkflowJobRunDurationSeconds   = "github_workflow_job_run_duration_seconds"
+	MetricGitHubWorkflowJobConclusionsTotal     = "github_workflow_job_conclusions_total"
+	MetricGitHubWorkflowJobsQueuedTotal         = "github_workflow_jobs_queued_total"
+	MetricGitHubWorkflowJobsStartedTotal        = "github_workflow_jobs_started_total"
+	MetricGitHubWorkflowJobsCompletedTotal      = "github_workflow_jobs_completed_total"
+)
+
+const labelKeyJobConclusion = "job_conclusion"
+
+var workflowJobLabels = []string{"runs_on", "job_name", "organization", "repository", "repository_full_name", "owner", "workflow_name", "head_branch"}
+
+// workflowJobMetrics records the github_workflow_job_* metrics.
+type workflowJobMetrics struct {
+	queueDurationSeconds *prometheus.HistogramVec
+	runDurationSeconds   *prometheus.HistogramVec
+	conclusionsTotal     *prometheus.CounterVec
+	queuedTotal          *prometheus.CounterVec
+	startedTotal         *prometheus.CounterVec
+	completedTotal       *prometheus.CounterVec
+	limiter              *cardinalityLimiter
+}
+
+func newWorkflowJobMetrics(reg prometheus.Registerer, limiter *cardinalityLimiter) *workflowJobMetrics {
+	withConclusion := append(append([]string{}, workflowJobLabels...), labelKeyJobConclusion)
+	m := &workflowJobMetrics{
+		limiter: limiter,
+		queueDurationSeconds: prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Name:    MetricGitHubWorkflowJobQueueDurationSeconds,
+				Help:    "Queue times for workflow jobs in seconds",
+				Buckets: defaultRuntimeBuckets,
+			},
+			workflowJobLabels,
+		),
+		runDurationSeconds: prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Name:    MetricGitHubWorkflowJobRunDurationSeconds,
+				Help:    "Run times for workflow jobs in seconds",
+				Buckets: defaultRuntimeBuckets,
+			},
+			withConclusion,
+		),
+		conclusionsTotal: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: MetricGitHubWorkflowJobConclusionsTotal,
+				Help: "Conclusions for tracked workflow jobs",
+			},
+			withConclusion,
+		),
+		queuedTotal: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: MetricGitHubWorkflowJobsQueuedTotal,
+				Help: "Total count of workflow jobs queued (events where job_status=queued)",
+			},
+			workflowJobLabels,
+		),
+		startedTotal: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: MetricGitHubWorkflowJobsStartedTotal,
+				Help: "Total count of workflow jobs started (events where job_status=in_progress)",
+			},
+			workflowJobLabels,
+		),
+		completedTotal: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: MetricGitHubWorkflowJobsCompletedTotal,
+				Help: "Total count of workflow jobs completed (events where job_status=completed)",
+			},
+			workflowJobLabels,
+		),
+	}
+	reg.MustRegister(
+		m.queueDurationSeconds,
+		m.runDurationSeconds,
+		m.conclusionsTotal,
+		m.queuedTotal,
+		m.startedTotal,
+		m.completedTotal,
+	)
+	return m
+}
+
+// labels maps the job message to the labels the actions metrics server
+// derives from the workflow_job webhook payload. The workflow name is taken
+// from the workflow file and the head branch from the workflow ref, as the
+// messages don't carry the workflow display name nor the pull request branch.
+func (m *workflowJobMetrics) labels(base *scaleset.JobMessageBase) prometheus.Labels {
+	ref := ParseWorkflowRef(base.JobWorkflowRef)
+	fullName := base.RepositoryName
+	if base.OwnerName != "" {
+		fullName = base.OwnerName + "/" + base.RepositoryName
+	}
+	return prometheus.Labels{
+		"runs_on":              strings.Join(base.RequestLabels, ","),
+		"job_name":             base.JobDisplayName,
+		"organization":         base.OwnerName,
+		"repository":           base.RepositoryName,
+		"repository_full_name": fullName,
+		"owner":                base.OwnerName,
+		"workflow_name":        ref.Name,
+		"head_branch":          strings.TrimPrefix(ref.Target, "heads/"),
+	}
+}
+
+// limit returns a copy of the labels with the cardinality limit of the metric applied.
+func (m *workflowJobMetrics) limit(metric string, labels prometheus.Labels) prometheus.Labels {
+	limited := make(prometheus.Labels, len(labels))
+	for k, v := range labels {
+		limited[k] = v
+	}
+	m.limiter.apply(metric, limited)
+	return limited
+}
+
+func (m *workflowJobMetrics) recordQueued(msg *scaleset.JobAssigned) {
+	labels := m.labels(&msg.JobMessageBase)
+	m.queuedTotal.With(m.limit(MetricGitHubWorkflowJobsQueuedTotal, labels)).Inc()
+}
+
+func (m *workflowJobMetrics) recordStarted(msg *scaleset.JobStarted) {
+	labels := m.labels(&msg.JobMessageBase)
+	m.startedTotal.With(m.limit(MetricGitHubWorkflowJobsStartedTotal, labels)).Inc()
+
+	if msg.QueueTime.IsZero() || msg.RunnerAssignTime.Before(msg.QueueTime) {
+		return
+	}
+	m.queueDurationSeconds.With(m.limit(MetricGitHubWorkflowJobQueueDurationSeconds, labels)).Observe(msg.RunnerAssignTime.Sub(msg.QueueTime).Seconds())
+}
+
+func (m *workflowJobMetrics) recordCompleted(msg *scaleset.JobCompleted) {
+	labels := m.labels(&msg.JobMessageBase)
+	m.completedTotal.With(m.limit(MetricGitHubWorkflowJobsCompletedTotal, labels)).Inc()
+
+	labels[labelKeyJobConclusion] = workflowJobConclusion(msg.Result)
+	m.conclusionsTotal.With(m.limit(MetricGitHubWorkflowJobConclusionsTotal, labels)).Inc()
+
+	if msg.RunnerAssignTime.IsZero() || msg.FinishTime.Before(msg.RunnerAssignTime) {
+		return
+	}
+	m.runDurationSeconds.With(m.limit(MetricGitHubWorkflowJobRunDurationSeconds, labels)).Observe(msg.FinishTime.Sub(msg.RunnerAssignTime).Seconds())
+}
+
+// workflowJobConclusion maps the job result reported to the scale set to the
+// conclusion reported in workflow_job webhook events.
+func workflowJobConclusion(result string) string {
+	switch r := strings.ToLower(result); r {
+	case "succeeded":
+		return "success"
+	case "failed":
+		return "failure"
+	case "canceled":
+		return "cancelled"
+	default:
+		return r
+	}
+}
+
+// messageRecordingClient records the job messages the listener does not
+// report to its metrics recorder.
+type messageRecordingClient struct {
+	listener.Client
+	recorder Recorder
+}
+
+// NewMessageRecordingClient wraps the client so that jobs assigned to the
+// scale set are reported to the recorder as messages are received.
+func NewMessageRecordingClient(client listener.Client, recorder Recorder) listener.Client {
+	return &messageRecordingClient{
+		Client:   client,
+		recorder: recorder,
+	}
+}
+
+func (c *messageRecordingClient) GetMessage(ctx context.Context, lastMessageID, maxCapacity int) (*scaleset.RunnerScaleSetMessage, error) {
+	msg, err := c.Client.GetMessage(ctx, lastMessageID, maxCapacity)
+	if err != nil || msg == nil {
+		return msg, err
+	}
+	for _, assigned := range msg.JobAssignedMessages {
+		c.recorder.RecordJobAssigned(assigned)
+	}
+	return msg, nil
+}
@@ -0,0 +1,146 @@
+package metrics
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/actions/actions-runner-controller/apis/actions.github.com/v1alpha1"
+	"github.com/actions/scaleset"
+	"github.com/actions/scaleset/listener"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWorkflowJobMetrics(t *testing.T) {
+	t.Run("disabled by default", func(t *testing.T) {
+		exporter, ok := NewExporter(ExporterConfig{Logger: discardLogger}).(*exporter)
+		require.True(t, ok, "expected exporter to be of type *exporter")
+		assert.Nil(t, exporter.workflowJobs)
+
+		// must not panic
+		exporter.RecordJobAssigned(&scaleset.JobAssigned{})
+	})
+
+	exporter, ok := NewExporter(ExporterConfig{
+		Logger:  discardLogger,
+		Metrics: &v1alpha1.MetricsConfig{WorkflowJobMetrics: true},
+	}).(*exporter)
+	require.True(t, ok, "expected exporter to be of type *exporter")
+	require.NotNil(t, exporter.workflowJobs)
+
+	now := time.Now()
+	base := scaleset.JobMessageBase{
+		OwnerName:        "org",
+		RepositoryName:   "repo",
+		JobDisplayName:   "build",
+		JobWorkflowRef:   "org/repo/.github/workflows/ci.yml@refs/heads/main",
+		RequestLabels:    []string{"self-hosted", "linux"},
+		QueueTime:        now.Add(-2 * time.Minute),
+		RunnerAssignTime: now.Add(-time.Minute),
+		FinishTime:       now,
+	}
+
+	exporter.RecordJobAssigned(&scaleset.JobAssigned{JobMessageBase: base})
+	exporter.RecordJobStarted(&scaleset.JobStarted{JobMessageBase: base})
+	exporter.RecordJobCompleted(&scaleset.JobCompleted{Result: "failed", JobMessageBase: base})
+
+	labels := prometheus.Labels{
+		"runs_on":              "self-hosted,linux",
+		"job_name":             "build",
+		"organization":         "org",
+		"repository":           "repo",
+		"repository_full_name": "org/repo",
+		"owner":                "org",
+		"workflow_name":        "ci",
+		"head_branch":          "main",
+	}
+	m := exporter.workflowJobs
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.queuedTotal.With(labels)))
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.startedTotal.With(labels)))
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.completedTotal.With(labels)))
+
+	labels[labelKeyJobConclusion] = "failure"
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.conclusionsTotal.With(labels)))
+	assert.Equal(t, 1, testutil.CollectAndCount(m.runDurationSeconds, MetricGitHubWorkflowJobRunDurationSeconds))
+	assert.Equal(t, 1, testutil.CollectAndCount(m.queueDurationSeconds, MetricGitHubWorkflowJobQueueDurationSeconds))
+}
+
+func TestWorkflowJobMetricsCardinality(t *testing.T) {
+	exporter, ok := NewExporter(ExporterConfig{
+		Logger: discardLogger,
+		Metrics: &v1alpha1.MetricsConfig{
+			WorkflowJobMetrics: true,
+			Cardinality:        &v1alpha1.CardinalityConfig{MaxLabelValues: 1},
+		},
+	}).(*exporter)
+	require.True(t, ok, "expected exporter to be of type *exporter")
+
+	for _, name := range []string{"build", "test"} {
+		exporter.RecordJobAssigned(&scaleset.JobAssigned{JobMessageBase: scaleset.JobMessageBase{
+			OwnerName:      "org",
+			RepositoryName: "repo",
+			JobDisplayName: name,
+		}})
+	}
+
+	m := exporter.workflowJobs
+	assert.Equal(t, 2, testutil.CollectAndCount(m.queuedTotal, MetricGitHubWorkflowJobsQueuedTotal))
+	// only job_name is over the limit
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.queuedTotal.With(prometheus.Labels{
+		"runs_on":              "",
+		"job_name":             overflowLabelValue,
+		"organization":         "org",
+		"repository":           "repo",
+		"repository_full_name": "org/repo",
+		"owner":                "org",
+		"workflow_name":        "",
+		"head_branch":          "",
+	})))
+	assert.Equal(t, 1.0, testutil.ToFloat64(exporter.limiter.dropped.WithLabelValues(MetricGitHubWorkflowJobsQueuedTotal, "job_name")))
+}
+
+func TestWorkflowJobConclusion(t *testing.T) {
+	tests := map[string]string{
+		"succeeded": "success",
+		"Failed":    "failure",
+		"canceled":  "cancelled",
+		"skipped":   "skipped",
+	}
+	for result, want := range tests {
+		assert.Equal(t, want, workflowJobConclusion(result), result)
+	}
+}
+
+type fakeMessageClient struct {
+	listener.Client
+	msg *scaleset.RunnerScaleSetMessage
+}
+
+func (c *fakeMessageClient) GetMessage(context.Context, int, int) (*scaleset.RunnerScaleSetMessage, error) {
+	return c.msg, nil
+}
+
+func TestMessageRecordingClient(t *testing.T) {
+	assigned := &scaleset.JobAssigned{JobMessageBase: scaleset.JobMessageBase{JobID: "1"}}
+	msg := &scaleset.RunnerScaleSetMessage{
+		MessageID:           1,
+		JobAssignedMessages: []*scaleset.JobAssigned{assigned},
+	}
+
+	recorder := NewMockRecorder(t)
+	recorder.EXPECT().RecordJobAssigned(assigned).Once()
+
+	client := NewMessageRecordingClient(&fakeMessageClient{msg: msg}, recorder)
+	got, err := client.GetMessage(context.Background(), 0, 10)
+	require.NoError(t, err)
+	assert.Same(t, msg, got)
+
+	// nil messages are returned as is
+	client = NewMessageRecordingClient(&fakeMessageClient{}, recorder)
+	got, err = client.GetMessage(context.Background(), 0, 10)
+	require.NoError(t, err)
+	assert.Nil(t, got)
+}
@@ -0,0 +1,136 @@
+package scaler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/actions/actions-runner-controller/apis/actions.github.com/v1alpha1"
+)
+
+const (
+	// prewarmWatchMinBackoff and prewarmWatchMaxBackoff bound the delay before the prewarm hint watch is restarted after a failure.
+	prewarmWatchMinBackoff = time.Second
+	prewarmWatchMaxBackoff = time.Minute
+)
+
+// WatchPrewarmHints watches the prewarm hint that the github webhook server sets on the ephemeral runner set
+// when jobs are queued for the runner scale set, and scales the ephemeral runner set up as soon as the hint
+// asks for more runners than the listener does.
+// The hint stops applying on the first desired runner count handled after it expired.
+// It blocks until the context is done.
+func (w *Scaler) WatchPrewarmHints(ctx context.Context) error {
+	backoff := prewarmWatchMinBackoff
+
+	for {
+		err := w.watchPrewarmHints(ctx)
+		if ctx.Err() != nil {
+			return nil
+		}
+
+		if err != nil {
+			w.logger.Error("Prewarm hint watch failed", "error", err.Error(), "retryAfter", backoff)
+		} else {
+			// The server closed the watch after its timeout.
+			backoff = prewarmWatchMinBackoff
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(backoff):
+		}
+
+		if err != nil {
+			backoff = min(backoff*2, prewarmWatchMaxBackoff)
+		}
+	}
+}
+
+func (w *Scaler) watchPrewarmHints(ctx context.Context) error {
+	stream, err := w.clientset.RESTClient().
+		Get().
+		Prefix("apis", v1alpha1.GroupVersion.Group, v1alpha1.GroupVersion.Version).
+		Namespace(w.config.EphemeralRunnerSetNamespace).
+		Resource("ephemeralrunnersets").
+		Param("watch", "true").
+		Param("fieldSelector", "metadata.name="+w.config.EphemeralRunnerSetName).
+		Stream(ctx)
+	if err != nil {
+		return fmt.Errorf("could not watch ephemeral runner set: %w", err)
+	}
+	defer stream.Close()
+
+	return decodeEphemeralRunnerSetEvents(stream, func(ers *v1alpha1.EphemeralRunnerSet) error {
+		hint, err := v1alpha1.ParsePrewarmHint(ers.Annotations)
+		if err != nil {
+			w.logger.Error("Ignoring invalid prewarm hint", "error", err.Error())
+			return nil
+		}
+
+		return w.HandlePrewarmHint(ctx, hint)
+	})
+}
+
+// HandlePrewarmHint records the prewarm hint, and scales the ephemeral runner set up right away
+// when the hint asks for more runners than currently targeted.
+func (w *Scaler) HandlePrewarmHint(ctx context.Context, hint *v1alpha1.PrewarmHint) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.prewarmHint = hint
+
+	// Leave the first patch to the listener, which knows the initial desired runner count.
+	if w.targetRunners < 0 {
+		return nil
+	}
+
+	floor := hint.Floor(time.Now())
+	if min(w.config.MinRunners+max(w.lastCount, floor), w.config.MaxRunners) <= w.targetRunners {
+		return nil
+	}
+
+	w.logger.Info("Prewarming ephemeral runner set", "prewarm", floor, "expiresAt", hint.ExpiresAt.Time)
+
+	_, err := w.scale(ctx, w.lastCount)
+	return err
+}
+
+// watchEvent is a watch event of the Kubernetes API, as streamed by the watch endpoints.
+type watchEvent struct {
+	Type   string          `json:"type"`
+	Object json.RawMessage `json:"object"`
+}
+
+// decodeEphemeralRunnerSetEvents calls handle for every added or modified ephemeral runner set in the watch stream,
+// until the stream ends.
+func decodeEphemeralRunnerSetEvents(r io.Reader, handle func(*v1alpha1.EphemeralRunnerSet) error) error {
+	dec := json.NewDecoder(r)
+
+	for {
+		var ev watchEvent
+		if err := dec.Decode(&ev); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return fmt.Errorf("failed to decode watch event: %w", err)
+		}
+
+		switch ev.Type {
+		case "ADDED", "MODIFIED":
+			var ers v1alpha1.EphemeralRunnerSet
+			if err := json.Unmarshal(ev.Object, &ers); err != nil {
+				return fmt.Errorf("failed to decode ephemeral runner set: %w", err)
+			}
+
+			if err := handle(&ers); err != nil {
+				return err
+			}
+		case "ERROR":
+			return fmt.Errorf("watch failed: %s", string(ev.Object))
+		}
+	}
+}
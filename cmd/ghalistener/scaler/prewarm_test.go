@@ -0,0 +1,107 @@
+package scaler
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/actions/actions-runner-controller/apis/actions.github.com/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestSetDesiredWorkerState_PrewarmHint(t *testing.T) {
+	newWorker := func(hint *v1alpha1.PrewarmHint) *Scaler {
+		return &Scaler{
+			config: Config{
+				MinRunners: 1,
+				MaxRunners: 5,
+			},
+			targetRunners: -1,
+			patchSeq:      -1,
+			prewarmHint:   hint,
+			logger:        discardLogger,
+		}
+	}
+
+	active := &v1alpha1.PrewarmHint{Runners: 3, ExpiresAt: metav1.NewTime(time.Now().Add(time.Minute))}
+	expired := &v1alpha1.PrewarmHint{Runners: 3, ExpiresAt: metav1.NewTime(time.Now().Add(-time.Minute))}
+
+	t.Run("floor above the desired count", func(t *testing.T) {
+		w := newWorker(active)
+		w.setDesiredWorkerState(1)
+		assert.Equal(t, 4, w.targetRunners)
+	})
+
+	t.Run("desired count above the floor", func(t *testing.T) {
+		w := newWorker(active)
+		w.setDesiredWorkerState(4)
+		assert.Equal(t, 5, w.targetRunners)
+	})
+
+	t.Run("floor is capped by max runners", func(t *testing.T) {
+		w := newWorker(&v1alpha1.PrewarmHint{Runners: 10, ExpiresAt: active.ExpiresAt})
+		w.setDesiredWorkerState(0)
+		assert.Equal(t, 5, w.targetRunners)
+	})
+
+	t.Run("expired hint is ignored", func(t *testing.T) {
+		w := newWorker(expired)
+		w.setDesiredWorkerState(1)
+		assert.Equal(t, 2, w.targetRunners)
+	})
+}
+
+func TestHandlePrewarmHint_NoScale(t *testing.T) {
+	hint := &v1alpha1.PrewarmHint{Runners: 2, ExpiresAt: metav1.NewTime(time.Now().Add(time.Minute))}
+
+	t.Run("before the first desired runner count", func(t *testing.T) {
+		w := &Scaler{
+			config:        Config{MaxRunners: 5},
+			targetRunners: -1,
+			logger:        discardLogger,
+		}
+
+		require.NoError(t, w.HandlePrewarmHint(context.Background(), hint))
+		assert.Equal(t, hint, w.prewarmHint)
+		assert.Equal(t, -1, w.targetRunners)
+	})
+
+	t.Run("already targeting enough runners", func(t *testing.T) {
+		w := &Scaler{
+			config:        Config{MaxRunners: 5},
+			targetRunners: 3,
+			lastCount:     3,
+			logger:        discardLogger,
+		}
+
+		require.NoError(t, w.HandlePrewarmHint(context.Background(), hint))
+		assert.Equal(t, hint, w.prewarmHint)
+		assert.Equal(t, 3, w.targetRunners)
+	})
+}
+
+func TestDecodeEphemeralRunnerSetEvents(t *testing.T) {
+	stream := strings.NewReader(`{"type":"ADDED","object":{"metadata":{"name":"ers","annotations":{"actions.github.com/prewarm-hint":"{\"runners\":1,\"expiresAt\":\"2026-01-01T00:00:00Z\"}"}}}}
+{"type":"BOOKMARK","object":{"metadata":{"resourceVersion":"2"}}}
+{"type":"MODIFIED","object":{"metadata":{"name":"ers"}}}
+`)
+
+	var hints []*v1alpha1.PrewarmHint
+	err := decodeEphemeralRunnerSetEvents(stream, func(ers *v1alpha1.EphemeralRunnerSet) error {
+		hint, err := v1alpha1.ParsePrewarmHint(ers.Annotations)
+		hints = append(hints, hint)
+		return err
+	})
+	require.NoError(t, err)
+	require.Len(t, hints, 2)
+	assert.Equal(t, 1, hints[0].Runners)
+	assert.Nil(t, hints[1])
+
+	err = decodeEphemeralRunnerSetEvents(strings.NewReader(`{"type":"ERROR","object":{"message":"too old resource version"}}`), func(*v1alpha1.EphemeralRunnerSet) error {
+		return nil
+	})
+	assert.ErrorContains(t, err, "too old resource version")
+}
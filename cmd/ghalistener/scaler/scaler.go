@@ -6,8 +6,11 @@ import (
 	"fmt"
 	"log/slog"
 	"math"
+	"sync"
+	"time"
 
 	"github.com/actions/actions-runner-controller/apis/actions.github.com/v1alpha1"
+	"github.com/actions/actions-runner-controller/cmd/ghalistener/metrics"
 	"github.com/actions/scaleset"
 	"github.com/actions/scaleset/listener"
 	jsonpatch "github.com/evanphx/json-patch"
@@ -25,6 +28,16 @@ func WithLogger(logger *slog.Logger) Option {
 	}
 }
 
+// WithMetricsRecorder sets the recorder used to report the job startup phases.
+func WithMetricsRecorder(recorder metrics.Recorder) Option {
+	return func(w *Scaler) {
+		w.recorder = recorder
+	}
+}
+
+// scaleUpRetention is how long scale up timestamps are kept to be matched with started jobs.
+const scaleUpRetention = time.Hour
+
 type Config struct {
 	EphemeralRunnerSetNamespace string
 	EphemeralRunnerSetName      string
@@ -35,13 +48,23 @@ type Config struct {
 // The Scaler's role is to process the messages it receives from the listener.
 // It then initiates Kubernetes API requests to carry out the necessary actions.
 type Scaler struct {
-	clientset     *kubernetes.Clientset
-	config        Config
+	clientset *kubernetes.Clientset
+	config    Config
+
+	// mu guards the state below, which is also updated by WatchPrewarmHints.
+	mu            sync.Mutex
 	targetRunners int
 	patchSeq      int
+	// lastCount is the last desired runner count received from the listener.
+	lastCount int
+	// prewarmHint is the last prewarm hint read from the ephemeral runner set.
+	prewarmHint *v1alpha1.PrewarmHint
 	// dirty is set when there are any events handled before the desired count is called.
-	dirty  bool
-	logger *slog.Logger
+	dirty bool
+	// scaleUps holds the times the ephemeral runner set was scaled up, oldest first.
+	scaleUps []time.Time
+	recorder metrics.Recorder
+	logger   *slog.Logger
 }
 
 var _ listener.Scaler = (*Scaler)(nil)
@@ -80,6 +103,9 @@ func (w *Scaler) applyDefaults() error {
 	if w.logger == nil {
 		w.logger = slog.New(slog.DiscardHandler)
 	}
+	if w.recorder == nil {
+		w.recorder = metrics.Discard
+	}
 
 	return nil
 }
@@ -100,6 +126,9 @@ func (w *Scaler) HandleJobStarted(ctx context.Context, jobInfo *scaleset.JobStar
 		"jobDisplayName", jobInfo.JobDisplayName,
 		"requestId", jobInfo.RunnerRequestID)
 
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
 	w.dirty = true
 
 	original, err := json.Marshal(&v1alpha1.EphemeralRunner{})
@@ -151,10 +180,56 @@ func (w *Scaler) HandleJobStarted(ctx context.Context, jobInfo *scaleset.JobStar
 
 	w.logger.Info("Ephemeral runner status updated with the merge patch successfully.")
 
+	w.recorder.RecordJobStartupTimeline(w.jobStartupTimeline(jobInfo, patchedStatus))
+
 	return nil
 }
 
+// jobStartupTimeline combines the timestamps of the job with the ones the controller
+// recorded on the ephemeral runner that picked it up.
+func (w *Scaler) jobStartupTimeline(jobInfo *scaleset.JobStarted, ephemeralRunner *v1alpha1.EphemeralRunner) *metrics.JobStartupTimeline {
+	timeline := &metrics.JobStartupTimeline{
+		Queued:       jobInfo.QueueTime,
+		Acquired:     jobInfo.ScaleSetAssignTime,
+		DesiredPatch: w.firstScaleUpAfter(jobInfo.ScaleSetAssignTime),
+		JobStarted:   jobInfo.RunnerAssignTime,
+	}
+	if ephemeralRunner.Status.PodCreatedAt != nil {
+		timeline.PodCreated = ephemeralRunner.Status.PodCreatedAt.Time
+	}
+	if ephemeralRunner.Status.ReadyAt != nil {
+		timeline.RunnerOnline = ephemeralRunner.Status.ReadyAt.Time
+	}
+	return timeline
+}
+
+func (w *Scaler) recordScaleUp(now time.Time) {
+	cutoff := now.Add(-scaleUpRetention)
+	i := 0
+	for i < len(w.scaleUps) && w.scaleUps[i].Before(cutoff) {
+		i++
+	}
+	w.scaleUps = append(w.scaleUps[i:], now)
+}
+
+// firstScaleUpAfter returns the time of the first scale up that happened at or after t,
+// or the zero time if there is none.
+func (w *Scaler) firstScaleUpAfter(t time.Time) time.Time {
+	if t.IsZero() {
+		return time.Time{}
+	}
+	for _, scaleUp := range w.scaleUps {
+		if !scaleUp.Before(t) {
+			return scaleUp
+		}
+	}
+	return time.Time{}
+}
+
 func (w *Scaler) HandleJobCompleted(ctx context.Context, msg *scaleset.JobCompleted) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
 	w.dirty = true
 	return nil
 }
@@ -167,6 +242,17 @@ func (w *Scaler) HandleJobCompleted(ctx context.Context, msg *scaleset.JobComple
 // Finally, it logs the scaled ephemeral runner set details and returns nil if successful.
 // If any error occurs during the process, it returns an error with a descriptive message.
 func (w *Scaler) HandleDesiredRunnerCount(ctx context.Context, count int) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.lastCount = count
+
+	return w.scale(ctx, count)
+}
+
+// scale patches the ephemeral runner set for the desired runner count. It must be called with mu held.
+func (w *Scaler) scale(ctx context.Context, count int) (int, error) {
+	previousTargetRunners := w.targetRunners
 	patchID := w.setDesiredWorkerState(count)
 
 	original, err := json.Marshal(
@@ -216,6 +302,10 @@ func (w *Scaler) HandleDesiredRunnerCount(ctx context.Context, count int) (int,
 		return 0, fmt.Errorf("could not patch ephemeral runner set , patch JSON: %s, error: %w", string(mergePatch), err)
 	}
 
+	if w.targetRunners > max(previousTargetRunners, 0) {
+		w.recordScaleUp(time.Now())
+	}
+
 	w.logger.Info("Ephemeral runner set scaled.",
 		"namespace", w.config.EphemeralRunnerSetNamespace,
 		"name", w.config.EphemeralRunnerSetName,
@@ -234,7 +324,8 @@ func (w *Scaler) setDesiredWorkerState(count int) int {
 	}
 	w.patchSeq++
 
-	targetRunnerCount := min(w.config.MinRunners+count, w.config.MaxRunners)
+	// The prewarm hint is a floor for the jobs the listener is not aware of yet.
+	targetRunnerCount := min(w.config.MinRunners+max(count, w.prewarmHint.Floor(time.Now())), w.config.MaxRunners)
 	oldTargetRunners := w.targetRunners
 	w.targetRunners = targetRunnerCount
 
@@ -250,6 +341,7 @@ func (w *Scaler) setDesiredWorkerState(count int) int {
 	w.logger.Info(
 		"Calculated target runner count",
 		"assigned job", count,
+		"prewarm", w.prewarmHint.Floor(time.Now()),
 		"decision", targetRunnerCount,
 		"min", w.config.MinRunners,
 		"max", w.config.MaxRunners,
@@ -4,6 +4,7 @@ import (
 	"log/slog"
 	"math"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -332,3 +333,22 @@ func TestSetDesiredWorkerState_MinMaxSet(t *testing.T) {
 		assert.Equal(t, 2, w.patchSeq)
 	})
 }
+
+func TestScaleUpHistory(t *testing.T) {
+	now := time.Now()
+	w := &Scaler{}
+
+	assert.True(t, w.firstScaleUpAfter(now).IsZero())
+
+	w.recordScaleUp(now.Add(-2 * scaleUpRetention))
+	w.recordScaleUp(now.Add(-time.Minute))
+	w.recordScaleUp(now)
+
+	// entries older than the retention are dropped
+	assert.Len(t, w.scaleUps, 2)
+
+	assert.Equal(t, now.Add(-time.Minute), w.firstScaleUpAfter(now.Add(-10*time.Minute)))
+	assert.Equal(t, now, w.firstScaleUpAfter(now.Add(-time.Second)))
+	assert.True(t, w.firstScaleUpAfter(now.Add(time.Second)).IsZero())
+	assert.True(t, w.firstScaleUpAfter(time.Time{}).IsZero())
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	listenermetrics "github.com/actions/actions-runner-controller/cmd/ghalistener/metrics"
+	controllermetrics "github.com/actions/actions-runner-controller/controllers/actions.github.com/metrics"
+)
+
+// metric is a metric exposed by either the listener or the controller.
+type metric struct {
+	name   string
+	kind   string
+	help   string
+	labels []string
+	// controller is set for metrics exposed by the controller rather than the listener.
+	controller bool
+}
+
+// catalog indexes the metrics the dashboard and the alerts can refer to.
+type catalog map[string]metric
+
+func newCatalog() catalog {
+	c := make(catalog)
+	for _, d := range listenermetrics.Definitions() {
+		c[d.Name] = metric{name: d.Name, kind: d.Kind, help: d.Help, labels: d.Labels}
+	}
+	for _, d := range controllermetrics.Definitions() {
+		c[d.Name] = metric{name: d.Name, kind: listenermetrics.KindGauge, help: d.Help, labels: d.Labels, controller: true}
+	}
+	return c
+}
+
+// lookup returns the metric with the given name, and fails if the metric does not
+// exist or is not exposed with all the given labels.
+func (c catalog) lookup(name string, labels ...string) (metric, error) {
+	m, ok := c[name]
+	if !ok {
+		return metric{}, fmt.Errorf("unknown metric %q", name)
+	}
+	for _, l := range labels {
+		if !slices.Contains(m.labels, l) {
+			return metric{}, fmt.Errorf("metric %q does not have label %q", name, l)
+		}
+	}
+	return m, nil
+}
+
+// listener returns the listener metrics of the given kind, sorted by name.
+func (c catalog) listener(kind string) []metric {
+	var metrics []metric
+	for _, m := range c {
+		if m.kind == kind && !m.controller {
+			metrics = append(metrics, m)
+		}
+	}
+	slices.SortFunc(metrics, func(a, b metric) int { return strings.Compare(a.name, b.name) })
+	return metrics
+}
@@ -0,0 +1,316 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+
+	listenermetrics "github.com/actions/actions-runner-controller/cmd/ghalistener/metrics"
+)
+
+const (
+	dashboardTitle = "ARC Autoscaling Runner Sets"
+	dashboardUID   = "arc-autoscaling-runner-sets"
+
+	datasourceVariable = "datasource"
+	rateInterval       = "$__rate_interval"
+
+	panelWidth  = 12
+	panelHeight = 8
+)
+
+// startupPhases are the histograms breaking down the job startup duration, in order.
+var startupPhases = []string{
+	listenermetrics.MetricJobQueuedToAcquiredDurationSeconds,
+	listenermetrics.MetricJobAcquiredToDesiredPatchDurationSeconds,
+	listenermetrics.MetricJobDesiredPatchToPodCreatedDurationSeconds,
+	listenermetrics.MetricJobPodCreatedToRunnerOnlineDurationSeconds,
+	listenermetrics.MetricJobRunnerOnlineToJobStartedDurationSeconds,
+}
+
+type dashboard struct {
+	Editable      bool       `json:"editable"`
+	Panels        []panel    `json:"panels"`
+	Refresh       string     `json:"refresh"`
+	SchemaVersion int        `json:"schemaVersion"`
+	Tags          []string   `json:"tags"`
+	Templating    templating `json:"templating"`
+	Time          timeRange  `json:"time"`
+	Title         string     `json:"title"`
+	UID           string     `json:"uid"`
+}
+
+type datasource struct {
+	Type string `json:"type"`
+	UID  string `json:"uid"`
+}
+
+type templating struct {
+	List []variable `json:"list"`
+}
+
+type variable struct {
+	Datasource *datasource `json:"datasource,omitempty"`
+	Definition string      `json:"definition,omitempty"`
+	IncludeAll bool        `json:"includeAll"`
+	Label      string      `json:"label"`
+	Multi      bool        `json:"multi"`
+	Name       string      `json:"name"`
+	Query      string      `json:"query"`
+	Refresh    int         `json:"refresh"`
+	Type       string      `json:"type"`
+}
+
+type timeRange struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+type gridPos struct {
+	H int `json:"h"`
+	W int `json:"w"`
+	X int `json:"x"`
+	Y int `json:"y"`
+}
+
+type panel struct {
+	Datasource  *datasource  `json:"datasource,omitempty"`
+	Description string       `json:"description,omitempty"`
+	FieldConfig *fieldConfig `json:"fieldConfig,omitempty"`
+	GridPos     gridPos      `json:"gridPos"`
+	ID          int          `json:"id"`
+	Targets     []target     `json:"targets,omitempty"`
+	Title       string       `json:"title"`
+	Type        string       `json:"type"`
+}
+
+type fieldConfig struct {
+	Defaults fieldDefaults `json:"defaults"`
+}
+
+type fieldDefaults struct {
+	Unit string `json:"unit,omitempty"`
+}
+
+type target struct {
+	Datasource   *datasource `json:"datasource"`
+	Expr         string      `json:"expr"`
+	LegendFormat string      `json:"legendFormat"`
+	RefID        string      `json:"refId"`
+}
+
+// dashboardBuilder lays out panels two per line, below row headers.
+type dashboardBuilder struct {
+	q      *queryBuilder
+	ds     *datasource
+	panels []panel
+	x, y   int
+}
+
+func (b *dashboardBuilder) row(title string) {
+	if b.x != 0 {
+		b.x = 0
+		b.y += panelHeight
+	}
+	b.panels = append(b.panels, panel{
+		GridPos: gridPos{H: 1, W: 24, X: 0, Y: b.y},
+		ID:      len(b.panels) + 1,
+		Title:   title,
+		Type:    "row",
+	})
+	b.y++
+}
+
+// timeseries adds a time series panel. Each query is paired with the metric it
+// reads, which is used to name the series.
+func (b *dashboardBuilder) timeseries(title, description, unit string, queries ...query) {
+	p := panel{
+		Datasource:  b.ds,
+		Description: description,
+		GridPos:     gridPos{H: panelHeight, W: panelWidth, X: b.x, Y: b.y},
+		ID:          len(b.panels) + 1,
+		Title:       title,
+		Type:        "timeseries",
+	}
+	if unit != "" {
+		p.FieldConfig = &fieldConfig{Defaults: fieldDefaults{Unit: unit}}
+	}
+	for i, q := range queries {
+		p.Targets = append(p.Targets, target{
+			Datasource:   b.ds,
+			Expr:         q.expr,
+			LegendFormat: q.legend,
+			RefID:        string(rune('A' + i)),
+		})
+	}
+	b.panels = append(b.panels, p)
+
+	b.x += panelWidth
+	if b.x >= 24 {
+		b.x = 0
+		b.y += panelHeight
+	}
+}
+
+type query struct {
+	expr   string
+	legend string
+}
+
+// legend names a series after the scale set it belongs to, optionally followed by a suffix.
+func (b *dashboardBuilder) legend(m metric, suffix string) string {
+	by := b.q.groupBy(m)
+	l := fmt.Sprintf("{{%s}}/{{%s}}", by[0], by[1])
+	if suffix != "" {
+		l += " " + suffix
+	}
+	return l
+}
+
+// generateDashboard renders the Grafana dashboard as JSON.
+func generateDashboard(c catalog, opts options) ([]byte, error) {
+	q := &queryBuilder{catalog: c, opts: opts, filter: true}
+	ds := &datasource{Type: "prometheus", UID: "${" + datasourceVariable + "}"}
+	b := &dashboardBuilder{q: q, ds: ds}
+
+	m := func(name string) metric { return c[name] }
+
+	b.row("Saturation")
+	busy, err := q.gauge(listenermetrics.MetricBusyRunners)
+	if err != nil {
+		return nil, err
+	}
+	maxRunners, err := q.gauge(listenermetrics.MetricMaxRunners)
+	if err != nil {
+		return nil, err
+	}
+	desired, err := q.gauge(listenermetrics.MetricDesiredRunners)
+	if err != nil {
+		return nil, err
+	}
+	b.timeseries(
+		"Runner saturation",
+		"Busy runners as a ratio of the maximum number of runners of the scale set.",
+		"percentunit",
+		query{expr: busy + " / " + maxRunners, legend: b.legend(m(listenermetrics.MetricBusyRunners), "")},
+	)
+	b.timeseries(
+		"Busy, desired and maximum runners",
+		"Runners running a job, runners desired by the scale set and the maximum number of runners.",
+		"",
+		query{expr: busy, legend: b.legend(m(listenermetrics.MetricBusyRunners), "busy")},
+		query{expr: desired, legend: b.legend(m(listenermetrics.MetricDesiredRunners), "desired")},
+		query{expr: maxRunners, legend: b.legend(m(listenermetrics.MetricMaxRunners), "max")},
+	)
+
+	b.row("Startup latency")
+	var startup []query
+	for _, quantile := range []float64{0.5, 0.95} {
+		expr, err := q.quantile(listenermetrics.MetricJobStartupDurationSeconds, quantile, rateInterval)
+		if err != nil {
+			return nil, err
+		}
+		startup = append(startup, query{
+			expr:   expr,
+			legend: b.legend(m(listenermetrics.MetricJobStartupDurationSeconds), fmt.Sprintf("p%g", quantile*100)),
+		})
+	}
+	b.timeseries(
+		"Job startup duration",
+		c[listenermetrics.MetricJobStartupDurationSeconds].help,
+		"s",
+		startup...,
+	)
+	var phases []query
+	for _, name := range startupPhases {
+		expr, err := q.quantile(name, 0.95, rateInterval)
+		if err != nil {
+			return nil, err
+		}
+		phases = append(phases, query{expr: expr, legend: b.legend(m(name), name)})
+	}
+	b.timeseries(
+		"Job startup phases (p95)",
+		"Time spent in each phase of the job startup.",
+		"s",
+		phases...,
+	)
+
+	b.row("Controller")
+	for _, name := range []string{
+		"gha_controller_pending_ephemeral_runners",
+		"gha_controller_running_ephemeral_runners",
+		"gha_controller_failed_ephemeral_runners",
+		"gha_controller_running_listeners",
+	} {
+		expr, err := q.gauge(name)
+		if err != nil {
+			return nil, err
+		}
+		b.timeseries(name, c[name].help, "", query{expr: expr, legend: b.legend(m(name), "")})
+	}
+
+	// Every listener metric gets a panel, so that new metrics show up without
+	// changes to the generator.
+	b.row("Listener gauges")
+	for _, metric := range c.listener(listenermetrics.KindGauge) {
+		b.timeseries(metric.name, metric.help, "", query{expr: q.gaugeOf(metric), legend: b.legend(metric, "")})
+	}
+	b.row("Listener counters")
+	for _, metric := range c.listener(listenermetrics.KindCounter) {
+		b.timeseries(metric.name, metric.help, "", query{expr: q.rateOf(metric, rateInterval), legend: b.legend(metric, "")})
+	}
+	b.row("Listener histograms (p95)")
+	for _, metric := range c.listener(listenermetrics.KindHistogram) {
+		b.timeseries(metric.name, metric.help, "s", query{expr: q.quantileOf(metric, 0.95, rateInterval), legend: b.legend(metric, "")})
+	}
+
+	d := dashboard{
+		Editable:      true,
+		Panels:        b.panels,
+		Refresh:       "30s",
+		SchemaVersion: 39,
+		Tags:          []string{"actions-runner-controller"},
+		Templating: templating{
+			List: []variable{
+				{
+					Label:   "Data source",
+					Name:    datasourceVariable,
+					Query:   "prometheus",
+					Refresh: 1,
+					Type:    "datasource",
+				},
+				{
+					Datasource: ds,
+					Definition: fmt.Sprintf("label_values(%s, %s)", listenermetrics.MetricDesiredRunners, opts.ScaleSetNamespaceLabel),
+					IncludeAll: true,
+					Label:      "Namespace",
+					Multi:      true,
+					Name:       namespaceVariable,
+					Query:      fmt.Sprintf("label_values(%s, %s)", listenermetrics.MetricDesiredRunners, opts.ScaleSetNamespaceLabel),
+					Refresh:    2,
+					Type:       "query",
+				},
+				{
+					Datasource: ds,
+					Definition: fmt.Sprintf(`label_values(%s{%s=~"$%s"}, %s)`, listenermetrics.MetricDesiredRunners, opts.ScaleSetNamespaceLabel, namespaceVariable, opts.ScaleSetNameLabel),
+					IncludeAll: true,
+					Label:      "Scale set",
+					Multi:      true,
+					Name:       scaleSetVariable,
+					Query:      fmt.Sprintf(`label_values(%s{%s=~"$%s"}, %s)`, listenermetrics.MetricDesiredRunners, opts.ScaleSetNamespaceLabel, namespaceVariable, opts.ScaleSetNameLabel),
+					Refresh:    2,
+					Type:       "query",
+				},
+			},
+		},
+		Time:  timeRange{From: "now-6h", To: "now"},
+		Title: dashboardTitle,
+		UID:   dashboardUID,
+	}
+
+	out, err := json.MarshalIndent(d, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal dashboard: %w", err)
+	}
+	return append(out, '\n'), nil
+}
@@ -0,0 +1,64 @@
+// ghamonitoringgen generates a Grafana dashboard and Prometheus alerting rules
+// from the metrics exposed by the gha-runner-scale-set listener and controller,
+// so that they do not drift from the metrics as they change.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+)
+
+func main() {
+	opts := defaultOptions()
+	var dashboardPath, rulesPath string
+
+	flag.StringVar(&dashboardPath, "dashboard", "", "Path to write the Grafana dashboard JSON to. Skipped when empty.")
+	flag.StringVar(&rulesPath, "rules", "", "Path to write the PrometheusRule YAML to. Skipped when empty.")
+	flag.StringVar(&opts.ScaleSetNameLabel, "scale-set-name-label", opts.ScaleSetNameLabel, "Label identifying the scale set name on listener metrics.")
+	flag.StringVar(&opts.ScaleSetNamespaceLabel, "scale-set-namespace-label", opts.ScaleSetNamespaceLabel, "Label identifying the scale set namespace on listener metrics.")
+	flag.StringVar(&opts.RuleName, "rule-name", opts.RuleName, "Name of the PrometheusRule.")
+	flag.StringVar(&opts.RuleNamespace, "rule-namespace", opts.RuleNamespace, "Namespace of the PrometheusRule.")
+	flag.Float64Var(&opts.SaturationThreshold, "saturation-threshold", opts.SaturationThreshold, "Ratio of busy to maximum runners above which a scale set is saturated.")
+	flag.DurationVar(&opts.StartupLatencyThreshold, "startup-latency-threshold", opts.StartupLatencyThreshold, "p95 job startup duration above which to alert.")
+	flag.DurationVar(&opts.For, "for", opts.For, "How long a condition has to hold before alerting.")
+	flag.Parse()
+
+	if err := run(opts, dashboardPath, rulesPath); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(opts options, dashboardPath, rulesPath string) error {
+	if err := opts.validate(); err != nil {
+		return err
+	}
+	if dashboardPath == "" && rulesPath == "" {
+		return fmt.Errorf("at least one of -dashboard or -rules is required")
+	}
+
+	c := newCatalog()
+
+	if dashboardPath != "" {
+		out, err := generateDashboard(c, opts)
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(dashboardPath, out, 0o644); err != nil {
+			return fmt.Errorf("failed to write dashboard: %w", err)
+		}
+	}
+
+	if rulesPath != "" {
+		out, err := generateRules(c, opts)
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(rulesPath, out, 0o644); err != nil {
+			return fmt.Errorf("failed to write rules: %w", err)
+		}
+	}
+
+	return nil
+}
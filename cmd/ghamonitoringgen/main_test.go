@@ -0,0 +1,102 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	listenermetrics "github.com/actions/actions-runner-controller/cmd/ghalistener/metrics"
+	"github.com/actions/actions-runner-controller/cmd/internal/golden"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// goldenDir holds the samples generated by make monitoring, so that they are
+// regenerated whenever the generated output changes.
+var goldenDir = filepath.Join("..", "..", "docs", "gha-runner-scale-set-controller", "samples", "monitoring")
+
+func assertGolden(t *testing.T, name string, got []byte) {
+	t.Helper()
+	golden.Assert(t, filepath.Join(goldenDir, name), got)
+}
+
+func TestGenerateDashboard(t *testing.T) {
+	out, err := generateDashboard(newCatalog(), defaultOptions())
+	require.NoError(t, err)
+	assertGolden(t, "dashboard.json", out)
+}
+
+func TestGenerateRules(t *testing.T) {
+	out, err := generateRules(newCatalog(), defaultOptions())
+	require.NoError(t, err)
+	assertGolden(t, "prometheusrule.yaml", out)
+
+	opts := defaultOptions()
+	opts.RuleNamespace = "arc-systems"
+
+	out, err = generateRules(newCatalog(), opts)
+	require.NoError(t, err)
+	assert.Contains(t, string(out), "namespace: arc-systems")
+}
+
+func TestQueriesJoinScaleSetLabels(t *testing.T) {
+	q := &queryBuilder{catalog: newCatalog(), opts: defaultOptions()}
+
+	// The listener gauges carry the scale set labels
+	expr, err := q.gauge(listenermetrics.MetricBusyRunners)
+	require.NoError(t, err)
+	assert.Equal(t, "sum by (namespace, name) (gha_busy_runners)", expr)
+
+	// The job metrics don't, and get them from the gauges of the same listener
+	expr, err = q.rate(listenermetrics.MetricStartedJobsTotal, "5m")
+	require.NoError(t, err)
+	assert.Equal(t, "sum by (namespace, name) (rate(gha_started_jobs_total[5m]) * on (instance) group_left (namespace, name) group by (instance, namespace, name) (gha_max_runners))", expr)
+
+	// Labels added to all metrics by the scrape configuration are not joined
+	q.opts.ScaleSetNameLabel = "actions_github_com_scale_set_name"
+	q.opts.ScaleSetNamespaceLabel = "actions_github_com_scale_set_namespace"
+
+	expr, err = q.rate(listenermetrics.MetricStartedJobsTotal, "5m")
+	require.NoError(t, err)
+	assert.Equal(t, "sum by (actions_github_com_scale_set_namespace, actions_github_com_scale_set_name) (rate(gha_started_jobs_total[5m]))", expr)
+}
+
+func TestGenerateFailsOnUnknownMetrics(t *testing.T) {
+	c := newCatalog()
+	delete(c, listenermetrics.MetricMaxRunners)
+
+	_, err := generateDashboard(c, defaultOptions())
+	assert.ErrorContains(t, err, listenermetrics.MetricMaxRunners)
+
+	_, err = generateRules(c, defaultOptions())
+	assert.ErrorContains(t, err, listenermetrics.MetricMaxRunners)
+}
+
+func TestGenerateFailsOnMissingControllerLabels(t *testing.T) {
+	c := newCatalog()
+	m := c["gha_controller_failed_ephemeral_runners"]
+	m.labels = []string{controllerNamespaceLabel}
+	c[m.name] = m
+
+	_, err := generateRules(c, defaultOptions())
+	assert.ErrorContains(t, err, `does not have label "name"`)
+}
+
+func TestOptionsValidate(t *testing.T) {
+	opts := defaultOptions()
+	assert.NoError(t, opts.validate())
+
+	opts.SaturationThreshold = 1.5
+	assert.Error(t, opts.validate())
+
+	opts = defaultOptions()
+	opts.StartupLatencyThreshold = 0
+	assert.Error(t, opts.validate())
+}
+
+func TestPromDuration(t *testing.T) {
+	assert.Equal(t, "", promDuration(0))
+	assert.Equal(t, "2h", promDuration(2*time.Hour))
+	assert.Equal(t, "15m", promDuration(15*time.Minute))
+	assert.Equal(t, "90s", promDuration(90*time.Second))
+}
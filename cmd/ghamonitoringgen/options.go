@@ -0,0 +1,59 @@
+package main
+
+import (
+	"fmt"
+	"time"
+)
+
+// options configures the generated dashboard and alerts.
+type options struct {
+	// ScaleSetNameLabel and ScaleSetNamespaceLabel identify the scale set on
+	// listener metrics. By default, they are the labels of the listener gauges,
+	// joined onto the listener metrics that don't carry them. They can also be
+	// target labels added to all metrics by the scrape configuration.
+	ScaleSetNameLabel      string
+	ScaleSetNamespaceLabel string
+
+	// RuleName and RuleNamespace are the name and namespace of the PrometheusRule.
+	RuleName      string
+	RuleNamespace string
+
+	// SaturationThreshold is the ratio of busy to maximum runners above which
+	// a scale set is considered saturated.
+	SaturationThreshold float64
+	// StartupLatencyThreshold is the p95 job startup duration above which
+	// an alert is raised.
+	StartupLatencyThreshold time.Duration
+	// For is how long a condition has to hold before alerting.
+	For time.Duration
+}
+
+func defaultOptions() options {
+	return options{
+		ScaleSetNameLabel:       "name",
+		ScaleSetNamespaceLabel:  "namespace",
+		RuleName:                "gha-runner-scale-set",
+		SaturationThreshold:     0.9,
+		StartupLatencyThreshold: 5 * time.Minute,
+		For:                     15 * time.Minute,
+	}
+}
+
+func (o *options) validate() error {
+	if o.ScaleSetNameLabel == "" || o.ScaleSetNamespaceLabel == "" {
+		return fmt.Errorf("scale set name and namespace labels are required")
+	}
+	if o.RuleName == "" {
+		return fmt.Errorf("rule name is required")
+	}
+	if o.SaturationThreshold <= 0 || o.SaturationThreshold > 1 {
+		return fmt.Errorf("saturation threshold must be in (0, 1], got %v", o.SaturationThreshold)
+	}
+	if o.StartupLatencyThreshold <= 0 {
+		return fmt.Errorf("startup latency threshold must be positive, got %v", o.StartupLatencyThreshold)
+	}
+	if o.For < 0 {
+		return fmt.Errorf("alert duration must not be negative, got %v", o.For)
+	}
+	return nil
+}
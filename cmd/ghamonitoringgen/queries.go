@@ -0,0 +1,148 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	listenermetrics "github.com/actions/actions-runner-controller/cmd/ghalistener/metrics"
+)
+
+// Labels of the controller metrics identifying the scale set.
+const (
+	controllerNameLabel      = "name"
+	controllerNamespaceLabel = "namespace"
+)
+
+// Dashboard variables filtering the scale sets.
+const (
+	namespaceVariable = "namespace"
+	scaleSetVariable  = "scaleset"
+)
+
+// scaleSetInfoMetric is the listener metric whose scale set labels are joined onto
+// the listener metrics that don't carry them, like the job counters and histograms,
+// by the target label Prometheus sets on every series scraped from the same listener.
+const (
+	scaleSetInfoMetric = listenermetrics.MetricMaxRunners
+	targetLabel        = "instance"
+)
+
+// queryBuilder writes PromQL for listener and controller metrics. When filter is
+// set, selectors are restricted to the scale sets picked in the dashboard.
+type queryBuilder struct {
+	catalog catalog
+	opts    options
+	filter  bool
+}
+
+// groupBy returns the labels identifying a scale set on the metric.
+func (q *queryBuilder) groupBy(m metric) []string {
+	if m.controller {
+		return []string{controllerNamespaceLabel, controllerNameLabel}
+	}
+	return []string{q.opts.ScaleSetNamespaceLabel, q.opts.ScaleSetNameLabel}
+}
+
+func (q *queryBuilder) selector(m metric, suffix string) string {
+	if !q.filter {
+		return m.name + suffix
+	}
+	by := q.groupBy(m)
+	return fmt.Sprintf(`%s%s{%s=~"$%s", %s=~"$%s"}`, m.name, suffix, by[0], namespaceVariable, by[1], scaleSetVariable)
+}
+
+// joined reports whether the scale set labels of the metric have to be joined
+// from scaleSetInfoMetric. When scaleSetInfoMetric doesn't carry them either,
+// the labels are expected to be added to all metrics by the scrape configuration.
+func (q *queryBuilder) joined(m metric) bool {
+	if m.controller {
+		return false
+	}
+	by := q.groupBy(m)
+	if _, err := q.catalog.lookup(m.name, by...); err == nil {
+		return false
+	}
+	_, err := q.catalog.lookup(scaleSetInfoMetric, by...)
+	return err == nil
+}
+
+// series returns the series of the metric, transformed by fn, labelled with the scale set.
+func (q *queryBuilder) series(m metric, suffix string, fn func(string) string) string {
+	if !q.joined(m) {
+		return fn(q.selector(m, suffix))
+	}
+	by := q.groupBy(m)
+	return fmt.Sprintf(
+		"%s * on (%s) group_left (%s) group by (%s) (%s)",
+		fn(m.name+suffix),
+		targetLabel,
+		strings.Join(by, ", "),
+		strings.Join(append([]string{targetLabel}, by...), ", "),
+		q.selector(q.catalog[scaleSetInfoMetric], ""),
+	)
+}
+
+func (q *queryBuilder) lookup(name, kind string) (metric, error) {
+	var labels []string
+	if m, ok := q.catalog[name]; ok && m.controller {
+		labels = []string{controllerNamespaceLabel, controllerNameLabel}
+	}
+	m, err := q.catalog.lookup(name, labels...)
+	if err != nil {
+		return metric{}, err
+	}
+	if m.kind != kind {
+		return metric{}, fmt.Errorf("metric %q is a %s, not a %s", name, m.kind, kind)
+	}
+	return m, nil
+}
+
+// gauge sums the gauge per scale set.
+func (q *queryBuilder) gauge(name string) (string, error) {
+	m, err := q.lookup(name, listenermetrics.KindGauge)
+	if err != nil {
+		return "", err
+	}
+	return q.gaugeOf(m), nil
+}
+
+func (q *queryBuilder) gaugeOf(m metric) string {
+	return fmt.Sprintf("sum by (%s) (%s)", strings.Join(q.groupBy(m), ", "), q.series(m, "", identity))
+}
+
+// rate sums the per-second rate of the counter per scale set.
+func (q *queryBuilder) rate(name, window string) (string, error) {
+	m, err := q.lookup(name, listenermetrics.KindCounter)
+	if err != nil {
+		return "", err
+	}
+	return q.rateOf(m, window), nil
+}
+
+func (q *queryBuilder) rateOf(m metric, window string) string {
+	return fmt.Sprintf("sum by (%s) (%s)", strings.Join(q.groupBy(m), ", "), q.series(m, "", rate(window)))
+}
+
+// quantile computes the quantile of the histogram per scale set.
+func (q *queryBuilder) quantile(name string, quantile float64, window string) (string, error) {
+	m, err := q.lookup(name, listenermetrics.KindHistogram)
+	if err != nil {
+		return "", err
+	}
+	return q.quantileOf(m, quantile, window), nil
+}
+
+func (q *queryBuilder) quantileOf(m metric, quantile float64, window string) string {
+	return fmt.Sprintf(
+		"histogram_quantile(%g, sum by (%s, le) (%s))",
+		quantile,
+		strings.Join(q.groupBy(m), ", "),
+		q.series(m, "_bucket", rate(window)),
+	)
+}
+
+func identity(s string) string { return s }
+
+func rate(window string) func(string) string {
+	return func(s string) string { return fmt.Sprintf("rate(%s[%s])", s, window) }
+}
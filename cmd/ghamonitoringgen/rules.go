@@ -0,0 +1,155 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	listenermetrics "github.com/actions/actions-runner-controller/cmd/ghalistener/metrics"
+	"sigs.k8s.io/yaml"
+)
+
+const ruleGroupName = "gha-runner-scale-set"
+
+type prometheusRule struct {
+	APIVersion string       `json:"apiVersion"`
+	Kind       string       `json:"kind"`
+	Metadata   ruleMetadata `json:"metadata"`
+	Spec       ruleSpec     `json:"spec"`
+}
+
+type ruleMetadata struct {
+	Name      string `json:"name"`
+	Namespace string `json:"namespace,omitempty"`
+}
+
+type ruleSpec struct {
+	Groups []ruleGroup `json:"groups"`
+}
+
+type ruleGroup struct {
+	Name  string `json:"name"`
+	Rules []rule `json:"rules"`
+}
+
+type rule struct {
+	Alert       string            `json:"alert"`
+	Expr        string            `json:"expr"`
+	For         string            `json:"for,omitempty"`
+	Labels      map[string]string `json:"labels,omitempty"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// generateRules renders the PrometheusRule holding the alerts as YAML.
+func generateRules(c catalog, opts options) ([]byte, error) {
+	q := &queryBuilder{catalog: c, opts: opts}
+	forDuration := promDuration(opts.For)
+	scaleSet := fmt.Sprintf("{{ $labels.%s }}/{{ $labels.%s }}", opts.ScaleSetNamespaceLabel, opts.ScaleSetNameLabel)
+	controllerScaleSet := fmt.Sprintf("{{ $labels.%s }}/{{ $labels.%s }}", controllerNamespaceLabel, controllerNameLabel)
+
+	busy, err := q.gauge(listenermetrics.MetricBusyRunners)
+	if err != nil {
+		return nil, err
+	}
+	maxRunners, err := q.gauge(listenermetrics.MetricMaxRunners)
+	if err != nil {
+		return nil, err
+	}
+	desired, err := q.gauge(listenermetrics.MetricDesiredRunners)
+	if err != nil {
+		return nil, err
+	}
+	startup, err := q.quantile(listenermetrics.MetricJobStartupDurationSeconds, 0.95, "10m")
+	if err != nil {
+		return nil, err
+	}
+	failed, err := q.gauge("gha_controller_failed_ephemeral_runners")
+	if err != nil {
+		return nil, err
+	}
+
+	rules := []rule{
+		{
+			Alert: "GHARunnerScaleSetSaturated",
+			Expr:  fmt.Sprintf("(%s) / (%s) >= %g", busy, maxRunners, opts.SaturationThreshold),
+			For:   forDuration,
+			Labels: map[string]string{
+				"severity": "warning",
+			},
+			Annotations: map[string]string{
+				"summary":     "Runner scale set is close to its maximum number of runners.",
+				"description": fmt.Sprintf("%s has {{ $value | humanizePercentage }} of its maximum runners busy.", scaleSet),
+			},
+		},
+		{
+			Alert: "GHARunnerScaleSetAtMaxRunners",
+			Expr:  fmt.Sprintf("(%s) >= (%s)", desired, maxRunners),
+			For:   forDuration,
+			Labels: map[string]string{
+				"severity": "warning",
+			},
+			Annotations: map[string]string{
+				"summary":     "Runner scale set wants more runners than it is allowed to run.",
+				"description": fmt.Sprintf("%s has been capped at its maximum number of runners, jobs are waiting for a runner.", scaleSet),
+			},
+		},
+		{
+			Alert: "GHAJobStartupLatencyHigh",
+			Expr:  fmt.Sprintf("%s > %g", startup, opts.StartupLatencyThreshold.Seconds()),
+			For:   forDuration,
+			Labels: map[string]string{
+				"severity": "warning",
+			},
+			Annotations: map[string]string{
+				"summary":     "Jobs take long to start on the runner scale set.",
+				"description": fmt.Sprintf("The p95 job startup duration of %s is {{ $value | humanizeDuration }}.", scaleSet),
+			},
+		},
+		{
+			Alert: "GHAEphemeralRunnersFailed",
+			Expr:  fmt.Sprintf("%s > 0", failed),
+			For:   forDuration,
+			Labels: map[string]string{
+				"severity": "warning",
+			},
+			Annotations: map[string]string{
+				"summary":     "Ephemeral runners failed.",
+				"description": fmt.Sprintf("%s has {{ $value }} failed ephemeral runners.", controllerScaleSet),
+			},
+		},
+	}
+
+	out, err := yaml.Marshal(prometheusRule{
+		APIVersion: "monitoring.coreos.com/v1",
+		Kind:       "PrometheusRule",
+		Metadata: ruleMetadata{
+			Name:      opts.RuleName,
+			Namespace: opts.RuleNamespace,
+		},
+		Spec: ruleSpec{
+			Groups: []ruleGroup{
+				{
+					Name:  ruleGroupName,
+					Rules: rules,
+				},
+			},
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal rules: %w", err)
+	}
+	return out, nil
+}
+
+// promDuration formats the duration the way Prometheus expects it.
+func promDuration(d time.Duration) string {
+	switch {
+	case d == 0:
+		return ""
+	case d%time.Hour == 0:
+		return fmt.Sprintf("%dh", d/time.Hour)
+	case d%time.Minute == 0:
+		return fmt.Sprintf("%dm", d/time.Minute)
+	default:
+		return fmt.Sprintf("%ds", d/time.Second)
+	}
+}
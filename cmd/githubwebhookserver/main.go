@@ -26,10 +26,12 @@ import (
 	"sync"
 	"time"
 
+	githubv1alpha1 "github.com/actions/actions-runner-controller/apis/actions.github.com/v1alpha1"
 	actionsv1alpha1 "github.com/actions/actions-runner-controller/apis/actions.summerwind.net/v1alpha1"
 	actionssummerwindnet "github.com/actions/actions-runner-controller/controllers/actions.summerwind.net"
 	"github.com/actions/actions-runner-controller/github"
 	"github.com/actions/actions-runner-controller/logging"
+	"github.com/actions/actions-runner-controller/pkg/webhooksecret"
 
 	"github.com/kelseyhightower/envconfig"
 
@@ -57,6 +59,7 @@ func init() {
 	_ = clientgoscheme.AddToScheme(scheme)
 
 	_ = actionsv1alpha1.AddToScheme(scheme)
+	_ = githubv1alpha1.AddToScheme(scheme)
 	// +kubebuilder:scaffold:scheme
 }
 
@@ -71,12 +74,26 @@ func main() {
 		webhookSecretToken    string
 		webhookSecretTokenEnv string
 
+		webhookSecretTokensFile           string
+		webhookSecretTokensReloadInterval time.Duration
+		rejectUnsignedWebhooks            bool
+
 		watchNamespace string
 
 		logLevel   string
 		queueLimit int
 		logFormat  string
 
+		deliveryCacheSize      int
+		deliveryCacheConfigMap string
+		deliveryCacheNamespace string
+
+		durableQueue         bool
+		enableLeaderElection bool
+		leaderElectionID     string
+
+		scaleSetPrewarmDuration time.Duration
+
 		ghClient *github.Client
 	)
 
@@ -93,8 +110,18 @@ func main() {
 	flag.StringVar(&metricsAddr, "metrics-addr", ":8080", "The address the metric endpoint binds to.")
 	flag.StringVar(&watchNamespace, "watch-namespace", "", "The namespace to watch for HorizontalRunnerAutoscaler's to scale on Webhook. Set to empty for letting it watch for all namespaces.")
 	flag.StringVar(&logLevel, "log-level", logging.LogLevelDebug, `The verbosity of the logging. Valid values are "debug", "info", "warn", "error". Defaults to "debug".`)
-	flag.IntVar(&queueLimit, "queue-limit", actionssummerwindnet.DefaultQueueLimit, `The maximum length of the scale operation queue. The scale opration is enqueued per every matching webhook event, and the server returns a 500 HTTP status when the queue was already full on enqueue attempt.`)
+	flag.IntVar(&queueLimit, "queue-limit", actionssummerwindnet.DefaultQueueLimit, `The maximum length of the scale operation queue. The scale opration is enqueued per every matching webhook event, and the server returns a 503 HTTP status with a Retry-After header when the queue was already full on enqueue attempt.`)
+	flag.IntVar(&deliveryCacheSize, "delivery-cache-size", actionssummerwindnet.DefaultDeliveryCacheSize, `The number of webhook deliveries remembered by their X-GitHub-Delivery ID, so that redeliveries are acknowledged without scaling again. Set to 0 to disable deduplication.`)
+	flag.StringVar(&deliveryCacheConfigMap, "delivery-cache-configmap", "", `The name of the ConfigMap to persist the remembered webhook deliveries to, so that they survive restarts and are shared across webhook server replicas. Deliveries are only remembered in memory when empty.`)
+	flag.StringVar(&deliveryCacheNamespace, "delivery-cache-namespace", "", `The namespace of the ConfigMap specified by -delivery-cache-configmap.`)
+	flag.BoolVar(&durableQueue, "durable-queue", false, `Persist each scale operation onto the HorizontalRunnerAutoscaler it targets before responding to the webhook event, instead of enqueueing it in memory. Persisted operations survive restarts and are applied by the leader replica, which makes it safe to run two or more replicas with -enable-leader-election. -queue-limit is then the maximum number of pending scale operations per HorizontalRunnerAutoscaler.`)
+	flag.BoolVar(&enableLeaderElection, "enable-leader-election", false, "Enable leader election for the webhook server. Enabling this will ensure there is only one replica applying scale operations when -durable-queue is enabled.")
+	flag.StringVar(&leaderElectionID, "leader-election-id", "actions-runner-controller-github-webhook-server", "Controller id for leader election.")
+	flag.DurationVar(&scaleSetPrewarmDuration, "scale-set-prewarm-duration", 0, `Prewarm AutoscalingRunnerSets whose runner scale set labels match the labels of queued workflow jobs, by asking their listeners to keep a runner for each queued job for this duration. Set to 0 to disable. Requires the actions.github.com CRDs to be installed.`)
 	flag.StringVar(&webhookSecretToken, "github-webhook-secret-token", "", "The personal access token of GitHub.")
+	flag.StringVar(&webhookSecretTokensFile, "github-webhook-secret-tokens-file", "", "The path of a file with a webhook secret token per line, or of a directory with a token per file like a mounted Secret. The tokens are tried in order before -github-webhook-secret-token, and reloaded whenever they change, so that the webhook secret can be rotated without downtime.")
+	flag.DurationVar(&webhookSecretTokensReloadInterval, "github-webhook-secret-tokens-reload-interval", 30*time.Second, "The interval to check the webhook secret tokens file for changes.")
+	flag.BoolVar(&rejectUnsignedWebhooks, "reject-unsigned-webhooks", false, "Reject the webhook payloads without signature, and every payload while no webhook secret token is configured, instead of accepting them unverified.")
 	flag.StringVar(&c.Token, "github-token", c.Token, "The personal access token of GitHub.")
 	flag.Int64Var(&c.AppID, "github-app-id", c.AppID, "The application ID of GitHub App.")
 	flag.Int64Var(&c.AppInstallationID, "github-app-installation-id", c.AppInstallationID, "The installation ID of GitHub App.")
@@ -120,10 +147,20 @@ func main() {
 		webhookSecretToken = webhookSecretTokenEnv
 	}
 
-	if webhookSecretToken == "" {
+	if webhookSecretToken == "" && webhookSecretTokensFile == "" {
 		logger.Info(fmt.Sprintf("-github-webhook-secret-token and %s are missing or empty. Create one following https://docs.github.com/en/developers/webhooks-and-events/securing-your-webhooks and specify it via the flag or the envvar", webhookSecretTokenEnvName))
 	}
 
+	if deliveryCacheSize < 0 {
+		fmt.Fprintf(os.Stderr, "Error: -delivery-cache-size must not be negative, got %d\n", deliveryCacheSize)
+		os.Exit(1)
+	}
+
+	if deliveryCacheConfigMap != "" && deliveryCacheNamespace == "" {
+		fmt.Fprintln(os.Stderr, "Error: -delivery-cache-namespace is required when -delivery-cache-configmap is set")
+		os.Exit(1)
+	}
+
 	if watchNamespace == "" {
 		logger.Info("-watch-namespace is empty. HorizontalRunnerAutoscalers in all the namespaces are watched, cached, and considered as scale targets.")
 	} else {
@@ -132,6 +169,14 @@ func main() {
 
 	ctrl.SetLogger(logger)
 
+	var staticWebhookSecrets []webhooksecret.Secret
+	if webhookSecretToken != "" {
+		staticWebhookSecrets = append(staticWebhookSecrets, webhooksecret.Secret{Name: "default", Key: []byte(webhookSecretToken)})
+	}
+
+	webhookSecrets := webhooksecret.NewVerifier("github-webhook-server", staticWebhookSecrets...)
+	webhookSecrets.RejectUnsigned = rejectUnsignedWebhooks
+
 	// In order to support runner groups with custom visibility (selected repositories), we need to perform some GitHub API calls.
 	// Let the user define if they want to opt-in supporting this option by providing the proper GitHub authentication parameters
 	// Without an opt-in, runner groups with custom visibility won't be supported to save API calls
@@ -165,6 +210,8 @@ func main() {
 		WebhookServer: webhook.NewServer(webhook.Options{
 			Port: 9443,
 		}),
+		LeaderElection:   enableLeaderElection,
+		LeaderElectionID: leaderElectionID,
 	})
 	if err != nil {
 		logger.Error(err, "unable to start manager")
@@ -172,15 +219,34 @@ func main() {
 	}
 
 	hraGitHubWebhook := &actionssummerwindnet.HorizontalRunnerAutoscalerGitHubWebhook{
-		Name:           "webhookbasedautoscaler",
-		Client:         mgr.GetClient(),
-		Log:            ctrl.Log.WithName("controllers").WithName("webhookbasedautoscaler"),
-		Recorder:       nil,
-		Scheme:         mgr.GetScheme(),
-		SecretKeyBytes: []byte(webhookSecretToken),
-		Namespace:      watchNamespace,
-		GitHubClient:   ghClient,
-		QueueLimit:     queueLimit,
+		Name:         "webhookbasedautoscaler",
+		Client:       mgr.GetClient(),
+		Log:          ctrl.Log.WithName("controllers").WithName("webhookbasedautoscaler"),
+		Recorder:     nil,
+		Scheme:       mgr.GetScheme(),
+		Secrets:      webhookSecrets,
+		Namespace:    watchNamespace,
+		GitHubClient: ghClient,
+		QueueLimit:   queueLimit,
+		DurableQueue: durableQueue,
+		APIReader:    mgr.GetAPIReader(),
+
+		ScaleSetPrewarmDuration: scaleSetPrewarmDuration,
+	}
+
+	switch {
+	case deliveryCacheSize == 0:
+		logger.Info("-delivery-cache-size is 0. Webhook deliveries are not deduplicated.")
+	case deliveryCacheConfigMap != "":
+		hraGitHubWebhook.Deliveries = &actionssummerwindnet.ConfigMapDeliveryStore{
+			Name:      deliveryCacheConfigMap,
+			Namespace: deliveryCacheNamespace,
+			Client:    mgr.GetClient(),
+			APIReader: mgr.GetAPIReader(),
+			Size:      deliveryCacheSize,
+		}
+	default:
+		hraGitHubWebhook.Deliveries = actionssummerwindnet.NewInMemoryDeliveryStore(deliveryCacheSize)
 	}
 
 	if err = hraGitHubWebhook.SetupWithManager(mgr); err != nil {
@@ -192,6 +258,13 @@ func main() {
 
 	ctx, cancel := context.WithCancel(context.Background())
 
+	if webhookSecretTokensFile != "" {
+		if err := webhookSecrets.Watch(ctx, ctrl.Log.WithName("webhooksecrets"), webhookSecretTokensFile, webhookSecretTokensReloadInterval, staticWebhookSecrets...); err != nil {
+			logger.Error(err, "unable to load the webhook secret tokens")
+			os.Exit(1)
+		}
+	}
+
 	wg.Add(1)
 	go func() {
 		defer cancel()
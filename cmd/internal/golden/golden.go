@@ -0,0 +1,25 @@
+// Package golden compares the output of the commands with the golden files committed next to their tests.
+package golden
+
+import (
+	"flag"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var update = flag.Bool("update", false, "update golden files")
+
+// Assert compares got with the golden file at path, which is rewritten with got first
+// when go test is run with -update.
+func Assert(t *testing.T, path string, got []byte) {
+	t.Helper()
+	if *update {
+		require.NoError(t, os.WriteFile(path, got, 0o644))
+	}
+	want, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, string(want), string(got), "run go test with -update to regenerate %s", path)
+}
@@ -0,0 +1,239 @@
+package actionsgithubcom
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/actions/actions-runner-controller/apis/actions.github.com/v1alpha1"
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// The cache volume pool of an autoscaling runner set is a set of persistent volume claims labeled with the scale set name.
+// The autoscaling runner set controller keeps the pool at its configured size, and each ephemeral runner leases
+// a free claim for its pod by annotating the claim with its name, until the pod is deleted.
+
+// reconcileCacheVolumePool keeps the number of claims in the cache volume pool at the configured size.
+// Claims that are not leased are deleted when the pool is over its size or when they were created from
+// a previous version of the claim template, and the leases held by ephemeral runners that no longer exist are released.
+// All the claims are deleted once they are returned when the pool is removed from the spec.
+func (r *AutoscalingRunnerSetReconciler) reconcileCacheVolumePool(ctx context.Context, autoscalingRunnerSet *v1alpha1.AutoscalingRunnerSet, log logr.Logger) error {
+	var claims corev1.PersistentVolumeClaimList
+	if err := r.List(
+		ctx,
+		&claims,
+		client.InNamespace(autoscalingRunnerSet.Namespace),
+		client.MatchingLabels(cacheVolumeClaimLabels(autoscalingRunnerSet.Name, autoscalingRunnerSet.Namespace)),
+	); err != nil {
+		return fmt.Errorf("failed to list cache volume claims: %w", err)
+	}
+
+	pool := autoscalingRunnerSet.Spec.CacheVolumePool
+	if pool == nil && len(claims.Items) == 0 {
+		return nil
+	}
+
+	var size int
+	var templateHash string
+	if pool != nil {
+		size = pool.Size
+		templateHash = cacheVolumeClaimTemplateHash(pool)
+	}
+
+	// Keep the oldest claims, which are the most likely to hold a warm cache.
+	slices.SortFunc(claims.Items, func(a, b corev1.PersistentVolumeClaim) int {
+		return a.CreationTimestamp.Compare(b.CreationTimestamp.Time)
+	})
+
+	var current int
+	var free []*corev1.PersistentVolumeClaim
+	for i := range claims.Items {
+		claim := &claims.Items[i]
+		if !claim.DeletionTimestamp.IsZero() {
+			continue
+		}
+
+		if holder := claim.Annotations[AnnotationKeyCacheVolumeLeaseHolder]; holder != "" {
+			err := r.Get(ctx, types.NamespacedName{Namespace: claim.Namespace, Name: holder}, new(v1alpha1.EphemeralRunner))
+			switch {
+			case err == nil:
+				current++
+				continue
+			case !kerrors.IsNotFound(err):
+				return fmt.Errorf("failed to get the ephemeral runner holding cache volume claim %q: %w", claim.Name, err)
+			}
+
+			log.Info("Releasing cache volume claim leased to a deleted ephemeral runner", "claim", claim.Name, "ephemeralRunner", holder)
+			delete(claim.Annotations, AnnotationKeyCacheVolumeLeaseHolder)
+			if err := r.Update(ctx, claim); err != nil {
+				return fmt.Errorf("failed to release cache volume claim %q: %w", claim.Name, err)
+			}
+		}
+
+		if claim.Annotations[annotationKeyCacheVolumeTemplateHash] != templateHash {
+			log.Info("Deleting outdated cache volume claim", "claim", claim.Name)
+			if err := r.Delete(ctx, claim); err != nil && !kerrors.IsNotFound(err) {
+				return fmt.Errorf("failed to delete outdated cache volume claim %q: %w", claim.Name, err)
+			}
+			continue
+		}
+
+		current++
+		free = append(free, claim)
+	}
+
+	// Delete the newest free claims first when the pool is over its size.
+	for i := len(free) - 1; i >= 0 && current > size; i-- {
+		log.Info("Deleting excess cache volume claim", "claim", free[i].Name)
+		if err := r.Delete(ctx, free[i]); err != nil && !kerrors.IsNotFound(err) {
+			return fmt.Errorf("failed to delete excess cache volume claim %q: %w", free[i].Name, err)
+		}
+		current--
+	}
+
+	for ; current < size; current++ {
+		claim, err := r.newCacheVolumeClaim(autoscalingRunnerSet)
+		if err != nil {
+			return fmt.Errorf("failed to build cache volume claim: %w", err)
+		}
+
+		if err := r.Create(ctx, claim); err != nil {
+			return fmt.Errorf("failed to create cache volume claim: %w", err)
+		}
+		log.Info("Created cache volume claim", "claim", claim.Name)
+	}
+
+	return nil
+}
+
+// leaseCacheVolumeClaim leases a free claim of the cache volume pool of the scale set to the ephemeral runner,
+// and records it on the runner for its pod to mount. The claim already leased to the runner, if any, is kept,
+// so that the pods re-created after a failure mount the same volume.
+func (r *EphemeralRunnerReconciler) leaseCacheVolumeClaim(ctx context.Context, ephemeralRunner *v1alpha1.EphemeralRunner, log logr.Logger) error {
+	if claimName := ephemeralRunner.Annotations[AnnotationKeyCacheVolumeClaim]; claimName != "" {
+		claim := new(corev1.PersistentVolumeClaim)
+		err := r.Get(ctx, types.NamespacedName{Namespace: ephemeralRunner.Namespace, Name: claimName}, claim)
+		switch {
+		case err == nil:
+			if claim.DeletionTimestamp.IsZero() && claim.Annotations[AnnotationKeyCacheVolumeLeaseHolder] == ephemeralRunner.Name {
+				return nil
+			}
+		case !kerrors.IsNotFound(err):
+			return fmt.Errorf("failed to get cache volume claim: %w", err)
+		}
+	}
+
+	// The ephemeral runner set, and thus the pool, is named after the autoscaling runner set.
+	owner := metav1.GetControllerOf(ephemeralRunner)
+	if owner == nil {
+		return fmt.Errorf("ephemeral runner has no owner ephemeral runner set")
+	}
+
+	var claims corev1.PersistentVolumeClaimList
+	if err := r.List(
+		ctx,
+		&claims,
+		client.InNamespace(ephemeralRunner.Namespace),
+		client.MatchingLabels(cacheVolumeClaimLabels(owner.Name, ephemeralRunner.Namespace)),
+	); err != nil {
+		return fmt.Errorf("failed to list cache volume claims: %w", err)
+	}
+
+	// Prefer the oldest claims, which are the most likely to hold a warm cache.
+	slices.SortFunc(claims.Items, func(a, b corev1.PersistentVolumeClaim) int {
+		return a.CreationTimestamp.Compare(b.CreationTimestamp.Time)
+	})
+
+	var leased string
+	for i := range claims.Items {
+		claim := &claims.Items[i]
+		if !claim.DeletionTimestamp.IsZero() || claim.Annotations[AnnotationKeyCacheVolumeLeaseHolder] != "" {
+			continue
+		}
+
+		if claim.Annotations == nil {
+			claim.Annotations = make(map[string]string)
+		}
+		claim.Annotations[AnnotationKeyCacheVolumeLeaseHolder] = ephemeralRunner.Name
+
+		// Update fails with a conflict when another runner leased the claim in the meantime.
+		if err := r.Update(ctx, claim); err != nil {
+			if kerrors.IsConflict(err) || kerrors.IsNotFound(err) {
+				continue
+			}
+			return fmt.Errorf("failed to lease cache volume claim %q: %w", claim.Name, err)
+		}
+
+		leased = claim.Name
+		break
+	}
+
+	if leased == "" {
+		log.Info("No cache volume claim is free. The runner pod uses an emptyDir volume instead")
+	} else {
+		log.Info("Leased cache volume claim", "claim", leased)
+	}
+
+	if ephemeralRunner.Annotations[AnnotationKeyCacheVolumeClaim] == leased {
+		return nil
+	}
+
+	original := ephemeralRunner.DeepCopy()
+	if leased == "" {
+		delete(ephemeralRunner.Annotations, AnnotationKeyCacheVolumeClaim)
+	} else {
+		if ephemeralRunner.Annotations == nil {
+			ephemeralRunner.Annotations = make(map[string]string)
+		}
+		ephemeralRunner.Annotations[AnnotationKeyCacheVolumeClaim] = leased
+	}
+
+	if err := r.Patch(ctx, ephemeralRunner, client.MergeFrom(original)); err != nil {
+		return fmt.Errorf("failed to record the cache volume claim on the ephemeral runner: %w", err)
+	}
+
+	return nil
+}
+
+// returnCacheVolumeClaim returns the claim leased to the ephemeral runner to the pool.
+// It reports false while the runner pod still exists, since the volume may still be in use.
+func (r *EphemeralRunnerReconciler) returnCacheVolumeClaim(ctx context.Context, ephemeralRunner *v1alpha1.EphemeralRunner, log logr.Logger) (bool, error) {
+	claimName := ephemeralRunner.Annotations[AnnotationKeyCacheVolumeClaim]
+	if claimName == "" {
+		return true, nil
+	}
+
+	pod := new(corev1.Pod)
+	err := r.Get(ctx, types.NamespacedName{Namespace: ephemeralRunner.Namespace, Name: ephemeralRunner.Name}, pod)
+	switch {
+	case err == nil:
+		return false, nil
+	case !kerrors.IsNotFound(err):
+		return false, fmt.Errorf("failed to get runner pod: %w", err)
+	}
+
+	claim := new(corev1.PersistentVolumeClaim)
+	if err := r.Get(ctx, types.NamespacedName{Namespace: ephemeralRunner.Namespace, Name: claimName}, claim); err != nil {
+		if kerrors.IsNotFound(err) {
+			return true, nil
+		}
+		return false, fmt.Errorf("failed to get cache volume claim: %w", err)
+	}
+
+	if claim.Annotations[AnnotationKeyCacheVolumeLeaseHolder] != ephemeralRunner.Name {
+		return true, nil
+	}
+
+	delete(claim.Annotations, AnnotationKeyCacheVolumeLeaseHolder)
+	if err := r.Update(ctx, claim); err != nil {
+		return false, fmt.Errorf("failed to return cache volume claim %q: %w", claimName, err)
+	}
+
+	log.Info("Returned cache volume claim to the pool", "claim", claimName)
+	return true, nil
+}
@@ -0,0 +1,324 @@
+package actionsgithubcom
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/actions/actions-runner-controller/apis/actions.github.com/v1alpha1"
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func newCacheVolumePoolTestScheme(t *testing.T) *runtime.Scheme {
+	sc := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(sc))
+	require.NoError(t, v1alpha1.AddToScheme(sc))
+	return sc
+}
+
+func newCacheVolumePoolTestRunnerSet(size int) *v1alpha1.AutoscalingRunnerSet {
+	return &v1alpha1.AutoscalingRunnerSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-scale-set",
+			Namespace: "test-ns",
+			UID:       "test-autoscaling-runner-set-uid",
+		},
+		Spec: v1alpha1.AutoscalingRunnerSetSpec{
+			CacheVolumePool: &v1alpha1.CacheVolumePoolConfig{
+				VolumeName: "cache",
+				Size:       size,
+				VolumeClaimTemplate: corev1.PersistentVolumeClaimTemplate{
+					Spec: corev1.PersistentVolumeClaimSpec{
+						AccessModes: []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce},
+						Resources: corev1.VolumeResourceRequirements{
+							Requests: corev1.ResourceList{corev1.ResourceStorage: resource.MustParse("10Gi")},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func newCacheVolumePoolTestClaim(name, templateHash, holder string, age time.Duration) *corev1.PersistentVolumeClaim {
+	claim := &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              name,
+			Namespace:         "test-ns",
+			Labels:            cacheVolumeClaimLabels("test-scale-set", "test-ns"),
+			Annotations:       map[string]string{annotationKeyCacheVolumeTemplateHash: templateHash},
+			CreationTimestamp: metav1.NewTime(time.Now().Add(-age)),
+		},
+	}
+	if holder != "" {
+		claim.Annotations[AnnotationKeyCacheVolumeLeaseHolder] = holder
+	}
+	return claim
+}
+
+func newCacheVolumePoolTestRunner(name string, annotations map[string]string) *v1alpha1.EphemeralRunner {
+	controller := true
+	return &v1alpha1.EphemeralRunner{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        name,
+			Namespace:   "test-ns",
+			Annotations: annotations,
+			OwnerReferences: []metav1.OwnerReference{
+				{
+					APIVersion: v1alpha1.GroupVersion.String(),
+					Kind:       "EphemeralRunnerSet",
+					Name:       "test-scale-set",
+					UID:        "test-ephemeral-runner-set-uid",
+					Controller: &controller,
+				},
+			},
+		},
+		Spec: v1alpha1.EphemeralRunnerSpec{
+			CacheVolumeName: "cache",
+		},
+	}
+}
+
+func listCacheVolumePoolTestClaims(t *testing.T, c client.Client) map[string]corev1.PersistentVolumeClaim {
+	var claims corev1.PersistentVolumeClaimList
+	require.NoError(t, c.List(context.Background(), &claims, client.InNamespace("test-ns")))
+
+	byName := make(map[string]corev1.PersistentVolumeClaim, len(claims.Items))
+	for _, claim := range claims.Items {
+		byName[claim.Name] = claim
+	}
+	return byName
+}
+
+func TestReconcileCacheVolumePool(t *testing.T) {
+	t.Run("creates claims up to the pool size", func(t *testing.T) {
+		ars := newCacheVolumePoolTestRunnerSet(3)
+		templateHash := cacheVolumeClaimTemplateHash(ars.Spec.CacheVolumePool)
+
+		c := fake.NewClientBuilder().
+			WithScheme(newCacheVolumePoolTestScheme(t)).
+			WithObjects(ars, newCacheVolumePoolTestClaim("existing", templateHash, "", time.Hour)).
+			Build()
+		r := &AutoscalingRunnerSetReconciler{Client: c}
+
+		require.NoError(t, r.reconcileCacheVolumePool(context.Background(), ars, logr.Discard()))
+
+		claims := listCacheVolumePoolTestClaims(t, c)
+		assert.Len(t, claims, 3)
+		assert.Contains(t, claims, "existing")
+	})
+
+	t.Run("deletes the newest free claims over the pool size", func(t *testing.T) {
+		ars := newCacheVolumePoolTestRunnerSet(2)
+		templateHash := cacheVolumeClaimTemplateHash(ars.Spec.CacheVolumePool)
+
+		c := fake.NewClientBuilder().
+			WithScheme(newCacheVolumePoolTestScheme(t)).
+			WithObjects(
+				ars,
+				newCacheVolumePoolTestRunner("busy-runner", nil),
+				newCacheVolumePoolTestClaim("oldest", templateHash, "", 3*time.Hour),
+				newCacheVolumePoolTestClaim("leased", templateHash, "busy-runner", 2*time.Hour),
+				newCacheVolumePoolTestClaim("newest", templateHash, "", time.Hour),
+			).
+			Build()
+		r := &AutoscalingRunnerSetReconciler{Client: c}
+
+		require.NoError(t, r.reconcileCacheVolumePool(context.Background(), ars, logr.Discard()))
+
+		claims := listCacheVolumePoolTestClaims(t, c)
+		assert.Len(t, claims, 2)
+		assert.Contains(t, claims, "oldest")
+		assert.Contains(t, claims, "leased")
+	})
+
+	t.Run("replaces free claims created from an outdated template", func(t *testing.T) {
+		ars := newCacheVolumePoolTestRunnerSet(2)
+
+		c := fake.NewClientBuilder().
+			WithScheme(newCacheVolumePoolTestScheme(t)).
+			WithObjects(
+				ars,
+				newCacheVolumePoolTestRunner("busy-runner", nil),
+				newCacheVolumePoolTestClaim("outdated-free", "outdated", "", time.Hour),
+				newCacheVolumePoolTestClaim("outdated-leased", "outdated", "busy-runner", time.Hour),
+			).
+			Build()
+		r := &AutoscalingRunnerSetReconciler{Client: c}
+
+		require.NoError(t, r.reconcileCacheVolumePool(context.Background(), ars, logr.Discard()))
+
+		claims := listCacheVolumePoolTestClaims(t, c)
+		assert.Len(t, claims, 2)
+		assert.NotContains(t, claims, "outdated-free")
+		assert.Contains(t, claims, "outdated-leased", "leased claims are kept until they are returned")
+	})
+
+	t.Run("releases claims leased to deleted runners", func(t *testing.T) {
+		ars := newCacheVolumePoolTestRunnerSet(1)
+		templateHash := cacheVolumeClaimTemplateHash(ars.Spec.CacheVolumePool)
+
+		c := fake.NewClientBuilder().
+			WithScheme(newCacheVolumePoolTestScheme(t)).
+			WithObjects(ars, newCacheVolumePoolTestClaim("leaked", templateHash, "deleted-runner", time.Hour)).
+			Build()
+		r := &AutoscalingRunnerSetReconciler{Client: c}
+
+		require.NoError(t, r.reconcileCacheVolumePool(context.Background(), ars, logr.Discard()))
+
+		claims := listCacheVolumePoolTestClaims(t, c)
+		require.Contains(t, claims, "leaked")
+		assert.NotContains(t, claims["leaked"].Annotations, AnnotationKeyCacheVolumeLeaseHolder)
+	})
+
+	t.Run("deletes free claims when the pool is removed", func(t *testing.T) {
+		ars := newCacheVolumePoolTestRunnerSet(1)
+		templateHash := cacheVolumeClaimTemplateHash(ars.Spec.CacheVolumePool)
+		ars.Spec.CacheVolumePool = nil
+
+		c := fake.NewClientBuilder().
+			WithScheme(newCacheVolumePoolTestScheme(t)).
+			WithObjects(
+				ars,
+				newCacheVolumePoolTestRunner("busy-runner", nil),
+				newCacheVolumePoolTestClaim("free", templateHash, "", time.Hour),
+				newCacheVolumePoolTestClaim("leased", templateHash, "busy-runner", time.Hour),
+			).
+			Build()
+		r := &AutoscalingRunnerSetReconciler{Client: c}
+
+		require.NoError(t, r.reconcileCacheVolumePool(context.Background(), ars, logr.Discard()))
+
+		claims := listCacheVolumePoolTestClaims(t, c)
+		assert.Len(t, claims, 1)
+		assert.Contains(t, claims, "leased")
+	})
+}
+
+func TestLeaseCacheVolumeClaim(t *testing.T) {
+	t.Run("leases the oldest free claim", func(t *testing.T) {
+		runner := newCacheVolumePoolTestRunner("runner", nil)
+
+		c := fake.NewClientBuilder().
+			WithScheme(newCacheVolumePoolTestScheme(t)).
+			WithObjects(
+				runner,
+				newCacheVolumePoolTestClaim("leased", "hash", "other-runner", 3*time.Hour),
+				newCacheVolumePoolTestClaim("oldest", "hash", "", 2*time.Hour),
+				newCacheVolumePoolTestClaim("newest", "hash", "", time.Hour),
+			).
+			Build()
+		r := &EphemeralRunnerReconciler{Client: c}
+
+		require.NoError(t, r.leaseCacheVolumeClaim(context.Background(), runner, logr.Discard()))
+
+		assert.Equal(t, "oldest", runner.Annotations[AnnotationKeyCacheVolumeClaim])
+
+		var updated v1alpha1.EphemeralRunner
+		require.NoError(t, c.Get(context.Background(), types.NamespacedName{Namespace: "test-ns", Name: "runner"}, &updated))
+		assert.Equal(t, "oldest", updated.Annotations[AnnotationKeyCacheVolumeClaim])
+
+		claims := listCacheVolumePoolTestClaims(t, c)
+		assert.Equal(t, "runner", claims["oldest"].Annotations[AnnotationKeyCacheVolumeLeaseHolder])
+		assert.Equal(t, "other-runner", claims["leased"].Annotations[AnnotationKeyCacheVolumeLeaseHolder])
+		assert.NotContains(t, claims["newest"].Annotations, AnnotationKeyCacheVolumeLeaseHolder)
+	})
+
+	t.Run("keeps the claim already leased to the runner", func(t *testing.T) {
+		runner := newCacheVolumePoolTestRunner("runner", map[string]string{AnnotationKeyCacheVolumeClaim: "newest"})
+
+		c := fake.NewClientBuilder().
+			WithScheme(newCacheVolumePoolTestScheme(t)).
+			WithObjects(
+				runner,
+				newCacheVolumePoolTestClaim("oldest", "hash", "", 2*time.Hour),
+				newCacheVolumePoolTestClaim("newest", "hash", "runner", time.Hour),
+			).
+			Build()
+		r := &EphemeralRunnerReconciler{Client: c}
+
+		require.NoError(t, r.leaseCacheVolumeClaim(context.Background(), runner, logr.Discard()))
+
+		assert.Equal(t, "newest", runner.Annotations[AnnotationKeyCacheVolumeClaim])
+		claims := listCacheVolumePoolTestClaims(t, c)
+		assert.NotContains(t, claims["oldest"].Annotations, AnnotationKeyCacheVolumeLeaseHolder)
+	})
+
+	t.Run("falls back to no claim when none is free", func(t *testing.T) {
+		runner := newCacheVolumePoolTestRunner("runner", map[string]string{AnnotationKeyCacheVolumeClaim: "deleted"})
+
+		c := fake.NewClientBuilder().
+			WithScheme(newCacheVolumePoolTestScheme(t)).
+			WithObjects(runner, newCacheVolumePoolTestClaim("leased", "hash", "other-runner", time.Hour)).
+			Build()
+		r := &EphemeralRunnerReconciler{Client: c}
+
+		require.NoError(t, r.leaseCacheVolumeClaim(context.Background(), runner, logr.Discard()))
+
+		assert.NotContains(t, runner.Annotations, AnnotationKeyCacheVolumeClaim)
+	})
+}
+
+func TestReturnCacheVolumeClaim(t *testing.T) {
+	t.Run("waits for the runner pod to be deleted", func(t *testing.T) {
+		runner := newCacheVolumePoolTestRunner("runner", map[string]string{AnnotationKeyCacheVolumeClaim: "claim"})
+		pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "runner", Namespace: "test-ns"}}
+
+		c := fake.NewClientBuilder().
+			WithScheme(newCacheVolumePoolTestScheme(t)).
+			WithObjects(runner, pod, newCacheVolumePoolTestClaim("claim", "hash", "runner", time.Hour)).
+			Build()
+		r := &EphemeralRunnerReconciler{Client: c}
+
+		returned, err := r.returnCacheVolumeClaim(context.Background(), runner, logr.Discard())
+		require.NoError(t, err)
+		assert.False(t, returned)
+
+		claims := listCacheVolumePoolTestClaims(t, c)
+		assert.Equal(t, "runner", claims["claim"].Annotations[AnnotationKeyCacheVolumeLeaseHolder])
+	})
+
+	t.Run("returns the claim once the runner pod is deleted", func(t *testing.T) {
+		runner := newCacheVolumePoolTestRunner("runner", map[string]string{AnnotationKeyCacheVolumeClaim: "claim"})
+
+		c := fake.NewClientBuilder().
+			WithScheme(newCacheVolumePoolTestScheme(t)).
+			WithObjects(runner, newCacheVolumePoolTestClaim("claim", "hash", "runner", time.Hour)).
+			Build()
+		r := &EphemeralRunnerReconciler{Client: c}
+
+		returned, err := r.returnCacheVolumeClaim(context.Background(), runner, logr.Discard())
+		require.NoError(t, err)
+		assert.True(t, returned)
+
+		claims := listCacheVolumePoolTestClaims(t, c)
+		assert.NotContains(t, claims["claim"].Annotations, AnnotationKeyCacheVolumeLeaseHolder)
+	})
+
+	t.Run("leaves the claim leased to another runner", func(t *testing.T) {
+		runner := newCacheVolumePoolTestRunner("runner", map[string]string{AnnotationKeyCacheVolumeClaim: "claim"})
+
+		c := fake.NewClientBuilder().
+			WithScheme(newCacheVolumePoolTestScheme(t)).
+			WithObjects(runner, newCacheVolumePoolTestClaim("claim", "hash", "other-runner", time.Hour)).
+			Build()
+		r := &EphemeralRunnerReconciler{Client: c}
+
+		returned, err := r.returnCacheVolumeClaim(context.Background(), runner, logr.Discard())
+		require.NoError(t, err)
+		assert.True(t, returned)
+
+		claims := listCacheVolumePoolTestClaims(t, c)
+		assert.Equal(t, "other-runner", claims["claim"].Annotations[AnnotationKeyCacheVolumeLeaseHolder])
+	})
+}
@@ -52,6 +52,32 @@ const (
 	AnnotationKeyPatchID                  = "actions.github.com/patch-id"
 )
 
+// Labels and annotations used to protect busy runners from eviction
+const (
+	// LabelKeyBusyEphemeralRunnerSet is set on the pod of an ephemeral runner that is running a job,
+	// to the name of its EphemeralRunnerSet, so that the PodDisruptionBudget of the set selects the pod.
+	LabelKeyBusyEphemeralRunnerSet = "actions.github.com/busy-ephemeral-runner-set"
+
+	// AnnotationKeyNodeDraining is set on an ephemeral runner whose pod runs on a draining node,
+	// to the name of the node.
+	AnnotationKeyNodeDraining = "actions.github.com/node-draining"
+)
+
+// Annotations used to lease the claims of the cache volume pool of a scale set
+const (
+	// AnnotationKeyCacheVolumeClaim is set on an ephemeral runner to the name of the claim leased to its pod.
+	AnnotationKeyCacheVolumeClaim = "actions.github.com/cache-volume-claim"
+
+	// AnnotationKeyCacheVolumeLeaseHolder is set on a claim of the pool to the name of the ephemeral runner it is leased to.
+	AnnotationKeyCacheVolumeLeaseHolder = "actions.github.com/cache-volume-lease-holder"
+
+	// annotationKeyCacheVolumeTemplateHash is set on a claim of the pool to the hash of the template it was created from.
+	annotationKeyCacheVolumeTemplateHash = "actions.github.com/cache-volume-template-hash"
+
+	// labelValueCacheVolumeComponent is the component label value of the claims of the pool.
+	labelValueCacheVolumeComponent = "runner-cache-volume"
+)
+
 // Labels applied to listener roles
 const (
 	labelKeyListenerName      = "auto-scaling-listener-name"
@@ -25,6 +25,7 @@ import (
 	"time"
 
 	"github.com/actions/actions-runner-controller/apis/actions.github.com/v1alpha1"
+	"github.com/actions/actions-runner-controller/controllers/internal/nodedrain"
 	"github.com/actions/scaleset"
 	"github.com/go-logr/logr"
 	corev1 "k8s.io/api/core/v1"
@@ -33,9 +34,12 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 )
 
 const (
@@ -48,6 +52,9 @@ type EphemeralRunnerReconciler struct {
 	client.Client
 	Log    logr.Logger
 	Scheme *runtime.Scheme
+	// EvictionProtection protects the pods of busy runners from eviction,
+	// and marks the runners whose pods run on draining nodes.
+	EvictionProtection bool
 	ResourceBuilder
 }
 
@@ -70,6 +77,8 @@ const maxFailures = 5
 // +kubebuilder:rbac:groups=core,resources=pods,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=core,resources=pods/status,verbs=get
 // +kubebuilder:rbac:groups=core,resources=secrets,verbs=create;get;list;watch;delete
+// +kubebuilder:rbac:groups=core,resources=nodes,verbs=get;list;watch
+// +kubebuilder:rbac:groups=core,resources=persistentvolumeclaims,verbs=get;list;watch;update
 
 // Reconcile is part of the main kubernetes reconciliation loop which aims to
 // move the current state of the cluster closer to the desired state.
@@ -120,6 +129,18 @@ func (r *EphemeralRunnerReconciler) Reconcile(ctx context.Context, req ctrl.Requ
 			return ctrl.Result{}, err
 		}
 
+		if ephemeralRunner.Spec.CacheVolumeName != "" {
+			returned, err := r.returnCacheVolumeClaim(ctx, &ephemeralRunner, log)
+			if err != nil {
+				log.Error(err, "Failed to return the cache volume claim")
+				return ctrl.Result{}, err
+			}
+			if !returned {
+				log.Info("Waiting for the runner pod to be deleted to return the cache volume claim")
+				return ctrl.Result{RequeueAfter: 5 * time.Second}, nil
+			}
+		}
+
 		if ephemeralRunner.HasContainerHookConfigured() {
 			log.Info("Runner has container hook configured, cleaning up container hook resources")
 			err = r.cleanupContainerHooksResources(ctx, &ephemeralRunner, log)
@@ -150,6 +171,14 @@ func (r *EphemeralRunnerReconciler) Reconcile(ctx context.Context, req ctrl.Requ
 			return ctrl.Result{}, err
 		}
 
+		// The claim is returned on the pod deletion event if the pod is still terminating.
+		if ephemeralRunner.Spec.CacheVolumeName != "" {
+			if _, err := r.returnCacheVolumeClaim(ctx, &ephemeralRunner, log); err != nil {
+				log.Error(err, "Failed to return the cache volume claim")
+				return ctrl.Result{}, err
+			}
+		}
+
 		// Stop reconciling on this object.
 		// The EphemeralRunnerSet is responsible for cleaning it up.
 		log.Info("EphemeralRunner has already finished. Stopping reconciliation and waiting for EphemeralRunnerSet to clean it up", "phase", ephemeralRunner.Status.Phase)
@@ -266,6 +295,13 @@ func (r *EphemeralRunnerReconciler) Reconcile(ctx context.Context, req ctrl.Requ
 		}
 		log.Info("Ephemeral runner pod does not exist. Creating new ephemeral runner")
 
+		if ephemeralRunner.Spec.CacheVolumeName != "" {
+			if err := r.leaseCacheVolumeClaim(ctx, &ephemeralRunner, log); err != nil {
+				log.Error(err, "Failed to lease a cache volume claim")
+				return ctrl.Result{}, err
+			}
+		}
+
 		result, err := r.createPod(ctx, &ephemeralRunner, secret, log)
 		switch {
 		case err == nil:
@@ -375,6 +411,12 @@ func (r *EphemeralRunnerReconciler) Reconcile(ctx context.Context, req ctrl.Requ
 			log.Info("Failed to update ephemeral runner status. Requeue to not miss this event")
 			return ctrl.Result{}, err
 		}
+		if r.EvictionProtection {
+			if err := r.reconcileEvictionProtection(ctx, &ephemeralRunner, pod, log); err != nil {
+				log.Error(err, "Failed to reconcile eviction protection")
+				return ctrl.Result{}, err
+			}
+		}
 		return ctrl.Result{}, nil
 
 	case cs.State.Terminated.ExitCode == 7: // outdated
@@ -631,6 +673,8 @@ func (r *EphemeralRunnerReconciler) deletePodAsFailed(ctx context.Context, ephem
 	}
 	ephemeralRunner.Status.Failures[string(pod.UID)] = metav1.Now()
 	ephemeralRunner.Status.Ready = false
+	ephemeralRunner.Status.PodCreatedAt = nil
+	ephemeralRunner.Status.ReadyAt = nil
 	ephemeralRunner.Status.Reason = pod.Status.Reason
 	ephemeralRunner.Status.Message = pod.Status.Message
 
@@ -814,8 +858,11 @@ func (r *EphemeralRunnerReconciler) updateRunStatusFromPod(ctx context.Context,
 	phase := v1alpha1.EphemeralRunnerPhase(pod.Status.Phase)
 	phaseChanged := ephemeralRunner.Status.Phase != phase
 	readyChanged := ready != ephemeralRunner.Status.Ready
+	// Timestamps are used by the listener to break down the job startup duration.
+	podCreatedAtMissing := ephemeralRunner.Status.PodCreatedAt == nil
+	readyAtMissing := ready && ephemeralRunner.Status.ReadyAt == nil
 
-	if !phaseChanged && !readyChanged {
+	if !phaseChanged && !readyChanged && !podCreatedAtMissing && !readyAtMissing {
 		return nil
 	}
 
@@ -831,6 +878,12 @@ func (r *EphemeralRunnerReconciler) updateRunStatusFromPod(ctx context.Context,
 	ephemeralRunner.Status.Ready = ready
 	ephemeralRunner.Status.Reason = pod.Status.Reason
 	ephemeralRunner.Status.Message = pod.Status.Message
+	if podCreatedAtMissing {
+		ephemeralRunner.Status.PodCreatedAt = pod.CreationTimestamp.DeepCopy()
+	}
+	if readyAtMissing {
+		ephemeralRunner.Status.ReadyAt = &metav1.Time{Time: lastTransitionTime}
+	}
 
 	if err := r.Status().Patch(ctx, ephemeralRunner, client.MergeFrom(original)); err != nil {
 		return fmt.Errorf("failed to update runner status for Phase/Reason/Message/Ready: %w", err)
@@ -840,6 +893,111 @@ func (r *EphemeralRunnerReconciler) updateRunStatusFromPod(ctx context.Context,
 	return nil
 }
 
+// reconcileEvictionProtection protects the pod of a busy ephemeral runner from eviction, and marks the
+// ephemeral runner when its pod runs on a draining node, so that the EphemeralRunnerSet can replace it
+// with a runner on another node while it is still idle.
+func (r *EphemeralRunnerReconciler) reconcileEvictionProtection(ctx context.Context, ephemeralRunner *v1alpha1.EphemeralRunner, pod *corev1.Pod, log logr.Logger) error {
+	if ephemeralRunner.HasJob() {
+		if err := r.protectPodFromEviction(ctx, ephemeralRunner, pod, log); err != nil {
+			return err
+		}
+	}
+
+	var draining bool
+	if pod.Spec.NodeName != "" {
+		node := new(corev1.Node)
+		if err := r.Get(ctx, types.NamespacedName{Name: pod.Spec.NodeName}, node); err != nil {
+			if !kerrors.IsNotFound(err) {
+				return fmt.Errorf("failed to get node %q: %w", pod.Spec.NodeName, err)
+			}
+			// The node is gone, so is the pod.
+			return nil
+		}
+		draining = nodedrain.IsDraining(node)
+	}
+
+	if _, marked := ephemeralRunner.Annotations[AnnotationKeyNodeDraining]; marked == draining {
+		return nil
+	}
+
+	original := ephemeralRunner.DeepCopy()
+	if draining {
+		log.Info("Runner pod is on a draining node, marking ephemeral runner", "node", pod.Spec.NodeName)
+		if ephemeralRunner.Annotations == nil {
+			ephemeralRunner.Annotations = make(map[string]string)
+		}
+		ephemeralRunner.Annotations[AnnotationKeyNodeDraining] = pod.Spec.NodeName
+	} else {
+		log.Info("Runner pod is no longer on a draining node, unmarking ephemeral runner", "node", pod.Spec.NodeName)
+		delete(ephemeralRunner.Annotations, AnnotationKeyNodeDraining)
+	}
+
+	if err := r.Patch(ctx, ephemeralRunner, client.MergeFrom(original)); err != nil {
+		return fmt.Errorf("failed to patch ephemeral runner with node draining annotation: %w", err)
+	}
+
+	return nil
+}
+
+// protectPodFromEviction tells the cluster autoscaler not to evict the pod of a busy ephemeral runner,
+// and labels the pod so that the PodDisruptionBudget of its EphemeralRunnerSet disallows its eviction.
+//
+// An ephemeral runner never becomes idle again once it is assigned a job, so the protection is never removed.
+func (r *EphemeralRunnerReconciler) protectPodFromEviction(ctx context.Context, ephemeralRunner *v1alpha1.EphemeralRunner, pod *corev1.Pod, log logr.Logger) error {
+	var setName string
+	if owner := metav1.GetControllerOfNoCopy(ephemeralRunner); owner != nil && owner.Kind == "EphemeralRunnerSet" {
+		setName = owner.Name
+	}
+
+	if pod.Annotations[nodedrain.AnnotationKeySafeToEvict] == "false" && pod.Labels[LabelKeyBusyEphemeralRunnerSet] == setName {
+		return nil
+	}
+
+	log.Info("Protecting busy runner pod from eviction", "jobId", ephemeralRunner.Status.JobID)
+	original := pod.DeepCopy()
+	if pod.Annotations == nil {
+		pod.Annotations = make(map[string]string)
+	}
+	pod.Annotations[nodedrain.AnnotationKeySafeToEvict] = "false"
+	if setName != "" {
+		if pod.Labels == nil {
+			pod.Labels = make(map[string]string)
+		}
+		pod.Labels[LabelKeyBusyEphemeralRunnerSet] = setName
+	}
+
+	if err := r.Patch(ctx, pod, client.MergeFrom(original)); err != nil {
+		return fmt.Errorf("failed to patch runner pod for eviction protection: %w", err)
+	}
+
+	log.Info("Protected busy runner pod from eviction")
+	return nil
+}
+
+// ephemeralRunnersOnNode maps a node to the ephemeral runners whose pods run on it.
+func (r *EphemeralRunnerReconciler) ephemeralRunnersOnNode(ctx context.Context, node client.Object) []reconcile.Request {
+	var podList corev1.PodList
+	if err := r.List(ctx, &podList, client.MatchingFields{nodedrain.PodNodeNameKey: node.GetName()}); err != nil {
+		r.Log.Error(err, "Failed to list pods on node", "node", node.GetName())
+		return nil
+	}
+
+	var requests []reconcile.Request
+	for i := range podList.Items {
+		pod := &podList.Items[i]
+		owner := metav1.GetControllerOfNoCopy(pod)
+		if owner == nil || owner.APIVersion != v1alpha1.GroupVersion.String() || owner.Kind != "EphemeralRunner" {
+			continue
+		}
+
+		requests = append(requests, reconcile.Request{
+			NamespacedName: types.NamespacedName{Namespace: pod.Namespace, Name: owner.Name},
+		})
+	}
+
+	return requests
+}
+
 func (r *EphemeralRunnerReconciler) deleteRunnerFromService(ctx context.Context, ephemeralRunner *v1alpha1.EphemeralRunner, log logr.Logger) error {
 	client, err := r.GetActionsService(ctx, ephemeralRunner)
 	if err != nil {
@@ -860,11 +1018,20 @@ func (r *EphemeralRunnerReconciler) deleteRunnerFromService(ctx context.Context,
 func (r *EphemeralRunnerReconciler) SetupWithManager(mgr ctrl.Manager, opts ...Option) error {
 	r.ResourceBuilder.setSchemeIfUnset(r.Scheme)
 
+	b := ctrl.NewControllerManagedBy(mgr).
+		For(&v1alpha1.EphemeralRunner{}).
+		Owns(&corev1.Pod{})
+
+	if r.EvictionProtection {
+		b = b.Watches(
+			&corev1.Node{},
+			handler.EnqueueRequestsFromMapFunc(r.ephemeralRunnersOnNode),
+			builder.WithPredicates(nodedrain.DrainingChangedPredicate()),
+		)
+	}
+
 	return builderWithOptions(
-		ctrl.NewControllerManagedBy(mgr).
-			For(&v1alpha1.EphemeralRunner{}).
-			Owns(&corev1.Pod{}).
-			WithEventFilter(predicate.ResourceVersionChangedPredicate{}),
+		b.WithEventFilter(predicate.ResourceVersionChangedPredicate{}),
 		opts,
 	).Complete(r)
 }
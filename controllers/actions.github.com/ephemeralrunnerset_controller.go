@@ -34,6 +34,7 @@ import (
 	"github.com/go-logr/logr"
 	"go.uber.org/multierr"
 	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
 	kerrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
@@ -54,6 +55,9 @@ type EphemeralRunnerSetReconciler struct {
 	Log            logr.Logger
 	Scheme         *runtime.Scheme
 	PublishMetrics bool
+	// EvictionProtection maintains a PodDisruptionBudget for the busy runners of each set,
+	// and replaces the idle runners whose pods run on draining nodes.
+	EvictionProtection bool
 	ResourceBuilder
 }
 
@@ -62,6 +66,7 @@ type EphemeralRunnerSetReconciler struct {
 // +kubebuilder:rbac:groups=actions.github.com,resources=ephemeralrunnersets/finalizers,verbs=update;patch
 // +kubebuilder:rbac:groups=actions.github.com,resources=ephemeralrunners,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=actions.github.com,resources=ephemeralrunners/status,verbs=get
+// +kubebuilder:rbac:groups=policy,resources=poddisruptionbudgets,verbs=get;list;watch;create;delete
 
 // Reconcile is part of the main kubernetes reconciliation loop which aims to
 // move the current state of the cluster closer to the desired state.
@@ -228,6 +233,24 @@ func (r *EphemeralRunnerSetReconciler) Reconcile(ctx context.Context, req ctrl.R
 		)
 	}
 
+	if r.EvictionProtection {
+		if err := r.reconcilePodDisruptionBudget(ctx, &ephemeralRunnerSet, log); err != nil {
+			log.Error(err, "Failed to reconcile pod disruption budget")
+			return ctrl.Result{}, err
+		}
+
+		handedOff, err := r.handOffDrainingEphemeralRunners(ctx, &ephemeralRunnerSet, ephemeralRunnersByState, log)
+		if err != nil {
+			log.Error(err, "Failed to hand off ephemeral runners on draining nodes")
+			return ctrl.Result{}, err
+		}
+		if handedOff {
+			// The deleted and created ephemeral runners trigger the next reconciliation,
+			// which scales and updates the status based on the up-to-date list.
+			return ctrl.Result{}, nil
+		}
+	}
+
 	total := ephemeralRunnersByState.scaleTotal()
 	if ephemeralRunnerSet.Spec.PatchID == 0 || ephemeralRunnerSet.Spec.PatchID != ephemeralRunnersByState.latestPatchID {
 		defer func() {
@@ -253,7 +276,7 @@ func (r *EphemeralRunnerSetReconciler) Reconcile(ctx context.Context, req ctrl.R
 		case ephemeralRunnerSet.Spec.PatchID == 0 && total > ephemeralRunnerSet.Spec.Replicas:
 			count := total - ephemeralRunnerSet.Spec.Replicas
 			log.Info("Deleting ephemeral runners (scale down)", "count", count)
-			if err := r.deleteIdleEphemeralRunners(
+			if _, err := r.deleteIdleEphemeralRunners(
 				ctx,
 				&ephemeralRunnerSet,
 				ephemeralRunnersByState.pending,
@@ -593,18 +616,19 @@ func (r *EphemeralRunnerSetReconciler) createProxySecret(ctx context.Context, ep
 // if there are not enough ephemeral runners that have registered with Actions service.
 // When this happens, the next reconcile loop will try to delete the remaining ephemeral runners
 // after we get notified by any of the `v1alpha1.EphemeralRunner.Status` updates.
-func (r *EphemeralRunnerSetReconciler) deleteIdleEphemeralRunners(ctx context.Context, ephemeralRunnerSet *v1alpha1.EphemeralRunnerSet, pendingEphemeralRunners, runningEphemeralRunners []*v1alpha1.EphemeralRunner, count int, log logr.Logger) error {
+// It returns the number of ephemeral runners it deleted.
+func (r *EphemeralRunnerSetReconciler) deleteIdleEphemeralRunners(ctx context.Context, ephemeralRunnerSet *v1alpha1.EphemeralRunnerSet, pendingEphemeralRunners, runningEphemeralRunners []*v1alpha1.EphemeralRunner, count int, log logr.Logger) (int, error) {
 	if count <= 0 {
-		return nil
+		return 0, nil
 	}
 	runners := newEphemeralRunnerStepper(pendingEphemeralRunners, runningEphemeralRunners)
 	if runners.len() == 0 {
 		log.Info("No pending or running ephemeral runners running at this time for scale down")
-		return nil
+		return 0, nil
 	}
 	actionsClient, err := r.GetActionsService(ctx, ephemeralRunnerSet)
 	if err != nil {
-		return fmt.Errorf("failed to create actions client for ephemeral runner replica set: %w", err)
+		return 0, fmt.Errorf("failed to create actions client for ephemeral runner replica set: %w", err)
 	}
 	var errs []error
 	deletedCount := 0
@@ -641,7 +665,71 @@ func (r *EphemeralRunnerSetReconciler) deleteIdleEphemeralRunners(ctx context.Co
 		}
 	}
 
-	return multierr.Combine(errs...)
+	return deletedCount, multierr.Combine(errs...)
+}
+
+// reconcilePodDisruptionBudget creates the PodDisruptionBudget that disallows the eviction of the busy runners of the set.
+// The PodDisruptionBudget is owned by the set, so it is garbage collected along with it.
+func (r *EphemeralRunnerSetReconciler) reconcilePodDisruptionBudget(ctx context.Context, ephemeralRunnerSet *v1alpha1.EphemeralRunnerSet, log logr.Logger) error {
+	pdb := new(policyv1.PodDisruptionBudget)
+	err := r.Get(ctx, types.NamespacedName{Namespace: ephemeralRunnerSet.Namespace, Name: ephemeralRunnerSet.Name}, pdb)
+	switch {
+	case err == nil:
+		return nil
+	case !kerrors.IsNotFound(err):
+		return fmt.Errorf("failed to get pod disruption budget: %w", err)
+	}
+
+	pdb, err = r.newEphemeralRunnerSetPodDisruptionBudget(ephemeralRunnerSet)
+	if err != nil {
+		return fmt.Errorf("failed to build pod disruption budget: %w", err)
+	}
+
+	log.Info("Creating pod disruption budget for busy ephemeral runners", "name", pdb.Name)
+	if err := r.Create(ctx, pdb); err != nil {
+		return fmt.Errorf("failed to create pod disruption budget: %w", err)
+	}
+
+	log.Info("Created pod disruption budget for busy ephemeral runners", "name", pdb.Name)
+	return nil
+}
+
+// handOffDrainingEphemeralRunners removes the idle ephemeral runners whose pods run on draining nodes
+// from the service, so that no new job is assigned to them, and creates new ephemeral runners in place of them.
+// The busy ones are left to finish their jobs, while the PodDisruptionBudget of the set protects them from eviction.
+//
+// It returns true when it deleted any ephemeral runner.
+func (r *EphemeralRunnerSetReconciler) handOffDrainingEphemeralRunners(ctx context.Context, ephemeralRunnerSet *v1alpha1.EphemeralRunnerSet, state *ephemeralRunnersByState, log logr.Logger) (bool, error) {
+	var draining []*v1alpha1.EphemeralRunner
+	for _, runners := range [][]*v1alpha1.EphemeralRunner{state.pending, state.running} {
+		for _, ephemeralRunner := range runners {
+			if _, ok := ephemeralRunner.Annotations[AnnotationKeyNodeDraining]; ok && !ephemeralRunner.HasJob() {
+				draining = append(draining, ephemeralRunner)
+			}
+		}
+	}
+
+	if len(draining) == 0 {
+		return false, nil
+	}
+
+	log.Info("Handing off idle ephemeral runners on draining nodes", "count", len(draining))
+	deleted, err := r.deleteIdleEphemeralRunners(ctx, ephemeralRunnerSet, nil, draining, len(draining), log)
+	if deleted == 0 {
+		return false, err
+	}
+
+	// Do not replace the deleted ephemeral runners beyond the desired replicas,
+	// so that a scale down is not undone.
+	count := min(deleted, ephemeralRunnerSet.Spec.Replicas-(state.scaleTotal()-deleted))
+	if count > 0 {
+		log.Info("Creating ephemeral runners in place of the handed off ones", "count", count)
+		if createErr := r.createEphemeralRunners(ctx, ephemeralRunnerSet, count, log); createErr != nil {
+			err = multierr.Append(err, createErr)
+		}
+	}
+
+	return true, err
 }
 
 func (r *EphemeralRunnerSetReconciler) deleteEphemeralRunnerWithActionsClient(ctx context.Context, ephemeralRunner *v1alpha1.EphemeralRunner, actionsClient multiclient.Client, log logr.Logger) (bool, error) {
@@ -667,11 +755,16 @@ func (r *EphemeralRunnerSetReconciler) deleteEphemeralRunnerWithActionsClient(ct
 func (r *EphemeralRunnerSetReconciler) SetupWithManager(mgr ctrl.Manager, opts ...Option) error {
 	r.setSchemeIfUnset(r.Scheme)
 
+	b := ctrl.NewControllerManagedBy(mgr).
+		For(&v1alpha1.EphemeralRunnerSet{}).
+		Owns(&v1alpha1.EphemeralRunner{})
+
+	if r.EvictionProtection {
+		b = b.Owns(&policyv1.PodDisruptionBudget{})
+	}
+
 	return builderWithOptions(
-		ctrl.NewControllerManagedBy(mgr).
-			For(&v1alpha1.EphemeralRunnerSet{}).
-			Owns(&v1alpha1.EphemeralRunner{}).
-			WithEventFilter(predicate.ResourceVersionChangedPredicate{}),
+		b.WithEventFilter(predicate.ResourceVersionChangedPredicate{}),
 		opts,
 	).Complete(r)
 }
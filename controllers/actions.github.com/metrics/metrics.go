@@ -1,6 +1,8 @@
 package metrics
 
 import (
+	"slices"
+
 	"github.com/prometheus/client_golang/prometheus"
 	"sigs.k8s.io/controller-runtime/pkg/metrics"
 )
@@ -34,40 +36,61 @@ func (l *CommonLabels) labels() prometheus.Labels {
 }
 
 var (
-	pendingEphemeralRunners = prometheus.NewGaugeVec(
-		prometheus.GaugeOpts{
-			Subsystem: githubScaleSetControllerSubsystem,
-			Name:      "pending_ephemeral_runners",
-			Help:      "Number of ephemeral runners in a pending state.",
-		},
-		labels,
-	)
-	runningEphemeralRunners = prometheus.NewGaugeVec(
-		prometheus.GaugeOpts{
-			Subsystem: githubScaleSetControllerSubsystem,
-			Name:      "running_ephemeral_runners",
-			Help:      "Number of ephemeral runners in a running state.",
-		},
-		labels,
-	)
-	failedEphemeralRunners = prometheus.NewGaugeVec(
-		prometheus.GaugeOpts{
-			Subsystem: githubScaleSetControllerSubsystem,
-			Name:      "failed_ephemeral_runners",
-			Help:      "Number of ephemeral runners in a failed state.",
-		},
-		labels,
-	)
-	runningListeners = prometheus.NewGaugeVec(
-		prometheus.GaugeOpts{
-			Subsystem: githubScaleSetControllerSubsystem,
-			Name:      "running_listeners",
-			Help:      "Number of listeners in a running state.",
-		},
-		labels,
-	)
+	pendingEphemeralRunnersOpts = prometheus.GaugeOpts{
+		Subsystem: githubScaleSetControllerSubsystem,
+		Name:      "pending_ephemeral_runners",
+		Help:      "Number of ephemeral runners in a pending state.",
+	}
+	runningEphemeralRunnersOpts = prometheus.GaugeOpts{
+		Subsystem: githubScaleSetControllerSubsystem,
+		Name:      "running_ephemeral_runners",
+		Help:      "Number of ephemeral runners in a running state.",
+	}
+	failedEphemeralRunnersOpts = prometheus.GaugeOpts{
+		Subsystem: githubScaleSetControllerSubsystem,
+		Name:      "failed_ephemeral_runners",
+		Help:      "Number of ephemeral runners in a failed state.",
+	}
+	runningListenersOpts = prometheus.GaugeOpts{
+		Subsystem: githubScaleSetControllerSubsystem,
+		Name:      "running_listeners",
+		Help:      "Number of listeners in a running state.",
+	}
 )
 
+var (
+	pendingEphemeralRunners = prometheus.NewGaugeVec(pendingEphemeralRunnersOpts, labels)
+	runningEphemeralRunners = prometheus.NewGaugeVec(runningEphemeralRunnersOpts, labels)
+	failedEphemeralRunners  = prometheus.NewGaugeVec(failedEphemeralRunnersOpts, labels)
+	runningListeners        = prometheus.NewGaugeVec(runningListenersOpts, labels)
+)
+
+// Definition describes a gauge exposed by the controller.
+type Definition struct {
+	Name   string
+	Help   string
+	Labels []string
+}
+
+// Definitions returns all metrics exposed by the controller, sorted by name.
+func Definitions() []Definition {
+	opts := []prometheus.GaugeOpts{
+		failedEphemeralRunnersOpts,
+		pendingEphemeralRunnersOpts,
+		runningEphemeralRunnersOpts,
+		runningListenersOpts,
+	}
+	defs := make([]Definition, 0, len(opts))
+	for _, o := range opts {
+		defs = append(defs, Definition{
+			Name:   prometheus.BuildFQName(o.Namespace, o.Subsystem, o.Name),
+			Help:   o.Help,
+			Labels: slices.Clone(labels),
+		})
+	}
+	return defs
+}
+
 func RegisterMetrics() {
 	metrics.Registry.MustRegister(
 		pendingEphemeralRunners,
@@ -8,6 +8,7 @@ import (
 	"maps"
 	"math"
 	"net"
+	"slices"
 	"strconv"
 	"strings"
 
@@ -23,9 +24,12 @@ import (
 	"github.com/actions/actions-runner-controller/vault/azurekeyvault"
 	"github.com/actions/scaleset"
 	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
 	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
@@ -145,6 +149,8 @@ func (b *ResourceBuilder) newAutoscalingListener(autoscalingRunnerSet *v1alpha1.
 		Proxy:                         autoscalingRunnerSet.Spec.Proxy,
 		GitHubServerTLS:               autoscalingRunnerSet.Spec.GitHubServerTLS,
 		Metrics:                       autoscalingRunnerSet.Spec.ListenerMetrics,
+		RunnerResourceRequests:        runnerPodResourceRequests(&autoscalingRunnerSet.Spec.Template.Spec),
+		Prewarm:                       autoscalingRunnerSet.Spec.ListenerPrewarm,
 		Template:                      autoscalingRunnerSet.Spec.ListenerTemplate,
 		ServiceAccountMetadata:        autoscalingRunnerSet.Spec.ListenerServiceAccountMetadata,
 		RoleMetadata:                  autoscalingRunnerSet.Spec.ListenerRoleMetadata,
@@ -186,6 +192,34 @@ func (b *ResourceBuilder) newAutoscalingListener(autoscalingRunnerSet *v1alpha1.
 	return autoscalingListener, nil
 }
 
+// runnerPodResourceRequests returns the effective CPU and memory requests of the runner pod,
+// following the scheduler's rule: the sum of the regular containers, or the largest init container
+// if that is greater.
+func runnerPodResourceRequests(spec *corev1.PodSpec) corev1.ResourceList {
+	requests := corev1.ResourceList{}
+	for _, name := range []corev1.ResourceName{corev1.ResourceCPU, corev1.ResourceMemory} {
+		var total resource.Quantity
+		for _, c := range spec.Containers {
+			if q, ok := c.Resources.Requests[name]; ok {
+				total.Add(q)
+			}
+		}
+		for _, c := range spec.InitContainers {
+			if q, ok := c.Resources.Requests[name]; ok && q.Cmp(total) > 0 {
+				total = q.DeepCopy()
+			}
+		}
+		if !total.IsZero() {
+			requests[name] = total
+		}
+	}
+
+	if len(requests) == 0 {
+		return nil
+	}
+	return requests
+}
+
 type listenerMetricsServerConfig struct {
 	addr     string
 	endpoint string
@@ -231,6 +265,8 @@ func (b *ResourceBuilder) newScaleSetListenerConfig(autoscalingListener *v1alpha
 		MetricsAddr:                 metricsAddr,
 		MetricsEndpoint:             metricsEndpoint,
 		Metrics:                     autoscalingListener.Spec.Metrics,
+		RunnerResourceRequests:      autoscalingListener.Spec.RunnerResourceRequests,
+		Prewarm:                     autoscalingListener.Spec.Prewarm,
 	}
 
 	vault := autoscalingListener.Spec.VaultConfig
@@ -758,6 +794,10 @@ func (b *ResourceBuilder) newEphemeralRunnerSet(autoscalingRunnerSet *v1alpha1.A
 		EphemeralRunnerMetadata: autoscalingRunnerSet.Spec.EphemeralRunnerMetadata,
 	}
 
+	if autoscalingRunnerSet.Spec.CacheVolumePool != nil {
+		spec.EphemeralRunnerSpec.CacheVolumeName = autoscalingRunnerSet.Spec.CacheVolumePool.VolumeName
+	}
+
 	labels := b.filterAndMergeLabels(autoscalingRunnerSet.Labels, map[string]string{
 		LabelKeyKubernetesPartOf:        labelValueKubernetesPartOf,
 		LabelKeyKubernetesComponent:     "runner-set",
@@ -880,6 +920,102 @@ func (b *ResourceBuilder) newEphemeralRunner(ephemeralRunnerSet *v1alpha1.Epheme
 	return ephemeralRunner, nil
 }
 
+// newEphemeralRunnerSetPodDisruptionBudget returns a PodDisruptionBudget that disallows the eviction
+// of the pods of the ephemeral runners of the set that are running a job.
+func (b *ResourceBuilder) newEphemeralRunnerSetPodDisruptionBudget(ephemeralRunnerSet *v1alpha1.EphemeralRunnerSet) (*policyv1.PodDisruptionBudget, error) {
+	labels := make(map[string]string, len(commonLabelKeys))
+	for _, key := range commonLabelKeys {
+		if v, ok := ephemeralRunnerSet.Labels[key]; ok {
+			labels[key] = v
+		}
+	}
+	labels[LabelKeyKubernetesComponent] = "runner-pod-disruption-budget"
+
+	maxUnavailable := intstr.FromInt32(0)
+	pdb := &policyv1.PodDisruptionBudget{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      ephemeralRunnerSet.Name,
+			Namespace: ephemeralRunnerSet.Namespace,
+			Labels:    labels,
+		},
+		Spec: policyv1.PodDisruptionBudgetSpec{
+			MaxUnavailable: &maxUnavailable,
+			Selector: &metav1.LabelSelector{
+				MatchLabels: map[string]string{
+					LabelKeyBusyEphemeralRunnerSet: ephemeralRunnerSet.Name,
+				},
+			},
+		},
+	}
+	if err := b.setControllerReference(ephemeralRunnerSet, pdb); err != nil {
+		return nil, fmt.Errorf("failed to set controller reference for pod disruption budget: %w", err)
+	}
+
+	return pdb, nil
+}
+
+// cacheVolumeClaimLabels returns the labels selecting the claims of the cache volume pool of a scale set.
+func cacheVolumeClaimLabels(scaleSetName, scaleSetNamespace string) map[string]string {
+	return map[string]string{
+		LabelKeyKubernetesComponent:     labelValueCacheVolumeComponent,
+		LabelKeyGitHubScaleSetName:      scaleSetName,
+		LabelKeyGitHubScaleSetNamespace: scaleSetNamespace,
+	}
+}
+
+func cacheVolumeClaimTemplateHash(pool *v1alpha1.CacheVolumePoolConfig) string {
+	return hash.ComputeTemplateHash(&pool.VolumeClaimTemplate)
+}
+
+// newCacheVolumeClaim returns a new claim for the cache volume pool of the autoscaling runner set.
+func (b *ResourceBuilder) newCacheVolumeClaim(autoscalingRunnerSet *v1alpha1.AutoscalingRunnerSet) (*corev1.PersistentVolumeClaim, error) {
+	pool := autoscalingRunnerSet.Spec.CacheVolumePool
+
+	labels := b.filterAndMergeLabels(pool.VolumeClaimTemplate.Labels, map[string]string{
+		LabelKeyKubernetesPartOf:  labelValueKubernetesPartOf,
+		LabelKeyKubernetesVersion: autoscalingRunnerSet.Labels[LabelKeyKubernetesVersion],
+	})
+	if labels == nil {
+		labels = make(map[string]string)
+	}
+	// The selector labels are set regardless of the excluded label prefixes, since the pool is looked up by them.
+	maps.Copy(labels, cacheVolumeClaimLabels(autoscalingRunnerSet.Name, autoscalingRunnerSet.Namespace))
+
+	annotations := make(map[string]string, len(pool.VolumeClaimTemplate.Annotations)+1)
+	maps.Copy(annotations, pool.VolumeClaimTemplate.Annotations)
+	annotations[annotationKeyCacheVolumeTemplateHash] = cacheVolumeClaimTemplateHash(pool)
+
+	claim := &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: autoscalingRunnerSet.Name + "-cache-",
+			Namespace:    autoscalingRunnerSet.Namespace,
+			Labels:       labels,
+			Annotations:  annotations,
+		},
+		Spec: *pool.VolumeClaimTemplate.Spec.DeepCopy(),
+	}
+
+	if err := b.setControllerReference(autoscalingRunnerSet, claim); err != nil {
+		return nil, fmt.Errorf("failed to set controller reference for cache volume claim: %w", err)
+	}
+
+	return claim, nil
+}
+
+// ephemeralRunnerCacheVolume returns the pod volume backed by the claim leased to the ephemeral runner,
+// or an emptyDir volume when the runner could not lease one.
+func ephemeralRunnerCacheVolume(runner *v1alpha1.EphemeralRunner) corev1.Volume {
+	volume := corev1.Volume{Name: runner.Spec.CacheVolumeName}
+
+	if claimName := runner.Annotations[AnnotationKeyCacheVolumeClaim]; claimName != "" {
+		volume.PersistentVolumeClaim = &corev1.PersistentVolumeClaimVolumeSource{ClaimName: claimName}
+	} else {
+		volume.EmptyDir = &corev1.EmptyDirVolumeSource{}
+	}
+
+	return volume
+}
+
 func (b *ResourceBuilder) newEphemeralRunnerPod(runner *v1alpha1.EphemeralRunner, secret *corev1.Secret, envs ...corev1.EnvVar) (*corev1.Pod, error) {
 	var newPod corev1.Pod
 
@@ -939,6 +1075,18 @@ func (b *ResourceBuilder) newEphemeralRunnerPod(runner *v1alpha1.EphemeralRunner
 		newPod.Spec.Containers = append(newPod.Spec.Containers, c)
 	}
 
+	if runner.Spec.CacheVolumeName != "" {
+		cacheVolume := ephemeralRunnerCacheVolume(runner)
+		idx := slices.IndexFunc(runner.Spec.Spec.Volumes, func(v corev1.Volume) bool { return v.Name == cacheVolume.Name })
+
+		newPod.Spec.Volumes = slices.Clone(runner.Spec.Spec.Volumes)
+		if idx >= 0 {
+			newPod.Spec.Volumes[idx] = cacheVolume
+		} else {
+			newPod.Spec.Volumes = append(newPod.Spec.Volumes, cacheVolume)
+		}
+	}
+
 	if err := b.setControllerReference(runner, &newPod); err != nil {
 		return nil, fmt.Errorf("failed to set controller reference for ephemeral runner pod: %w", err)
 	}
@@ -1055,7 +1203,8 @@ func rulesForListenerRole(resourceNames []string) []rbacv1.PolicyRule {
 			APIGroups:     []string{"actions.github.com"},
 			Resources:     []string{"ephemeralrunnersets"},
 			ResourceNames: resourceNames,
-			Verbs:         []string{"patch"},
+			// watch is used by the listener to follow the prewarm hint annotation.
+			Verbs: []string{"patch", "watch"},
 		},
 		{
 			APIGroups: []string{"actions.github.com"},
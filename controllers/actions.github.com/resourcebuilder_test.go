@@ -1,15 +1,19 @@
 package actionsgithubcom
 
 import (
+	"encoding/json"
 	"fmt"
 	"strings"
 	"testing"
 
 	"github.com/actions/actions-runner-controller/apis/actions.github.com/v1alpha1"
+	"github.com/actions/actions-runner-controller/apis/actions.github.com/v1alpha1/appconfig"
+	ghalistenerconfig "github.com/actions/actions-runner-controller/cmd/ghalistener/config"
 	"github.com/actions/scaleset"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -542,3 +546,271 @@ func TestListenerPodNodeSelector(t *testing.T) {
 			"explicitly empty nodeSelector should override the linux default")
 	})
 }
+
+func TestRunnerPodResourceRequests(t *testing.T) {
+	tests := map[string]struct {
+		spec corev1.PodSpec
+		want corev1.ResourceList
+	}{
+		"no requests": {
+			spec: corev1.PodSpec{
+				Containers: []corev1.Container{{Name: "runner"}},
+			},
+			want: nil,
+		},
+		"sums regular containers": {
+			spec: corev1.PodSpec{
+				Containers: []corev1.Container{
+					{
+						Name: "runner",
+						Resources: corev1.ResourceRequirements{
+							Requests: corev1.ResourceList{
+								corev1.ResourceCPU:    resource.MustParse("1"),
+								corev1.ResourceMemory: resource.MustParse("2Gi"),
+							},
+						},
+					},
+					{
+						Name: "dind",
+						Resources: corev1.ResourceRequirements{
+							Requests: corev1.ResourceList{
+								corev1.ResourceCPU: resource.MustParse("500m"),
+							},
+						},
+					},
+				},
+			},
+			want: corev1.ResourceList{
+				corev1.ResourceCPU:    resource.MustParse("1500m"),
+				corev1.ResourceMemory: resource.MustParse("2Gi"),
+			},
+		},
+		"init container larger than regular containers": {
+			spec: corev1.PodSpec{
+				InitContainers: []corev1.Container{
+					{
+						Name: "init",
+						Resources: corev1.ResourceRequirements{
+							Requests: corev1.ResourceList{
+								corev1.ResourceMemory: resource.MustParse("4Gi"),
+							},
+						},
+					},
+				},
+				Containers: []corev1.Container{
+					{
+						Name: "runner",
+						Resources: corev1.ResourceRequirements{
+							Requests: corev1.ResourceList{
+								corev1.ResourceCPU:    resource.MustParse("1"),
+								corev1.ResourceMemory: resource.MustParse("1Gi"),
+							},
+						},
+					},
+				},
+			},
+			want: corev1.ResourceList{
+				corev1.ResourceCPU:    resource.MustParse("1"),
+				corev1.ResourceMemory: resource.MustParse("4Gi"),
+			},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got := runnerPodResourceRequests(&tc.spec)
+			require.Len(t, got, len(tc.want))
+			for k, v := range tc.want {
+				q := got[k]
+				assert.Truef(t, v.Equal(q), "resource %s: want %s, got %s", k, v.String(), q.String())
+			}
+		})
+	}
+}
+
+func TestEphemeralRunnerSetPodDisruptionBudget(t *testing.T) {
+	ephemeralRunnerSet := &v1alpha1.EphemeralRunnerSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-scale-set-abcde",
+			Namespace: "test-ns",
+			UID:       "test-ephemeral-runner-set-uid",
+			Labels: map[string]string{
+				LabelKeyKubernetesPartOf:  labelValueKubernetesPartOf,
+				LabelKeyKubernetesVersion: "0.2.0",
+			},
+		},
+	}
+
+	b := ResourceBuilder{}
+	pdb, err := b.newEphemeralRunnerSetPodDisruptionBudget(ephemeralRunnerSet)
+	require.NoError(t, err)
+
+	assert.Equal(t, ephemeralRunnerSet.Name, pdb.Name)
+	assert.Equal(t, ephemeralRunnerSet.Namespace, pdb.Namespace)
+	assert.Equal(t, labelValueKubernetesPartOf, pdb.Labels[LabelKeyKubernetesPartOf])
+	require.NotNil(t, pdb.Spec.MaxUnavailable)
+	assert.Equal(t, 0, pdb.Spec.MaxUnavailable.IntValue())
+	require.NotNil(t, pdb.Spec.Selector)
+	assert.Equal(t, map[string]string{LabelKeyBusyEphemeralRunnerSet: ephemeralRunnerSet.Name}, pdb.Spec.Selector.MatchLabels)
+
+	ref := metav1.GetControllerOf(pdb)
+	require.NotNil(t, ref, "PodDisruptionBudget should have a controller reference")
+	assert.Equal(t, ephemeralRunnerSet.UID, ref.UID)
+	assert.Equal(t, "EphemeralRunnerSet", ref.Kind)
+}
+
+func TestCacheVolumeClaim(t *testing.T) {
+	autoscalingRunnerSet := &v1alpha1.AutoscalingRunnerSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-scale-set",
+			Namespace: "test-ns",
+			UID:       "test-autoscaling-runner-set-uid",
+			Labels: map[string]string{
+				LabelKeyKubernetesVersion: "0.2.0",
+			},
+		},
+		Spec: v1alpha1.AutoscalingRunnerSetSpec{
+			CacheVolumePool: &v1alpha1.CacheVolumePoolConfig{
+				VolumeName: "cache",
+				Size:       2,
+				VolumeClaimTemplate: corev1.PersistentVolumeClaimTemplate{
+					ObjectMeta: metav1.ObjectMeta{
+						Labels:      map[string]string{"actions.github.com/excluded": "true", "team": "build"},
+						Annotations: map[string]string{"example.com/backup": "false"},
+					},
+					Spec: corev1.PersistentVolumeClaimSpec{
+						AccessModes: []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce},
+						Resources: corev1.VolumeResourceRequirements{
+							Requests: corev1.ResourceList{corev1.ResourceStorage: resource.MustParse("10Gi")},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	b := ResourceBuilder{ExcludeLabelPropagationPrefixes: []string{"actions.github.com/"}}
+	claim, err := b.newCacheVolumeClaim(autoscalingRunnerSet)
+	require.NoError(t, err)
+
+	assert.Equal(t, "test-scale-set-cache-", claim.GenerateName)
+	assert.Equal(t, "test-ns", claim.Namespace)
+	assert.Equal(t, "build", claim.Labels["team"])
+	assert.NotContains(t, claim.Labels, "actions.github.com/excluded")
+	for k, v := range cacheVolumeClaimLabels(autoscalingRunnerSet.Name, autoscalingRunnerSet.Namespace) {
+		assert.Equal(t, v, claim.Labels[k], "selector label %q should be set regardless of the excluded prefixes", k)
+	}
+	assert.Equal(t, "false", claim.Annotations["example.com/backup"])
+	assert.Equal(t, cacheVolumeClaimTemplateHash(autoscalingRunnerSet.Spec.CacheVolumePool), claim.Annotations[annotationKeyCacheVolumeTemplateHash])
+	assert.Equal(t, autoscalingRunnerSet.Spec.CacheVolumePool.VolumeClaimTemplate.Spec, claim.Spec)
+
+	ref := metav1.GetControllerOf(claim)
+	require.NotNil(t, ref, "claim should have a controller reference")
+	assert.Equal(t, autoscalingRunnerSet.UID, ref.UID)
+}
+
+func TestEphemeralRunnerPodCacheVolume(t *testing.T) {
+	newRunner := func(claimName string) *v1alpha1.EphemeralRunner {
+		runner := &v1alpha1.EphemeralRunner{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "test-runner",
+				Namespace: "test-ns",
+				UID:       "test-runner-uid",
+			},
+			Spec: v1alpha1.EphemeralRunnerSpec{
+				CacheVolumeName: "cache",
+				PodTemplateSpec: corev1.PodTemplateSpec{
+					Spec: corev1.PodSpec{
+						Containers: []corev1.Container{{Name: v1alpha1.EphemeralRunnerContainerName}},
+						Volumes: []corev1.Volume{
+							{Name: "work", VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}}},
+							{Name: "cache", VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{Medium: corev1.StorageMediumMemory}}},
+						},
+					},
+				},
+			},
+		}
+		if claimName != "" {
+			runner.Annotations = map[string]string{AnnotationKeyCacheVolumeClaim: claimName}
+		}
+		return runner
+	}
+
+	b := ResourceBuilder{}
+
+	t.Run("mounts the leased claim", func(t *testing.T) {
+		runner := newRunner("test-scale-set-cache-abcde")
+		pod, err := b.newEphemeralRunnerPod(runner, &corev1.Secret{})
+		require.NoError(t, err)
+
+		require.Len(t, pod.Spec.Volumes, 2)
+		assert.Equal(t, "work", pod.Spec.Volumes[0].Name)
+		assert.Equal(t, "cache", pod.Spec.Volumes[1].Name)
+		require.NotNil(t, pod.Spec.Volumes[1].PersistentVolumeClaim)
+		assert.Equal(t, "test-scale-set-cache-abcde", pod.Spec.Volumes[1].PersistentVolumeClaim.ClaimName)
+		assert.Nil(t, pod.Spec.Volumes[1].EmptyDir)
+
+		// The runner spec must not be modified.
+		assert.NotNil(t, runner.Spec.Spec.Volumes[1].EmptyDir)
+	})
+
+	t.Run("falls back to an emptyDir volume", func(t *testing.T) {
+		pod, err := b.newEphemeralRunnerPod(newRunner(""), &corev1.Secret{})
+		require.NoError(t, err)
+
+		require.Len(t, pod.Spec.Volumes, 2)
+		assert.Nil(t, pod.Spec.Volumes[1].PersistentVolumeClaim)
+		require.NotNil(t, pod.Spec.Volumes[1].EmptyDir)
+		assert.Empty(t, pod.Spec.Volumes[1].EmptyDir.Medium)
+	})
+
+	t.Run("adds the volume missing from the template", func(t *testing.T) {
+		runner := newRunner("test-scale-set-cache-abcde")
+		runner.Spec.Spec.Volumes = runner.Spec.Spec.Volumes[:1]
+
+		pod, err := b.newEphemeralRunnerPod(runner, &corev1.Secret{})
+		require.NoError(t, err)
+
+		require.Len(t, pod.Spec.Volumes, 2)
+		assert.Equal(t, "cache", pod.Spec.Volumes[1].Name)
+		require.NotNil(t, pod.Spec.Volumes[1].PersistentVolumeClaim)
+	})
+}
+
+func TestListenerPrewarm(t *testing.T) {
+	autoscalingRunnerSet := v1alpha1.AutoscalingRunnerSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-scale-set",
+			Namespace: "test-ns",
+			Annotations: map[string]string{
+				runnerScaleSetIDAnnotationKey: "1",
+			},
+		},
+		Spec: v1alpha1.AutoscalingRunnerSetSpec{
+			GitHubConfigUrl: "https://github.com/org/repo",
+		},
+	}
+
+	b := ResourceBuilder{}
+	ephemeralRunnerSet, err := b.newEphemeralRunnerSet(&autoscalingRunnerSet)
+	require.NoError(t, err)
+
+	readConfig := func(t *testing.T) ghalistenerconfig.Config {
+		t.Helper()
+
+		listener, err := b.newAutoscalingListener(&autoscalingRunnerSet, ephemeralRunnerSet, autoscalingRunnerSet.Namespace, "test:latest", nil)
+		require.NoError(t, err)
+
+		secret, err := b.newScaleSetListenerConfig(listener, &appconfig.AppConfig{Token: "token"}, nil, "")
+		require.NoError(t, err)
+
+		var config ghalistenerconfig.Config
+		require.NoError(t, json.Unmarshal(secret.Data["config.json"], &config))
+		return config
+	}
+
+	assert.False(t, readConfig(t).Prewarm, "prewarm hints should not be watched by default")
+
+	autoscalingRunnerSet.Spec.ListenerPrewarm = true
+	assert.True(t, readConfig(t).Prewarm)
+}
@@ -53,6 +53,8 @@ func (r *HorizontalRunnerAutoscalerReconciler) suggestDesiredReplicas(ghc *arcgi
 		suggested, err = r.suggestReplicasByQueuedAndInProgressWorkflowRuns(ghc, st, hra, &primaryMetric)
 	case v1alpha1.AutoscalingMetricTypePercentageRunnersBusy:
 		suggested, err = r.suggestReplicasByPercentageRunnersBusy(ghc, st, hra, primaryMetric)
+	case v1alpha1.AutoscalingMetricTypeExternal:
+		suggested, err = r.suggestReplicasByExternalMetric(st, hra, primaryMetric)
 	default:
 		return nil, fmt.Errorf("validating autoscaling metrics: unsupported metric type %q", primaryMetric.Type)
 	}
@@ -186,7 +188,7 @@ func (r *HorizontalRunnerAutoscalerReconciler) suggestReplicasByQueuedAndInProgr
 
 	for _, repo := range repos {
 		user, repoName := repo[0], repo[1]
-		workflowRuns, err := ghc.ListRepositoryWorkflowRuns(context.TODO(), user, repoName)
+		workflowRuns, err := r.listRepositoryWorkflowRuns(context.TODO(), ghc, user, repoName)
 		if err != nil {
 			return nil, err
 		}
@@ -242,19 +244,31 @@ func (r *HorizontalRunnerAutoscalerReconciler) suggestReplicasByQueuedAndInProgr
 	return &necessaryReplicas, nil
 }
 
-func (r *HorizontalRunnerAutoscalerReconciler) suggestReplicasByPercentageRunnersBusy(ghc *arcgithub.Client, st scaleTarget, hra v1alpha1.HorizontalRunnerAutoscaler, metrics v1alpha1.MetricSpec) (*int, error) {
-	ctx := context.Background()
-	scaleUpThreshold := defaultScaleUpThreshold
-	scaleDownThreshold := defaultScaleDownThreshold
-	scaleUpFactor := defaultScaleUpFactor
-	scaleDownFactor := defaultScaleDownFactor
+// scaleParams holds the thresholds and the factors or adjustments that map a ratio,
+// like the fraction of busy runners, to the desired number of runners.
+type scaleParams struct {
+	scaleUpThreshold    float64
+	scaleDownThreshold  float64
+	scaleUpFactor       float64
+	scaleDownFactor     float64
+	scaleUpAdjustment   int
+	scaleDownAdjustment int
+}
+
+func parseScaleParams(metrics v1alpha1.MetricSpec) (*scaleParams, error) {
+	p := &scaleParams{
+		scaleUpThreshold:   defaultScaleUpThreshold,
+		scaleDownThreshold: defaultScaleDownThreshold,
+		scaleUpFactor:      defaultScaleUpFactor,
+		scaleDownFactor:    defaultScaleDownFactor,
+	}
 
 	if metrics.ScaleUpThreshold != "" {
 		sut, err := strconv.ParseFloat(metrics.ScaleUpThreshold, 64)
 		if err != nil {
 			return nil, errors.New("validating autoscaling metrics: spec.autoscaling.metrics[].scaleUpThreshold cannot be parsed into a float64")
 		}
-		scaleUpThreshold = sut
+		p.scaleUpThreshold = sut
 	}
 	if metrics.ScaleDownThreshold != "" {
 		sdt, err := strconv.ParseFloat(metrics.ScaleDownThreshold, 64)
@@ -262,11 +276,11 @@ func (r *HorizontalRunnerAutoscalerReconciler) suggestReplicasByPercentageRunner
 			return nil, errors.New("validating autoscaling metrics: spec.autoscaling.metrics[].scaleDownThreshold cannot be parsed into a float64")
 		}
 
-		scaleDownThreshold = sdt
+		p.scaleDownThreshold = sdt
 	}
 
-	scaleUpAdjustment := metrics.ScaleUpAdjustment
-	if scaleUpAdjustment != 0 {
+	p.scaleUpAdjustment = metrics.ScaleUpAdjustment
+	if p.scaleUpAdjustment != 0 {
 		if metrics.ScaleUpAdjustment < 0 {
 			return nil, errors.New("validating autoscaling metrics: spec.autoscaling.metrics[].scaleUpAdjustment cannot be lower than 0")
 		}
@@ -279,11 +293,11 @@ func (r *HorizontalRunnerAutoscalerReconciler) suggestReplicasByPercentageRunner
 		if err != nil {
 			return nil, errors.New("validating autoscaling metrics: spec.autoscaling.metrics[].scaleUpFactor cannot be parsed into a float64")
 		}
-		scaleUpFactor = suf
+		p.scaleUpFactor = suf
 	}
 
-	scaleDownAdjustment := metrics.ScaleDownAdjustment
-	if scaleDownAdjustment != 0 {
+	p.scaleDownAdjustment = metrics.ScaleDownAdjustment
+	if p.scaleDownAdjustment != 0 {
 		if metrics.ScaleDownAdjustment < 0 {
 			return nil, errors.New("validating autoscaling metrics: spec.autoscaling.metrics[].scaleDownAdjustment cannot be lower than 0")
 		}
@@ -296,7 +310,37 @@ func (r *HorizontalRunnerAutoscalerReconciler) suggestReplicasByPercentageRunner
 		if err != nil {
 			return nil, errors.New("validating autoscaling metrics: spec.autoscaling.metrics[].scaleDownFactor cannot be parsed into a float64")
 		}
-		scaleDownFactor = sdf
+		p.scaleDownFactor = sdf
+	}
+
+	return p, nil
+}
+
+// desiredReplicas scales desiredReplicasBefore up or down when the ratio crosses the thresholds.
+func (p *scaleParams) desiredReplicas(desiredReplicasBefore int, ratio float64) int {
+	if ratio >= p.scaleUpThreshold {
+		if p.scaleUpAdjustment > 0 {
+			return desiredReplicasBefore + p.scaleUpAdjustment
+		}
+		return int(math.Ceil(float64(desiredReplicasBefore) * p.scaleUpFactor))
+	}
+
+	if ratio < p.scaleDownThreshold {
+		if p.scaleDownAdjustment > 0 {
+			return desiredReplicasBefore - p.scaleDownAdjustment
+		}
+		return int(float64(desiredReplicasBefore) * p.scaleDownFactor)
+	}
+
+	return desiredReplicasBefore
+}
+
+func (r *HorizontalRunnerAutoscalerReconciler) suggestReplicasByPercentageRunnersBusy(ghc *arcgithub.Client, st scaleTarget, hra v1alpha1.HorizontalRunnerAutoscaler, metrics v1alpha1.MetricSpec) (*int, error) {
+	ctx := context.Background()
+
+	params, err := parseScaleParams(metrics)
+	if err != nil {
+		return nil, err
 	}
 
 	runnerMap, err := st.getRunnerMap()
@@ -311,8 +355,9 @@ func (r *HorizontalRunnerAutoscalerReconciler) suggestReplicasByPercentageRunner
 	)
 
 	// ListRunners will return all runners managed by GitHub - not restricted to ns
-	runners, err := ghc.ListRunners(
+	runners, err := r.listRunners(
 		ctx,
+		ghc,
 		enterprise,
 		organization,
 		repository)
@@ -376,23 +421,8 @@ func (r *HorizontalRunnerAutoscalerReconciler) suggestReplicasByPercentageRunner
 		numTerminatingBusy++
 	}
 
-	var desiredReplicas int
 	fractionBusy := float64(numRunnersBusy+numTerminatingBusy) / float64(desiredReplicasBefore)
-	if fractionBusy >= scaleUpThreshold {
-		if scaleUpAdjustment > 0 {
-			desiredReplicas = desiredReplicasBefore + scaleUpAdjustment
-		} else {
-			desiredReplicas = int(math.Ceil(float64(desiredReplicasBefore) * scaleUpFactor))
-		}
-	} else if fractionBusy < scaleDownThreshold {
-		if scaleDownAdjustment > 0 {
-			desiredReplicas = desiredReplicasBefore - scaleDownAdjustment
-		} else {
-			desiredReplicas = int(float64(desiredReplicasBefore) * scaleDownFactor)
-		}
-	} else {
-		desiredReplicas = *st.replicas
-	}
+	desiredReplicas := params.desiredReplicas(desiredReplicasBefore, fractionBusy)
 
 	// NOTES for operators:
 	//
@@ -431,3 +461,17 @@ func (r *HorizontalRunnerAutoscalerReconciler) suggestReplicasByPercentageRunner
 
 	return &desiredReplicas, nil
 }
+
+func (r *HorizontalRunnerAutoscalerReconciler) listRunners(ctx context.Context, ghc *arcgithub.Client, enterprise, org, repo string) ([]*github.Runner, error) {
+	if r.Inventory != nil {
+		return r.Inventory.ListRunners(ctx, ghc, enterprise, org, repo)
+	}
+	return ghc.ListRunners(ctx, enterprise, org, repo)
+}
+
+func (r *HorizontalRunnerAutoscalerReconciler) listRepositoryWorkflowRuns(ctx context.Context, ghc *arcgithub.Client, user, repoName string) ([]*github.WorkflowRun, error) {
+	if r.Inventory != nil {
+		return r.Inventory.ListRepositoryWorkflowRuns(ctx, ghc, user, repoName)
+	}
+	return ghc.ListRepositoryWorkflowRuns(ctx, user, repoName)
+}
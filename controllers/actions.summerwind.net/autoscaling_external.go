@@ -0,0 +1,279 @@
+package actionssummerwindnet
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/actions/actions-runner-controller/apis/actions.summerwind.net/v1alpha1"
+	prometheus_metrics "github.com/actions/actions-runner-controller/controllers/actions.summerwind.net/metrics"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var prometheusHTTPClient = &http.Client{
+	Timeout: 30 * time.Second,
+	// Redirects are not followed, so that a Prometheus address cannot send the controller to one that is not allowed.
+	CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	},
+}
+
+func (r *HorizontalRunnerAutoscalerReconciler) suggestReplicasByExternalMetric(st scaleTarget, hra v1alpha1.HorizontalRunnerAutoscaler, metrics v1alpha1.MetricSpec) (*int, error) {
+	ctx := context.Background()
+
+	params, err := parseScaleParams(metrics)
+	if err != nil {
+		return nil, err
+	}
+
+	value, err := r.readExternalMetric(ctx, hra.Namespace, metrics.External)
+	if err != nil {
+		return nil, err
+	}
+
+	desiredReplicasBefore := 1
+	if v := st.replicas; v != nil && *v > 0 {
+		// Scaling from zero is computed as if one runner were desired,
+		// so that a non-zero value can bring the first runner up.
+		desiredReplicasBefore = *v
+	}
+
+	desiredReplicas := params.desiredReplicas(desiredReplicasBefore, value/float64(desiredReplicasBefore))
+
+	prometheus_metrics.SetHorizontalRunnerAutoscalerExternalMetric(
+		hra.ObjectMeta,
+		st.enterprise,
+		st.org,
+		st.repo,
+		st.kind,
+		st.st,
+		desiredReplicas,
+		value,
+	)
+
+	r.Log.V(1).Info(
+		fmt.Sprintf("Suggested desired replicas of %d by External", desiredReplicas),
+		"replicas_desired_before", desiredReplicasBefore,
+		"replicas_desired", desiredReplicas,
+		"value", value,
+		"namespace", hra.Namespace,
+		"kind", st.kind,
+		"name", st.st,
+		"horizontal_runner_autoscaler", hra.Name,
+	)
+
+	return &desiredReplicas, nil
+}
+
+func (r *HorizontalRunnerAutoscalerReconciler) readExternalMetric(ctx context.Context, namespace string, src *v1alpha1.ExternalMetricSource) (float64, error) {
+	if src == nil || (src.Prometheus == nil) == (src.Kubernetes == nil) {
+		return 0, errors.New("validating autoscaling metrics: spec.autoscaling.metrics[].external must specify exactly one of prometheus and kubernetes")
+	}
+
+	if src.Prometheus != nil {
+		if err := r.checkPrometheusAddress(src.Prometheus.Address); err != nil {
+			return 0, err
+		}
+
+		return queryPrometheus(ctx, prometheusHTTPClient, *src.Prometheus)
+	}
+
+	return r.readKubernetesMetric(ctx, namespace, *src.Kubernetes)
+}
+
+// ValidatePrometheusAddress returns an error when the address is not the base URL of an HTTP API.
+func ValidatePrometheusAddress(address string) error {
+	_, err := normalizePrometheusAddress(address)
+	return err
+}
+
+func normalizePrometheusAddress(address string) (string, error) {
+	u, err := url.Parse(address)
+	if err != nil {
+		return "", fmt.Errorf("invalid prometheus address %q: %w", address, err)
+	}
+
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return "", fmt.Errorf("invalid prometheus address %q: the scheme must be http or https", address)
+	}
+
+	if u.Host == "" || u.User != nil || u.RawQuery != "" || u.Fragment != "" {
+		return "", fmt.Errorf("invalid prometheus address %q: it must be a base URL without credentials, query or fragment", address)
+	}
+
+	return strings.TrimSuffix(u.String(), "/"), nil
+}
+
+// checkPrometheusAddress returns an error unless the address is one of the PrometheusAddresses of the controller.
+// HorizontalRunnerAutoscalers are namespaced, so the controller must not query any address their authors ask for.
+func (r *HorizontalRunnerAutoscalerReconciler) checkPrometheusAddress(address string) error {
+	normalized, err := normalizePrometheusAddress(address)
+	if err != nil {
+		return fmt.Errorf("validating autoscaling metrics: spec.autoscaling.metrics[].external.prometheus: %w", err)
+	}
+
+	for _, allowed := range r.PrometheusAddresses {
+		if a, err := normalizePrometheusAddress(allowed); err == nil && a == normalized {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("validating autoscaling metrics: spec.autoscaling.metrics[].external.prometheus: address %q is not allowed by the --prometheus-address flag of the controller", address)
+}
+
+// prometheusQueryResponse is the response of the instant query endpoint of the Prometheus HTTP API.
+// See https://prometheus.io/docs/prometheus/latest/querying/api/#instant-queries
+type prometheusQueryResponse struct {
+	Status    string `json:"status"`
+	ErrorType string `json:"errorType"`
+	Error     string `json:"error"`
+	Data      struct {
+		ResultType string          `json:"resultType"`
+		Result     json.RawMessage `json:"result"`
+	} `json:"data"`
+}
+
+// queryPrometheus evaluates the query and returns the sum of the values of the resulting samples.
+func queryPrometheus(ctx context.Context, c *http.Client, src v1alpha1.PrometheusMetricSource) (float64, error) {
+	if src.Address == "" || src.Query == "" {
+		return 0, errors.New("validating autoscaling metrics: spec.autoscaling.metrics[].external.prometheus requires both address and query")
+	}
+
+	u := strings.TrimSuffix(src.Address, "/") + "/api/v1/query?" + url.Values{"query": {src.Query}}.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return 0, fmt.Errorf("querying prometheus: %w", err)
+	}
+
+	res, err := c.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("querying prometheus: %w", err)
+	}
+	defer res.Body.Close()
+
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		return 0, fmt.Errorf("querying prometheus: reading response: %w", err)
+	}
+
+	var qr prometheusQueryResponse
+	if err := json.Unmarshal(body, &qr); err != nil {
+		return 0, fmt.Errorf("querying prometheus: unexpected response with status %d: %w", res.StatusCode, err)
+	}
+
+	if qr.Status != "success" {
+		return 0, fmt.Errorf("querying prometheus: %s: %s", qr.ErrorType, qr.Error)
+	}
+
+	switch qr.Data.ResultType {
+	case "scalar":
+		var sample []interface{}
+		if err := json.Unmarshal(qr.Data.Result, &sample); err != nil {
+			return 0, fmt.Errorf("querying prometheus: decoding scalar: %w", err)
+		}
+		return parsePrometheusSampleValue(sample)
+	case "vector":
+		var samples []struct {
+			Value []interface{} `json:"value"`
+		}
+		if err := json.Unmarshal(qr.Data.Result, &samples); err != nil {
+			return 0, fmt.Errorf("querying prometheus: decoding vector: %w", err)
+		}
+
+		var sum float64
+		for _, s := range samples {
+			v, err := parsePrometheusSampleValue(s.Value)
+			if err != nil {
+				return 0, err
+			}
+			sum += v
+		}
+		return sum, nil
+	default:
+		return 0, fmt.Errorf("querying prometheus: unsupported result type %q: the query must return a scalar or an instant vector", qr.Data.ResultType)
+	}
+}
+
+// parsePrometheusSampleValue parses a [<unix_time>, "<value>"] pair.
+func parsePrometheusSampleValue(sample []interface{}) (float64, error) {
+	if len(sample) != 2 {
+		return 0, fmt.Errorf("querying prometheus: unexpected sample %v", sample)
+	}
+
+	s, ok := sample[1].(string)
+	if !ok {
+		return 0, fmt.Errorf("querying prometheus: unexpected sample value %v", sample[1])
+	}
+
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, fmt.Errorf("querying prometheus: parsing sample value %q: %w", s, err)
+	}
+
+	return v, nil
+}
+
+// kubernetesMetricValueList is the subset of the ExternalMetricValueList of external.metrics.k8s.io
+// and the MetricValueList of custom.metrics.k8s.io that is needed to read the values.
+type kubernetesMetricValueList struct {
+	Items []struct {
+		Value resource.Quantity `json:"value"`
+	} `json:"items"`
+}
+
+// readKubernetesMetric returns the sum of the values of the selected series of an external metric,
+// or of a custom metric when src.Object is set.
+func (r *HorizontalRunnerAutoscalerReconciler) readKubernetesMetric(ctx context.Context, namespace string, src v1alpha1.KubernetesMetricSource) (float64, error) {
+	if r.KubernetesMetricsClient == nil {
+		return 0, errors.New("reading kubernetes metric: the controller has no client for the metrics APIs")
+	}
+
+	if src.Name == "" {
+		return 0, errors.New("validating autoscaling metrics: spec.autoscaling.metrics[].external.kubernetes.name is required")
+	}
+
+	var segments []string
+	if o := src.Object; o != nil {
+		segments = []string{"/apis/custom.metrics.k8s.io/v1beta1/namespaces", namespace, o.Resource, o.Name, src.Name}
+	} else {
+		segments = []string{"/apis/external.metrics.k8s.io/v1beta1/namespaces", namespace, src.Name}
+	}
+
+	req := r.KubernetesMetricsClient.Get().AbsPath(segments...)
+
+	if src.Selector != nil {
+		selector, err := metav1.LabelSelectorAsSelector(src.Selector)
+		if err != nil {
+			return 0, fmt.Errorf("validating autoscaling metrics: spec.autoscaling.metrics[].external.kubernetes.selector: %w", err)
+		}
+		if !selector.Empty() {
+			req = req.Param("labelSelector", selector.String())
+		}
+	}
+
+	body, err := req.DoRaw(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("reading kubernetes metric %q: %w", src.Name, err)
+	}
+
+	var list kubernetesMetricValueList
+	if err := json.Unmarshal(body, &list); err != nil {
+		return 0, fmt.Errorf("reading kubernetes metric %q: decoding response: %w", src.Name, err)
+	}
+
+	var sum float64
+	for _, item := range list.Items {
+		sum += item.Value.AsApproximateFloat64()
+	}
+
+	return sum, nil
+}
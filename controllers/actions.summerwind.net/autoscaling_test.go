@@ -6,6 +6,7 @@ import (
 	"net/http/httptest"
 	"net/url"
 	"testing"
+	"time"
 
 	"github.com/actions/actions-runner-controller/apis/actions.summerwind.net/v1alpha1"
 	"github.com/actions/actions-runner-controller/github"
@@ -433,6 +434,17 @@ func TestDetermineDesiredReplicas_RepositoryRunner(t *testing.T) {
 			if got != tc.want {
 				t.Errorf("%d: incorrect desired replicas: want %d, got %d", i, tc.want, got)
 			}
+
+			h.Inventory = &github.Inventory{RefreshInterval: time.Minute}
+
+			got, err = h.computeReplicasWithCache(client, log, metav1Now.Time, st, hra, minReplicas)
+			if err != nil {
+				t.Fatalf("unexpected error with inventory: %v", err)
+			}
+
+			if got != tc.want {
+				t.Errorf("%d: incorrect desired replicas with inventory: want %d, got %d", i, tc.want, got)
+			}
 		})
 	}
 }
@@ -843,6 +855,17 @@ func TestDetermineDesiredReplicas_OrganizationalRunner(t *testing.T) {
 			if got != tc.want {
 				t.Errorf("%d: incorrect desired replicas: want %d, got %d", i, tc.want, got)
 			}
+
+			h.Inventory = &github.Inventory{RefreshInterval: time.Minute}
+
+			got, err = h.computeReplicasWithCache(client, log, metav1Now.Time, st, hra, minReplicas)
+			if err != nil {
+				t.Fatalf("unexpected error with inventory: %v", err)
+			}
+
+			if got != tc.want {
+				t.Errorf("%d: incorrect desired replicas with inventory: want %d, got %d", i, tc.want, got)
+			}
 		})
 	}
 }
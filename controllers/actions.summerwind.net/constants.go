@@ -39,6 +39,14 @@ const (
 
 	AnnotationKeyRunnerID = annotationKeyPrefix + "id"
 
+	// AnnotationKeyEvictionProtected is the annotation that is added onto the pod of a busy runner when ARC protected it from eviction.
+	// It contains the previous value of the safe-to-evict annotation, which is restored once the runner becomes idle.
+	AnnotationKeyEvictionProtected = annotationKeyPrefix + "eviction-protected"
+
+	// AnnotationKeyPendingScaleOperations is the annotation that is added onto a HorizontalRunnerAutoscaler by the github webhook server
+	// when the durable queue is enabled. It contains the scale operations that are enqueued but not yet applied to the capacity reservations.
+	AnnotationKeyPendingScaleOperations = "actions-runner-controller/pending-scale-operations"
+
 	// This can be any value but a larger value can make an unregistration timeout longer than configured in practice.
 	DefaultUnregistrationRetryDelay = time.Minute
 
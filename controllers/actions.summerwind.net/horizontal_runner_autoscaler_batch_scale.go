@@ -156,6 +156,66 @@ func (s *batchScaler) batchScale(ctx context.Context, batch batchScaleOperation)
 	return nil
 }
 
+// applyPendingScaleOperations applies the scale operations enqueued onto the HRA by durableQueue.
+// It is run by the leader replica only, on every change to the HRA.
+// Operations are batched by waiting until the oldest one has been pending for the batch interval, in which case
+// the returned duration is the remaining time to wait before calling it again.
+// The capacity reservations are added and the applied operations are removed in the same optimistically locked patch,
+// so that an operation is never applied twice, and is retried until applied in case of a failure.
+func (s *batchScaler) applyPendingScaleOperations(ctx context.Context, nsName types.NamespacedName) (time.Duration, error) {
+	var hra v1alpha1.HorizontalRunnerAutoscaler
+
+	if err := s.Client.Get(ctx, nsName, &hra); err != nil {
+		return 0, client.IgnoreNotFound(err)
+	}
+
+	ops, err := getPendingScaleOperations(&hra)
+	if err != nil {
+		return 0, err
+	}
+
+	if len(ops) == 0 {
+		return 0, nil
+	}
+
+	now := time.Now()
+
+	if wait := ops[0].Time.Add(s.interval).Sub(now); wait > 0 {
+		return wait, nil
+	}
+
+	batch := batchScaleOperation{
+		namespacedName: nsName,
+	}
+
+	for _, op := range ops {
+		batch.scaleOps = append(batch.scaleOps, scaleOperation{
+			log: s.Log.WithValues("hra", nsName, "operation", op.ID),
+			trigger: v1alpha1.ScaleUpTrigger{
+				Amount:   op.Amount,
+				Duration: op.Duration,
+			},
+		})
+	}
+
+	s.Log.V(2).Info("Applying pending scale operations", "hra", nsName, "ops", len(ops))
+
+	copy, err := s.planBatchScale(ctx, batch, &hra, now)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := setPendingScaleOperations(copy, nil); err != nil {
+		return 0, err
+	}
+
+	if err := s.Client.Patch(ctx, copy, client.MergeFromWithOptions(&hra, client.MergeFromWithOptimisticLock{})); err != nil {
+		return 0, fmt.Errorf("patching horizontalrunnerautoscaler to apply pending scale operations: %w", err)
+	}
+
+	return 0, nil
+}
+
 func (s *batchScaler) planBatchScale(ctx context.Context, batch batchScaleOperation, hra *v1alpha1.HorizontalRunnerAutoscaler, now time.Time) (*v1alpha1.HorizontalRunnerAutoscaler, error) {
 	copy := hra.DeepCopy()
 
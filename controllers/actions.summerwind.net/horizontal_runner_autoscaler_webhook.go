@@ -19,13 +19,16 @@ package actionssummerwindnet
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
 
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/tools/events"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
@@ -36,8 +39,12 @@ import (
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
+	githubv1alpha1 "github.com/actions/actions-runner-controller/apis/actions.github.com/v1alpha1"
 	"github.com/actions/actions-runner-controller/apis/actions.summerwind.net/v1alpha1"
+	"github.com/actions/actions-runner-controller/controllers/actions.summerwind.net/metrics"
 	"github.com/actions/actions-runner-controller/github"
+	"github.com/actions/actions-runner-controller/pkg/actionsglob"
+	"github.com/actions/actions-runner-controller/pkg/webhooksecret"
 	"github.com/actions/actions-runner-controller/simulator"
 )
 
@@ -48,6 +55,10 @@ const (
 	keyRunnerGroup      = "/group/"
 
 	DefaultQueueLimit = 100
+
+	// defaultScaleUpTriggerDuration is how long a capacity reservation lasts
+	// when the scale-up trigger has no duration.
+	defaultScaleUpTriggerDuration = 10 * time.Minute
 )
 
 // HorizontalRunnerAutoscalerGitHubWebhook autoscales a HorizontalRunnerAutoscaler and the RunnerDeployment on each
@@ -62,6 +73,10 @@ type HorizontalRunnerAutoscalerGitHubWebhook struct {
 	// the administrator is generated and specified in GitHub Web UI.
 	SecretKeyBytes []byte
 
+	// Secrets validates the webhook payloads against one or more secrets tried in order, so that the secret can be rotated.
+	// SecretKeyBytes is ignored when set.
+	Secrets *webhooksecret.Verifier
+
 	// GitHub Client to discover runner groups assigned to a repository
 	GitHubClient *github.Client
 
@@ -75,12 +90,49 @@ type HorizontalRunnerAutoscalerGitHubWebhook struct {
 	// A scale target is enqueued on each retrieval of each eligible webhook event, so that it is processed asynchronously.
 	QueueLimit int
 
+	// Deliveries remembers the deliveries received so far, so that redeliveries of the same
+	// event by GitHub or the hookdeliveryforwarder do not scale twice.
+	// Set to nil to disable deduplication.
+	Deliveries DeliveryStore
+
+	// DurableQueue makes the webhook server persist each scale operation onto the HRA it targets before responding to the webhook delivery,
+	// instead of enqueueing it in memory. The persisted operations are applied in batches by the leader replica,
+	// so that no operation is lost on restart and more than one replica can be run safely.
+	// QueueLimit is then the maximum number of pending scale operations per HRA.
+	DurableQueue bool
+
+	// APIReader is used to read HRAs on enqueue when DurableQueue is enabled, and EphemeralRunnerSets on prewarm,
+	// bypassing the cache. Defaults to Client.
+	APIReader client.Reader
+
+	// ScaleSetPrewarmDuration enables prewarming AutoscalingRunnerSets on workflow_job queued events, when greater than zero.
+	// The runner scale sets whose labels match the job labels are asked by their listeners to keep a runner for each queued job
+	// for this duration, which should be long enough for the runner scale set statistics to catch up with the job.
+	ScaleSetPrewarmDuration time.Duration
+
 	worker     *worker
 	workerInit sync.Once
+
+	durableQueue *durableQueue
 }
 
-func (autoscaler *HorizontalRunnerAutoscalerGitHubWebhook) Reconcile(_ context.Context, request reconcile.Request) (reconcile.Result, error) {
-	return ctrl.Result{}, nil
+func (autoscaler *HorizontalRunnerAutoscalerGitHubWebhook) Reconcile(ctx context.Context, request reconcile.Request) (reconcile.Result, error) {
+	if !autoscaler.DurableQueue {
+		return ctrl.Result{}, nil
+	}
+
+	batchScaler := newBatchScaler(ctx, autoscaler.Client, autoscaler.Log)
+
+	requeueAfter, err := batchScaler.applyPendingScaleOperations(ctx, request.NamespacedName)
+	if err != nil {
+		if kerrors.IsConflict(err) {
+			return ctrl.Result{Requeue: true}, nil
+		}
+
+		return ctrl.Result{}, err
+	}
+
+	return ctrl.Result{RequeueAfter: requeueAfter}, nil
 }
 
 // +kubebuilder:rbac:groups=actions.summerwind.dev,resources=horizontalrunnerautoscalers,verbs=get;list;watch;create;update;patch;delete
@@ -92,11 +144,22 @@ func (autoscaler *HorizontalRunnerAutoscalerGitHubWebhook) Handle(w http.Respons
 	var (
 		ok bool
 
+		// saturated is set when the scale operation could not be enqueued because the queue is full,
+		// in which case the sender is asked to retry later.
+		saturated bool
+
 		err error
 	)
 
 	defer func() {
-		if !ok {
+		if !ok && saturated {
+			w.Header().Set("Retry-After", strconv.Itoa(int(queueFullRetryAfter.Seconds())))
+			w.WriteHeader(http.StatusServiceUnavailable)
+
+			if written, err := w.Write([]byte(errQueueFull.Error())); err != nil {
+				autoscaler.Log.V(1).Error(err, "failed writing http error response", "written", written)
+			}
+		} else if !ok {
 			w.WriteHeader(http.StatusInternalServerError)
 
 			if err != nil {
@@ -123,7 +186,14 @@ func (autoscaler *HorizontalRunnerAutoscalerGitHubWebhook) Handle(w http.Respons
 
 	var payload []byte
 
-	if len(autoscaler.SecretKeyBytes) > 0 {
+	if autoscaler.Secrets != nil {
+		payload, err = autoscaler.Secrets.ValidatePayload(r)
+		if err != nil {
+			autoscaler.Log.Error(err, "error validating request body")
+
+			return
+		}
+	} else if len(autoscaler.SecretKeyBytes) > 0 {
 		payload, err = gogithub.ValidatePayload(r, autoscaler.SecretKeyBytes)
 		if err != nil {
 			autoscaler.Log.Error(err, "error validating request body")
@@ -140,6 +210,41 @@ func (autoscaler *HorizontalRunnerAutoscalerGitHubWebhook) Handle(w http.Respons
 	}
 
 	webhookType := gogithub.WebHookType(r)
+	deliveryID := gogithub.DeliveryID(r)
+
+	if deliveryID != "" && autoscaler.Deliveries != nil {
+		added, err := autoscaler.Deliveries.Add(r.Context(), deliveryID)
+		if err != nil {
+			// Processing a redelivery twice is better than not processing the delivery at all.
+			autoscaler.Log.Error(err, "could not record webhook delivery, processing it without deduplication", "delivery", deliveryID)
+		} else if !added {
+			ok = true
+
+			metrics.IncGitHubWebhookDuplicateDeliveries(webhookType)
+
+			w.WriteHeader(http.StatusOK)
+
+			msg := fmt.Sprintf("ignored duplicate delivery %s", deliveryID)
+
+			autoscaler.Log.V(1).Info(msg, "event", webhookType)
+
+			if written, err := w.Write([]byte(msg)); err != nil {
+				autoscaler.Log.Error(err, "failed writing http response", "msg", msg, "written", written)
+			}
+
+			return
+		} else {
+			defer func() {
+				// Let GitHub redeliver events we failed to process.
+				if !ok {
+					if err := autoscaler.Deliveries.Remove(context.Background(), deliveryID); err != nil {
+						autoscaler.Log.Error(err, "could not forget failed webhook delivery", "delivery", deliveryID)
+					}
+				}
+			}()
+		}
+	}
+
 	event, err := gogithub.ParseWebHook(webhookType, payload)
 	if err != nil {
 		var s string
@@ -174,6 +279,9 @@ func (autoscaler *HorizontalRunnerAutoscalerGitHubWebhook) Handle(w http.Respons
 	}
 	enterpriseSlug := enterpriseEvent.Enterprise.Slug
 
+	// prewarmedScaleSets is the list of AutoscalingRunnerSets prewarmed for the workflow_job event.
+	var prewarmedScaleSets []string
+
 	switch e := event.(type) {
 	case *gogithub.WorkflowJobEvent:
 		if workflowJob := e.GetWorkflowJob(); workflowJob != nil {
@@ -192,6 +300,22 @@ func (autoscaler *HorizontalRunnerAutoscalerGitHubWebhook) Handle(w http.Respons
 
 		labels := e.WorkflowJob.Labels
 
+		if e.GetAction() == "queued" && autoscaler.ScaleSetPrewarmDuration > 0 {
+			prewarmed, err := autoscaler.prewarmScaleSets(
+				context.TODO(),
+				log,
+				e.Repo.GetName(),
+				e.Repo.Owner.GetLogin(),
+				enterpriseSlug,
+				labels,
+			)
+			if err != nil {
+				// Prewarming is best-effort, as the listener scales the runner scale set anyway.
+				log.Error(err, "could not prewarm runner scale sets")
+			}
+			prewarmedScaleSets = prewarmed
+		}
+
 		switch action := e.GetAction(); action {
 		case "queued", "completed":
 			target, err = autoscaler.getJobScaleUpTargetForRepoOrOrg(
@@ -239,6 +363,64 @@ func (autoscaler *HorizontalRunnerAutoscalerGitHubWebhook) Handle(w http.Respons
 
 			return
 		}
+	case *gogithub.CheckRunEvent:
+		log = log.WithValues(
+			"checkRun.name", e.GetCheckRun().GetName(),
+			"checkRun.status", e.GetCheckRun().GetStatus(),
+			"repository.name", e.GetRepo().GetName(),
+			"repository.owner.login", e.GetRepo().GetOwner().GetLogin(),
+			"repository.owner.type", e.GetRepo().GetOwner().GetType(),
+			"enterprise.slug", enterpriseSlug,
+			"action", e.GetAction(),
+		)
+
+		target, err = autoscaler.getScaleUpTarget(
+			context.TODO(),
+			log,
+			e.GetRepo().GetName(),
+			e.GetRepo().GetOwner().GetLogin(),
+			e.GetRepo().GetOwner().GetType(),
+			enterpriseSlug,
+			autoscaler.MatchCheckRunEvent(e),
+		)
+	case *gogithub.PullRequestEvent:
+		log = log.WithValues(
+			"pullRequest.number", e.GetNumber(),
+			"pullRequest.base.ref", e.GetPullRequest().GetBase().GetRef(),
+			"repository.name", e.GetRepo().GetName(),
+			"repository.owner.login", e.GetRepo().GetOwner().GetLogin(),
+			"repository.owner.type", e.GetRepo().GetOwner().GetType(),
+			"enterprise.slug", enterpriseSlug,
+			"action", e.GetAction(),
+		)
+
+		target, err = autoscaler.getScaleUpTarget(
+			context.TODO(),
+			log,
+			e.GetRepo().GetName(),
+			e.GetRepo().GetOwner().GetLogin(),
+			e.GetRepo().GetOwner().GetType(),
+			enterpriseSlug,
+			autoscaler.MatchPullRequestEvent(e),
+		)
+	case *gogithub.PushEvent:
+		log = log.WithValues(
+			"ref", e.GetRef(),
+			"repository.name", e.GetRepo().GetName(),
+			"repository.owner.login", e.GetRepo().GetOwner().GetLogin(),
+			"repository.owner.type", e.GetRepo().GetOwner().GetType(),
+			"enterprise.slug", enterpriseSlug,
+		)
+
+		target, err = autoscaler.getScaleUpTarget(
+			context.TODO(),
+			log,
+			e.GetRepo().GetName(),
+			e.GetRepo().GetOwner().GetLogin(),
+			e.GetRepo().GetOwner().GetType(),
+			enterpriseSlug,
+			autoscaler.MatchPushEvent(e),
+		)
 	case *gogithub.PingEvent:
 		ok = true
 
@@ -260,7 +442,7 @@ func (autoscaler *HorizontalRunnerAutoscalerGitHubWebhook) Handle(w http.Respons
 	}
 
 	if err != nil {
-		log.Error(err, "handling check_run event")
+		log.Error(err, "handling github event")
 
 		return
 	}
@@ -271,6 +453,9 @@ func (autoscaler *HorizontalRunnerAutoscalerGitHubWebhook) Handle(w http.Respons
 		)
 
 		msg := "no horizontalrunnerautoscaler to scale for this github event"
+		if len(prewarmedScaleSets) > 0 {
+			msg = fmt.Sprintf("prewarmed %s", strings.Join(prewarmedScaleSets, ", "))
+		}
 
 		ok = true
 
@@ -284,18 +469,36 @@ func (autoscaler *HorizontalRunnerAutoscalerGitHubWebhook) Handle(w http.Respons
 	}
 
 	autoscaler.workerInit.Do(func() {
-		batchScaler := newBatchScaler(context.Background(), autoscaler.Client, autoscaler.Log)
-
 		queueLimit := autoscaler.QueueLimit
 		if queueLimit == 0 {
 			queueLimit = DefaultQueueLimit
 		}
+
+		if autoscaler.DurableQueue {
+			autoscaler.durableQueue = newDurableQueue(autoscaler.Client, autoscaler.APIReader, queueLimit)
+			return
+		}
+
+		batchScaler := newBatchScaler(context.Background(), autoscaler.Client, autoscaler.Log)
+
 		autoscaler.worker = newWorker(context.Background(), queueLimit, batchScaler.Add)
 	})
 
 	target.log = &log
-	if ok := autoscaler.worker.Add(target); !ok {
-		log.Error(err, "Could not scale up due to queue full")
+	if autoscaler.durableQueue != nil {
+		if err = autoscaler.durableQueue.Add(context.TODO(), target, deliveryID); err != nil {
+			if errors.Is(err, errQueueFull) {
+				saturated = true
+				err = nil
+				log.Info("Could not scale up due to queue full", "hra", target.Name)
+			} else {
+				log.Error(err, "Could not enqueue scale operation", "hra", target.Name)
+			}
+			return
+		}
+	} else if ok := autoscaler.worker.Add(target); !ok {
+		saturated = true
+		log.Info("Could not scale up due to queue full", "hra", target.Name)
 		return
 	}
 
@@ -367,6 +570,15 @@ func (autoscaler *HorizontalRunnerAutoscalerGitHubWebhook) getJobScaleUpTargetFo
 	return autoscaler.getScaleUpTargetWithFunction(ctx, log, repo, owner, ownerType, enterprise, scaleTarget)
 }
 
+func (autoscaler *HorizontalRunnerAutoscalerGitHubWebhook) getScaleUpTarget(
+	ctx context.Context, log logr.Logger, repo, owner, ownerType, enterprise string, f func(v1alpha1.ScaleUpTrigger) bool,
+) (*ScaleTarget, error) {
+	scaleTarget := func(value string) (*ScaleTarget, error) {
+		return autoscaler.getScaleTarget(ctx, value, f)
+	}
+	return autoscaler.getScaleUpTargetWithFunction(ctx, log, repo, owner, ownerType, enterprise, scaleTarget)
+}
+
 func (autoscaler *HorizontalRunnerAutoscalerGitHubWebhook) getScaleUpTargetWithFunction(
 	ctx context.Context, log logr.Logger, repo, owner, ownerType, enterprise string, scaleTarget func(value string) (*ScaleTarget, error),
 ) (*ScaleTarget, error) {
@@ -603,7 +815,7 @@ HRA:
 			// we won't end up in the reserved capacity remained forever in case GitHub somehow stopped sending us "completed" workflow_job events.
 			// GitHub usually send us those but nothing is 100% guaranteed, e.g. in case of something went wrong on GitHub :)
 			// Probably we'd better make this configurable via custom resources in the future?
-			duration.Duration = 10 * time.Minute
+			duration.Duration = defaultScaleUpTriggerDuration
 		}
 
 		switch hra.Spec.ScaleTargetRef.Kind {
@@ -677,6 +889,87 @@ HRA:
 	return nil, nil
 }
 
+// getScaleTarget returns the first HRA found by the key that has a scale-up trigger matched by f.
+// The trigger is returned along with the HRA so that its amount and duration are used for the
+// capacity reservations. Unlike workflow_job based scaling, HRAs can have any number of such triggers.
+func (autoscaler *HorizontalRunnerAutoscalerGitHubWebhook) getScaleTarget(ctx context.Context, name string, f func(v1alpha1.ScaleUpTrigger) bool) (*ScaleTarget, error) {
+	hras, err := autoscaler.findHRAsByKey(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	autoscaler.Log.V(1).Info(fmt.Sprintf("Found %d HRAs by key", len(hras)), "key", name)
+
+	for _, hra := range hras {
+		if !hra.DeletionTimestamp.IsZero() {
+			continue
+		}
+
+		for _, scaleUpTrigger := range hra.Spec.ScaleUpTriggers {
+			if !f(scaleUpTrigger) {
+				continue
+			}
+
+			if scaleUpTrigger.Amount == 0 {
+				scaleUpTrigger.Amount = 1
+			}
+
+			if scaleUpTrigger.Duration.Duration <= 0 {
+				scaleUpTrigger.Duration.Duration = defaultScaleUpTriggerDuration
+			}
+
+			return &ScaleTarget{HorizontalRunnerAutoscaler: hra, ScaleUpTrigger: scaleUpTrigger}, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// matchTriggerConditionAgainstEvent reports whether the event action is one of types.
+// Empty types match any action.
+func matchTriggerConditionAgainstEvent(types []string, eventAction string) bool {
+	if len(types) == 0 {
+		return true
+	}
+
+	for _, tpe := range types {
+		if tpe == eventAction {
+			return true
+		}
+	}
+
+	return false
+}
+
+// matchGlobs reports whether s matches the GitHub Actions glob patterns evaluated in order,
+// so that a later `!` pattern excludes what the earlier ones matched.
+func matchGlobs(patterns []string, s string) bool {
+	return actionsglob.MatchList(patterns, s)
+}
+
+// matchRepository reports whether the repository matches repositories,
+// given as GitHub Actions glob patterns either of the name or in the owner/name form.
+func matchRepository(repositories []string, repo *gogithub.Repository) bool {
+	patterns := make([]string, 0, len(repositories))
+
+	for _, r := range repositories {
+		var negation string
+
+		if strings.HasPrefix(r, "!") {
+			negation, r = "!", r[1:]
+		}
+
+		// A pattern of the name matches the repository of any owner
+		if r != "" && !strings.Contains(r, "/") {
+			r = "*/" + r
+		}
+
+		patterns = append(patterns, negation+r)
+	}
+
+	return actionsglob.MatchList(patterns, repo.GetFullName())
+}
+
 func getValidCapacityReservations(autoscaler *v1alpha1.HorizontalRunnerAutoscaler) []v1alpha1.CapacityReservation {
 	var capacityReservations []v1alpha1.CapacityReservation
 
@@ -703,6 +996,12 @@ func (autoscaler *HorizontalRunnerAutoscalerGitHubWebhook) SetupWithManager(mgr
 		return err
 	}
 
+	if autoscaler.ScaleSetPrewarmDuration > 0 {
+		if err := mgr.GetFieldIndexer().IndexField(context.TODO(), &githubv1alpha1.AutoscalingRunnerSet{}, scaleSetLabelKey, scaleSetIndexer); err != nil {
+			return err
+		}
+	}
+
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&v1alpha1.HorizontalRunnerAutoscaler{}).
 		Named(name).
@@ -0,0 +1,254 @@
+/*
+Copyright 2026 The actions-runner-controller authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package actionssummerwindnet
+
+import (
+	"container/list"
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// DefaultDeliveryCacheSize is the default number of webhook deliveries remembered for deduplication.
+const DefaultDeliveryCacheSize = 1000
+
+// deliveryTimeFormat is a fixed width time format, so that formatted times sort chronologically.
+const deliveryTimeFormat = "2006-01-02T15:04:05.000000000Z"
+
+// DeliveryStore remembers the IDs of the webhook deliveries received from GitHub,
+// given by the X-GitHub-Delivery header, so that redeliveries are not processed twice.
+type DeliveryStore interface {
+	// Add records the delivery. It returns false when the delivery was already recorded.
+	Add(ctx context.Context, id string) (bool, error)
+	// Remove forgets the delivery, so that a redelivery is processed again.
+	Remove(ctx context.Context, id string) error
+}
+
+// InMemoryDeliveryStore is a DeliveryStore that remembers up to Size deliveries,
+// forgetting the oldest ones first.
+type InMemoryDeliveryStore struct {
+	size int
+
+	mu    sync.Mutex
+	order *list.List
+	ids   map[string]*list.Element
+}
+
+func NewInMemoryDeliveryStore(size int) *InMemoryDeliveryStore {
+	if size <= 0 {
+		size = DefaultDeliveryCacheSize
+	}
+
+	return &InMemoryDeliveryStore{
+		size:  size,
+		order: list.New(),
+		ids:   map[string]*list.Element{},
+	}
+}
+
+func (s *InMemoryDeliveryStore) Add(_ context.Context, id string) (bool, error) {
+	return s.add(id), nil
+}
+
+func (s *InMemoryDeliveryStore) Remove(_ context.Context, id string) error {
+	s.remove(id)
+	return nil
+}
+
+func (s *InMemoryDeliveryStore) add(id string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.ids[id]; ok {
+		return false
+	}
+
+	s.ids[id] = s.order.PushBack(id)
+
+	for s.order.Len() > s.size {
+		oldest := s.order.Front()
+		s.order.Remove(oldest)
+		delete(s.ids, oldest.Value.(string))
+	}
+
+	return true
+}
+
+func (s *InMemoryDeliveryStore) contains(id string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, ok := s.ids[id]
+	return ok
+}
+
+func (s *InMemoryDeliveryStore) remove(id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if e, ok := s.ids[id]; ok {
+		s.order.Remove(e)
+		delete(s.ids, id)
+	}
+}
+
+// ConfigMapDeliveryStore is a DeliveryStore persisted in a ConfigMap, so that deliveries
+// are remembered across restarts and shared between webhook server replicas.
+// Each delivery is stored as a key of the ConfigMap data holding the time it was received.
+// Concurrent updates from other replicas are detected by the resourceVersion of the ConfigMap.
+type ConfigMapDeliveryStore struct {
+	Name      string
+	Namespace string
+	Client    client.Client
+	// APIReader is used to read the ConfigMap, so that it is not cached by the manager.
+	// Defaults to Client.
+	APIReader client.Reader
+	// Size is the maximum number of deliveries kept in the ConfigMap.
+	Size int
+
+	local     *InMemoryDeliveryStore
+	localInit sync.Once
+
+	now func() time.Time
+}
+
+func (s *ConfigMapDeliveryStore) init() {
+	s.localInit.Do(func() {
+		if s.Size <= 0 {
+			s.Size = DefaultDeliveryCacheSize
+		}
+		s.local = NewInMemoryDeliveryStore(s.Size)
+		if s.APIReader == nil {
+			s.APIReader = s.Client
+		}
+		if s.now == nil {
+			s.now = time.Now
+		}
+	})
+}
+
+func (s *ConfigMapDeliveryStore) Add(ctx context.Context, id string) (bool, error) {
+	s.init()
+
+	if s.local.contains(id) {
+		return false, nil
+	}
+
+	var added bool
+
+	err := s.update(ctx, func(cm *corev1.ConfigMap) bool {
+		if _, ok := cm.Data[id]; ok {
+			added = false
+			return false
+		}
+
+		if cm.Data == nil {
+			cm.Data = map[string]string{}
+		}
+		cm.Data[id] = s.now().UTC().Format(deliveryTimeFormat)
+		pruneDeliveries(cm.Data, s.Size)
+
+		added = true
+		return true
+	})
+	if err != nil {
+		return false, err
+	}
+
+	s.local.add(id)
+
+	return added, nil
+}
+
+func (s *ConfigMapDeliveryStore) Remove(ctx context.Context, id string) error {
+	s.init()
+
+	s.local.remove(id)
+
+	return s.update(ctx, func(cm *corev1.ConfigMap) bool {
+		if _, ok := cm.Data[id]; !ok {
+			return false
+		}
+
+		delete(cm.Data, id)
+		return true
+	})
+}
+
+// update applies f to the ConfigMap, creating it if it does not exist, and writes it back
+// when f returns true. It retries when the ConfigMap was modified concurrently.
+func (s *ConfigMapDeliveryStore) update(ctx context.Context, f func(*corev1.ConfigMap) bool) error {
+	conflict := func(err error) bool {
+		return kerrors.IsConflict(err) || kerrors.IsAlreadyExists(err)
+	}
+
+	return retry.OnError(retry.DefaultRetry, conflict, func() error {
+		var cm corev1.ConfigMap
+
+		if err := s.APIReader.Get(ctx, types.NamespacedName{Namespace: s.Namespace, Name: s.Name}, &cm); err != nil {
+			if !kerrors.IsNotFound(err) {
+				return err
+			}
+
+			cm = corev1.ConfigMap{}
+			cm.Name = s.Name
+			cm.Namespace = s.Namespace
+
+			if !f(&cm) {
+				return nil
+			}
+
+			return s.Client.Create(ctx, &cm)
+		}
+
+		if !f(&cm) {
+			return nil
+		}
+
+		return s.Client.Update(ctx, &cm)
+	})
+}
+
+// pruneDeliveries removes the oldest deliveries until at most size are left.
+func pruneDeliveries(data map[string]string, size int) {
+	if len(data) <= size {
+		return
+	}
+
+	ids := make([]string, 0, len(data))
+	for id := range data {
+		ids = append(ids, id)
+	}
+
+	sort.Slice(ids, func(i, j int) bool {
+		if data[ids[i]] == data[ids[j]] {
+			return ids[i] < ids[j]
+		}
+		return data[ids[i]] < data[ids[j]]
+	})
+
+	for _, id := range ids[:len(ids)-size] {
+		delete(data, id)
+	}
+}
@@ -0,0 +1,184 @@
+package actionssummerwindnet
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	actionsv1alpha1 "github.com/actions/actions-runner-controller/apis/actions.summerwind.net/v1alpha1"
+	"github.com/google/go-github/v52/github"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestInMemoryDeliveryStore(t *testing.T) {
+	ctx := context.Background()
+	s := NewInMemoryDeliveryStore(2)
+
+	for _, id := range []string{"a", "b"} {
+		added, err := s.Add(ctx, id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !added {
+			t.Errorf("expected %s to be added", id)
+		}
+	}
+
+	if added, _ := s.Add(ctx, "a"); added {
+		t.Error("expected a to be a duplicate")
+	}
+
+	// Adding c evicts a, the oldest delivery.
+	if added, _ := s.Add(ctx, "c"); !added {
+		t.Error("expected c to be added")
+	}
+	if added, _ := s.Add(ctx, "a"); !added {
+		t.Error("expected a to be added again after eviction")
+	}
+
+	if err := s.Remove(ctx, "a"); err != nil {
+		t.Fatal(err)
+	}
+	if added, _ := s.Add(ctx, "a"); !added {
+		t.Error("expected a to be added again after removal")
+	}
+}
+
+func TestConfigMapDeliveryStore(t *testing.T) {
+	ctx := context.Background()
+
+	c := fake.NewClientBuilder().WithScheme(sc).Build()
+
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock := func() time.Time {
+		now = now.Add(time.Second)
+		return now
+	}
+
+	newStore := func() *ConfigMapDeliveryStore {
+		return &ConfigMapDeliveryStore{
+			Name:      "deliveries",
+			Namespace: "default",
+			Client:    c,
+			Size:      2,
+			now:       clock,
+		}
+	}
+
+	s := newStore()
+
+	for _, id := range []string{"a", "b", "c"} {
+		added, err := s.Add(ctx, id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !added {
+			t.Errorf("expected %s to be added", id)
+		}
+	}
+
+	var cm corev1.ConfigMap
+	if err := c.Get(ctx, types.NamespacedName{Namespace: "default", Name: "deliveries"}, &cm); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(cm.Data) != 2 {
+		t.Fatalf("expected 2 deliveries, got %v", cm.Data)
+	}
+	if _, ok := cm.Data["a"]; ok {
+		t.Errorf("expected the oldest delivery to be pruned, got %v", cm.Data)
+	}
+
+	// Another replica sharing the ConfigMap sees the deliveries recorded by the first one.
+	other := newStore()
+
+	if added, err := other.Add(ctx, "c"); err != nil {
+		t.Fatal(err)
+	} else if added {
+		t.Error("expected c to be a duplicate")
+	}
+
+	if err := other.Remove(ctx, "c"); err != nil {
+		t.Fatal(err)
+	}
+
+	if added, err := other.Add(ctx, "c"); err != nil {
+		t.Fatal(err)
+	} else if !added {
+		t.Error("expected c to be added again after removal")
+	}
+}
+
+func TestWebhookDuplicateDelivery(t *testing.T) {
+	var e github.PushEvent
+	loadWebhookFixture(t, "testdata/org_webhook_push_payload.json", &e)
+
+	hra := newTestHRAWithTriggers(actionsv1alpha1.ScaleUpTrigger{
+		GitHubEvent: &actionsv1alpha1.GitHubEventScaleUpTriggerSpec{
+			Push: &actionsv1alpha1.PushSpec{},
+		},
+	})
+	rd := newTestRunnerDeployment(actionsv1alpha1.RunnerConfig{Organization: "MYORG"})
+
+	hraWebhook := &HorizontalRunnerAutoscalerGitHubWebhook{
+		Deliveries: NewInMemoryDeliveryStore(10),
+	}
+
+	hraWebhook.Client = fake.NewClientBuilder().
+		WithScheme(sc).
+		WithRuntimeObjects(hra, rd).
+		WithIndex(&actionsv1alpha1.HorizontalRunnerAutoscaler{}, scaleTargetKey, hraWebhook.indexer).
+		Build()
+
+	logs := installTestLogger(hraWebhook)
+
+	defer func() {
+		if t.Failed() {
+			t.Logf("diagnostics: %s", logs.String())
+		}
+	}()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", hraWebhook.Handle)
+
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	send := func(deliveryID string) string {
+		t.Helper()
+
+		resp, err := sendWebhookDelivery(server, "push", deliveryID, &e)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK {
+			t.Errorf("status: %d", resp.StatusCode)
+		}
+
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return string(body)
+	}
+
+	if got, want := send("delivery-1"), "scaled test-name by 1"; got != want {
+		t.Errorf("first delivery: got %q, want %q", got, want)
+	}
+
+	if got, want := send("delivery-1"), "ignored duplicate delivery delivery-1"; got != want {
+		t.Errorf("redelivery: got %q, want %q", got, want)
+	}
+
+	if got, want := send("delivery-2"), "scaled test-name by 1"; got != want {
+		t.Errorf("second delivery: got %q, want %q", got, want)
+	}
+}
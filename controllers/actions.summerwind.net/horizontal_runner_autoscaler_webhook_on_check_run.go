@@ -0,0 +1,59 @@
+/*
+Copyright 2020 The actions-runner-controller authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package actionssummerwindnet
+
+import (
+	gogithub "github.com/google/go-github/v52/github"
+
+	"github.com/actions/actions-runner-controller/apis/actions.summerwind.net/v1alpha1"
+)
+
+// MatchCheckRunEvent returns a function reporting whether a scale-up trigger
+// matches the check_run event.
+func (autoscaler *HorizontalRunnerAutoscalerGitHubWebhook) MatchCheckRunEvent(event *gogithub.CheckRunEvent) func(scaleUpTrigger v1alpha1.ScaleUpTrigger) bool {
+	return func(scaleUpTrigger v1alpha1.ScaleUpTrigger) bool {
+		g := scaleUpTrigger.GitHubEvent
+
+		if g == nil {
+			return false
+		}
+
+		cr := g.CheckRun
+
+		if cr == nil {
+			return false
+		}
+
+		if !matchTriggerConditionAgainstEvent(cr.Types, event.GetAction()) {
+			return false
+		}
+
+		if cr.Status != "" && event.GetCheckRun().GetStatus() != cr.Status {
+			return false
+		}
+
+		if len(cr.Names) > 0 && !matchGlobs(cr.Names, event.GetCheckRun().GetName()) {
+			return false
+		}
+
+		if len(cr.Repositories) > 0 && !matchRepository(cr.Repositories, event.GetRepo()) {
+			return false
+		}
+
+		return true
+	}
+}
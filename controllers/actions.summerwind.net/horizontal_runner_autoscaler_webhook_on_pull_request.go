@@ -0,0 +1,53 @@
+/*
+Copyright 2020 The actions-runner-controller authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package actionssummerwindnet
+
+import (
+	gogithub "github.com/google/go-github/v52/github"
+
+	"github.com/actions/actions-runner-controller/apis/actions.summerwind.net/v1alpha1"
+)
+
+// MatchPullRequestEvent returns a function reporting whether a scale-up trigger
+// matches the pull_request event.
+func (autoscaler *HorizontalRunnerAutoscalerGitHubWebhook) MatchPullRequestEvent(event *gogithub.PullRequestEvent) func(scaleUpTrigger v1alpha1.ScaleUpTrigger) bool {
+	return func(scaleUpTrigger v1alpha1.ScaleUpTrigger) bool {
+		g := scaleUpTrigger.GitHubEvent
+
+		if g == nil {
+			return false
+		}
+
+		pr := g.PullRequest
+
+		if pr == nil {
+			return false
+		}
+
+		if !matchTriggerConditionAgainstEvent(pr.Types, event.GetAction()) {
+			return false
+		}
+
+		// Branches are matched against the base branch, the same way
+		// on.pull_request.branches in workflows does.
+		if len(pr.Branches) > 0 && !matchGlobs(pr.Branches, event.GetPullRequest().GetBase().GetRef()) {
+			return false
+		}
+
+		return true
+	}
+}
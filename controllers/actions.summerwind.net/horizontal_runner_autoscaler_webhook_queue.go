@@ -0,0 +1,161 @@
+/*
+Copyright 2026 The actions-runner-controller authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package actionssummerwindnet
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/actions/actions-runner-controller/apis/actions.summerwind.net/v1alpha1"
+	"github.com/google/uuid"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// queueFullRetryAfter is the delay GitHub or the hookdeliveryforwarder is asked to wait for
+// before redelivering a webhook event that could not be enqueued.
+const queueFullRetryAfter = 30 * time.Second
+
+// errQueueFull is returned when a scale operation could not be enqueued because the queue is saturated.
+var errQueueFull = errors.New("scale operation queue is full")
+
+// pendingScaleOperation is a scale operation persisted in the AnnotationKeyPendingScaleOperations annotation of the HRA it targets.
+type pendingScaleOperation struct {
+	// ID identifies the operation, so that a retried enqueue does not add the same operation twice.
+	// It is the webhook delivery ID when there is one.
+	ID       string          `json:"id"`
+	Amount   int             `json:"amount"`
+	Duration metav1.Duration `json:"duration"`
+	// Time is when the operation was enqueued.
+	Time metav1.Time `json:"time"`
+}
+
+// durableQueue enqueues scale operations by persisting them onto the HRA they target,
+// so that they survive restarts of the github webhook server and can be enqueued by any replica.
+// The operations are dequeued and applied by batchScaler.applyPendingScaleOperations.
+type durableQueue struct {
+	client client.Client
+	reader client.Reader
+	// limit is the maximum number of pending scale operations per HRA.
+	limit int
+	now   func() time.Time
+}
+
+func newDurableQueue(c client.Client, reader client.Reader, limit int) *durableQueue {
+	if reader == nil {
+		reader = c
+	}
+
+	if limit <= 0 {
+		limit = DefaultQueueLimit
+	}
+
+	return &durableQueue{
+		client: c,
+		reader: reader,
+		limit:  limit,
+		now:    time.Now,
+	}
+}
+
+// Add persists the scale operation onto the target HRA. It returns errQueueFull when the HRA already has too many pending operations.
+// Adding an operation with the same ID twice is a no-op, so that redeliveries of the same webhook event are enqueued at most once.
+func (q *durableQueue) Add(ctx context.Context, st *ScaleTarget, id string) error {
+	if id == "" {
+		id = uuid.NewString()
+	}
+
+	nsName := types.NamespacedName{Namespace: st.Namespace, Name: st.Name}
+
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		var hra v1alpha1.HorizontalRunnerAutoscaler
+
+		if err := q.reader.Get(ctx, nsName, &hra); err != nil {
+			return err
+		}
+
+		ops, err := getPendingScaleOperations(&hra)
+		if err != nil {
+			return err
+		}
+
+		for _, op := range ops {
+			if op.ID == id {
+				return nil
+			}
+		}
+
+		if len(ops) >= q.limit {
+			return errQueueFull
+		}
+
+		ops = append(ops, pendingScaleOperation{
+			ID:       id,
+			Amount:   st.Amount,
+			Duration: st.Duration,
+			Time:     metav1.NewTime(q.now()),
+		})
+
+		copy := hra.DeepCopy()
+
+		if err := setPendingScaleOperations(copy, ops); err != nil {
+			return err
+		}
+
+		return q.client.Patch(ctx, copy, client.MergeFromWithOptions(&hra, client.MergeFromWithOptimisticLock{}))
+	})
+}
+
+func getPendingScaleOperations(hra *v1alpha1.HorizontalRunnerAutoscaler) ([]pendingScaleOperation, error) {
+	v, ok := hra.Annotations[AnnotationKeyPendingScaleOperations]
+	if !ok || v == "" {
+		return nil, nil
+	}
+
+	var ops []pendingScaleOperation
+
+	if err := json.Unmarshal([]byte(v), &ops); err != nil {
+		return nil, fmt.Errorf("parsing %s annotation of horizontalrunnerautoscaler %s/%s: %w", AnnotationKeyPendingScaleOperations, hra.Namespace, hra.Name, err)
+	}
+
+	return ops, nil
+}
+
+func setPendingScaleOperations(hra *v1alpha1.HorizontalRunnerAutoscaler, ops []pendingScaleOperation) error {
+	if len(ops) == 0 {
+		delete(hra.Annotations, AnnotationKeyPendingScaleOperations)
+		return nil
+	}
+
+	v, err := json.Marshal(ops)
+	if err != nil {
+		return err
+	}
+
+	if hra.Annotations == nil {
+		hra.Annotations = map[string]string{}
+	}
+
+	hra.Annotations[AnnotationKeyPendingScaleOperations] = string(v)
+
+	return nil
+}
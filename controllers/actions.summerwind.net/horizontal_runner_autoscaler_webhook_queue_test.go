@@ -0,0 +1,172 @@
+package actionssummerwindnet
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	actionsv1alpha1 "github.com/actions/actions-runner-controller/apis/actions.summerwind.net/v1alpha1"
+	"github.com/go-logr/logr"
+	"github.com/google/go-github/v52/github"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestDurableQueueAdd(t *testing.T) {
+	ctx := context.Background()
+
+	hra := newTestHRAWithTriggers()
+	c := fake.NewClientBuilder().WithScheme(sc).WithRuntimeObjects(hra).Build()
+
+	// Times are read back from the annotation in the local time zone.
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC).Local()
+
+	q := newDurableQueue(c, nil, 2)
+	q.now = func() time.Time { return now }
+
+	st := &ScaleTarget{
+		HorizontalRunnerAutoscaler: *hra,
+		ScaleUpTrigger: actionsv1alpha1.ScaleUpTrigger{
+			Amount:   1,
+			Duration: metav1.Duration{Duration: 5 * time.Minute},
+		},
+	}
+
+	require.NoError(t, q.Add(ctx, st, "delivery-1"))
+	// A redelivery of the same event is enqueued only once.
+	require.NoError(t, q.Add(ctx, st, "delivery-1"))
+	require.NoError(t, q.Add(ctx, st, "delivery-2"))
+
+	err := q.Add(ctx, st, "delivery-3")
+	require.True(t, errors.Is(err, errQueueFull), "unexpected error: %v", err)
+
+	var got actionsv1alpha1.HorizontalRunnerAutoscaler
+	require.NoError(t, c.Get(ctx, types.NamespacedName{Namespace: hra.Namespace, Name: hra.Name}, &got))
+
+	ops, err := getPendingScaleOperations(&got)
+	require.NoError(t, err)
+	require.Equal(t, []pendingScaleOperation{
+		{ID: "delivery-1", Amount: 1, Duration: metav1.Duration{Duration: 5 * time.Minute}, Time: metav1.NewTime(now)},
+		{ID: "delivery-2", Amount: 1, Duration: metav1.Duration{Duration: 5 * time.Minute}, Time: metav1.NewTime(now)},
+	}, ops)
+}
+
+func TestApplyPendingScaleOperations(t *testing.T) {
+	ctx := context.Background()
+
+	hra := newTestHRAWithTriggers()
+
+	enqueuedAt := time.Now().Add(-time.Minute)
+
+	require.NoError(t, setPendingScaleOperations(hra, []pendingScaleOperation{
+		{ID: "a", Amount: 1, Duration: metav1.Duration{Duration: 5 * time.Minute}, Time: metav1.NewTime(enqueuedAt)},
+		{ID: "b", Amount: 2, Duration: metav1.Duration{Duration: 5 * time.Minute}, Time: metav1.NewTime(enqueuedAt)},
+		{ID: "c", Amount: -1, Duration: metav1.Duration{Duration: 5 * time.Minute}, Time: metav1.NewTime(enqueuedAt)},
+	}))
+
+	c := fake.NewClientBuilder().WithScheme(sc).WithRuntimeObjects(hra).Build()
+
+	nsName := types.NamespacedName{Namespace: hra.Namespace, Name: hra.Name}
+
+	s := newBatchScaler(ctx, c, logr.Discard())
+
+	requeueAfter, err := s.applyPendingScaleOperations(ctx, nsName)
+	require.NoError(t, err)
+	require.Zero(t, requeueAfter)
+
+	var got actionsv1alpha1.HorizontalRunnerAutoscaler
+	require.NoError(t, c.Get(ctx, nsName, &got))
+
+	require.Len(t, got.Spec.CapacityReservations, 2)
+	require.NotContains(t, got.Annotations, AnnotationKeyPendingScaleOperations)
+
+	t.Run("waits for the batch interval", func(t *testing.T) {
+		copy := got.DeepCopy()
+		require.NoError(t, setPendingScaleOperations(copy, []pendingScaleOperation{
+			{ID: "d", Amount: 1, Duration: metav1.Duration{Duration: 5 * time.Minute}, Time: metav1.Now()},
+		}))
+		require.NoError(t, c.Update(ctx, copy))
+
+		requeueAfter, err := s.applyPendingScaleOperations(ctx, nsName)
+		require.NoError(t, err)
+		require.True(t, requeueAfter > 0 && requeueAfter <= s.interval, "unexpected requeueAfter: %v", requeueAfter)
+
+		var unchanged actionsv1alpha1.HorizontalRunnerAutoscaler
+		require.NoError(t, c.Get(ctx, nsName, &unchanged))
+		require.Len(t, unchanged.Spec.CapacityReservations, 2)
+		require.Contains(t, unchanged.Annotations, AnnotationKeyPendingScaleOperations)
+	})
+}
+
+func TestWebhookDurableQueue(t *testing.T) {
+	var e github.PushEvent
+	loadWebhookFixture(t, "testdata/org_webhook_push_payload.json", &e)
+
+	hra := newTestHRAWithTriggers(actionsv1alpha1.ScaleUpTrigger{
+		GitHubEvent: &actionsv1alpha1.GitHubEventScaleUpTriggerSpec{
+			Push: &actionsv1alpha1.PushSpec{},
+		},
+	})
+	rd := newTestRunnerDeployment(actionsv1alpha1.RunnerConfig{Organization: "MYORG"})
+
+	hraWebhook := &HorizontalRunnerAutoscalerGitHubWebhook{
+		DurableQueue: true,
+		QueueLimit:   1,
+	}
+
+	hraWebhook.Client = fake.NewClientBuilder().
+		WithScheme(sc).
+		WithRuntimeObjects(hra, rd).
+		WithIndex(&actionsv1alpha1.HorizontalRunnerAutoscaler{}, scaleTargetKey, hraWebhook.indexer).
+		Build()
+
+	logs := installTestLogger(hraWebhook)
+
+	defer func() {
+		if t.Failed() {
+			t.Logf("diagnostics: %s", logs.String())
+		}
+	}()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", hraWebhook.Handle)
+
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	send := func(deliveryID string) (*http.Response, string) {
+		t.Helper()
+
+		resp, err := sendWebhookDelivery(server, "push", deliveryID, &e)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+
+		return resp, string(body)
+	}
+
+	resp, body := send("delivery-1")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, "scaled test-name by 1", body)
+
+	resp, body = send("delivery-2")
+	require.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+	require.Equal(t, "30", resp.Header.Get("Retry-After"))
+	require.Equal(t, errQueueFull.Error(), body)
+
+	var got actionsv1alpha1.HorizontalRunnerAutoscaler
+	require.NoError(t, hraWebhook.Client.Get(context.Background(), types.NamespacedName{Namespace: hra.Namespace, Name: hra.Name}, &got))
+
+	ops, err := getPendingScaleOperations(&got)
+	require.NoError(t, err)
+	require.Len(t, ops, 1)
+	require.Equal(t, "delivery-1", ops[0].ID)
+}
@@ -0,0 +1,207 @@
+/*
+Copyright 2026 The actions-runner-controller authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package actionssummerwindnet
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	githubv1alpha1 "github.com/actions/actions-runner-controller/apis/actions.github.com/v1alpha1"
+	"github.com/actions/actions-runner-controller/github/actions"
+)
+
+// scaleSetLabelKey is the index key of AutoscalingRunnerSets by the labels of their runner scale sets.
+const scaleSetLabelKey = "scaleSetLabel"
+
+// scaleSetIndexer indexes an AutoscalingRunnerSet by the labels a workflow job can use to target its runner scale set,
+// which are the scale set name and the RunnerScaleSetLabels.
+func scaleSetIndexer(rawObj client.Object) []string {
+	return scaleSetLabels(rawObj.(*githubv1alpha1.AutoscalingRunnerSet))
+}
+
+// scaleSetLabels returns the lower-cased labels of the runner scale set of the AutoscalingRunnerSet.
+func scaleSetLabels(ars *githubv1alpha1.AutoscalingRunnerSet) []string {
+	name := ars.Spec.RunnerScaleSetName
+	if name == "" {
+		name = ars.Name
+	}
+
+	labels := []string{strings.ToLower(name)}
+
+	for _, l := range ars.Spec.RunnerScaleSetLabels {
+		l = strings.ToLower(l)
+		if !slices.Contains(labels, l) {
+			labels = append(labels, l)
+		}
+	}
+
+	return labels
+}
+
+// prewarmScaleSets adds the queued workflow job to the prewarm hints of the runner scale sets that can run it
+// and enable listenerPrewarm.
+// It returns the namespaced names of the AutoscalingRunnerSets that were prewarmed.
+func (autoscaler *HorizontalRunnerAutoscalerGitHubWebhook) prewarmScaleSets(
+	ctx context.Context, log logr.Logger, repo, owner, enterprise string, jobLabels []string,
+) ([]string, error) {
+	var labels []string
+	for _, l := range jobLabels {
+		l = strings.ToLower(l)
+		// Runners of scale sets do not have the self-hosted label, but jobs can still target them with it.
+		if l == "self-hosted" || slices.Contains(labels, l) {
+			continue
+		}
+		labels = append(labels, l)
+	}
+
+	if len(labels) == 0 {
+		return nil, nil
+	}
+
+	opts := []client.ListOption{client.MatchingFields{scaleSetLabelKey: labels[0]}}
+	if autoscaler.Namespace != "" {
+		opts = append(opts, client.InNamespace(autoscaler.Namespace))
+	}
+
+	var list githubv1alpha1.AutoscalingRunnerSetList
+	if err := autoscaler.List(ctx, &list, opts...); err != nil {
+		return nil, err
+	}
+
+	var prewarmed []string
+
+	for i := range list.Items {
+		ars := &list.Items[i]
+
+		// The hints of the scale sets that did not opt in would be ignored by their listeners
+		if !ars.Spec.ListenerPrewarm {
+			continue
+		}
+
+		if !scaleSetMatchesJob(ars, labels, repo, owner, enterprise) {
+			continue
+		}
+
+		if err := autoscaler.prewarmScaleSet(ctx, ars); err != nil {
+			return prewarmed, fmt.Errorf("prewarming autoscalingrunnerset %s/%s: %w", ars.Namespace, ars.Name, err)
+		}
+
+		log.V(1).Info("Prewarmed runner scale set", "autoscalingrunnerset", ars.Name, "namespace", ars.Namespace)
+
+		prewarmed = append(prewarmed, ars.Namespace+"/"+ars.Name)
+	}
+
+	return prewarmed, nil
+}
+
+// scaleSetMatchesJob returns true when the runner scale set has all the job labels,
+// and is registered to the repository, organization, or enterprise the job belongs to.
+func scaleSetMatchesJob(ars *githubv1alpha1.AutoscalingRunnerSet, jobLabels []string, repo, owner, enterprise string) bool {
+	labels := scaleSetLabels(ars)
+	for _, l := range jobLabels {
+		if !slices.Contains(labels, l) {
+			return false
+		}
+	}
+
+	config, err := actions.ParseGitHubConfigFromURL(ars.Spec.GitHubConfigUrl)
+	if err != nil {
+		return false
+	}
+
+	switch config.Scope {
+	case actions.GitHubScopeEnterprise:
+		return enterprise != "" && strings.EqualFold(config.Enterprise, enterprise)
+	case actions.GitHubScopeOrganization:
+		return strings.EqualFold(config.Organization, owner)
+	case actions.GitHubScopeRepository:
+		return strings.EqualFold(config.Organization, owner) && strings.EqualFold(config.Repository, repo)
+	default:
+		return false
+	}
+}
+
+// prewarmScaleSet adds a runner to the prewarm hint of the EphemeralRunnerSets of the AutoscalingRunnerSet, extending the hint's expiration.
+func (autoscaler *HorizontalRunnerAutoscalerGitHubWebhook) prewarmScaleSet(ctx context.Context, ars *githubv1alpha1.AutoscalingRunnerSet) error {
+	var list githubv1alpha1.EphemeralRunnerSetList
+	if err := autoscaler.List(ctx, &list, client.InNamespace(ars.Namespace)); err != nil {
+		return err
+	}
+
+	for i := range list.Items {
+		ers := &list.Items[i]
+
+		if !metav1.IsControlledBy(ers, ars) {
+			continue
+		}
+
+		key := client.ObjectKeyFromObject(ers)
+
+		if err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+			return autoscaler.addPrewarmHint(ctx, key)
+		}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (autoscaler *HorizontalRunnerAutoscalerGitHubWebhook) addPrewarmHint(ctx context.Context, key client.ObjectKey) error {
+	reader := autoscaler.APIReader
+	if reader == nil {
+		reader = autoscaler.Client
+	}
+
+	var ers githubv1alpha1.EphemeralRunnerSet
+	if err := reader.Get(ctx, key, &ers); err != nil {
+		return client.IgnoreNotFound(err)
+	}
+
+	hint, err := githubv1alpha1.ParsePrewarmHint(ers.Annotations)
+	if err != nil {
+		// A broken hint is replaced with a new one.
+		hint = nil
+	}
+
+	now := time.Now()
+
+	v, err := json.Marshal(githubv1alpha1.PrewarmHint{
+		Runners:   hint.Floor(now) + 1,
+		ExpiresAt: metav1.NewTime(now.Add(autoscaler.ScaleSetPrewarmDuration)),
+	})
+	if err != nil {
+		return err
+	}
+
+	copy := ers.DeepCopy()
+	if copy.Annotations == nil {
+		copy.Annotations = map[string]string{}
+	}
+	copy.Annotations[githubv1alpha1.AnnotationKeyPrewarmHint] = string(v)
+
+	return autoscaler.Patch(ctx, copy, client.MergeFromWithOptions(&ers, client.MergeFromWithOptimisticLock{}))
+}
@@ -0,0 +1,134 @@
+package actionssummerwindnet
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	githubv1alpha1 "github.com/actions/actions-runner-controller/apis/actions.github.com/v1alpha1"
+	actionsv1alpha1 "github.com/actions/actions-runner-controller/apis/actions.summerwind.net/v1alpha1"
+	"github.com/google/go-github/v52/github"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func newTestAutoscalingRunnerSet(name, configURL string, labels ...string) (*githubv1alpha1.AutoscalingRunnerSet, *githubv1alpha1.EphemeralRunnerSet) {
+	ars := &githubv1alpha1.AutoscalingRunnerSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: "default",
+			UID:       types.UID("uid-" + name),
+		},
+		Spec: githubv1alpha1.AutoscalingRunnerSetSpec{
+			GitHubConfigUrl:      configURL,
+			RunnerScaleSetLabels: labels,
+			ListenerPrewarm:      true,
+		},
+	}
+
+	ers := &githubv1alpha1.EphemeralRunnerSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name + "-abcde",
+			Namespace: "default",
+			OwnerReferences: []metav1.OwnerReference{
+				{
+					APIVersion: githubv1alpha1.GroupVersion.String(),
+					Kind:       "AutoscalingRunnerSet",
+					Name:       ars.Name,
+					UID:        ars.UID,
+					Controller: ptr.To(true),
+				},
+			},
+		},
+	}
+
+	return ars, ers
+}
+
+func TestScaleSetMatchesJob(t *testing.T) {
+	ars, _ := newTestAutoscalingRunnerSet("arc-runners", "https://github.com/myorg", "Linux", "GPU")
+
+	assert.Equal(t, []string{"arc-runners", "linux", "gpu"}, scaleSetLabels(ars))
+
+	assert.True(t, scaleSetMatchesJob(ars, []string{"linux", "gpu"}, "myrepo", "MYORG", ""))
+	assert.True(t, scaleSetMatchesJob(ars, []string{"arc-runners"}, "myrepo", "myorg", ""))
+	assert.False(t, scaleSetMatchesJob(ars, []string{"linux", "arm64"}, "myrepo", "myorg", ""), "missing label")
+	assert.False(t, scaleSetMatchesJob(ars, []string{"linux"}, "myrepo", "otherorg", ""), "other organization")
+
+	ars.Spec.GitHubConfigUrl = "https://github.com/myorg/myrepo"
+	assert.True(t, scaleSetMatchesJob(ars, []string{"linux"}, "myrepo", "myorg", ""))
+	assert.False(t, scaleSetMatchesJob(ars, []string{"linux"}, "otherrepo", "myorg", ""), "other repository")
+
+	ars.Spec.GitHubConfigUrl = "https://github.com/enterprises/myent"
+	assert.True(t, scaleSetMatchesJob(ars, []string{"linux"}, "myrepo", "myorg", "myent"))
+	assert.False(t, scaleSetMatchesJob(ars, []string{"linux"}, "myrepo", "myorg", ""), "no enterprise")
+}
+
+func TestWebhookWorkflowJobPrewarm(t *testing.T) {
+	var e github.WorkflowJobEvent
+	loadWebhookFixture(t, "testdata/org_webhook_workflow_job_payload.json", &e)
+
+	matching, matchingERS := newTestAutoscalingRunnerSet("matching", "https://github.com/MYORG", "label1")
+	otherOrg, otherOrgERS := newTestAutoscalingRunnerSet("other-org", "https://github.com/OTHERORG", "label1")
+	optedOut, optedOutERS := newTestAutoscalingRunnerSet("opted-out", "https://github.com/MYORG", "label1")
+	optedOut.Spec.ListenerPrewarm = false
+
+	hraWebhook := &HorizontalRunnerAutoscalerGitHubWebhook{
+		ScaleSetPrewarmDuration: 2 * time.Minute,
+	}
+
+	hraWebhook.Client = fake.NewClientBuilder().
+		WithScheme(sc).
+		WithRuntimeObjects(matching, matchingERS, otherOrg, otherOrgERS, optedOut, optedOutERS).
+		WithIndex(&githubv1alpha1.AutoscalingRunnerSet{}, scaleSetLabelKey, scaleSetIndexer).
+		WithIndex(&actionsv1alpha1.HorizontalRunnerAutoscaler{}, scaleTargetKey, hraWebhook.indexer).
+		Build()
+
+	logs := installTestLogger(hraWebhook)
+
+	defer func() {
+		if t.Failed() {
+			t.Logf("diagnostics: %s", logs.String())
+		}
+	}()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", hraWebhook.Handle)
+
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	for i := 1; i <= 2; i++ {
+		resp, err := sendWebhook(server, "workflow_job", &e)
+		require.NoError(t, err)
+
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		require.NoError(t, err)
+
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, "prewarmed default/matching", string(body))
+	}
+
+	var got githubv1alpha1.EphemeralRunnerSet
+	require.NoError(t, hraWebhook.Client.Get(context.Background(), client.ObjectKeyFromObject(matchingERS), &got))
+
+	hint, err := githubv1alpha1.ParsePrewarmHint(got.Annotations)
+	require.NoError(t, err)
+	require.NotNil(t, hint)
+	assert.Equal(t, 2, hint.Runners)
+	assert.WithinDuration(t, time.Now().Add(2*time.Minute), hint.ExpiresAt.Time, 10*time.Second)
+
+	for _, ers := range []*githubv1alpha1.EphemeralRunnerSet{otherOrgERS, optedOutERS} {
+		require.NoError(t, hraWebhook.Client.Get(context.Background(), client.ObjectKeyFromObject(ers), &got))
+		assert.NotContains(t, got.Annotations, githubv1alpha1.AnnotationKeyPrewarmHint, ers.Name)
+	}
+}
@@ -2,6 +2,10 @@ package actionssummerwindnet
 
 import (
 	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -13,7 +17,9 @@ import (
 	"testing"
 	"time"
 
+	githubv1alpha1 "github.com/actions/actions-runner-controller/apis/actions.github.com/v1alpha1"
 	actionsv1alpha1 "github.com/actions/actions-runner-controller/apis/actions.summerwind.net/v1alpha1"
+	"github.com/actions/actions-runner-controller/pkg/webhooksecret"
 	"github.com/go-logr/logr"
 	"github.com/google/go-github/v52/github"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -29,6 +35,7 @@ var (
 func init() {
 	_ = clientgoscheme.AddToScheme(sc)
 	_ = actionsv1alpha1.AddToScheme(sc)
+	_ = githubv1alpha1.AddToScheme(sc)
 }
 
 func TestWebhookPing(t *testing.T) {
@@ -362,6 +369,244 @@ func TestWebhookWorkflowJobWithSelfHostedLabel(t *testing.T) {
 	})
 }
 
+func loadWebhookFixture(t *testing.T, path string, event interface{}) {
+	t.Helper()
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("could not open the fixture: %s", err)
+	}
+	defer f.Close()
+	if err := json.NewDecoder(f).Decode(event); err != nil {
+		t.Fatalf("invalid json: %s", err)
+	}
+}
+
+func newTestHRAWithTriggers(triggers ...actionsv1alpha1.ScaleUpTrigger) *actionsv1alpha1.HorizontalRunnerAutoscaler {
+	return &actionsv1alpha1.HorizontalRunnerAutoscaler{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "test-name",
+		},
+		Spec: actionsv1alpha1.HorizontalRunnerAutoscalerSpec{
+			ScaleTargetRef: actionsv1alpha1.ScaleTargetRef{
+				Name: "test-name",
+			},
+			ScaleUpTriggers: triggers,
+		},
+	}
+}
+
+func newTestRunnerDeployment(config actionsv1alpha1.RunnerConfig) *actionsv1alpha1.RunnerDeployment {
+	return &actionsv1alpha1.RunnerDeployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "test-name",
+		},
+		Spec: actionsv1alpha1.RunnerDeploymentSpec{
+			Template: actionsv1alpha1.RunnerTemplate{
+				Spec: actionsv1alpha1.RunnerSpec{
+					RunnerConfig: config,
+				},
+			},
+		},
+	}
+}
+
+func TestWebhookCheckRun(t *testing.T) {
+	t.Run("Organization", func(t *testing.T) {
+		var e github.CheckRunEvent
+		loadWebhookFixture(t, "testdata/org_webhook_check_run_payload.json", &e)
+
+		hra := newTestHRAWithTriggers(actionsv1alpha1.ScaleUpTrigger{
+			GitHubEvent: &actionsv1alpha1.GitHubEventScaleUpTriggerSpec{
+				CheckRun: &actionsv1alpha1.CheckRunSpec{
+					Types:  []string{"created"},
+					Status: "queued",
+					Names:  []string{"valid*"},
+				},
+			},
+			Amount: 2,
+		})
+		rd := newTestRunnerDeployment(actionsv1alpha1.RunnerConfig{Organization: "MYORG"})
+
+		testServerWithInitObjs(t,
+			"check_run",
+			&e,
+			200,
+			"scaled test-name by 2",
+			[]runtime.Object{hra, rd},
+		)
+	})
+	t.Run("Repository", func(t *testing.T) {
+		var e github.CheckRunEvent
+		loadWebhookFixture(t, "testdata/repo_webhook_check_run_payload.json", &e)
+
+		hra := newTestHRAWithTriggers(actionsv1alpha1.ScaleUpTrigger{
+			GitHubEvent: &actionsv1alpha1.GitHubEventScaleUpTriggerSpec{
+				CheckRun: &actionsv1alpha1.CheckRunSpec{
+					Types:        []string{"completed"},
+					Repositories: []string{"MYREPO"},
+				},
+			},
+		})
+		rd := newTestRunnerDeployment(actionsv1alpha1.RunnerConfig{Repository: "MYUSER/MYREPO"})
+
+		testServerWithInitObjs(t,
+			"check_run",
+			&e,
+			200,
+			"scaled test-name by 1",
+			[]runtime.Object{hra, rd},
+		)
+	})
+	t.Run("NameMismatch", func(t *testing.T) {
+		var e github.CheckRunEvent
+		loadWebhookFixture(t, "testdata/org_webhook_check_run_payload.json", &e)
+
+		hra := newTestHRAWithTriggers(actionsv1alpha1.ScaleUpTrigger{
+			GitHubEvent: &actionsv1alpha1.GitHubEventScaleUpTriggerSpec{
+				CheckRun: &actionsv1alpha1.CheckRunSpec{
+					Names: []string{"build*"},
+				},
+			},
+		})
+		rd := newTestRunnerDeployment(actionsv1alpha1.RunnerConfig{Organization: "MYORG"})
+
+		testServerWithInitObjs(t,
+			"check_run",
+			&e,
+			200,
+			"no horizontalrunnerautoscaler to scale for this github event",
+			[]runtime.Object{hra, rd},
+		)
+	})
+}
+
+func TestWebhookPullRequest(t *testing.T) {
+	t.Run("Successful", func(t *testing.T) {
+		var e github.PullRequestEvent
+		loadWebhookFixture(t, "testdata/org_webhook_pull_request_payload.json", &e)
+
+		hra := newTestHRAWithTriggers(
+			actionsv1alpha1.ScaleUpTrigger{
+				GitHubEvent: &actionsv1alpha1.GitHubEventScaleUpTriggerSpec{
+					Push: &actionsv1alpha1.PushSpec{},
+				},
+			},
+			actionsv1alpha1.ScaleUpTrigger{
+				GitHubEvent: &actionsv1alpha1.GitHubEventScaleUpTriggerSpec{
+					PullRequest: &actionsv1alpha1.PullRequestSpec{
+						Types:    []string{"opened", "synchronize"},
+						Branches: []string{"main", "release/*"},
+					},
+				},
+				Amount: 3,
+			},
+		)
+		rd := newTestRunnerDeployment(actionsv1alpha1.RunnerConfig{Organization: "MYORG"})
+
+		testServerWithInitObjs(t,
+			"pull_request",
+			&e,
+			200,
+			"scaled test-name by 3",
+			[]runtime.Object{hra, rd},
+		)
+	})
+	t.Run("BranchMismatch", func(t *testing.T) {
+		var e github.PullRequestEvent
+		loadWebhookFixture(t, "testdata/org_webhook_pull_request_payload.json", &e)
+
+		hra := newTestHRAWithTriggers(actionsv1alpha1.ScaleUpTrigger{
+			GitHubEvent: &actionsv1alpha1.GitHubEventScaleUpTriggerSpec{
+				PullRequest: &actionsv1alpha1.PullRequestSpec{
+					Branches: []string{"release/*"},
+				},
+			},
+		})
+		rd := newTestRunnerDeployment(actionsv1alpha1.RunnerConfig{Organization: "MYORG"})
+
+		testServerWithInitObjs(t,
+			"pull_request",
+			&e,
+			200,
+			"no horizontalrunnerautoscaler to scale for this github event",
+			[]runtime.Object{hra, rd},
+		)
+	})
+}
+
+func TestWebhookPush(t *testing.T) {
+	t.Run("Successful", func(t *testing.T) {
+		var e github.PushEvent
+		loadWebhookFixture(t, "testdata/org_webhook_push_payload.json", &e)
+
+		hra := newTestHRAWithTriggers(actionsv1alpha1.ScaleUpTrigger{
+			GitHubEvent: &actionsv1alpha1.GitHubEventScaleUpTriggerSpec{
+				Push: &actionsv1alpha1.PushSpec{},
+			},
+		})
+		rd := newTestRunnerDeployment(actionsv1alpha1.RunnerConfig{Organization: "MYORG"})
+
+		testServerWithInitObjs(t,
+			"push",
+			&e,
+			200,
+			"scaled test-name by 1",
+			[]runtime.Object{hra, rd},
+		)
+	})
+	t.Run("NoPushTrigger", func(t *testing.T) {
+		var e github.PushEvent
+		loadWebhookFixture(t, "testdata/org_webhook_push_payload.json", &e)
+
+		hra := newTestHRAWithTriggers(actionsv1alpha1.ScaleUpTrigger{
+			GitHubEvent: &actionsv1alpha1.GitHubEventScaleUpTriggerSpec{
+				PullRequest: &actionsv1alpha1.PullRequestSpec{},
+			},
+		})
+		rd := newTestRunnerDeployment(actionsv1alpha1.RunnerConfig{Organization: "MYORG"})
+
+		testServerWithInitObjs(t,
+			"push",
+			&e,
+			200,
+			"no horizontalrunnerautoscaler to scale for this github event",
+			[]runtime.Object{hra, rd},
+		)
+	})
+}
+
+func TestGetScaleTargetDefaults(t *testing.T) {
+	hraWebhook := &HorizontalRunnerAutoscalerGitHubWebhook{}
+	installTestLogger(hraWebhook)
+
+	hra := newTestHRAWithTriggers(actionsv1alpha1.ScaleUpTrigger{
+		GitHubEvent: &actionsv1alpha1.GitHubEventScaleUpTriggerSpec{
+			Push: &actionsv1alpha1.PushSpec{},
+		},
+	})
+	rd := newTestRunnerDeployment(actionsv1alpha1.RunnerConfig{Organization: "MYORG"})
+
+	hraWebhook.Client = fake.NewClientBuilder().
+		WithScheme(sc).
+		WithRuntimeObjects(hra, rd).
+		Build()
+
+	target, err := hraWebhook.getScaleTarget(context.Background(), "MYORG", hraWebhook.MatchPushEvent(&github.PushEvent{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if target == nil {
+		t.Fatal("expected a scale target")
+	}
+	if target.Amount != 1 {
+		t.Errorf("want amount 1, got %d", target.Amount)
+	}
+	if target.Duration.Duration != defaultScaleUpTriggerDuration {
+		t.Errorf("want duration %s, got %s", defaultScaleUpTriggerDuration, target.Duration.Duration)
+	}
+}
+
 func TestGetRequest(t *testing.T) {
 	hra := HorizontalRunnerAutoscalerGitHubWebhook{}
 	request, _ := http.NewRequest(http.MethodGet, "/", nil)
@@ -375,6 +620,44 @@ func TestGetRequest(t *testing.T) {
 	}
 }
 
+func TestWebhookPingWithSecrets(t *testing.T) {
+	secrets := webhooksecret.NewVerifier("test", webhooksecret.Secret{Name: "new", Key: []byte("new")}, webhooksecret.Secret{Name: "old", Key: []byte("old")})
+	secrets.RejectUnsigned = true
+
+	hra := &HorizontalRunnerAutoscalerGitHubWebhook{Secrets: secrets}
+	installTestLogger(hra)
+
+	ping := func(secret string) *http.Response {
+		payload := `{"zen":"zen"}`
+
+		request := httptest.NewRequest(http.MethodPost, "/", bytes.NewBufferString(payload))
+		request.Header.Set("X-GitHub-Event", "ping")
+		request.Header.Set("Content-Type", "application/json")
+
+		if secret != "" {
+			mac := hmac.New(sha256.New, []byte(secret))
+			mac.Write([]byte(payload))
+			request.Header.Set("X-Hub-Signature-256", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+		}
+
+		recorder := httptest.NewRecorder()
+		hra.Handle(recorder, request)
+
+		return recorder.Result()
+	}
+
+	for secret, want := range map[string]int{
+		"new":   http.StatusOK,
+		"old":   http.StatusOK,
+		"other": http.StatusInternalServerError,
+		"":      http.StatusInternalServerError,
+	} {
+		if got := ping(secret).StatusCode; got != want {
+			t.Errorf("secret %q: want %d, got %d", secret, want, got)
+		}
+	}
+}
+
 func TestGetValidCapacityReservations(t *testing.T) {
 	now := time.Now()
 	duration, _ := time.ParseDuration("10m")
@@ -495,6 +778,10 @@ func testServer(t *testing.T, eventType string, event interface{}, wantCode int,
 }
 
 func sendWebhook(server *httptest.Server, eventType string, event interface{}) (*http.Response, error) {
+	return sendWebhookDelivery(server, eventType, "", event)
+}
+
+func sendWebhookDelivery(server *httptest.Server, eventType, deliveryID string, event interface{}) (*http.Response, error) {
 	jsonBuf := &bytes.Buffer{}
 	enc := json.NewEncoder(jsonBuf)
 	enc.SetIndent("  ", "")
@@ -520,6 +807,10 @@ func sendWebhook(server *httptest.Server, eventType string, event interface{}) (
 		Body: io.NopCloser(bytes.NewBuffer(reqBody)),
 	}
 
+	if deliveryID != "" {
+		req.Header.Set("X-GitHub-Delivery", deliveryID)
+	}
+
 	return http.DefaultClient.Do(req)
 }
 
@@ -592,3 +883,30 @@ func (l *testLogSink) WithValues(kvs ...interface{}) logr.LogSink {
 		writer:    l.writer,
 	}
 }
+
+func TestMatchRepository(t *testing.T) {
+	repo := &github.Repository{
+		Name:     github.String("myrepo"),
+		FullName: github.String("myorg/myrepo"),
+	}
+
+	testcases := []struct {
+		repositories []string
+		want         bool
+	}{
+		{repositories: []string{"myrepo"}, want: true},
+		{repositories: []string{"myorg/myrepo"}, want: true},
+		{repositories: []string{"otherorg/myrepo"}, want: false},
+		{repositories: []string{"my*"}, want: true},
+		{repositories: []string{"myorg/*"}, want: true},
+		{repositories: []string{"myorg/*", "!myrepo"}, want: false},
+		{repositories: []string{"!myorg/other*"}, want: true},
+		{repositories: []string{"repo"}, want: false},
+	}
+
+	for _, tc := range testcases {
+		if got := matchRepository(tc.repositories, repo); got != tc.want {
+			t.Errorf("%v: want %v, got %v", tc.repositories, tc.want, got)
+		}
+	}
+}
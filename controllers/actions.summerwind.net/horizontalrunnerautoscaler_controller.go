@@ -23,6 +23,7 @@ import (
 	"time"
 
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/events"
 
 	"github.com/go-logr/logr"
@@ -53,6 +54,19 @@ type HorizontalRunnerAutoscalerReconciler struct {
 	Scheme                *runtime.Scheme
 	DefaultScaleDownDelay time.Duration
 	Name                  string
+
+	// Inventory, when set, serves the runners and workflow runs used by the
+	// PercentageRunnersBusy and TotalNumberOfQueuedAndInProgressWorkflowRuns metrics
+	// from a cache shared by all the HorizontalRunnerAutoscalers.
+	Inventory *arcgithub.Inventory
+
+	// KubernetesMetricsClient reads the metrics of the External type from the
+	// external and custom metrics APIs of Kubernetes.
+	KubernetesMetricsClient rest.Interface
+
+	// PrometheusAddresses are the Prometheus-compatible HTTP APIs that the metrics of the External type
+	// are allowed to query. No Prometheus is queried when empty.
+	PrometheusAddresses []string
 }
 
 const defaultReplicas = 1
@@ -62,6 +76,7 @@ const defaultReplicas = 1
 // +kubebuilder:rbac:groups=actions.summerwind.dev,resources=horizontalrunnerautoscalers/finalizers,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=actions.summerwind.dev,resources=horizontalrunnerautoscalers/status,verbs=get;update;patch
 // +kubebuilder:rbac:groups=core,resources=events,verbs=create;patch
+// +kubebuilder:rbac:groups=custom.metrics.k8s.io;external.metrics.k8s.io,resources=*,verbs=get;list
 
 func (r *HorizontalRunnerAutoscalerReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
 	log := r.Log.WithValues("horizontalrunnerautoscaler", req.NamespacedName)
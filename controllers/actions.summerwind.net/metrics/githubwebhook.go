@@ -0,0 +1,29 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	webhookEvent = "event"
+)
+
+var (
+	githubWebhookMetrics = []prometheus.Collector{
+		githubWebhookDuplicateDeliveriesTotal,
+	}
+)
+
+var (
+	githubWebhookDuplicateDeliveriesTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "github_webhook_duplicate_deliveries_total",
+			Help: "Number of GitHub webhook deliveries ignored because a delivery with the same X-GitHub-Delivery ID was already received",
+		},
+		[]string{webhookEvent},
+	)
+)
+
+func IncGitHubWebhookDuplicateDeliveries(event string) {
+	githubWebhookDuplicateDeliveriesTotal.With(prometheus.Labels{webhookEvent: event}).Inc()
+}
@@ -31,6 +31,8 @@ var (
 		horizontalRunnerAutoscalerWorkflowRunsInProgress,
 		horizontalRunnerAutoscalerWorkflowRunsQueued,
 		horizontalRunnerAutoscalerWorkflowRunsUnknown,
+		horizontalRunnerAutoscalerExternalReplicasDesired,
+		horizontalRunnerAutoscalerExternalMetricValue,
 	}
 )
 
@@ -128,6 +130,21 @@ var (
 		},
 		[]string{hraName, hraNamespace, stEnterprise, stOrganization, stRepository, stKind, stName},
 	)
+	// External
+	horizontalRunnerAutoscalerExternalReplicasDesired = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "horizontalrunnerautoscaler_external_replicas_desired",
+			Help: "replicas_desired of External",
+		},
+		[]string{hraName, hraNamespace, stEnterprise, stOrganization, stRepository, stKind, stName},
+	)
+	horizontalRunnerAutoscalerExternalMetricValue = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "horizontalrunnerautoscaler_external_metric_value",
+			Help: "value of External",
+		},
+		[]string{hraName, hraNamespace, stEnterprise, stOrganization, stRepository, stKind, stName},
+	)
 )
 
 func SetHorizontalRunnerAutoscalerSpec(o metav1.ObjectMeta, spec v1alpha1.HorizontalRunnerAutoscalerSpec) {
@@ -210,3 +227,26 @@ func SetHorizontalRunnerAutoscalerQueuedAndInProgressWorkflowRuns(
 	horizontalRunnerAutoscalerWorkflowRunsQueued.With(labels).Set(float64(workflowRunsQueued))
 	horizontalRunnerAutoscalerWorkflowRunsUnknown.With(labels).Set(float64(workflowRunsUnknown))
 }
+
+func SetHorizontalRunnerAutoscalerExternalMetric(
+	o metav1.ObjectMeta,
+	enterprise string,
+	organization string,
+	repository string,
+	kind string,
+	name string,
+	desiredReplicas int,
+	value float64,
+) {
+	labels := prometheus.Labels{
+		hraName:        o.Name,
+		hraNamespace:   o.Namespace,
+		stEnterprise:   enterprise,
+		stOrganization: organization,
+		stRepository:   repository,
+		stKind:         kind,
+		stName:         name,
+	}
+	horizontalRunnerAutoscalerExternalReplicasDesired.With(labels).Set(float64(desiredReplicas))
+	horizontalRunnerAutoscalerExternalMetricValue.With(labels).Set(value)
+}
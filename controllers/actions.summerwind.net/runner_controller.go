@@ -74,6 +74,10 @@ type RunnerReconciler struct {
 	RegistrationRecheckJitter   time.Duration
 	UnregistrationRetryDelay    time.Duration
 
+	// EvictionProtection protects the runner pod from the cluster autoscaler while the runner is running a job.
+	// It relies on the runner status update hook to know whether the runner is busy.
+	EvictionProtection bool
+
 	RunnerPodDefaults RunnerPodDefaults
 }
 
@@ -176,6 +180,13 @@ func (r *RunnerReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctr
 		}
 	}
 
+	if r.EvictionProtection && r.RunnerPodDefaults.UseRunnerStatusUpdateHook && pod.DeletionTimestamp.IsZero() {
+		if err := r.reconcilePodEvictionProtection(ctx, &runner, &pod, log); err != nil {
+			log.Error(err, "Failed to reconcile eviction protection of runner pod")
+			return ctrl.Result{}, err
+		}
+	}
+
 	return ctrl.Result{}, nil
 }
 
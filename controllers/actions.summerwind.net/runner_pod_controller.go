@@ -30,9 +30,12 @@ import (
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/tools/events"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 
 	arcv1alpha1 "github.com/actions/actions-runner-controller/apis/actions.summerwind.net/v1alpha1"
+	"github.com/actions/actions-runner-controller/controllers/internal/nodedrain"
 
 	corev1 "k8s.io/api/core/v1"
 )
@@ -49,11 +52,16 @@ type RunnerPodReconciler struct {
 	RegistrationRecheckJitter   time.Duration
 
 	UnregistrationRetryDelay time.Duration
+
+	// EvictionProtection unregisters the runners whose pods run on draining nodes and deletes the pods,
+	// so that they are recreated on other nodes.
+	EvictionProtection bool
 }
 
 // +kubebuilder:rbac:groups=core,resources=pods,verbs=get;list;watch;update;patch;delete
 // +kubebuilder:rbac:groups=core,resources=secrets,verbs=get;list;watch
 // +kubebuilder:rbac:groups=core,resources=events,verbs=create;patch
+// +kubebuilder:rbac:groups=core,resources=nodes,verbs=get;list;watch
 
 func (r *RunnerPodReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
 	log := r.Log.WithValues("runnerpod", req.NamespacedName)
@@ -261,6 +269,18 @@ func (r *RunnerPodReconciler) Reconcile(ctx context.Context, req ctrl.Request) (
 		return ctrl.Result{}, nil
 	}
 
+	if r.EvictionProtection {
+		draining, err := r.runnerPodIsOnDrainingNode(ctx, &runnerPod)
+		if err != nil {
+			log.Error(err, "Failed to get the node of the runner pod")
+			return ctrl.Result{}, err
+		}
+
+		if draining {
+			return r.handOffRunnerPodOnDrainingNode(ctx, log, ghc, enterprise, org, repo, &runnerPod)
+		}
+	}
+
 	return ctrl.Result{}, nil
 }
 
@@ -281,10 +301,22 @@ func (r *RunnerPodReconciler) SetupWithManager(mgr ctrl.Manager) error {
 
 	r.Recorder = mgr.GetEventRecorder(name)
 
-	return ctrl.NewControllerManagedBy(mgr).
-		For(&corev1.Pod{}).
-		Named(name).
-		Complete(r)
+	b := ctrl.NewControllerManagedBy(mgr).
+		For(&corev1.Pod{})
+
+	if r.EvictionProtection {
+		if err := mgr.GetFieldIndexer().IndexField(context.TODO(), &corev1.Pod{}, nodedrain.PodNodeNameKey, nodedrain.PodNodeNameIndexer); err != nil {
+			return err
+		}
+
+		b = b.Watches(
+			&corev1.Node{},
+			handler.EnqueueRequestsFromMapFunc(r.runnerPodsOnNode),
+			builder.WithPredicates(nodedrain.DrainingChangedPredicate()),
+		)
+	}
+
+	return b.Named(name).Complete(r)
 }
 
 func (r *RunnerPodReconciler) cleanupRunnerLinkedPods(ctx context.Context, pod *corev1.Pod, log logr.Logger) error {
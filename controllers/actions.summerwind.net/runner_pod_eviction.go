@@ -0,0 +1,138 @@
+package actionssummerwindnet
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/actions/actions-runner-controller/controllers/internal/nodedrain"
+	"github.com/actions/actions-runner-controller/github"
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/actions/actions-runner-controller/apis/actions.summerwind.net/v1alpha1"
+)
+
+// runnerIsBusy returns true when the runner status says it is running a job.
+// The status is reported only by the runner status update hook, so a runner is never seen busy without it.
+func runnerIsBusy(runner *v1alpha1.Runner) bool {
+	return runner.Status.Phase == string(corev1.PodRunning) && runner.Status.WorkflowStatus != nil && runner.Status.WorkflowStatus.RunID != ""
+}
+
+// reconcilePodEvictionProtection tells the cluster autoscaler not to evict the runner pod while the runner is running a job,
+// and restores the previous safe-to-evict annotation of the pod once the runner becomes idle again.
+func (r *RunnerReconciler) reconcilePodEvictionProtection(ctx context.Context, runner *v1alpha1.Runner, pod *corev1.Pod, log logr.Logger) error {
+	previous, protected := getAnnotation(pod, AnnotationKeyEvictionProtected)
+	busy := runnerIsBusy(runner)
+
+	if busy == protected {
+		return nil
+	}
+
+	updated := pod.DeepCopy()
+	if busy {
+		current, _ := getAnnotation(pod, nodedrain.AnnotationKeySafeToEvict)
+		setAnnotation(&updated.ObjectMeta, AnnotationKeyEvictionProtected, current)
+		setAnnotation(&updated.ObjectMeta, nodedrain.AnnotationKeySafeToEvict, "false")
+	} else {
+		delete(updated.Annotations, AnnotationKeyEvictionProtected)
+		if previous == "" {
+			delete(updated.Annotations, nodedrain.AnnotationKeySafeToEvict)
+		} else {
+			setAnnotation(&updated.ObjectMeta, nodedrain.AnnotationKeySafeToEvict, previous)
+		}
+	}
+
+	if err := r.Patch(ctx, updated, client.MergeFrom(pod)); err != nil {
+		return fmt.Errorf("failed to patch runner pod for eviction protection: %w", err)
+	}
+
+	if busy {
+		log.V(1).Info("Protected busy runner pod from eviction", "workflowRunID", runner.Status.WorkflowStatus.RunID)
+	} else {
+		log.V(1).Info("Removed eviction protection from idle runner pod")
+	}
+
+	return nil
+}
+
+// runnerPodIsOnDrainingNode returns true when the runner pod is scheduled to a draining node.
+func (r *RunnerPodReconciler) runnerPodIsOnDrainingNode(ctx context.Context, pod *corev1.Pod) (bool, error) {
+	if pod.Spec.NodeName == "" {
+		return false, nil
+	}
+
+	var node corev1.Node
+	if err := r.Get(ctx, types.NamespacedName{Name: pod.Spec.NodeName}, &node); err != nil {
+		if kerrors.IsNotFound(err) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	return nodedrain.IsDraining(&node), nil
+}
+
+// handOffRunnerPodOnDrainingNode unregisters the runner whose pod runs on a draining node and deletes the pod,
+// so that no new job is assigned to the runner and its owner recreates it on another node.
+// A busy runner is not unregistered until it completes its job, as in any other graceful stop.
+func (r *RunnerPodReconciler) handOffRunnerPodOnDrainingNode(ctx context.Context, log logr.Logger, ghc *github.Client, enterprise, org, repo string, pod *corev1.Pod) (ctrl.Result, error) {
+	log.V(2).Info("Runner pod is on a draining node. Unregistering the runner to recreate it on another node", "node", pod.Spec.NodeName)
+
+	updated, res, err := tickRunnerGracefulStop(ctx, r.unregistrationRetryDelay(), log, ghc, r.Client, enterprise, org, repo, pod.Name, pod)
+	if res != nil {
+		return *res, err
+	}
+
+	if err := r.Delete(ctx, updated); err != nil {
+		if kerrors.IsNotFound(err) {
+			return ctrl.Result{}, nil
+		}
+		log.Error(err, "Failed to delete runner pod on draining node")
+		return ctrl.Result{}, err
+	}
+
+	r.Recorder.Eventf(
+		updated,
+		nil,
+		corev1.EventTypeNormal,
+		"RunnerPodDrained",
+		"",
+		fmt.Sprintf("Unregistered runner and deleted pod '%s' on draining node '%s'", updated.Name, updated.Spec.NodeName),
+	)
+	log.Info("Deleted unregistered runner pod on draining node", "node", updated.Spec.NodeName)
+
+	// Give the pod controller a chance to see the deletion timestamp and remove the finalizer.
+	return ctrl.Result{RequeueAfter: 3 * time.Second}, nil
+}
+
+// runnerPodsOnNode maps a node to the runner pods on it.
+func (r *RunnerPodReconciler) runnerPodsOnNode(ctx context.Context, node client.Object) []reconcile.Request {
+	var pods corev1.PodList
+	if err := r.List(ctx, &pods, client.MatchingFields{nodedrain.PodNodeNameKey: node.GetName()}); err != nil {
+		r.Log.Error(err, "Failed to list pods on node", "node", node.GetName())
+		return nil
+	}
+
+	var requests []reconcile.Request
+	for _, pod := range pods.Items {
+		_, isRunnerPod := pod.Labels[LabelKeyRunner]
+		_, isRunnerSetPod := pod.Labels[LabelKeyRunnerSetName]
+		_, isRunnerDeploymentPod := pod.Labels[LabelKeyRunnerDeploymentName]
+
+		if !isRunnerPod && !isRunnerSetPod && !isRunnerDeploymentPod {
+			continue
+		}
+
+		requests = append(requests, reconcile.Request{
+			NamespacedName: types.NamespacedName{Namespace: pod.Namespace, Name: pod.Name},
+		})
+	}
+
+	return requests
+}
@@ -0,0 +1,123 @@
+package actionssummerwindnet
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	actionsv1alpha1 "github.com/actions/actions-runner-controller/apis/actions.summerwind.net/v1alpha1"
+	"github.com/actions/actions-runner-controller/controllers/internal/nodedrain"
+)
+
+func TestReconcilePodEvictionProtection(t *testing.T) {
+	tests := []struct {
+		name            string
+		annotations     map[string]string
+		busy            bool
+		wantAnnotations map[string]string
+	}{
+		{
+			name:            "protects busy runner pod",
+			busy:            true,
+			wantAnnotations: map[string]string{nodedrain.AnnotationKeySafeToEvict: "false", AnnotationKeyEvictionProtected: ""},
+		},
+		{
+			name:            "remembers previous safe-to-evict value",
+			annotations:     map[string]string{nodedrain.AnnotationKeySafeToEvict: "true"},
+			busy:            true,
+			wantAnnotations: map[string]string{nodedrain.AnnotationKeySafeToEvict: "false", AnnotationKeyEvictionProtected: "true"},
+		},
+		{
+			name:            "removes protection from idle runner pod",
+			annotations:     map[string]string{nodedrain.AnnotationKeySafeToEvict: "false", AnnotationKeyEvictionProtected: ""},
+			wantAnnotations: nil,
+		},
+		{
+			name:            "restores previous safe-to-evict value",
+			annotations:     map[string]string{nodedrain.AnnotationKeySafeToEvict: "false", AnnotationKeyEvictionProtected: "true"},
+			wantAnnotations: map[string]string{nodedrain.AnnotationKeySafeToEvict: "true"},
+		},
+		{
+			name:            "leaves unprotected idle runner pod alone",
+			annotations:     map[string]string{nodedrain.AnnotationKeySafeToEvict: "true"},
+			wantAnnotations: map[string]string{nodedrain.AnnotationKeySafeToEvict: "true"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sc := runtime.NewScheme()
+			require.NoError(t, clientgoscheme.AddToScheme(sc))
+
+			pod := &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Name: "example-runner", Namespace: "default", Annotations: tt.annotations},
+			}
+
+			runner := &actionsv1alpha1.Runner{}
+			if tt.busy {
+				runner.Status.Phase = string(corev1.PodRunning)
+				runner.Status.WorkflowStatus = &actionsv1alpha1.WorkflowStatus{RunID: "1234"}
+			} else {
+				runner.Status.Phase = "Idle"
+			}
+
+			c := fake.NewClientBuilder().WithScheme(sc).WithObjects(pod).Build()
+			r := &RunnerReconciler{Client: c}
+
+			require.NoError(t, r.reconcilePodEvictionProtection(context.Background(), runner, pod, logr.Discard()))
+
+			var updated corev1.Pod
+			require.NoError(t, c.Get(context.Background(), types.NamespacedName{Namespace: "default", Name: "example-runner"}, &updated))
+
+			if len(tt.wantAnnotations) == 0 {
+				assert.Empty(t, updated.Annotations)
+			} else {
+				assert.Equal(t, tt.wantAnnotations, updated.Annotations)
+			}
+		})
+	}
+}
+
+func TestRunnerPodsOnNode(t *testing.T) {
+	sc := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(sc))
+
+	newPod := func(name, nodeName string, labels map[string]string) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", Labels: labels},
+			Spec:       corev1.PodSpec{NodeName: nodeName},
+		}
+	}
+
+	node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-1"}}
+
+	c := fake.NewClientBuilder().
+		WithScheme(sc).
+		WithIndex(&corev1.Pod{}, nodedrain.PodNodeNameKey, nodedrain.PodNodeNameIndexer).
+		WithObjects(
+			node,
+			newPod("runner", "node-1", map[string]string{LabelKeyRunner: ""}),
+			newPod("runnerset-runner", "node-1", map[string]string{LabelKeyRunnerSetName: "example"}),
+			newPod("other", "node-1", nil),
+			newPod("runner-on-other-node", "node-2", map[string]string{LabelKeyRunner: ""}),
+		).
+		Build()
+
+	r := &RunnerPodReconciler{Client: c, Log: logr.Discard()}
+
+	var names []string
+	for _, req := range r.runnerPodsOnNode(context.Background(), node) {
+		names = append(names, req.Name)
+	}
+
+	assert.ElementsMatch(t, []string{"runner", "runnerset-runner"}, names)
+}
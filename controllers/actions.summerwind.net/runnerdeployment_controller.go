@@ -111,6 +111,16 @@ func (r *RunnerDeploymentReconciler) Reconcile(ctx context.Context, req ctrl.Req
 		return ctrl.Result{}, err
 	}
 
+	ru, err := newRollingUpdate(&rd)
+	if err != nil {
+		r.Recorder.Eventf(&rd, nil, corev1.EventTypeWarning, "InvalidStrategy", "", err.Error())
+
+		log.Error(err, "Invalid rolling update strategy. Falling back to the default strategy")
+
+		// The runner deployment is still reconciled so that it keeps being scaled while the strategy is fixed.
+		ru = nil
+	}
+
 	if newestSet == nil {
 		if err := r.Create(ctx, desiredRS); err != nil {
 			log.Error(err, "Failed to create runnerreplicaset resource")
@@ -138,6 +148,13 @@ func (r *RunnerDeploymentReconciler) Reconcile(ctx context.Context, req ctrl.Req
 	}
 
 	if newestTemplateHash != desiredTemplateHash {
+		if ru != nil {
+			// The new runner replica set starts with as many runners as maxSurge allows,
+			// and is scaled up as the outdated runners are removed.
+			replicas := ru.newReplicas(nil, myRunnerReplicaSets)
+			desiredRS.Spec.Replicas = &replicas
+		}
+
 		if err := r.Create(ctx, desiredRS); err != nil {
 			log.Error(err, "Failed to create runnerreplicaset resource")
 
@@ -178,7 +195,13 @@ func (r *RunnerDeploymentReconciler) Reconcile(ctx context.Context, req ctrl.Req
 	const defaultReplicas = 1
 
 	currentDesiredReplicas := getIntOrDefault(newestSet.Spec.Replicas, defaultReplicas)
-	newDesiredReplicas := getIntOrDefault(desiredRS.Spec.Replicas, defaultReplicas)
+	newDesiredReplicas := getIntOrDefault(rd.Spec.Replicas, defaultReplicas)
+
+	// During a rolling update, the newest runner replica set is scaled up only as the outdated runners are removed.
+	targetReplicas := newDesiredReplicas
+	if ru != nil && len(oldSets) > 0 {
+		targetReplicas = ru.newReplicas(newestSet, oldSets)
+	}
 
 	// Please add more conditions that we can in-place update the newest runnerreplicaset without disruption
 	//
@@ -191,8 +214,8 @@ func (r *RunnerDeploymentReconciler) Reconcile(ctx context.Context, req ctrl.Req
 	if rd.Spec.EffectiveTime != nil {
 		et2 = rd.Spec.EffectiveTime.Time
 	}
-	if currentDesiredReplicas != newDesiredReplicas || et1 != et2 {
-		newestSet.Spec.Replicas = &newDesiredReplicas
+	if currentDesiredReplicas != targetReplicas || et1 != et2 {
+		newestSet.Spec.Replicas = &targetReplicas
 		newestSet.Spec.EffectiveTime = rd.Spec.EffectiveTime
 
 		if err := r.Update(ctx, newestSet); err != nil {
@@ -203,7 +226,7 @@ func (r *RunnerDeploymentReconciler) Reconcile(ctx context.Context, req ctrl.Req
 
 		log.V(1).Info("Updated runnerreplicaset due to spec change",
 			"currentDesiredReplicas", currentDesiredReplicas,
-			"newDesiredReplicas", newDesiredReplicas,
+			"newDesiredReplicas", targetReplicas,
 			"currentEffectiveTime", newestSet.Spec.EffectiveTime,
 			"newEffectiveTime", rd.Spec.EffectiveTime,
 		)
@@ -212,7 +235,11 @@ func (r *RunnerDeploymentReconciler) Reconcile(ctx context.Context, req ctrl.Req
 	}
 
 	// Do we have old runner replica sets that should eventually deleted?
-	if len(oldSets) > 0 {
+	if len(oldSets) > 0 && ru != nil {
+		if err := r.scaleDownOldRunnerReplicaSets(ctx, log, &rd, ru, newestSet, oldSets); err != nil {
+			return ctrl.Result{}, err
+		}
+	} else if len(oldSets) > 0 {
 		var readyReplicas int
 		if newestSet.Status.ReadyReplicas != nil {
 			readyReplicas = *newestSet.Status.ReadyReplicas
@@ -233,57 +260,19 @@ func (r *RunnerDeploymentReconciler) Reconcile(ctx context.Context, req ctrl.Req
 		if readyReplicas < currentDesiredReplicas {
 			logWithDebugInfo.
 				Info("Waiting until the newest runnerreplicaset to be 100% available")
+		} else {
+			if oldSetsCount > 0 {
+				logWithDebugInfo.
+					Info("The newest runnerreplicaset is 100% available. Deleting old runnerreplicasets")
+			}
 
-			return ctrl.Result{}, nil
-		}
-
-		if oldSetsCount > 0 {
-			logWithDebugInfo.
-				Info("The newest runnerreplicaset is 100% available. Deleting old runnerreplicasets")
-		}
-
-		for i := range oldSets {
-			rs := oldSets[i]
-
-			rslog := log.WithValues("runnerreplicaset", rs.Name)
-
-			if rs.Status.Replicas != nil && *rs.Status.Replicas > 0 {
-				if rs.Spec.Replicas != nil && *rs.Spec.Replicas == 0 {
-					rslog.V(2).Info("Waiting for runnerreplicaset to scale to zero")
-
-					continue
-				}
-
-				updated := rs.DeepCopy()
-				zero := 0
-				updated.Spec.Replicas = &zero
-				if err := r.Update(ctx, updated); err != nil {
-					rslog.Error(err, "Failed to scale runnerreplicaset to zero")
+			for i := range oldSets {
+				rs := oldSets[i]
 
+				if err := r.scaleDownOrDeleteRunnerReplicaSet(ctx, log, &rd, &rs, 0); err != nil {
 					return ctrl.Result{}, err
 				}
-
-				rslog.Info("Scaled runnerreplicaset to zero")
-
-				continue
 			}
-
-			if err := r.Delete(ctx, &rs); err != nil {
-				rslog.Error(err, "Failed to delete runnerreplicaset resource")
-
-				return ctrl.Result{}, err
-			}
-
-			r.Recorder.Eventf(
-				&rd,
-				&rs,
-				corev1.EventTypeNormal,
-				"RunnerReplicaSetDeleted",
-				"",
-				fmt.Sprintf("Deleted runnerreplicaset '%s'", rs.Name),
-			)
-
-			rslog.Info("Deleted runnerreplicaset")
 		}
 	}
 
@@ -320,6 +309,23 @@ func (r *RunnerDeploymentReconciler) Reconcile(ctx context.Context, req ctrl.Req
 	status.DesiredReplicas = &newDesiredReplicas
 	status.Replicas = &totalCurrentReplicas
 	status.UpdatedReplicas = &updatedReplicas
+	status.ObservedGeneration = rd.Generation
+
+	// The progress of the rollout is reported only for the RollingUpdate strategy.
+	var untilDeadline time.Duration
+	if ru != nil {
+		var cond v1alpha1.RunnerDeploymentCondition
+
+		complete := len(oldSets) == 0
+		cond, untilDeadline = progressingCondition(&rd, status, newestSet, complete, time.Now())
+		status.Conditions = []v1alpha1.RunnerDeploymentCondition{cond}
+
+		if prev := getRunnerDeploymentCondition(rd.Status, v1alpha1.RunnerDeploymentProgressing); cond.Reason == v1alpha1.RunnerDeploymentReasonProgressDeadlineExceeded && (prev == nil || prev.Reason != cond.Reason) {
+			r.Recorder.Eventf(&rd, newestSet, corev1.EventTypeWarning, v1alpha1.RunnerDeploymentReasonProgressDeadlineExceeded, "", cond.Message)
+
+			log.Info("Rollout exceeded its progress deadline", "runnerreplicaset", newestSet.Name)
+		}
+	}
 
 	if !reflect.DeepEqual(rd.Status, status) {
 		updated := rd.DeepCopy()
@@ -333,7 +339,71 @@ func (r *RunnerDeploymentReconciler) Reconcile(ctx context.Context, req ctrl.Req
 		}
 	}
 
-	return ctrl.Result{}, nil
+	// Requeue to report the rollout as failed once the progress deadline is exceeded, even when no runner replica set changes until then.
+	// untilDeadline is zero when there is no deadline to watch, which does not requeue.
+	return ctrl.Result{RequeueAfter: untilDeadline}, nil
+}
+
+// scaleDownOldRunnerReplicaSets scales down the outdated runner replica sets within the limits of the rolling update,
+// and deletes the ones that have no runners left.
+func (r *RunnerDeploymentReconciler) scaleDownOldRunnerReplicaSets(ctx context.Context, log logr.Logger, rd *v1alpha1.RunnerDeployment, ru *rollingUpdate, newestSet *v1alpha1.RunnerReplicaSet, oldSets []v1alpha1.RunnerReplicaSet) error {
+	replicas := ru.oldReplicas(newestSet, oldSets)
+
+	for i := range oldSets {
+		if err := r.scaleDownOrDeleteRunnerReplicaSet(ctx, log, rd, &oldSets[i], replicas[i]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// scaleDownOrDeleteRunnerReplicaSet scales the outdated runner replica set down to the replicas, and deletes it once it has no runners left.
+// Busy runners are not removed by the runner replica set until they finish their jobs, so it can take a while
+// until the runner replica set is deleted.
+func (r *RunnerDeploymentReconciler) scaleDownOrDeleteRunnerReplicaSet(ctx context.Context, log logr.Logger, rd *v1alpha1.RunnerDeployment, rs *v1alpha1.RunnerReplicaSet, replicas int) error {
+	rslog := log.WithValues("runnerreplicaset", rs.Name)
+
+	current := getIntOrDefault(rs.Spec.Replicas, 1)
+
+	if rs.Status.Replicas != nil && *rs.Status.Replicas > 0 || replicas > 0 {
+		if current <= replicas {
+			rslog.V(2).Info("Waiting for runnerreplicaset to scale down", "replicas", replicas)
+
+			return nil
+		}
+
+		updated := rs.DeepCopy()
+		updated.Spec.Replicas = &replicas
+		if err := r.Update(ctx, updated); err != nil {
+			rslog.Error(err, "Failed to scale down runnerreplicaset", "replicas", replicas)
+
+			return err
+		}
+
+		rslog.Info("Scaled down runnerreplicaset", "replicas", replicas)
+
+		return nil
+	}
+
+	if err := r.Delete(ctx, rs); err != nil {
+		rslog.Error(err, "Failed to delete runnerreplicaset resource")
+
+		return err
+	}
+
+	r.Recorder.Eventf(
+		rd,
+		rs,
+		corev1.EventTypeNormal,
+		"RunnerReplicaSetDeleted",
+		"",
+		fmt.Sprintf("Deleted runnerreplicaset '%s'", rs.Name),
+	)
+
+	rslog.Info("Deleted runnerreplicaset")
+
+	return nil
 }
 
 func getIntOrDefault(p *int, d int) int {
@@ -491,19 +561,7 @@ func (r *RunnerDeploymentReconciler) SetupWithManager(mgr ctrl.Manager) error {
 
 	r.Recorder = mgr.GetEventRecorder(name)
 
-	if err := mgr.GetFieldIndexer().IndexField(context.TODO(), &v1alpha1.RunnerReplicaSet{}, runnerSetOwnerKey, func(rawObj client.Object) []string {
-		runnerSet := rawObj.(*v1alpha1.RunnerReplicaSet)
-		owner := metav1.GetControllerOf(runnerSet)
-		if owner == nil {
-			return nil
-		}
-
-		if owner.APIVersion != v1alpha1.GroupVersion.String() || owner.Kind != "RunnerDeployment" {
-			return nil
-		}
-
-		return []string{owner.Name}
-	}); err != nil {
+	if err := mgr.GetFieldIndexer().IndexField(context.TODO(), &v1alpha1.RunnerReplicaSet{}, runnerSetOwnerKey, runnerReplicaSetOwnerIndexer); err != nil {
 		return err
 	}
 
@@ -513,3 +571,17 @@ func (r *RunnerDeploymentReconciler) SetupWithManager(mgr ctrl.Manager) error {
 		Named(name).
 		Complete(r)
 }
+
+func runnerReplicaSetOwnerIndexer(rawObj client.Object) []string {
+	runnerSet := rawObj.(*v1alpha1.RunnerReplicaSet)
+	owner := metav1.GetControllerOf(runnerSet)
+	if owner == nil {
+		return nil
+	}
+
+	if owner.APIVersion != v1alpha1.GroupVersion.String() || owner.Kind != "RunnerDeployment" {
+		return nil
+	}
+
+	return []string{owner.Name}
+}
@@ -0,0 +1,183 @@
+package actionssummerwindnet
+
+import (
+	"fmt"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/actions/actions-runner-controller/apis/actions.summerwind.net/v1alpha1"
+)
+
+const defaultProgressDeadline = 600 * time.Second
+
+// rollingUpdate is the RollingUpdate strategy of a RunnerDeployment, resolved against its desired replicas.
+//
+// It follows the Deployment controller's rolling update, except that the runners of outdated runner replica sets
+// are counted from their status rather than their spec. A busy runner is not removed until it finishes its job,
+// even after its runner replica set is scaled down, so it keeps counting towards maxSurge in the meantime.
+type rollingUpdate struct {
+	desired        int
+	maxSurge       int
+	maxUnavailable int
+}
+
+// newRollingUpdate returns nil when the RunnerDeployment does not use the RollingUpdate strategy.
+func newRollingUpdate(rd *v1alpha1.RunnerDeployment) (*rollingUpdate, error) {
+	s := rd.Spec.Strategy
+	if s == nil || s.Type != v1alpha1.RollingUpdateRunnerDeploymentStrategyType {
+		return nil, nil
+	}
+
+	desired := getIntOrDefault(rd.Spec.Replicas, 1)
+
+	maxSurge, maxUnavailable, err := s.ResolveRollingUpdate(desired)
+	if err != nil {
+		return nil, err
+	}
+
+	return &rollingUpdate{
+		desired:        desired,
+		maxSurge:       maxSurge,
+		maxUnavailable: maxUnavailable,
+	}, nil
+}
+
+// currentReplicas is the number of runners of an outdated runner replica set, including the busy runners that
+// are waiting for their jobs to finish before being removed.
+func currentReplicas(rs *v1alpha1.RunnerReplicaSet) int {
+	return max(getIntOrDefault(rs.Spec.Replicas, 1), getIntOrDefault(rs.Status.Replicas, 0))
+}
+
+// newReplicas returns the replicas of the newest runner replica set, scaled up as much as maxSurge allows.
+// newestSet is nil when the newest runner replica set is yet to be created.
+func (u *rollingUpdate) newReplicas(newestSet *v1alpha1.RunnerReplicaSet, oldSets []v1alpha1.RunnerReplicaSet) int {
+	var newSpec int
+	if newestSet != nil {
+		newSpec = getIntOrDefault(newestSet.Spec.Replicas, 1)
+	}
+
+	if newSpec >= u.desired {
+		return u.desired
+	}
+
+	total := newSpec
+	for i := range oldSets {
+		total += currentReplicas(&oldSets[i])
+	}
+
+	maxTotal := u.desired + u.maxSurge
+	if total >= maxTotal {
+		return newSpec
+	}
+
+	return min(newSpec+maxTotal-total, u.desired)
+}
+
+// oldReplicas returns the replicas of the outdated runner replica sets, scaled down as much as maxUnavailable allows.
+// oldSets are sorted from the newest to the oldest, and the oldest ones are scaled down first.
+func (u *rollingUpdate) oldReplicas(newestSet *v1alpha1.RunnerReplicaSet, oldSets []v1alpha1.RunnerReplicaSet) []int {
+	replicas := make([]int, len(oldSets))
+
+	newSpec := getIntOrDefault(newestSet.Spec.Replicas, 1)
+	newAvailable := getIntOrDefault(newestSet.Status.AvailableReplicas, 0)
+
+	allSpec, allAvailable := newSpec, newAvailable
+	for i := range oldSets {
+		replicas[i] = getIntOrDefault(oldSets[i].Spec.Replicas, 1)
+		allSpec += replicas[i]
+		allAvailable += min(replicas[i], getIntOrDefault(oldSets[i].Status.AvailableReplicas, 0))
+	}
+
+	minAvailable := u.desired - u.maxUnavailable
+
+	// Unavailable runners are removed first, as removing them never reduces the availability.
+	// The new runners that are yet to be available are accounted for so that the rollout cannot go below minAvailable
+	// when they never become available.
+	maxCleanup := allSpec - minAvailable - max(newSpec-newAvailable, 0)
+
+	for i := len(oldSets) - 1; i >= 0 && maxCleanup > 0; i-- {
+		unavailable := replicas[i] - min(replicas[i], getIntOrDefault(oldSets[i].Status.AvailableReplicas, 0))
+		n := min(unavailable, maxCleanup)
+		replicas[i] -= n
+		maxCleanup -= n
+	}
+
+	scaleDown := allAvailable - minAvailable
+
+	for i := len(oldSets) - 1; i >= 0 && scaleDown > 0; i-- {
+		n := min(replicas[i], scaleDown)
+		replicas[i] -= n
+		scaleDown -= n
+	}
+
+	return replicas
+}
+
+// progressingCondition computes the Progressing condition of the RunnerDeployment from its previous status.
+// complete is true once all the runners are of the newest runner replica set.
+// The returned duration is the time left until the progress deadline, or zero when there is no deadline to watch.
+func progressingCondition(rd *v1alpha1.RunnerDeployment, status v1alpha1.RunnerDeploymentStatus, newestSet *v1alpha1.RunnerReplicaSet, complete bool, now time.Time) (v1alpha1.RunnerDeploymentCondition, time.Duration) {
+	prev := getRunnerDeploymentCondition(rd.Status, v1alpha1.RunnerDeploymentProgressing)
+
+	deadline := defaultProgressDeadline
+	if s := rd.Spec.ProgressDeadlineSeconds; s != nil {
+		deadline = time.Duration(*s) * time.Second
+	}
+
+	cond := v1alpha1.RunnerDeploymentCondition{
+		Type:           v1alpha1.RunnerDeploymentProgressing,
+		Status:         metav1.ConditionTrue,
+		LastUpdateTime: metav1.NewTime(now),
+	}
+
+	var remaining time.Duration
+
+	switch {
+	case complete:
+		cond.Reason = v1alpha1.RunnerDeploymentReasonNewReplicaSetAvailable
+		cond.Message = fmt.Sprintf("RunnerReplicaSet %q has successfully progressed.", newestSet.Name)
+
+		// Keep the condition as is so that the status is not patched on every reconciliation once completed.
+		if prev != nil && prev.Reason == cond.Reason && prev.Message == cond.Message {
+			return *prev, 0
+		}
+	case prev == nil || prev.Reason == v1alpha1.RunnerDeploymentReasonNewReplicaSetAvailable || rolloutProgressed(rd.Status, status):
+		cond.Reason = v1alpha1.RunnerDeploymentReasonReplicaSetUpdated
+		cond.Message = fmt.Sprintf("RunnerReplicaSet %q is progressing.", newestSet.Name)
+		remaining = deadline
+	case prev.Reason == v1alpha1.RunnerDeploymentReasonProgressDeadlineExceeded:
+		return *prev, 0
+	case now.Sub(prev.LastUpdateTime.Time) >= deadline:
+		cond.Status = metav1.ConditionFalse
+		cond.Reason = v1alpha1.RunnerDeploymentReasonProgressDeadlineExceeded
+		cond.Message = fmt.Sprintf("RunnerReplicaSet %q has timed out progressing.", newestSet.Name)
+		cond.LastUpdateTime = prev.LastUpdateTime
+	default:
+		return *prev, deadline - now.Sub(prev.LastUpdateTime.Time)
+	}
+
+	if prev != nil && prev.Status == cond.Status {
+		cond.LastTransitionTime = prev.LastTransitionTime
+	} else {
+		cond.LastTransitionTime = metav1.NewTime(now)
+	}
+
+	return cond, remaining
+}
+
+// rolloutProgressed returns true when the runner replica sets were scaled, or runners became available, since the previous status.
+func rolloutProgressed(prev, cur v1alpha1.RunnerDeploymentStatus) bool {
+	return getIntOrDefault(prev.UpdatedReplicas, 0) != getIntOrDefault(cur.UpdatedReplicas, 0) ||
+		getIntOrDefault(prev.AvailableReplicas, 0) != getIntOrDefault(cur.AvailableReplicas, 0) ||
+		getIntOrDefault(prev.Replicas, 0) != getIntOrDefault(cur.Replicas, 0)
+}
+
+func getRunnerDeploymentCondition(status v1alpha1.RunnerDeploymentStatus, t v1alpha1.RunnerDeploymentConditionType) *v1alpha1.RunnerDeploymentCondition {
+	for i := range status.Conditions {
+		if status.Conditions[i].Type == t {
+			return &status.Conditions[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,452 @@
+package actionssummerwindnet
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/tools/events"
+	"k8s.io/utils/ptr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	actionsv1alpha1 "github.com/actions/actions-runner-controller/apis/actions.summerwind.net/v1alpha1"
+)
+
+func newTestReplicaSet(name string, spec, replicas, available int) actionsv1alpha1.RunnerReplicaSet {
+	return actionsv1alpha1.RunnerReplicaSet{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Spec:       actionsv1alpha1.RunnerReplicaSetSpec{Replicas: intPtr(spec)},
+		Status: actionsv1alpha1.RunnerReplicaSetStatus{
+			Replicas:          intPtr(replicas),
+			AvailableReplicas: intPtr(available),
+		},
+	}
+}
+
+func TestRollingUpdateNewReplicas(t *testing.T) {
+	ru := &rollingUpdate{desired: 4, maxSurge: 1, maxUnavailable: 1}
+
+	tests := []struct {
+		name    string
+		newest  *actionsv1alpha1.RunnerReplicaSet
+		oldSets []actionsv1alpha1.RunnerReplicaSet
+		want    int
+	}{
+		{
+			name:    "new set starts within maxSurge",
+			oldSets: []actionsv1alpha1.RunnerReplicaSet{newTestReplicaSet("old", 4, 4, 4)},
+			want:    1,
+		},
+		{
+			name:    "busy outdated runners count towards maxSurge",
+			newest:  ptr.To(newTestReplicaSet("new", 1, 1, 1)),
+			oldSets: []actionsv1alpha1.RunnerReplicaSet{newTestReplicaSet("old", 2, 4, 3)},
+			want:    1,
+		},
+		{
+			name:    "scales up as outdated runners are removed",
+			newest:  ptr.To(newTestReplicaSet("new", 1, 1, 1)),
+			oldSets: []actionsv1alpha1.RunnerReplicaSet{newTestReplicaSet("old", 2, 2, 2)},
+			want:    3,
+		},
+		{
+			name:    "never exceeds the desired replicas",
+			newest:  ptr.To(newTestReplicaSet("new", 3, 3, 3)),
+			oldSets: []actionsv1alpha1.RunnerReplicaSet{newTestReplicaSet("old", 0, 0, 0)},
+			want:    4,
+		},
+		{
+			name:    "scales down to the desired replicas",
+			newest:  ptr.To(newTestReplicaSet("new", 6, 6, 6)),
+			oldSets: []actionsv1alpha1.RunnerReplicaSet{newTestReplicaSet("old", 1, 1, 1)},
+			want:    4,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, ru.newReplicas(tt.newest, tt.oldSets))
+		})
+	}
+}
+
+func TestRollingUpdateOldReplicas(t *testing.T) {
+	ru := &rollingUpdate{desired: 4, maxSurge: 1, maxUnavailable: 1}
+
+	tests := []struct {
+		name    string
+		newest  actionsv1alpha1.RunnerReplicaSet
+		oldSets []actionsv1alpha1.RunnerReplicaSet
+		want    []int
+	}{
+		{
+			name:    "scales down within maxUnavailable",
+			newest:  newTestReplicaSet("new", 1, 0, 0),
+			oldSets: []actionsv1alpha1.RunnerReplicaSet{newTestReplicaSet("old", 4, 4, 4)},
+			want:    []int{3},
+		},
+		{
+			name:    "waits for the new runners to become available",
+			newest:  newTestReplicaSet("new", 1, 1, 0),
+			oldSets: []actionsv1alpha1.RunnerReplicaSet{newTestReplicaSet("old", 3, 3, 3)},
+			want:    []int{3},
+		},
+		{
+			name:    "removes unavailable outdated runners first",
+			newest:  newTestReplicaSet("new", 1, 1, 0),
+			oldSets: []actionsv1alpha1.RunnerReplicaSet{newTestReplicaSet("old", 4, 4, 2)},
+			want:    []int{3},
+		},
+		{
+			name:   "scales down the oldest set first",
+			newest: newTestReplicaSet("new", 2, 2, 2),
+			oldSets: []actionsv1alpha1.RunnerReplicaSet{
+				newTestReplicaSet("older", 1, 1, 1),
+				newTestReplicaSet("oldest", 2, 2, 2),
+			},
+			want: []int{1, 0},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, ru.oldReplicas(&tt.newest, tt.oldSets))
+		})
+	}
+}
+
+func TestResolveRollingUpdate(t *testing.T) {
+	surge, unavailable, err := (*actionsv1alpha1.RunnerDeploymentStrategy)(nil).ResolveRollingUpdate(10)
+	require.NoError(t, err)
+	assert.Equal(t, 3, surge)
+	assert.Equal(t, 2, unavailable)
+
+	zero := intstr.FromInt32(0)
+	s := &actionsv1alpha1.RunnerDeploymentStrategy{
+		Type: actionsv1alpha1.RollingUpdateRunnerDeploymentStrategyType,
+		RollingUpdate: &actionsv1alpha1.RollingUpdateRunnerDeployment{
+			MaxSurge:       &zero,
+			MaxUnavailable: ptr.To(intstr.FromString("10%")),
+		},
+	}
+	surge, unavailable, err = s.ResolveRollingUpdate(3)
+	require.NoError(t, err)
+	assert.Equal(t, 0, surge)
+	assert.Equal(t, 1, unavailable, "maxUnavailable must be at least 1 when maxSurge is 0")
+
+	s.RollingUpdate.MaxSurge = ptr.To(intstr.FromString("lots"))
+	_, _, err = s.ResolveRollingUpdate(3)
+	assert.Error(t, err)
+}
+
+func TestRunnerDeploymentValidateStrategy(t *testing.T) {
+	zero := intstr.FromInt32(0)
+
+	rd := &actionsv1alpha1.RunnerDeployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "example"},
+		Spec: actionsv1alpha1.RunnerDeploymentSpec{
+			Template: actionsv1alpha1.RunnerTemplate{
+				Spec: actionsv1alpha1.RunnerSpec{
+					RunnerConfig: actionsv1alpha1.RunnerConfig{Organization: "example"},
+				},
+			},
+			Strategy: &actionsv1alpha1.RunnerDeploymentStrategy{
+				Type: actionsv1alpha1.RollingUpdateRunnerDeploymentStrategyType,
+				RollingUpdate: &actionsv1alpha1.RollingUpdateRunnerDeployment{
+					MaxSurge:       &zero,
+					MaxUnavailable: &zero,
+				},
+			},
+		},
+	}
+
+	err := rd.Validate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "spec.strategy.rollingUpdate.maxUnavailable")
+
+	rd.Spec.Strategy.RollingUpdate.MaxUnavailable = ptr.To(intstr.FromString("50%"))
+	assert.NoError(t, rd.Validate())
+
+	rd.Spec.ProgressDeadlineSeconds = ptr.To(int32(0))
+	err = rd.Validate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "spec.progressDeadlineSeconds")
+}
+
+func TestProgressingCondition(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	newest := &actionsv1alpha1.RunnerReplicaSet{ObjectMeta: metav1.ObjectMeta{Name: "example-new"}}
+
+	status := actionsv1alpha1.RunnerDeploymentStatus{
+		Replicas:          intPtr(5),
+		AvailableReplicas: intPtr(4),
+		UpdatedReplicas:   intPtr(1),
+	}
+
+	rd := &actionsv1alpha1.RunnerDeployment{
+		Spec: actionsv1alpha1.RunnerDeploymentSpec{ProgressDeadlineSeconds: ptr.To(int32(60))},
+	}
+
+	cond, remaining := progressingCondition(rd, status, newest, false, now)
+	assert.Equal(t, metav1.ConditionTrue, cond.Status)
+	assert.Equal(t, actionsv1alpha1.RunnerDeploymentReasonReplicaSetUpdated, cond.Reason)
+	assert.Equal(t, 60*time.Second, remaining)
+
+	rd.Status = status
+	rd.Status.Conditions = []actionsv1alpha1.RunnerDeploymentCondition{cond}
+
+	// No progress, within the deadline
+	cond, remaining = progressingCondition(rd, status, newest, false, now.Add(20*time.Second))
+	assert.Equal(t, rd.Status.Conditions[0], cond)
+	assert.Equal(t, 40*time.Second, remaining)
+
+	// No progress, past the deadline
+	cond, remaining = progressingCondition(rd, status, newest, false, now.Add(time.Minute))
+	assert.Equal(t, metav1.ConditionFalse, cond.Status)
+	assert.Equal(t, actionsv1alpha1.RunnerDeploymentReasonProgressDeadlineExceeded, cond.Reason)
+	assert.Equal(t, now, cond.LastUpdateTime.Time)
+	assert.Equal(t, now.Add(time.Minute), cond.LastTransitionTime.Time)
+	assert.Zero(t, remaining)
+
+	rd.Status.Conditions = []actionsv1alpha1.RunnerDeploymentCondition{cond}
+
+	// A busy runner finished its job after the deadline, resuming the rollout
+	status.Replicas = intPtr(4)
+	cond, _ = progressingCondition(rd, status, newest, false, now.Add(2*time.Minute))
+	assert.Equal(t, metav1.ConditionTrue, cond.Status)
+	assert.Equal(t, actionsv1alpha1.RunnerDeploymentReasonReplicaSetUpdated, cond.Reason)
+	assert.Equal(t, now.Add(2*time.Minute), cond.LastUpdateTime.Time)
+
+	rd.Status.Conditions = []actionsv1alpha1.RunnerDeploymentCondition{cond}
+
+	cond, remaining = progressingCondition(rd, status, newest, true, now.Add(3*time.Minute))
+	assert.Equal(t, metav1.ConditionTrue, cond.Status)
+	assert.Equal(t, actionsv1alpha1.RunnerDeploymentReasonNewReplicaSetAvailable, cond.Reason)
+	assert.Zero(t, remaining)
+
+	rd.Status.Conditions = []actionsv1alpha1.RunnerDeploymentCondition{cond}
+
+	again, _ := progressingCondition(rd, status, newest, true, now.Add(4*time.Minute))
+	assert.Equal(t, cond, again, "a completed rollout must not update the condition")
+}
+
+func TestRunnerDeploymentRollingUpdate(t *testing.T) {
+	sc := runtime.NewScheme()
+	require.NoError(t, actionsv1alpha1.AddToScheme(sc))
+
+	rd := &actionsv1alpha1.RunnerDeployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "example", Namespace: "default", Generation: 2},
+		Spec: actionsv1alpha1.RunnerDeploymentSpec{
+			Replicas: intPtr(4),
+			Template: actionsv1alpha1.RunnerTemplate{
+				Spec: actionsv1alpha1.RunnerSpec{
+					RunnerConfig: actionsv1alpha1.RunnerConfig{Organization: "example", Image: "runner:v2"},
+				},
+			},
+			Strategy: &actionsv1alpha1.RunnerDeploymentStrategy{
+				Type: actionsv1alpha1.RollingUpdateRunnerDeploymentStrategyType,
+				RollingUpdate: &actionsv1alpha1.RollingUpdateRunnerDeployment{
+					MaxSurge:       ptr.To(intstr.FromInt32(1)),
+					MaxUnavailable: ptr.To(intstr.FromInt32(1)),
+				},
+			},
+		},
+	}
+
+	outdated := rd.DeepCopy()
+	outdated.Spec.Template.Spec.Image = "runner:v1"
+
+	oldRS, err := newRunnerReplicaSet(outdated, nil, sc)
+	require.NoError(t, err)
+	oldRS.Name = "example-old"
+	oldRS.CreationTimestamp = metav1.NewTime(time.Now().Add(-time.Hour))
+	oldRS.Status = actionsv1alpha1.RunnerReplicaSetStatus{Replicas: intPtr(4), AvailableReplicas: intPtr(4), ReadyReplicas: intPtr(4)}
+
+	newRS, err := newRunnerReplicaSet(rd, nil, sc)
+	require.NoError(t, err)
+	newRS.Name = "example-new"
+	newRS.CreationTimestamp = metav1.NewTime(time.Now().Add(-time.Minute))
+	newRS.Spec.Replicas = intPtr(1)
+
+	c := fake.NewClientBuilder().
+		WithScheme(sc).
+		WithStatusSubresource(&actionsv1alpha1.RunnerDeployment{}, &actionsv1alpha1.RunnerReplicaSet{}).
+		WithObjects(rd, oldRS, newRS).
+		WithIndex(&actionsv1alpha1.RunnerReplicaSet{}, runnerSetOwnerKey, runnerReplicaSetOwnerIndexer).
+		Build()
+
+	r := &RunnerDeploymentReconciler{
+		Client:   c,
+		Log:      logr.Discard(),
+		Recorder: events.NewFakeRecorder(10),
+		Scheme:   sc,
+	}
+
+	ctx := context.Background()
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: "example"}}
+
+	get := func(name string) actionsv1alpha1.RunnerReplicaSet {
+		t.Helper()
+		var rs actionsv1alpha1.RunnerReplicaSet
+		require.NoError(t, c.Get(ctx, types.NamespacedName{Namespace: "default", Name: name}, &rs))
+		return rs
+	}
+
+	setStatus := func(name string, replicas, available int) {
+		t.Helper()
+		rs := get(name)
+		rs.Status = actionsv1alpha1.RunnerReplicaSetStatus{Replicas: intPtr(replicas), AvailableReplicas: intPtr(available), ReadyReplicas: intPtr(available)}
+		require.NoError(t, c.Status().Update(ctx, &rs))
+	}
+
+	// The new runner is yet to be available, so only one outdated runner can be removed.
+	_, err = r.Reconcile(ctx, req)
+	require.NoError(t, err)
+	assert.Equal(t, 3, *get("example-old").Spec.Replicas)
+	assert.Equal(t, 1, *get("example-new").Spec.Replicas)
+
+	var got actionsv1alpha1.RunnerDeployment
+	require.NoError(t, c.Get(ctx, req.NamespacedName, &got))
+	assert.Equal(t, int64(2), got.Status.ObservedGeneration)
+	require.Len(t, got.Status.Conditions, 1)
+	assert.Equal(t, actionsv1alpha1.RunnerDeploymentReasonReplicaSetUpdated, got.Status.Conditions[0].Reason)
+
+	// One of the outdated runners is busy, so it is not removed yet and the new runner replica set cannot surge.
+	setStatus("example-new", 1, 1)
+	setStatus("example-old", 4, 3)
+
+	_, err = r.Reconcile(ctx, req)
+	require.NoError(t, err)
+	assert.Equal(t, 2, *get("example-old").Spec.Replicas)
+	assert.Equal(t, 1, *get("example-new").Spec.Replicas)
+
+	// The busy runner finished its job.
+	setStatus("example-old", 2, 2)
+
+	_, err = r.Reconcile(ctx, req)
+	require.NoError(t, err)
+	assert.Equal(t, 3, *get("example-new").Spec.Replicas)
+
+	setStatus("example-new", 3, 3)
+
+	_, err = r.Reconcile(ctx, req)
+	require.NoError(t, err)
+	assert.Equal(t, 0, *get("example-old").Spec.Replicas)
+
+	// The outdated runners are gone, so the new runner replica set is scaled up to the desired replicas
+	// before the outdated runner replica set is deleted.
+	setStatus("example-old", 0, 0)
+
+	_, err = r.Reconcile(ctx, req)
+	require.NoError(t, err)
+	assert.Equal(t, 4, *get("example-new").Spec.Replicas)
+
+	_, err = r.Reconcile(ctx, req)
+	require.NoError(t, err)
+
+	var list actionsv1alpha1.RunnerReplicaSetList
+	require.NoError(t, c.List(ctx, &list, client.InNamespace("default")))
+	require.Len(t, list.Items, 1)
+	assert.Equal(t, "example-new", list.Items[0].Name)
+
+	_, err = r.Reconcile(ctx, req)
+	require.NoError(t, err)
+
+	require.NoError(t, c.Get(ctx, req.NamespacedName, &got))
+	assert.Equal(t, actionsv1alpha1.RunnerDeploymentReasonNewReplicaSetAvailable, got.Status.Conditions[0].Reason)
+}
+
+func TestRunnerDeploymentWithoutRollingUpdate(t *testing.T) {
+	sc := runtime.NewScheme()
+	require.NoError(t, actionsv1alpha1.AddToScheme(sc))
+
+	tests := []struct {
+		name     string
+		strategy *actionsv1alpha1.RunnerDeploymentStrategy
+	}{
+		{
+			name: "default",
+		},
+		{
+			name: "invalid rolling update",
+			strategy: &actionsv1alpha1.RunnerDeploymentStrategy{
+				Type: actionsv1alpha1.RollingUpdateRunnerDeploymentStrategyType,
+				RollingUpdate: &actionsv1alpha1.RollingUpdateRunnerDeployment{
+					MaxSurge: ptr.To(intstr.FromInt32(-1)),
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rd := &actionsv1alpha1.RunnerDeployment{
+				ObjectMeta: metav1.ObjectMeta{Name: "example", Namespace: "default", Generation: 2},
+				Spec: actionsv1alpha1.RunnerDeploymentSpec{
+					Replicas: intPtr(2),
+					Template: actionsv1alpha1.RunnerTemplate{
+						Spec: actionsv1alpha1.RunnerSpec{
+							RunnerConfig: actionsv1alpha1.RunnerConfig{Organization: "example", Image: "runner:v2"},
+						},
+					},
+					Strategy: tt.strategy,
+				},
+			}
+
+			outdated := rd.DeepCopy()
+			outdated.Spec.Template.Spec.Image = "runner:v1"
+
+			oldRS, err := newRunnerReplicaSet(outdated, nil, sc)
+			require.NoError(t, err)
+			oldRS.Name = "example-old"
+			oldRS.CreationTimestamp = metav1.NewTime(time.Now().Add(-time.Hour))
+			oldRS.Status = actionsv1alpha1.RunnerReplicaSetStatus{Replicas: intPtr(2), AvailableReplicas: intPtr(2), ReadyReplicas: intPtr(2)}
+
+			newRS, err := newRunnerReplicaSet(rd, nil, sc)
+			require.NoError(t, err)
+			newRS.Name = "example-new"
+			newRS.CreationTimestamp = metav1.NewTime(time.Now().Add(-time.Minute))
+			newRS.Status = actionsv1alpha1.RunnerReplicaSetStatus{Replicas: intPtr(2), AvailableReplicas: intPtr(2), ReadyReplicas: intPtr(2)}
+
+			c := fake.NewClientBuilder().
+				WithScheme(sc).
+				WithStatusSubresource(&actionsv1alpha1.RunnerDeployment{}, &actionsv1alpha1.RunnerReplicaSet{}).
+				WithObjects(rd, oldRS, newRS).
+				WithIndex(&actionsv1alpha1.RunnerReplicaSet{}, runnerSetOwnerKey, runnerReplicaSetOwnerIndexer).
+				Build()
+
+			r := &RunnerDeploymentReconciler{
+				Client:   c,
+				Log:      logr.Discard(),
+				Recorder: events.NewFakeRecorder(10),
+				Scheme:   sc,
+			}
+
+			ctx := context.Background()
+			req := ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: "example"}}
+
+			// The newest runner replica set is fully available, so the outdated one is scaled down at once.
+			res, err := r.Reconcile(ctx, req)
+			require.NoError(t, err)
+			assert.Equal(t, ctrl.Result{}, res, "no progress deadline must be watched")
+
+			var old actionsv1alpha1.RunnerReplicaSet
+			require.NoError(t, c.Get(ctx, types.NamespacedName{Namespace: "default", Name: "example-old"}, &old))
+			assert.Equal(t, 0, *old.Spec.Replicas)
+
+			var got actionsv1alpha1.RunnerDeployment
+			require.NoError(t, c.Get(ctx, req.NamespacedName, &got))
+			assert.Equal(t, int64(2), got.Status.ObservedGeneration)
+			assert.Empty(t, got.Status.Conditions)
+		})
+	}
+}
@@ -0,0 +1,74 @@
+// Package nodedrain provides what the runner controllers share to protect busy runners from eviction
+// and to move idle runners off the nodes that are being drained.
+package nodedrain
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+)
+
+const (
+	// AnnotationKeySafeToEvict tells the cluster autoscaler whether it can evict the pod to remove its node.
+	AnnotationKeySafeToEvict = "cluster-autoscaler.kubernetes.io/safe-to-evict"
+
+	// TaintKeyToBeDeletedByClusterAutoscaler is the taint the cluster autoscaler sets on a node before draining it.
+	TaintKeyToBeDeletedByClusterAutoscaler = "ToBeDeletedByClusterAutoscaler"
+
+	// PodNodeNameKey is the field index of the name of the node a pod is scheduled to.
+	PodNodeNameKey = "spec.nodeName"
+)
+
+// IsDraining returns true when no new pods can be scheduled to the node because it is cordoned,
+// or because the cluster autoscaler is about to remove it.
+func IsDraining(node *corev1.Node) bool {
+	if node.Spec.Unschedulable {
+		return true
+	}
+
+	for _, taint := range node.Spec.Taints {
+		if taint.Key == TaintKeyToBeDeletedByClusterAutoscaler {
+			return true
+		}
+	}
+
+	return false
+}
+
+// PodNodeNameIndexer indexes the pods by PodNodeNameKey.
+func PodNodeNameIndexer(o client.Object) []string {
+	pod, ok := o.(*corev1.Pod)
+	if !ok || pod.Spec.NodeName == "" {
+		return nil
+	}
+
+	return []string{pod.Spec.NodeName}
+}
+
+// DrainingChangedPredicate filters the node events down to the ones where a node starts or stops draining.
+func DrainingChangedPredicate() predicate.Predicate {
+	return predicate.Funcs{
+		CreateFunc: func(e event.CreateEvent) bool {
+			node, ok := e.Object.(*corev1.Node)
+			return ok && IsDraining(node)
+		},
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			oldNode, ok := e.ObjectOld.(*corev1.Node)
+			if !ok {
+				return false
+			}
+			newNode, ok := e.ObjectNew.(*corev1.Node)
+			if !ok {
+				return false
+			}
+			return IsDraining(oldNode) != IsDraining(newNode)
+		},
+		DeleteFunc: func(event.DeleteEvent) bool {
+			return false
+		},
+		GenericFunc: func(event.GenericEvent) bool {
+			return false
+		},
+	}
+}
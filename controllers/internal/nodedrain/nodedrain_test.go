@@ -0,0 +1,73 @@
+package nodedrain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+)
+
+func TestIsDraining(t *testing.T) {
+	tests := []struct {
+		name string
+		node corev1.Node
+		want bool
+	}{
+		{
+			name: "schedulable",
+			node: corev1.Node{},
+			want: false,
+		},
+		{
+			name: "cordoned",
+			node: corev1.Node{Spec: corev1.NodeSpec{Unschedulable: true}},
+			want: true,
+		},
+		{
+			name: "to be deleted by cluster autoscaler",
+			node: corev1.Node{Spec: corev1.NodeSpec{Taints: []corev1.Taint{{Key: TaintKeyToBeDeletedByClusterAutoscaler, Effect: corev1.TaintEffectNoSchedule}}}},
+			want: true,
+		},
+		{
+			name: "other taint",
+			node: corev1.Node{Spec: corev1.NodeSpec{Taints: []corev1.Taint{{Key: "example.com/dedicated", Effect: corev1.TaintEffectNoSchedule}}}},
+			want: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsDraining(&tt.node); got != tt.want {
+				t.Errorf("IsDraining() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPodNodeNameIndexer(t *testing.T) {
+	assert.Equal(t, []string{"node-1"}, PodNodeNameIndexer(&corev1.Pod{Spec: corev1.PodSpec{NodeName: "node-1"}}))
+	assert.Nil(t, PodNodeNameIndexer(&corev1.Pod{}))
+	assert.Nil(t, PodNodeNameIndexer(&corev1.Node{}))
+}
+
+func TestDrainingChangedPredicate(t *testing.T) {
+	p := DrainingChangedPredicate()
+
+	schedulable := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-1"}}
+	cordoned := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-1"}, Spec: corev1.NodeSpec{Unschedulable: true}}
+	toBeDeleted := &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: "node-1"},
+		Spec:       corev1.NodeSpec{Taints: []corev1.Taint{{Key: TaintKeyToBeDeletedByClusterAutoscaler, Effect: corev1.TaintEffectNoSchedule}}},
+	}
+
+	assert.False(t, p.Create(event.CreateEvent{Object: schedulable}))
+	assert.True(t, p.Create(event.CreateEvent{Object: cordoned}))
+
+	assert.True(t, p.Update(event.UpdateEvent{ObjectOld: schedulable, ObjectNew: cordoned}))
+	assert.True(t, p.Update(event.UpdateEvent{ObjectOld: schedulable, ObjectNew: toBeDeleted}))
+	assert.False(t, p.Update(event.UpdateEvent{ObjectOld: cordoned, ObjectNew: toBeDeleted}))
+	assert.False(t, p.Update(event.UpdateEvent{ObjectOld: schedulable, ObjectNew: schedulable}))
+
+	assert.False(t, p.Delete(event.DeleteEvent{Object: cordoned}))
+}
@@ -0,0 +1,359 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/actions/actions-runner-controller/github/metrics"
+	"github.com/go-logr/logr"
+	"github.com/google/go-github/v52/github"
+)
+
+const (
+	inventoryKindRunners                = "runners"
+	inventoryKindQueuedWorkflowRuns     = "queued_workflow_runs"
+	inventoryKindInProgressWorkflowRuns = "in_progress_workflow_runs"
+
+	inventoryPerPage = 100
+)
+
+// Inventory is a shared, periodically refreshed cache of the runners and the pending workflow runs
+// of enterprises, organizations and repositories.
+//
+// Every HorizontalRunnerAutoscaler that reads the same enterprise, organization or repository
+// shares one list, so the number of GitHub API calls no longer grows with the number of autoscalers.
+// Each page of a list is refreshed with a conditional request carrying the ETag of the previous response,
+// so that an unchanged page is answered with 304 Not Modified, which does not count against the rate limit.
+//
+// Inventory implements manager.Runnable. Lists are only refreshed in the background while it is started.
+type Inventory struct {
+	// RefreshInterval is the interval at which lists are refreshed in the background.
+	RefreshInterval time.Duration
+
+	// MaxStaleness is the maximum age of a list that is served without refreshing it first.
+	// Defaults to three times RefreshInterval.
+	MaxStaleness time.Duration
+
+	// IdleTimeout is the duration after which a list that is not read anymore is evicted.
+	// Defaults to ten times RefreshInterval.
+	IdleTimeout time.Duration
+
+	Log logr.Logger
+
+	mu      sync.Mutex
+	entries map[inventoryKey]*inventoryEntry
+
+	// now is overridden in tests
+	now func() time.Time
+}
+
+type inventoryKey struct {
+	kind       string
+	enterprise string
+	org        string
+	repo       string
+}
+
+type inventoryEntry struct {
+	mu sync.Mutex
+
+	// client is the client that last read the list. It is used to refresh the list in the background.
+	client      *Client
+	pages       []inventoryPage
+	refreshedAt time.Time
+	readAt      time.Time
+}
+
+type inventoryPage struct {
+	etag         string
+	nextPage     int
+	runners      []*github.Runner
+	workflowRuns []*github.WorkflowRun
+}
+
+// ListRunners returns the runners of the enterprise, organization or repository like Client.ListRunners does,
+// serving them from the inventory.
+func (i *Inventory) ListRunners(ctx context.Context, c *Client, enterprise, org, repo string) ([]*github.Runner, error) {
+	enterprise, owner, repo, err := getEnterpriseOrganizationAndRepo(enterprise, org, repo)
+	if err != nil {
+		return nil, err
+	}
+
+	pages, err := i.read(ctx, c, inventoryKey{kind: inventoryKindRunners, enterprise: enterprise, org: owner, repo: repo})
+	if err != nil {
+		return nil, err
+	}
+
+	var runners []*github.Runner
+	for _, p := range pages {
+		runners = append(runners, p.runners...)
+	}
+
+	return runners, nil
+}
+
+// ListRepositoryWorkflowRuns returns the queued and in_progress workflow runs of the repository
+// like Client.ListRepositoryWorkflowRuns does, serving them from the inventory.
+func (i *Inventory) ListRepositoryWorkflowRuns(ctx context.Context, c *Client, user string, repoName string) ([]*github.WorkflowRun, error) {
+	queued, err := i.read(ctx, c, inventoryKey{kind: inventoryKindQueuedWorkflowRuns, org: user, repo: repoName})
+	if err != nil {
+		return nil, fmt.Errorf("listing queued workflow runs: %w", err)
+	}
+
+	inProgress, err := i.read(ctx, c, inventoryKey{kind: inventoryKindInProgressWorkflowRuns, org: user, repo: repoName})
+	if err != nil {
+		return nil, fmt.Errorf("listing in_progress workflow runs: %w", err)
+	}
+
+	var workflowRuns []*github.WorkflowRun
+
+	for _, p := range queued {
+		workflowRuns = append(workflowRuns, p.workflowRuns...)
+	}
+
+	for _, p := range inProgress {
+		workflowRuns = append(workflowRuns, p.workflowRuns...)
+	}
+
+	return workflowRuns, nil
+}
+
+// Start refreshes the lists in the inventory every RefreshInterval until the context is done.
+func (i *Inventory) Start(ctx context.Context) error {
+	if i.RefreshInterval <= 0 {
+		return fmt.Errorf("github inventory: refresh interval must be positive, got %s", i.RefreshInterval)
+	}
+
+	metrics.SetInventoryRefreshInterval(i.RefreshInterval)
+
+	ticker := time.NewTicker(i.RefreshInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			i.refreshAll(ctx)
+		}
+	}
+}
+
+func (i *Inventory) read(ctx context.Context, c *Client, key inventoryKey) ([]inventoryPage, error) {
+	i.mu.Lock()
+	if i.entries == nil {
+		i.entries = map[inventoryKey]*inventoryEntry{}
+	}
+	e, ok := i.entries[key]
+	if !ok {
+		e = &inventoryEntry{}
+		i.entries[key] = e
+	}
+	i.mu.Unlock()
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	now := i.timeNow()
+
+	e.client = c
+	e.readAt = now
+
+	if e.refreshedAt.IsZero() || now.Sub(e.refreshedAt) > i.maxStaleness() {
+		if err := i.refresh(ctx, key, e); err != nil {
+			return nil, err
+		}
+	}
+
+	return e.pages, nil
+}
+
+func (i *Inventory) refreshAll(ctx context.Context) {
+	i.mu.Lock()
+	entries := make(map[inventoryKey]*inventoryEntry, len(i.entries))
+	for k, e := range i.entries {
+		entries[k] = e
+	}
+	i.mu.Unlock()
+
+	for key, e := range entries {
+		if ctx.Err() != nil {
+			return
+		}
+
+		e.mu.Lock()
+
+		now := i.timeNow()
+
+		if now.Sub(e.readAt) > i.idleTimeout() {
+			i.mu.Lock()
+			delete(i.entries, key)
+			i.mu.Unlock()
+
+			metrics.DeleteInventoryStaleness(key.kind, key.enterprise, key.org, key.repo)
+
+			e.mu.Unlock()
+			continue
+		}
+
+		if now.Sub(e.refreshedAt) >= i.RefreshInterval {
+			if err := i.refresh(ctx, key, e); err != nil {
+				i.Log.Error(err, "Failed to refresh github inventory", "kind", key.kind, "enterprise", key.enterprise, "organization", key.org, "repository", key.repo)
+			}
+		}
+
+		if !e.refreshedAt.IsZero() {
+			metrics.SetInventoryStaleness(key.kind, key.enterprise, key.org, key.repo, i.timeNow().Sub(e.refreshedAt))
+		}
+
+		e.mu.Unlock()
+	}
+}
+
+// refresh fetches all the pages of the list, reusing the pages the API reports as not modified.
+// It must be called with e.mu held.
+func (i *Inventory) refresh(ctx context.Context, key inventoryKey, e *inventoryEntry) error {
+	start := i.timeNow()
+
+	var (
+		pages      []inventoryPage
+		modified   bool
+		pageNumber = 1
+		pageIndex  int
+	)
+
+	for {
+		var prev *inventoryPage
+		if pageIndex < len(e.pages) {
+			prev = &e.pages[pageIndex]
+		}
+
+		page, notModified, err := fetchInventoryPage(ctx, e.client, key, pageNumber, prev)
+		if err != nil {
+			metrics.ObserveInventoryRefresh(key.kind, metrics.InventoryRefreshResultError, i.timeNow().Sub(start))
+			return err
+		}
+
+		if !notModified {
+			modified = true
+		}
+
+		pages = append(pages, page)
+
+		if page.nextPage == 0 {
+			break
+		}
+
+		pageNumber = page.nextPage
+		pageIndex++
+	}
+
+	// A list that got shorter is reported as modified even when every remaining page was not.
+	if len(pages) != len(e.pages) {
+		modified = true
+	}
+
+	e.pages = pages
+	e.refreshedAt = i.timeNow()
+
+	result := metrics.InventoryRefreshResultNotModified
+	if modified {
+		result = metrics.InventoryRefreshResultOK
+	}
+
+	metrics.ObserveInventoryRefresh(key.kind, result, e.refreshedAt.Sub(start))
+	metrics.SetInventoryStaleness(key.kind, key.enterprise, key.org, key.repo, 0)
+
+	return nil
+}
+
+// fetchInventoryPage fetches a page of the list with a conditional request against the ETag of prev.
+// It returns prev as is, along with true, when the page has not been modified since.
+func fetchInventoryPage(ctx context.Context, c *Client, key inventoryKey, pageNumber int, prev *inventoryPage) (inventoryPage, bool, error) {
+	req, err := c.NewRequest(http.MethodGet, key.url(pageNumber), nil)
+	if err != nil {
+		return inventoryPage{}, false, err
+	}
+
+	if prev != nil && prev.etag != "" {
+		req.Header.Set("If-None-Match", prev.etag)
+	}
+
+	var (
+		runners      github.Runners
+		workflowRuns github.WorkflowRuns
+		v            interface{}
+	)
+
+	if key.kind == inventoryKindRunners {
+		v = &runners
+	} else {
+		v = &workflowRuns
+	}
+
+	resp, err := c.Do(ctx, req, v)
+	if resp != nil && resp.StatusCode == http.StatusNotModified && prev != nil {
+		return *prev, true, nil
+	}
+	if err != nil {
+		return inventoryPage{}, false, fmt.Errorf("listing %s: %w", key.kind, err)
+	}
+
+	page := inventoryPage{
+		etag:         resp.Header.Get("ETag"),
+		nextPage:     resp.NextPage,
+		runners:      runners.Runners,
+		workflowRuns: workflowRuns.WorkflowRuns,
+	}
+
+	// The response may have been revalidated by the caching transport of the client,
+	// in which case it carries the ETag we already have.
+	notModified := prev != nil && prev.etag != "" && prev.etag == page.etag
+
+	return page, notModified, nil
+}
+
+func (k inventoryKey) url(page int) string {
+	switch k.kind {
+	case inventoryKindQueuedWorkflowRuns:
+		return fmt.Sprintf("repos/%v/%v/actions/runs?status=queued&per_page=%d&page=%d", k.org, k.repo, inventoryPerPage, page)
+	case inventoryKindInProgressWorkflowRuns:
+		return fmt.Sprintf("repos/%v/%v/actions/runs?status=in_progress&per_page=%d&page=%d", k.org, k.repo, inventoryPerPage, page)
+	}
+
+	var u string
+	switch {
+	case k.repo != "":
+		u = fmt.Sprintf("repos/%v/%v/actions/runners", k.org, k.repo)
+	case k.org != "":
+		u = fmt.Sprintf("orgs/%v/actions/runners", k.org)
+	default:
+		u = fmt.Sprintf("enterprises/%v/actions/runners", k.enterprise)
+	}
+
+	return fmt.Sprintf("%s?per_page=%d&page=%d", u, inventoryPerPage, page)
+}
+
+func (i *Inventory) maxStaleness() time.Duration {
+	if i.MaxStaleness > 0 {
+		return i.MaxStaleness
+	}
+	return 3 * i.RefreshInterval
+}
+
+func (i *Inventory) idleTimeout() time.Duration {
+	if i.IdleTimeout > 0 {
+		return i.IdleTimeout
+	}
+	return 10 * i.RefreshInterval
+}
+
+func (i *Inventory) timeNow() time.Time {
+	if i.now != nil {
+		return i.now()
+	}
+	return time.Now()
+}
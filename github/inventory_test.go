@@ -0,0 +1,257 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// inventoryServer serves paginated lists with ETags, answering conditional requests with 304 Not Modified.
+type inventoryServer struct {
+	mu sync.Mutex
+
+	// pages holds the body of each page by path
+	pages map[string][]string
+
+	fullResponses        int
+	notModifiedResponses int
+}
+
+func (s *inventoryServer) setPages(path string, pages ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.pages == nil {
+		s.pages = map[string][]string{}
+	}
+	s.pages[path] = pages
+}
+
+func (s *inventoryServer) counts() (int, int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.fullResponses, s.notModifiedResponses
+}
+
+func (s *inventoryServer) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := req.URL.Path
+	if status := req.URL.Query().Get("status"); status != "" {
+		key += "?status=" + status
+	}
+
+	pages, ok := s.pages[key]
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(w, `{"message": "Not Found"}`)
+		return
+	}
+
+	page := 1
+	if p := req.URL.Query().Get("page"); p != "" {
+		fmt.Sscanf(p, "%d", &page)
+	}
+
+	body := pages[page-1]
+	etag := fmt.Sprintf(`"%x"`, body)
+
+	if page < len(pages) {
+		next := *req.URL
+		q := next.Query()
+		q.Set("page", fmt.Sprint(page+1))
+		next.RawQuery = q.Encode()
+		w.Header().Set("Link", fmt.Sprintf(`<%s>; rel="next"`, next.String()))
+	}
+	w.Header().Set("ETag", etag)
+
+	if req.Header.Get("If-None-Match") == etag {
+		s.notModifiedResponses++
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	s.fullResponses++
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprint(w, body)
+}
+
+func newInventoryTestClient(t *testing.T, s *inventoryServer) *Client {
+	t.Helper()
+
+	server := httptest.NewServer(s)
+	t.Cleanup(server.Close)
+
+	c := Config{
+		Token: "token",
+	}
+	client, err := c.NewClient()
+	require.NoError(t, err)
+
+	baseURL, err := url.Parse(server.URL + "/")
+	require.NoError(t, err)
+	client.BaseURL = baseURL
+
+	return client
+}
+
+func runnersPage(ids ...int) string {
+	body := `{"total_count": 0, "runners": [`
+	for i, id := range ids {
+		if i > 0 {
+			body += ","
+		}
+		body += fmt.Sprintf(`{"id": %d, "name": "runner%d", "os": "linux", "status": "online", "busy": false}`, id, id)
+	}
+	return body + `]}`
+}
+
+func workflowRunsPage(ids ...int) string {
+	body := `{"total_count": 0, "workflow_runs": [`
+	for i, id := range ids {
+		if i > 0 {
+			body += ","
+		}
+		body += fmt.Sprintf(`{"id": %d}`, id)
+	}
+	return body + `]}`
+}
+
+func TestInventoryListRunners(t *testing.T) {
+	s := &inventoryServer{}
+	s.setPages("/orgs/test/actions/runners", runnersPage(1, 2), runnersPage(3))
+	client := newInventoryTestClient(t, s)
+
+	now := time.Now()
+	inventory := &Inventory{
+		RefreshInterval: time.Minute,
+		now:             func() time.Time { return now },
+	}
+
+	ids := func(t *testing.T) []int64 {
+		t.Helper()
+		runners, err := inventory.ListRunners(context.Background(), client, "", "test", "")
+		require.NoError(t, err)
+		var ids []int64
+		for _, r := range runners {
+			ids = append(ids, r.GetID())
+		}
+		return ids
+	}
+
+	assert.Equal(t, []int64{1, 2, 3}, ids(t))
+	full, notModified := s.counts()
+	assert.Equal(t, 2, full)
+	assert.Equal(t, 0, notModified)
+
+	// A second autoscaler reading the same organization is served from the inventory
+	now = now.Add(time.Minute)
+	assert.Equal(t, []int64{1, 2, 3}, ids(t))
+	full, notModified = s.counts()
+	assert.Equal(t, 2, full)
+	assert.Equal(t, 0, notModified)
+
+	// A list older than MaxStaleness is revalidated before being served
+	now = now.Add(3 * time.Minute)
+	assert.Equal(t, []int64{1, 2, 3}, ids(t))
+	full, notModified = s.counts()
+	assert.Equal(t, 2, full)
+	assert.Equal(t, 2, notModified)
+
+	// Only the changed page is fetched in full
+	s.setPages("/orgs/test/actions/runners", runnersPage(1, 2), runnersPage(4))
+	now = now.Add(4 * time.Minute)
+	assert.Equal(t, []int64{1, 2, 4}, ids(t))
+	full, notModified = s.counts()
+	assert.Equal(t, 3, full)
+	assert.Equal(t, 3, notModified)
+}
+
+func TestInventoryListRunnersErrors(t *testing.T) {
+	s := &inventoryServer{}
+	client := newInventoryTestClient(t, s)
+
+	inventory := &Inventory{RefreshInterval: time.Minute}
+
+	_, err := inventory.ListRunners(context.Background(), client, "", "", "")
+	assert.Error(t, err)
+
+	_, err = inventory.ListRunners(context.Background(), client, "", "", "invalid")
+	assert.Error(t, err)
+
+	_, err = inventory.ListRunners(context.Background(), client, "", "missing", "")
+	assert.Error(t, err)
+}
+
+func TestInventoryListRepositoryWorkflowRuns(t *testing.T) {
+	s := &inventoryServer{}
+	s.setPages("/repos/test/valid/actions/runs?status=queued", workflowRunsPage(1), workflowRunsPage(2))
+	s.setPages("/repos/test/valid/actions/runs?status=in_progress", workflowRunsPage(3))
+	client := newInventoryTestClient(t, s)
+
+	inventory := &Inventory{RefreshInterval: time.Minute}
+
+	runs, err := inventory.ListRepositoryWorkflowRuns(context.Background(), client, "test", "valid")
+	require.NoError(t, err)
+
+	var ids []int64
+	for _, r := range runs {
+		ids = append(ids, r.GetID())
+	}
+	assert.Equal(t, []int64{1, 2, 3}, ids)
+
+	_, err = inventory.ListRepositoryWorkflowRuns(context.Background(), client, "test", "missing")
+	assert.Error(t, err)
+}
+
+func TestInventoryRefreshAll(t *testing.T) {
+	s := &inventoryServer{}
+	s.setPages("/repos/test/valid/actions/runners", runnersPage(1))
+	s.setPages("/enterprises/test/actions/runners", runnersPage(2))
+	client := newInventoryTestClient(t, s)
+
+	now := time.Now()
+	inventory := &Inventory{
+		RefreshInterval: time.Minute,
+		now:             func() time.Time { return now },
+	}
+
+	_, err := inventory.ListRunners(context.Background(), client, "", "", "test/valid")
+	require.NoError(t, err)
+
+	_, err = inventory.ListRunners(context.Background(), client, "test", "", "")
+	require.NoError(t, err)
+
+	// Keep reading the repository, but not the enterprise
+	for n := 0; n < 10; n++ {
+		now = now.Add(time.Minute)
+		s.setPages("/repos/test/valid/actions/runners", runnersPage(1, 10+n))
+
+		inventory.refreshAll(context.Background())
+
+		runners, err := inventory.ListRunners(context.Background(), client, "", "", "test/valid")
+		require.NoError(t, err)
+		require.Len(t, runners, 2)
+		assert.Equal(t, int64(10+n), runners[1].GetID())
+	}
+
+	now = now.Add(time.Minute)
+	inventory.refreshAll(context.Background())
+
+	inventory.mu.Lock()
+	defer inventory.mu.Unlock()
+
+	_, ok := inventory.entries[inventoryKey{kind: inventoryKindRunners, org: "test", repo: "valid"}]
+	assert.True(t, ok, "the repository list should be kept")
+
+	_, ok = inventory.entries[inventoryKey{kind: inventoryKindRunners, enterprise: "test"}]
+	assert.False(t, ok, "the idle enterprise list should be evicted")
+}
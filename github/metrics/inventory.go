@@ -0,0 +1,68 @@
+package metrics
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	// InventoryRefreshResultOK is the result of a refresh that fetched at least one changed page.
+	InventoryRefreshResultOK = "ok"
+	// InventoryRefreshResultNotModified is the result of a refresh for which every page was answered with 304 Not Modified.
+	InventoryRefreshResultNotModified = "not_modified"
+	// InventoryRefreshResultError is the result of a refresh that failed.
+	InventoryRefreshResultError = "error"
+)
+
+var (
+	metricInventoryRefreshInterval = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "github_inventory_refresh_interval_seconds",
+			Help: "The configured interval at which the GitHub inventory refreshes its lists",
+		},
+	)
+	metricInventoryRefreshesTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "github_inventory_refreshes_total",
+			Help: "The number of GitHub inventory list refreshes by kind and result",
+		},
+		[]string{"kind", "result"},
+	)
+	metricInventoryRefreshDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "github_inventory_refresh_duration_seconds",
+			Help:    "The time taken to refresh a GitHub inventory list, including all its pages",
+			Buckets: prometheus.DefBuckets,
+		},
+		[]string{"kind"},
+	)
+	metricInventoryStaleness = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "github_inventory_staleness_seconds",
+			Help: "The age of the data served by the GitHub inventory for a list",
+		},
+		[]string{"kind", "enterprise", "organization", "repository"},
+	)
+)
+
+// SetInventoryRefreshInterval records the configured refresh interval of the GitHub inventory.
+func SetInventoryRefreshInterval(d time.Duration) {
+	metricInventoryRefreshInterval.Set(d.Seconds())
+}
+
+// ObserveInventoryRefresh records the result and the duration of a refresh of a GitHub inventory list.
+func ObserveInventoryRefresh(kind, result string, d time.Duration) {
+	metricInventoryRefreshesTotal.WithLabelValues(kind, result).Inc()
+	metricInventoryRefreshDuration.WithLabelValues(kind).Observe(d.Seconds())
+}
+
+// SetInventoryStaleness records the age of the data served by the GitHub inventory for a list.
+func SetInventoryStaleness(kind, enterprise, org, repo string, d time.Duration) {
+	metricInventoryStaleness.WithLabelValues(kind, enterprise, org, repo).Set(d.Seconds())
+}
+
+// DeleteInventoryStaleness removes the staleness of a list that is no longer held by the GitHub inventory.
+func DeleteInventoryStaleness(kind, enterprise, org, repo string) {
+	metricInventoryStaleness.DeleteLabelValues(kind, enterprise, org, repo)
+}
@@ -17,7 +17,14 @@ var onceRegister sync.Once
 
 func Register() {
 	onceRegister.Do(func() {
-		metrics.Registry.MustRegister(metricRateLimit, metricRateLimitRemaining)
+		metrics.Registry.MustRegister(
+			metricRateLimit,
+			metricRateLimitRemaining,
+			metricInventoryRefreshInterval,
+			metricInventoryRefreshesTotal,
+			metricInventoryRefreshDuration,
+			metricInventoryStaleness,
+		)
 	})
 }
 
@@ -37,6 +37,7 @@ import (
 	"github.com/kelseyhightower/envconfig"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/discovery"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	_ "k8s.io/client-go/plugin/pkg/client/auth/gcp"
 	"k8s.io/client-go/util/workqueue"
@@ -116,6 +117,12 @@ func main() {
 		k8sClientRateLimiterBurst int
 
 		workqueueRateLimiter string
+
+		runnerEvictionProtection bool
+
+		githubInventoryRefreshInterval time.Duration
+
+		prometheusAddresses stringSlice
 	)
 	var c github.Config
 	err = envconfig.Process("github", &c)
@@ -152,6 +159,9 @@ func main() {
 	flag.IntVar(&port, "port", 9443, "The port to which the admission webhook endpoint should bind")
 	flag.DurationVar(&syncPeriod, "sync-period", 1*time.Minute, "Determines the minimum frequency at which K8s resources managed by this controller are reconciled.")
 	flag.IntVar(&opts.RunnerMaxConcurrentReconciles, "runner-max-concurrent-reconciles", opts.RunnerMaxConcurrentReconciles, "The maximum number of concurrent reconciles which can be run by the EphemeralRunner controller. Increase this value to improve the throughput of the controller, but it may also increase the load on the API server and the external service (e.g. GitHub API).")
+	flag.BoolVar(&runnerEvictionProtection, "runner-eviction-protection", false, "Protect the pods of runners that are running a job from eviction, and replace the idle runners whose pods run on draining nodes. Requires the permission to watch nodes, and to manage poddisruptionbudgets for runner scale sets.")
+	flag.Var(&prometheusAddresses, "prometheus-address", "The base URL of a Prometheus-compatible HTTP API that the External metrics of HorizontalRunnerAutoscalers are allowed to query, like http://prometheus.monitoring:9090. Can be specified multiple times. No Prometheus is queried when unset.")
+	flag.DurationVar(&githubInventoryRefreshInterval, "github-inventory-refresh-interval", 0, "The interval at which the shared inventory of runners and workflow runs used by HorizontalRunnerAutoscalers is refreshed in the background. Set to 0 to let every HorizontalRunnerAutoscaler call the GitHub API on its own.")
 	flag.Var(&commonRunnerLabels, "common-runner-labels", "Runner labels in the K1=V1,K2=V2,... format that are inherited all the runners created by the controller. See https://github.com/actions/actions-runner-controller/issues/321 for more information")
 	flag.StringVar(&namespace, "watch-namespace", "", "The namespace to watch for custom resources. Set to empty for letting it watch for all namespaces.")
 	flag.StringVar(&watchSingleNamespace, "watch-single-namespace", "", "Restrict to watch for custom resources in a single namespace.")
@@ -172,6 +182,13 @@ func main() {
 		fmt.Fprintf(os.Stderr, "Error: creating logger: %v\n", err)
 		os.Exit(1)
 	}
+
+	for _, address := range prometheusAddresses {
+		if err := actionssummerwindnet.ValidatePrometheusAddress(address); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: validating --prometheus-address: %v\n", err)
+			os.Exit(1)
+		}
+	}
 	c.Log = &log
 
 	log.Info("Using options", "runner-max-concurrent-reconciles", opts.RunnerMaxConcurrentReconciles)
@@ -337,21 +354,23 @@ func main() {
 
 		runnerOpts := append(controllerOpts, actionsgithubcom.WithMaxConcurrentReconciles(opts.RunnerMaxConcurrentReconciles))
 		if err = (&actionsgithubcom.EphemeralRunnerReconciler{
-			Client:          mgr.GetClient(),
-			Log:             log.WithName("EphemeralRunner").WithValues("version", build.Version),
-			Scheme:          mgr.GetScheme(),
-			ResourceBuilder: rb,
+			Client:             mgr.GetClient(),
+			Log:                log.WithName("EphemeralRunner").WithValues("version", build.Version),
+			Scheme:             mgr.GetScheme(),
+			EvictionProtection: runnerEvictionProtection,
+			ResourceBuilder:    rb,
 		}).SetupWithManager(mgr, runnerOpts...); err != nil {
 			log.Error(err, "unable to create controller", "controller", "EphemeralRunner")
 			os.Exit(1)
 		}
 
 		if err = (&actionsgithubcom.EphemeralRunnerSetReconciler{
-			Client:          mgr.GetClient(),
-			Log:             log.WithName("EphemeralRunnerSet").WithValues("version", build.Version),
-			Scheme:          mgr.GetScheme(),
-			PublishMetrics:  metricsAddr != "0",
-			ResourceBuilder: rb,
+			Client:             mgr.GetClient(),
+			Log:                log.WithName("EphemeralRunnerSet").WithValues("version", build.Version),
+			Scheme:             mgr.GetScheme(),
+			PublishMetrics:     metricsAddr != "0",
+			EvictionProtection: runnerEvictionProtection,
+			ResourceBuilder:    rb,
 		}).SetupWithManager(mgr, controllerOpts...); err != nil {
 			log.Error(err, "unable to create controller", "controller", "EphemeralRunnerSet")
 			os.Exit(1)
@@ -375,11 +394,12 @@ func main() {
 		)
 
 		runnerReconciler := &actionssummerwindnet.RunnerReconciler{
-			Client:            mgr.GetClient(),
-			Log:               log.WithName("runner"),
-			Scheme:            mgr.GetScheme(),
-			GitHubClient:      multiClient,
-			RunnerPodDefaults: runnerPodDefaults,
+			Client:             mgr.GetClient(),
+			Log:                log.WithName("runner"),
+			Scheme:             mgr.GetScheme(),
+			GitHubClient:       multiClient,
+			EvictionProtection: runnerEvictionProtection,
+			RunnerPodDefaults:  runnerPodDefaults,
 		}
 
 		if err = runnerReconciler.SetupWithManager(mgr); err != nil {
@@ -428,6 +448,7 @@ func main() {
 			"Initializing actions-runner-controller",
 			"version", build.Version,
 			"default-scale-down-delay", defaultScaleDownDelay,
+			"github-inventory-refresh-interval", githubInventoryRefreshInterval,
 			"sync-period", syncPeriod,
 			"default-runner-image", runnerPodDefaults.RunnerImage,
 			"default-docker-image", runnerPodDefaults.DockerImage,
@@ -446,11 +467,34 @@ func main() {
 			DefaultScaleDownDelay: defaultScaleDownDelay,
 		}
 
+		// The discovery client is an unversioned REST client for the API server,
+		// which is what reading the external and custom metrics APIs needs.
+		metricsClient, err := discovery.NewDiscoveryClientForConfig(cfg)
+		if err != nil {
+			log.Error(err, "unable to create client for the metrics APIs")
+			os.Exit(1)
+		}
+		horizontalRunnerAutoscaler.KubernetesMetricsClient = metricsClient.RESTClient()
+		horizontalRunnerAutoscaler.PrometheusAddresses = prometheusAddresses
+
+		if githubInventoryRefreshInterval > 0 {
+			inventory := &github.Inventory{
+				RefreshInterval: githubInventoryRefreshInterval,
+				Log:             log.WithName("githubinventory"),
+			}
+			if err := mgr.Add(inventory); err != nil {
+				log.Error(err, "unable to add github inventory to manager")
+				os.Exit(1)
+			}
+			horizontalRunnerAutoscaler.Inventory = inventory
+		}
+
 		runnerPodReconciler := &actionssummerwindnet.RunnerPodReconciler{
-			Client:       mgr.GetClient(),
-			Log:          log.WithName("runnerpod"),
-			Scheme:       mgr.GetScheme(),
-			GitHubClient: multiClient,
+			Client:             mgr.GetClient(),
+			Log:                log.WithName("runnerpod"),
+			Scheme:             mgr.GetScheme(),
+			GitHubClient:       multiClient,
+			EvictionProtection: runnerEvictionProtection,
 		}
 
 		runnerPersistentVolumeReconciler := &actionssummerwindnet.RunnerPersistentVolumeReconciler{
@@ -2,9 +2,23 @@ package actionsglob
 
 import (
 	"fmt"
+	"regexp"
 	"strings"
+	"sync"
+	"unicode/utf8"
 )
 
+// Match reports whether s matches the pattern, following the filter pattern syntax of GitHub Actions workflows:
+//
+//   - `*` matches zero or more characters but `/`
+//   - `**` matches zero or more of any characters. `**/` also matches no directory at all
+//   - `?` matches zero or one of the preceding character
+//   - `+` matches one or more of the preceding character
+//   - `[]` matches one of the characters or ranges of characters in the brackets, like `[0-9a-f]`
+//   - `\` escapes the following character
+//
+// A pattern starting with `!` matches what the rest of the pattern doesn't.
+// See https://docs.github.com/en/actions/using-workflows/workflow-syntax-for-github-actions#filter-pattern-cheat-sheet
 func Match(pat string, s string) bool {
 	if len(pat) == 0 {
 		panic(fmt.Sprintf("unexpected length of pattern: %d", len(pat)))
@@ -17,62 +31,211 @@ func Match(pat string, s string) bool {
 		inverse = true
 	}
 
-	tokens := strings.SplitAfter(pat, "*")
+	return match(pat, s) != inverse
+}
 
-	var wildcardInHead bool
+// MatchList reports whether s matches the list of patterns evaluated in order, like the branches and paths filters
+// of GitHub Actions workflows. A pattern starting with `!` excludes what the preceding patterns matched,
+// and a pattern without it includes what the preceding patterns excluded, so that the last pattern matching s wins.
+//
+// s matches nothing but what the patterns include, unless the first pattern is a negative one,
+// in which case it matches anything but what the patterns exclude, so that ["!foo"] matches anything but foo
+// like Match("!foo", s). Empty patterns are ignored.
+func MatchList(patterns []string, s string) bool {
+	var (
+		matched bool
+		first   = true
+	)
+
+	for _, pat := range patterns {
+		if pat == "" {
+			continue
+		}
 
-	for i := 0; i < len(tokens); i++ {
-		p := tokens[i]
+		negative := pat[0] == '!'
 
-		if p == "" {
-			s = ""
-			break
+		if first {
+			matched = negative
+			first = false
 		}
 
-		if p == "*" {
-			if i == len(tokens)-1 {
-				s = ""
-				break
+		if negative {
+			if match(pat[1:], s) {
+				matched = false
 			}
+		} else if match(pat, s) {
+			matched = true
+		}
+	}
 
-			wildcardInHead = true
+	return matched
+}
 
-			continue
-		}
+var (
+	cacheMu sync.RWMutex
+	cache   = map[string]*regexp.Regexp{}
+)
 
-		wildcardInTail := p[len(p)-1] == '*'
-		if wildcardInTail {
-			p = p[:len(p)-1]
-		}
+// match reports whether s matches the pattern without the leading `!`.
+// Patterns that can't be translated, like ones with invalid UTF-8, match themselves only.
+func match(pat, s string) bool {
+	if re := compile(pat); re != nil {
+		return re.MatchString(s)
+	}
 
-		subs := strings.SplitN(s, p, 2)
+	return pat == s
+}
 
-		if len(subs) == 0 {
-			break
-		}
+// compile translates the pattern into an anchored regular expression, caching it as the same patterns are matched
+// against every webhook event. It returns nil for the patterns that can't be translated.
+func compile(pat string) *regexp.Regexp {
+	cacheMu.RLock()
+	re, ok := cache[pat]
+	cacheMu.RUnlock()
+
+	if ok {
+		return re
+	}
+
+	if utf8.ValidString(pat) {
+		re, _ = regexp.Compile("^" + translate([]rune(pat)) + "$")
+	}
+
+	cacheMu.Lock()
+	cache[pat] = re
+	cacheMu.Unlock()
+
+	return re
+}
+
+// translate converts the characters of the pattern into a regular expression.
+// Quantifiers following no character, like a leading `?` or one following `*`, match themselves.
+func translate(pat []rune) string {
+	var (
+		b strings.Builder
+
+		// quantifiable is true when the last regular expression written is a single character or class
+		quantifiable bool
+	)
+
+	for i := 0; i < len(pat); i++ {
+		c := pat[i]
+
+		switch c {
+		case '*':
+			quantifiable = false
+
+			if i+1 < len(pat) && pat[i+1] == '*' {
+				i++
 
-		if subs[0] != "" {
-			if !wildcardInHead {
-				break
+				if i+1 < len(pat) && pat[i+1] == '/' {
+					i++
+					b.WriteString(`(?:.*/)?`)
+				} else {
+					b.WriteString(`.*`)
+				}
+
+				continue
+			}
+
+			b.WriteString(`[^/]*`)
+		case '?', '+':
+			if quantifiable {
+				b.WriteRune(c)
+				quantifiable = false
+			} else {
+				b.WriteString(regexp.QuoteMeta(string(c)))
+			}
+		case '[':
+			class, n, ok := translateClass(pat[i+1:])
+			if !ok {
+				// An unclosed bracket matches itself
+				b.WriteString(regexp.QuoteMeta(string(c)))
+				quantifiable = true
+
+				continue
 			}
+
+			b.WriteString(class)
+			i += n
+			quantifiable = true
+		case '\\':
+			if i+1 < len(pat) {
+				i++
+				c = pat[i]
+			}
+
+			b.WriteString(regexp.QuoteMeta(string(c)))
+			quantifiable = true
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+			quantifiable = true
 		}
+	}
+
+	return b.String()
+}
+
+// translateClass converts the characters and ranges of a bracket expression, starting after `[`, into a character class.
+// It returns the number of characters consumed including the closing `]`, or false if the bracket is not closed or empty.
+func translateClass(pat []rune) (string, int, bool) {
+	var (
+		members int
+		b       strings.Builder
+	)
+
+	b.WriteByte('[')
+
+	for i := 0; i < len(pat); i++ {
+		c := pat[i]
 
-		if subs[1] != "" {
-			if !wildcardInTail {
-				break
+		switch c {
+		case ']':
+			if members == 0 {
+				return "", 0, false
+			}
+
+			b.WriteByte(']')
+
+			return b.String(), i + 1, true
+		case '\\':
+			if i+1 < len(pat) {
+				i++
+				c = pat[i]
 			}
 		}
 
-		s = subs[1]
+		members++
+
+		// A range like a-z. A trailing or leading hyphen matches itself
+		if i+2 < len(pat) && pat[i+1] == '-' && pat[i+2] != ']' {
+			hi := pat[i+2]
+			if hi == '\\' && i+3 < len(pat) {
+				hi = pat[i+3]
+				i++
+			}
+			i += 2
+
+			if hi < c {
+				return "", 0, false
+			}
+
+			b.WriteString(quoteClassChar(c) + "-" + quoteClassChar(hi))
+
+			continue
+		}
 
-		wildcardInHead = wildcardInTail
+		b.WriteString(quoteClassChar(c))
 	}
 
-	r := s == ""
+	return "", 0, false
+}
 
-	if inverse {
-		r = !r
+func quoteClassChar(c rune) string {
+	switch c {
+	case '\\', ']', '[', '^', '-':
+		return `\` + string(c)
 	}
 
-	return r
+	return string(c)
 }
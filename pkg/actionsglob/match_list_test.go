@@ -0,0 +1,243 @@
+package actionsglob
+
+import (
+	"strings"
+	"testing"
+)
+
+// filterPatternCheatSheet is the examples of the filter pattern cheat sheet of the workflow syntax for GitHub Actions
+// https://docs.github.com/en/actions/using-workflows/workflow-syntax-for-github-actions#filter-pattern-cheat-sheet
+var filterPatternCheatSheet = []struct {
+	Patterns   []string
+	Matches    []string
+	NonMatches []string
+}{
+	// Patterns to match branches and tags
+	{
+		Patterns:   []string{"feature/*"},
+		Matches:    []string{"feature/my-branch", "feature/your-branch"},
+		NonMatches: []string{"feature/beta-a/my-branch", "feature", "main"},
+	},
+	{
+		Patterns:   []string{"feature/**"},
+		Matches:    []string{"feature/beta-a/my-branch", "feature/your-branch", "feature/mona/the/octocat"},
+		NonMatches: []string{"feature", "features/my-branch"},
+	},
+	{
+		Patterns: []string{"main", "releases/mona-the-octocat"},
+		Matches:  []string{"main", "releases/mona-the-octocat"},
+		// Patterns are matched against the whole name
+		NonMatches: []string{"mainline", "releases/mona"},
+	},
+	{
+		Patterns:   []string{"*"},
+		Matches:    []string{"main", "releases"},
+		NonMatches: []string{"releases/v1", "all/the/branches"},
+	},
+	{
+		Patterns: []string{"**"},
+		Matches:  []string{"all/the/branches", "every/tag", "main"},
+	},
+	{
+		Patterns:   []string{"*feature"},
+		Matches:    []string{"mona-feature", "feature", "ver-10-feature"},
+		NonMatches: []string{"feature-1", "my/feature"},
+	},
+	{
+		Patterns:   []string{"v2*"},
+		Matches:    []string{"v2", "v2.0", "v2.9"},
+		NonMatches: []string{"v1", "v2/hotfix"},
+	},
+	{
+		Patterns:   []string{"v[12].[0-9]+.[0-9]+"},
+		Matches:    []string{"v1.10.1", "v2.0.0"},
+		NonMatches: []string{"v3.0.0", "v1.0", "v1.x.0"},
+	},
+	// Patterns to match file paths
+	{
+		Patterns:   []string{"*"},
+		Matches:    []string{"README.md", "server.rb"},
+		NonMatches: []string{"docs/README.md"},
+	},
+	{
+		Patterns:   []string{"*.jsx?"},
+		Matches:    []string{"page.js", "page.jsx"},
+		NonMatches: []string{"page.jsxx", "js/page.js"},
+	},
+	{
+		Patterns: []string{"**"},
+		Matches:  []string{"index.js", "js/index.js", "src/js/app.js"},
+	},
+	{
+		Patterns:   []string{"*.js"},
+		Matches:    []string{"app.js", "index.js"},
+		NonMatches: []string{"js/index.js", "app.jsx"},
+	},
+	{
+		Patterns:   []string{"**.js"},
+		Matches:    []string{"index.js", "js/index.js", "src/js/app.js"},
+		NonMatches: []string{"index.jsx"},
+	},
+	{
+		Patterns:   []string{"docs/*"},
+		Matches:    []string{"docs/README.md", "docs/file.txt"},
+		NonMatches: []string{"docs/mona/octocat.txt", "README.md"},
+	},
+	{
+		Patterns:   []string{"docs/**"},
+		Matches:    []string{"docs/README.md", "docs/mona/octocat.txt"},
+		NonMatches: []string{"README.md", "src/docs/README.md"},
+	},
+	{
+		Patterns:   []string{"docs/**/*.md"},
+		Matches:    []string{"docs/README.md", "docs/mona/hello-world.md", "docs/a/markdown/file.md"},
+		NonMatches: []string{"docs/mona/hello-world.txt", "README.md"},
+	},
+	{
+		Patterns:   []string{"**/docs/**"},
+		Matches:    []string{"docs/hello.md", "dir/docs/my-file.txt", "space/docs/plan/space.doc"},
+		NonMatches: []string{"mydocs/hello.md", "dir/docs"},
+	},
+	{
+		Patterns:   []string{"**/README.md"},
+		Matches:    []string{"README.md", "js/README.md"},
+		NonMatches: []string{"README.mdx", "js/MY-README.md"},
+	},
+	{
+		Patterns:   []string{"**/*src/**"},
+		Matches:    []string{"a/src/app.js", "my-src/code/js/app.js"},
+		NonMatches: []string{"src", "a/srcs/app.js"},
+	},
+	{
+		Patterns:   []string{"**/*-post.md"},
+		Matches:    []string{"my-post.md", "path/their-post.md"},
+		NonMatches: []string{"post.md", "path/their-post.txt"},
+	},
+	{
+		Patterns:   []string{"**/migrate-*.sql"},
+		Matches:    []string{"migrate-10909.sql", "db/migrate-v1.0.sql", "db/sept/migrate-v1.sql"},
+		NonMatches: []string{"db/migrate/v1.sql", "migrate.sql"},
+	},
+	// Ordered negation
+	{
+		Patterns:   []string{"*.md", "!README.md"},
+		Matches:    []string{"hello.md"},
+		NonMatches: []string{"README.md", "docs/hello.md"},
+	},
+	{
+		Patterns:   []string{"*.md", "!README.md", "README*"},
+		Matches:    []string{"hello.md", "README.md", "README.doc"},
+		NonMatches: []string{"docs/hello.md"},
+	},
+	{
+		Patterns:   []string{"releases/**", "!releases/**-alpha"},
+		Matches:    []string{"releases/10", "releases/beta/mona"},
+		NonMatches: []string{"releases/10-alpha", "releases/beta/3-alpha", "main"},
+	},
+	{
+		Patterns:   []string{"!main"},
+		Matches:    []string{"feature/my-branch", "mainline"},
+		NonMatches: []string{"main"},
+	},
+	// Escaping of the special characters
+	{
+		Patterns:   []string{`releases/\*`},
+		Matches:    []string{"releases/*"},
+		NonMatches: []string{"releases/v1"},
+	},
+	{
+		Patterns:   []string{`\[skip]*`, `\!important`},
+		Matches:    []string{"[skip] docs", "!important"},
+		NonMatches: []string{"s", "important"},
+	},
+	{
+		Patterns:   []string{`v1\.0\+`},
+		Matches:    []string{"v1.0+"},
+		NonMatches: []string{"v1.00", "v1x0"},
+	},
+	// Non-ASCII characters
+	{
+		Patterns:   []string{"feature/é*"},
+		Matches:    []string{"feature/é", "feature/éa", "feature/été"},
+		NonMatches: []string{"feature/e", "feature/a", "feature/é/a"},
+	},
+	{
+		Patterns:   []string{"caf[é]", "caf[à-ê]s", "日本+語?"},
+		Matches:    []string{"café", "cafès", "日本", "日本本語"},
+		NonMatches: []string{"cafe", "cafôs", "日", "日本語語"},
+	},
+}
+
+func TestMatchListFilterPatternCheatSheet(t *testing.T) {
+	for _, tc := range filterPatternCheatSheet {
+		tc := tc
+
+		t.Run(strings.Join(tc.Patterns, ","), func(t *testing.T) {
+			for _, s := range tc.Matches {
+				if !MatchList(tc.Patterns, s) {
+					t.Errorf("%v against %s: want true, got false", tc.Patterns, s)
+				}
+			}
+
+			for _, s := range tc.NonMatches {
+				if MatchList(tc.Patterns, s) {
+					t.Errorf("%v against %s: want false, got true", tc.Patterns, s)
+				}
+			}
+		})
+	}
+}
+
+func TestMatchList(t *testing.T) {
+	testcases := []struct {
+		Patterns []string
+		Target   string
+		Want     bool
+	}{
+		{Patterns: nil, Target: "main", Want: false},
+		{Patterns: []string{""}, Target: "main", Want: false},
+		{Patterns: []string{"", "main"}, Target: "main", Want: true},
+		// The last matching pattern wins
+		{Patterns: []string{"!main", "main"}, Target: "main", Want: true},
+		{Patterns: []string{"main", "!main"}, Target: "main", Want: false},
+		{Patterns: []string{"!main", "!dev"}, Target: "dev", Want: false},
+		{Patterns: []string{"!main", "!dev"}, Target: "feature", Want: true},
+		// * doesn't match across /, unlike **
+		{Patterns: []string{"build*"}, Target: "build / test", Want: false},
+		{Patterns: []string{"build**"}, Target: "build / test", Want: true},
+		{Patterns: []string{"release/*"}, Target: "release/a/b", Want: false},
+		{Patterns: []string{"release/**"}, Target: "release/a/b", Want: true},
+	}
+
+	for _, tc := range testcases {
+		if got := MatchList(tc.Patterns, tc.Target); got != tc.Want {
+			t.Errorf("%v against %s: want %v, got %v", tc.Patterns, tc.Target, tc.Want, got)
+		}
+	}
+}
+
+func TestMatchNonASCII(t *testing.T) {
+	testcases := []struct {
+		Pattern string
+		Target  string
+		Want    bool
+	}{
+		{Pattern: "café", Target: "café", Want: true},
+		{Pattern: "caf[é]", Target: "café", Want: true},
+		{Pattern: "caf[é]", Target: "cafe", Want: false},
+		{Pattern: "feature/é*", Target: "feature/éa", Want: true},
+		{Pattern: "!feature/é*", Target: "feature/éa", Want: false},
+		{Pattern: `caf\é`, Target: "café", Want: true},
+		{Pattern: "é+", Target: "éé", Want: true},
+		{Pattern: "aé?", Target: "a", Want: true},
+		// Patterns with invalid UTF-8 match themselves only
+		{Pattern: "caf\xe9*", Target: "caf\xe9*", Want: true},
+		{Pattern: "caf\xe9*", Target: "caf\xe9a", Want: false},
+	}
+
+	for _, tc := range testcases {
+		if got := Match(tc.Pattern, tc.Target); got != tc.Want {
+			t.Errorf("%q against %q: want %v, got %v", tc.Pattern, tc.Target, tc.Want, got)
+		}
+	}
+}
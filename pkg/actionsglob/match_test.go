@@ -180,11 +180,28 @@ func TestMatch(t *testing.T) {
 		})
 	})
 
+	t.Run("foo (** == foo ( 1 / 2 )", func(t *testing.T) {
+		run(t, testcase{
+			Pattern: "foo (**",
+			Target:  "foo ( 1 / 2 )",
+			Want:    true,
+		})
+	})
+
+	t.Run("!foo (** == foo ( 1 / 2 )", func(t *testing.T) {
+		run(t, testcase{
+			Pattern: "!foo (**",
+			Target:  "foo ( 1 / 2 )",
+			Want:    false,
+		})
+	})
+
+	// * doesn't match /
 	t.Run("foo (* == foo ( 1 / 2 )", func(t *testing.T) {
 		run(t, testcase{
 			Pattern: "foo (*",
 			Target:  "foo ( 1 / 2 )",
-			Want:    true,
+			Want:    false,
 		})
 	})
 
@@ -192,7 +209,7 @@ func TestMatch(t *testing.T) {
 		run(t, testcase{
 			Pattern: "!foo (*",
 			Target:  "foo ( 1 / 2 )",
-			Want:    false,
+			Want:    true,
 		})
 	})
 
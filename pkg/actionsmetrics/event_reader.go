@@ -22,6 +22,19 @@ type EventReader struct {
 	// GitHub Client to fetch information about job failures
 	GitHubClient *github.Client
 
+	// GitHubClients routes the workflow jobs of organizations and enterprises to their own GitHub clients.
+	// GitHubClient is used for the jobs matching no route. The logs of the jobs are not read when neither is set,
+	// and only the metrics available from the webhook events are recorded.
+	GitHubClients *GitHubClientRouter
+
+	// LogAnalyzer extracts step durations, cache hits and misses, and failure categories from the job logs.
+	// Nothing but the queue time, run time and exit code is read from the logs when nil.
+	LogAnalyzer *LogAnalyzer
+
+	// JobStore tracks the times of the events of the in-flight jobs, so that their queue and run durations are observed
+	// even when their logs are not read. Durations are observed only from the logs when nil.
+	JobStore *JobStore
+
 	// Event queue
 	Events chan interface{}
 }
@@ -34,6 +47,19 @@ func (reader *EventReader) HandleWorkflowJobEvent(event interface{}) {
 	reader.Events <- event
 }
 
+// workflowJobEvent is a workflow_job event queued along with the slug of the enterprise it occurred in
+type workflowJobEvent struct {
+	*gogithub.WorkflowJobEvent
+
+	enterpriseSlug string
+}
+
+// HandleWorkflowJobEventOfEnterprise is like HandleWorkflowJobEvent, but also sends the slug of the enterprise
+// the event occurred in, so that the job is routed to the GitHub client of the enterprise.
+func (reader *EventReader) HandleWorkflowJobEventOfEnterprise(e *gogithub.WorkflowJobEvent, enterpriseSlug string) {
+	reader.Events <- &workflowJobEvent{WorkflowJobEvent: e, enterpriseSlug: enterpriseSlug}
+}
+
 // ProcessWorkflowJobEvents pop events in a loop for processing
 //
 // Should be called asynchronously with `go`
@@ -52,9 +78,17 @@ func (reader *EventReader) ProcessWorkflowJobEvents(ctx context.Context) {
 //
 // Events should be processed in the same order that Github emits them
 func (reader *EventReader) ProcessWorkflowJobEvent(ctx context.Context, event interface{}) {
+	var (
+		e          *gogithub.WorkflowJobEvent
+		enterprise string
+	)
 
-	e, ok := event.(*gogithub.WorkflowJobEvent)
-	if !ok {
+	switch ev := event.(type) {
+	case *workflowJobEvent:
+		e, enterprise = ev.WorkflowJobEvent, ev.enterpriseSlug
+	case *gogithub.WorkflowJobEvent:
+		e = ev
+	default:
 		return
 	}
 
@@ -114,6 +148,16 @@ func (reader *EventReader) ProcessWorkflowJobEvent(ctx context.Context, event in
 
 	log := reader.Log.WithValues(keysAndValues...)
 
+	client := reader.gitHubClientFor(e, enterprise)
+	if client == nil && reader.GitHubClients != nil {
+		log.V(1).Info("no GitHub API credentials for the organization or enterprise of the workflow job. Its logs are not read", "enterprise", enterprise)
+	}
+
+	var jobState *JobState
+	if reader.JobStore != nil {
+		jobState = reader.JobStore.Observe(e.GetWorkflowJob().GetID(), e.GetAction(), eventTime(e))
+	}
+
 	// switch on job status
 	switch action := e.GetAction(); action {
 	case "queued":
@@ -122,11 +166,15 @@ func (reader *EventReader) ProcessWorkflowJobEvent(ctx context.Context, event in
 	case "in_progress":
 		githubWorkflowJobsStartedTotal.With(labels).Inc()
 
-		if reader.GitHubClient == nil {
+		if client == nil {
+			if d, ok := jobState.QueueDuration(); ok {
+				githubWorkflowJobQueueDurationSeconds.With(labels).Observe(d.Seconds())
+			}
+
 			return
 		}
 
-		parseResult, err := reader.fetchAndParseWorkflowJobLogs(ctx, e)
+		parseResult, err := reader.fetchAndParseWorkflowJobLogs(ctx, client, e)
 		if err != nil {
 			log.Error(err, "reading workflow job log")
 			return
@@ -143,15 +191,16 @@ func (reader *EventReader) ProcessWorkflowJobEvent(ctx context.Context, event in
 		githubWorkflowJobConclusionsTotal.With(extraLabel("job_conclusion", *e.WorkflowJob.Conclusion, labels)).Inc()
 
 		var (
-			exitCode       = "na"
-			runTimeSeconds *float64
+			exitCode        = "na"
+			failureCategory = "na"
+			runTimeSeconds  *float64
 		)
 
 		// We need to do our best not to fail the whole event processing
 		// when the user provided no GitHub API credentials.
 		// See https://github.com/actions/actions-runner-controller/issues/2424
-		if reader.GitHubClient != nil {
-			parseResult, err := reader.fetchAndParseWorkflowJobLogs(ctx, e)
+		if client != nil {
+			parseResult, err := reader.fetchAndParseWorkflowJobLogs(ctx, client, e)
 			if err != nil {
 				log.Error(err, "reading workflow job log")
 				return
@@ -162,7 +211,17 @@ func (reader *EventReader) ProcessWorkflowJobEvent(ctx context.Context, event in
 			s := parseResult.RunTime.Seconds()
 			runTimeSeconds = &s
 
+			failureCategory = "null"
+			if c := parseResult.Analysis.FailureCategory; c != "" {
+				failureCategory = c
+			}
+
+			observeLogAnalysis(labels, parseResult.Analysis)
+
 			log.WithValues(keysAndValues...).Info("reading workflow_job logs", "exit_code", exitCode)
+		} else if d, ok := jobState.RunDuration(); ok {
+			s := d.Seconds()
+			runTimeSeconds = &s
 		}
 
 		if *e.WorkflowJob.Conclusion == "failure" {
@@ -193,8 +252,10 @@ func (reader *EventReader) ProcessWorkflowJobEvent(ctx context.Context, event in
 				}
 			}
 			githubWorkflowJobFailuresTotal.With(
-				extraLabel("failed_step", failedStep,
-					extraLabel("exit_code", exitCode, labels),
+				extraLabel("failure_category", failureCategory,
+					extraLabel("failed_step", failedStep,
+						extraLabel("exit_code", exitCode, labels),
+					),
 				),
 			).Inc()
 		}
@@ -218,17 +279,53 @@ type ParseResult struct {
 	ExitCode  string
 	QueueTime time.Duration
 	RunTime   time.Duration
+	Analysis  *LogAnalysis
 }
 
 var logLine = regexp.MustCompile(`^(\d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}.\d{7}Z)\s(.+)$`)
 var exitCodeLine = regexp.MustCompile(`##\[error\]Process completed with exit code (\d)\.`)
 
-func (reader *EventReader) fetchAndParseWorkflowJobLogs(ctx context.Context, e *gogithub.WorkflowJobEvent) (*ParseResult, error) {
+// eventTime returns the time of the event from the workflow job, or the current time when the payload lacks it.
+func eventTime(e *gogithub.WorkflowJobEvent) time.Time {
+	var t *gogithub.Timestamp
+
+	switch e.GetAction() {
+	case "queued":
+		t = e.GetWorkflowJob().CreatedAt
+	case "in_progress":
+		t = e.GetWorkflowJob().StartedAt
+	case "completed":
+		t = e.GetWorkflowJob().CompletedAt
+	}
+
+	if t == nil || t.IsZero() {
+		return time.Now()
+	}
+
+	return t.Time
+}
+
+// gitHubClientFor returns the client routed for the organization or the enterprise of the event, or GitHubClient.
+// The organization is the owner of the repository when the event lacks one.
+func (reader *EventReader) gitHubClientFor(e *gogithub.WorkflowJobEvent, enterprise string) *github.Client {
+	org := e.GetOrg().GetLogin()
+	if org == "" {
+		org = e.GetRepo().GetOwner().GetLogin()
+	}
+
+	if c := reader.GitHubClients.Route(org, enterprise); c != nil {
+		return c
+	}
+
+	return reader.GitHubClient
+}
+
+func (reader *EventReader) fetchAndParseWorkflowJobLogs(ctx context.Context, client *github.Client, e *gogithub.WorkflowJobEvent) (*ParseResult, error) {
 
 	owner := *e.Repo.Owner.Login
 	repo := *e.Repo.Name
 	id := *e.WorkflowJob.ID
-	url, _, err := reader.GitHubClient.Actions.GetWorkflowJobLogs(ctx, owner, repo, id, true)
+	url, _, err := client.Actions.GetWorkflowJobLogs(ctx, owner, repo, id, true)
 	if err != nil {
 		return nil, err
 	}
@@ -239,6 +336,8 @@ func (reader *EventReader) fetchAndParseWorkflowJobLogs(ctx context.Context, e *
 
 	exitCode := "null"
 
+	analysis := reader.LogAnalyzer.newLogAnalysis()
+
 	var (
 		queuedTime    time.Time
 		startedTime   time.Time
@@ -259,6 +358,9 @@ func (reader *EventReader) fetchAndParseWorkflowJobLogs(ctx context.Context, e *
 			timestamp := matches[1]
 			line := matches[2]
 
+			t, _ := time.Parse(time.RFC3339, timestamp)
+			analysis.add(t, line)
+
 			if strings.HasPrefix(line, "##[error]") {
 				// Get exit code
 				exitCodeMatch := exitCodeLine.FindStringSubmatch(line)
@@ -287,5 +389,22 @@ func (reader *EventReader) fetchAndParseWorkflowJobLogs(ctx context.Context, e *
 		ExitCode:  exitCode,
 		QueueTime: startedTime.Sub(queuedTime),
 		RunTime:   completedTime.Sub(startedTime),
+		Analysis:  analysis.finish(),
 	}, nil
 }
+
+func observeLogAnalysis(labels prometheus.Labels, a *LogAnalysis) {
+	for step, durations := range a.StepDurations {
+		for _, d := range durations {
+			githubWorkflowJobStepDurationSeconds.With(extraLabel("step", step, labels)).Observe(d.Seconds())
+		}
+	}
+
+	if a.CacheHits > 0 {
+		githubWorkflowJobCacheRequestsTotal.With(extraLabel("cache_result", CacheResultHit, labels)).Add(float64(a.CacheHits))
+	}
+
+	if a.CacheMisses > 0 {
+		githubWorkflowJobCacheRequestsTotal.With(extraLabel("cache_result", CacheResultMiss, labels)).Add(float64(a.CacheMisses))
+	}
+}
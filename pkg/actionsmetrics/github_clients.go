@@ -0,0 +1,190 @@
+package actionsmetrics
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"sigs.k8s.io/yaml"
+
+	"github.com/actions/actions-runner-controller/github"
+)
+
+// GitHubClientRoutes configures the GitHub API credentials to read the workflow job logs with, per organization or enterprise.
+type GitHubClientRoutes struct {
+	Routes []GitHubClientRoute `json:"routes"`
+}
+
+// GitHubClientRoute routes the workflow jobs of either an organization or an enterprise, by slug, to credentials.
+//
+// CredentialsDir is a directory with a file per key of the Secret referenced by githubAPICredentialsFrom of runners,
+// like github_token, or github_app_id, github_app_installation_id and github_app_private_key, usually a mounted Secret.
+type GitHubClientRoute struct {
+	Organization   string `json:"organization,omitempty"`
+	Enterprise     string `json:"enterprise,omitempty"`
+	CredentialsDir string `json:"credentialsDir"`
+}
+
+// GitHubClientRouter selects the GitHub client for a workflow job by the organization or enterprise it belongs to.
+// Organization routes take precedence over enterprise routes.
+type GitHubClientRouter struct {
+	organizations map[string]*github.Client
+	enterprises   map[string]*github.Client
+}
+
+// NewGitHubClientRouter creates a client per route. The URLs of base are used for the routes whose credentials don't set them.
+func NewGitHubClientRouter(routes GitHubClientRoutes, base github.Config) (*GitHubClientRouter, error) {
+	r := &GitHubClientRouter{
+		organizations: map[string]*github.Client{},
+		enterprises:   map[string]*github.Client{},
+	}
+
+	for i, route := range routes.Routes {
+		if err := r.add(route, base); err != nil {
+			return nil, fmt.Errorf("routes[%d]: %w", i, err)
+		}
+	}
+
+	return r, nil
+}
+
+func (r *GitHubClientRouter) add(route GitHubClientRoute, base github.Config) error {
+	var (
+		clients map[string]*github.Client
+		slug    string
+	)
+
+	switch {
+	case route.Organization != "" && route.Enterprise != "":
+		return fmt.Errorf("only one of organization and enterprise can be set")
+	case route.Organization != "":
+		clients, slug = r.organizations, route.Organization
+	case route.Enterprise != "":
+		clients, slug = r.enterprises, route.Enterprise
+	default:
+		return fmt.Errorf("either organization or enterprise is required")
+	}
+
+	slug = strings.ToLower(slug)
+
+	if _, ok := clients[slug]; ok {
+		return fmt.Errorf("duplicate route for %q", slug)
+	}
+
+	if route.CredentialsDir == "" {
+		return fmt.Errorf("credentialsDir is required")
+	}
+
+	conf, err := loadGitHubCredentials(route.CredentialsDir, base)
+	if err != nil {
+		return fmt.Errorf("credentialsDir: %w", err)
+	}
+
+	client, err := conf.NewClient()
+	if err != nil {
+		return fmt.Errorf("creating client: %w", err)
+	}
+
+	clients[slug] = client
+
+	return nil
+}
+
+// Route returns the client of the organization, or of the enterprise when the organization has no route.
+// It returns nil when neither has a route.
+func (r *GitHubClientRouter) Route(organization, enterprise string) *github.Client {
+	if r == nil {
+		return nil
+	}
+
+	if c, ok := r.organizations[strings.ToLower(organization)]; ok && organization != "" {
+		return c
+	}
+
+	if c, ok := r.enterprises[strings.ToLower(enterprise)]; ok && enterprise != "" {
+		return c
+	}
+
+	return nil
+}
+
+// loadGitHubCredentials reads the credentials from the files of dir named after the keys of githubAPICredentialsFrom secrets.
+func loadGitHubCredentials(dir string, base github.Config) (*github.Config, error) {
+	read := func(key string) (string, error) {
+		data, err := os.ReadFile(filepath.Join(dir, key))
+		if os.IsNotExist(err) {
+			return "", nil
+		} else if err != nil {
+			return "", err
+		}
+
+		return strings.TrimSpace(string(data)), nil
+	}
+
+	conf := github.Config{
+		EnterpriseURL: base.EnterpriseURL,
+		URL:           base.URL,
+		UploadURL:     base.UploadURL,
+		Log:           base.Log,
+	}
+
+	for key, v := range map[string]*string{
+		"github_enterprise_url":  &conf.EnterpriseURL,
+		"github_url":             &conf.URL,
+		"github_upload_url":      &conf.UploadURL,
+		"github_token":           &conf.Token,
+		"github_app_private_key": &conf.AppPrivateKey,
+	} {
+		value, err := read(key)
+		if err != nil {
+			return nil, err
+		}
+
+		if value != "" {
+			*v = value
+		}
+	}
+
+	for key, v := range map[string]*int64{
+		"github_app_id":              &conf.AppID,
+		"github_app_installation_id": &conf.AppInstallationID,
+	} {
+		value, err := read(key)
+		if err != nil {
+			return nil, err
+		}
+
+		if value == "" {
+			continue
+		}
+
+		*v, err = strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", key, err)
+		}
+	}
+
+	if conf.Token == "" && (conf.AppID == 0 || conf.AppInstallationID == 0 || conf.AppPrivateKey == "") {
+		return nil, fmt.Errorf("%s contains neither github_token nor github_app_id, github_app_installation_id and github_app_private_key", dir)
+	}
+
+	return &conf, nil
+}
+
+// LoadGitHubClientRoutes reads the routes from the YAML or JSON file.
+func LoadGitHubClientRoutes(path string) (GitHubClientRoutes, error) {
+	var routes GitHubClientRoutes
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return routes, err
+	}
+
+	if err := yaml.UnmarshalStrict(data, &routes); err != nil {
+		return routes, fmt.Errorf("parsing %s: %w", path, err)
+	}
+
+	return routes, nil
+}
@@ -0,0 +1,175 @@
+package actionsmetrics
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/actions/actions-runner-controller/github"
+	"github.com/go-logr/logr"
+	gogithub "github.com/google/go-github/v52/github"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeTestCredentials(t *testing.T, data map[string]string) string {
+	t.Helper()
+
+	dir := t.TempDir()
+
+	for k, v := range data {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, k), []byte(v+"\n"), 0o600))
+	}
+
+	return dir
+}
+
+func TestNewGitHubClientRouterErrors(t *testing.T) {
+	creds := writeTestCredentials(t, map[string]string{"github_token": "token"})
+	noCreds := writeTestCredentials(t, map[string]string{"github_app_id": "1"})
+	badAppID := writeTestCredentials(t, map[string]string{"github_app_id": "one"})
+
+	testcases := []struct {
+		routes []GitHubClientRoute
+		err    string
+	}{
+		{routes: []GitHubClientRoute{{CredentialsDir: creds}}, err: "routes[0]: either organization or enterprise is required"},
+		{routes: []GitHubClientRoute{{Organization: "a", Enterprise: "b", CredentialsDir: creds}}, err: "routes[0]: only one of organization and enterprise can be set"},
+		{routes: []GitHubClientRoute{{Organization: "a"}}, err: "routes[0]: credentialsDir is required"},
+		{routes: []GitHubClientRoute{{Organization: "a", CredentialsDir: creds}, {Organization: "A", CredentialsDir: creds}}, err: `routes[1]: duplicate route for "a"`},
+		{routes: []GitHubClientRoute{{Enterprise: "a", CredentialsDir: noCreds}}, err: fmt.Sprintf("routes[0]: credentialsDir: %s contains neither github_token nor github_app_id, github_app_installation_id and github_app_private_key", noCreds)},
+		{routes: []GitHubClientRoute{{Enterprise: "a", CredentialsDir: badAppID}}, err: `routes[0]: credentialsDir: github_app_id: strconv.ParseInt: parsing "one": invalid syntax`},
+	}
+
+	for _, tc := range testcases {
+		_, err := NewGitHubClientRouter(GitHubClientRoutes{Routes: tc.routes}, github.Config{})
+		assert.EqualError(t, err, tc.err)
+	}
+}
+
+func TestLoadGitHubClientRoutes(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "routes.yaml")
+
+	require.NoError(t, os.WriteFile(path, []byte(`
+routes:
+- organization: org-a
+  credentialsDir: /etc/org-a
+- enterprise: ent
+  credentialsDir: /etc/ent
+`), 0o644))
+
+	routes, err := LoadGitHubClientRoutes(path)
+	require.NoError(t, err)
+	assert.Equal(t, []GitHubClientRoute{
+		{Organization: "org-a", CredentialsDir: "/etc/org-a"},
+		{Enterprise: "ent", CredentialsDir: "/etc/ent"},
+	}, routes.Routes)
+
+	require.NoError(t, os.WriteFile(path, []byte("routes:\n- org: org-a\n"), 0o644))
+
+	_, err = LoadGitHubClientRoutes(path)
+	assert.ErrorContains(t, err, `unknown field "org"`)
+}
+
+func TestEventReaderRoutesGitHubClients(t *testing.T) {
+	var (
+		mu     sync.Mutex
+		tokens = map[string]string{}
+	)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repos/", func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		tokens[r.URL.Path] = r.Header.Get("Authorization")
+		mu.Unlock()
+
+		http.Redirect(w, r, "http://"+r.Host+"/logs", http.StatusFound)
+	})
+	mux.HandleFunc("/logs", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, testJobLog)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	router, err := NewGitHubClientRouter(GitHubClientRoutes{Routes: []GitHubClientRoute{
+		{Organization: "Org-A", CredentialsDir: writeTestCredentials(t, map[string]string{"github_token": "org-a-token"})},
+		{Enterprise: "ent", CredentialsDir: writeTestCredentials(t, map[string]string{"github_token": "ent-token"})},
+	}}, github.Config{URL: server.URL})
+	require.NoError(t, err)
+
+	process := func(reader *EventReader, id int64, owner, enterprise string) {
+		reader.ProcessWorkflowJobEvent(context.Background(), &workflowJobEvent{
+			WorkflowJobEvent: &gogithub.WorkflowJobEvent{
+				Action: gogithub.String("in_progress"),
+				WorkflowJob: &gogithub.WorkflowJob{
+					ID:   gogithub.Int64(id),
+					Name: gogithub.String("test-routing"),
+				},
+				Repo: &gogithub.Repository{
+					Name:     gogithub.String("repo"),
+					FullName: gogithub.String(owner + "/repo"),
+					Owner:    &gogithub.User{Login: gogithub.String(owner)},
+				},
+			},
+			enterpriseSlug: enterprise,
+		})
+	}
+
+	reader := &EventReader{Log: logr.Discard(), GitHubClients: router}
+
+	// The organization route takes precedence over the enterprise one
+	process(reader, 1, "org-a", "ent")
+	process(reader, 2, "org-b", "ent")
+	// Neither matches, and the job is left to webhook-only metrics
+	process(reader, 3, "org-c", "other")
+
+	assert.Equal(t, map[string]string{
+		"/repos/org-a/repo/actions/jobs/1/logs": "Bearer org-a-token",
+		"/repos/org-b/repo/actions/jobs/2/logs": "Bearer ent-token",
+	}, tokens)
+
+	c := github.Config{Token: "default-token", URL: server.URL}
+	reader.GitHubClient, err = c.NewClient()
+	require.NoError(t, err)
+
+	process(reader, 4, "org-c", "other")
+	assert.Equal(t, "Bearer default-token", tokens["/repos/org-c/repo/actions/jobs/4/logs"])
+}
+
+func TestWebhookServerDecodesEnterpriseSlug(t *testing.T) {
+	var (
+		events      []interface{}
+		enterprises []string
+	)
+
+	server := &WebhookServer{
+		Log:        logr.Discard(),
+		EventHooks: []EventHook{func(e interface{}) { events = append(events, e) }},
+		WorkflowJobEventHooks: []WorkflowJobEventHook{func(e *gogithub.WorkflowJobEvent, enterpriseSlug string) {
+			enterprises = append(enterprises, enterpriseSlug)
+		}},
+	}
+
+	r := httptest.NewRequest(http.MethodPost, "/", bytes.NewBufferString(`{"action":"queued","workflow_job":{"id":1},"enterprise":{"slug":"ent"}}`))
+	r.Header.Set("X-GitHub-Event", "workflow_job")
+	r.Header.Set("Content-Type", "application/json")
+
+	w := httptest.NewRecorder()
+	server.Handle(w, r)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Len(t, events, 1)
+
+	// EventHooks are still sent the events decoded by go-github
+	e, ok := events[0].(*gogithub.WorkflowJobEvent)
+	require.True(t, ok)
+	assert.Equal(t, int64(1), e.GetWorkflowJob().GetID())
+
+	assert.Equal(t, []string{"ent"}, enterprises)
+}
@@ -0,0 +1,464 @@
+package actionsmetrics
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// DefaultJobStateTTL is how long a job is tracked without any event before it is forgotten.
+// Jobs can stay queued for up to 24 hours.
+const DefaultJobStateTTL = 24 * time.Hour
+
+// JobState is the times of the queued, in_progress and completed events of a workflow job.
+type JobState struct {
+	QueuedAt    time.Time `json:"queuedAt,omitempty"`
+	StartedAt   time.Time `json:"startedAt,omitempty"`
+	CompletedAt time.Time `json:"completedAt,omitempty"`
+
+	// UpdatedAt is when the last event of the job was received, which the TTL is counted from.
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// QueueDuration returns the time the job spent queued, when both the queued and in_progress events were tracked.
+func (s *JobState) QueueDuration() (time.Duration, bool) {
+	if s == nil || s.QueuedAt.IsZero() || s.StartedAt.IsZero() {
+		return 0, false
+	}
+
+	return s.StartedAt.Sub(s.QueuedAt), true
+}
+
+// RunDuration returns the time the job spent running, when both the in_progress and completed events were tracked.
+func (s *JobState) RunDuration() (time.Duration, bool) {
+	if s == nil || s.StartedAt.IsZero() || s.CompletedAt.IsZero() {
+		return 0, false
+	}
+
+	return s.CompletedAt.Sub(s.StartedAt), true
+}
+
+// JobSnapshotter persists the states of the in-flight jobs of a JobStore.
+// Save is passed the IDs of the jobs updated and forgotten since the last save along with the tracked jobs,
+// so that a snapshotter shared by several stores can apply the changes while keeping the jobs of the other stores.
+type JobSnapshotter interface {
+	Load(ctx context.Context) (map[int64]JobState, error)
+	Save(ctx context.Context, jobs map[int64]JobState, updated, forgotten []int64) error
+}
+
+// JobStore tracks the in-flight workflow jobs by ID, so that their queue and run durations can be computed
+// from the webhook events alone. A job is forgotten once completed, or after TTL without any event.
+// The jobs are kept in memory, and saved by Snapshotter, if any, so that they survive restarts.
+type JobStore struct {
+	Log         logr.Logger
+	TTL         time.Duration
+	Snapshotter JobSnapshotter
+
+	mu   sync.Mutex
+	jobs map[int64]JobState
+
+	// changes is whether each job changed since the last snapshot was updated (true) or forgotten (false)
+	changes map[int64]bool
+
+	now func() time.Time
+}
+
+// NewJobStore returns an empty JobStore.
+func NewJobStore(log logr.Logger, ttl time.Duration, snapshotter JobSnapshotter) *JobStore {
+	if ttl <= 0 {
+		ttl = DefaultJobStateTTL
+	}
+
+	return &JobStore{
+		Log:         log,
+		TTL:         ttl,
+		Snapshotter: snapshotter,
+		jobs:        map[int64]JobState{},
+		changes:     map[int64]bool{},
+		now:         time.Now,
+	}
+}
+
+// Observe records the time of the event of the job, and returns the state of the job including it.
+// The job is forgotten on the completed event.
+func (s *JobStore) Observe(id int64, action string, t time.Time) *JobState {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	state := s.jobs[id]
+
+	switch action {
+	case "queued":
+		state.QueuedAt = t
+	case "in_progress":
+		state.StartedAt = t
+	case "completed":
+		state.CompletedAt = t
+	default:
+		return &state
+	}
+
+	state.UpdatedAt = s.now()
+
+	if action == "completed" {
+		delete(s.jobs, id)
+		s.changes[id] = false
+	} else {
+		s.jobs[id] = state
+		s.changes[id] = true
+	}
+
+	githubWorkflowJobStateStoreJobs.Set(float64(len(s.jobs)))
+
+	return &state
+}
+
+// Len returns the number of jobs tracked.
+func (s *JobStore) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return len(s.jobs)
+}
+
+// expire forgets the jobs that had no event for TTL.
+func (s *JobStore) expire() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+
+	for id, state := range s.jobs {
+		if now.Sub(state.UpdatedAt) > s.TTL {
+			delete(s.jobs, id)
+			s.changes[id] = false
+
+			githubWorkflowJobStateStoreExpiredTotal.Inc()
+		}
+	}
+
+	githubWorkflowJobStateStoreJobs.Set(float64(len(s.jobs)))
+}
+
+// Load restores the jobs saved by the snapshotter, dropping the expired ones.
+func (s *JobStore) Load(ctx context.Context) error {
+	if s.Snapshotter == nil {
+		return nil
+	}
+
+	jobs, err := s.Snapshotter.Load(ctx)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	for id, state := range jobs {
+		s.jobs[id] = state
+	}
+	s.mu.Unlock()
+
+	s.expire()
+
+	return nil
+}
+
+// Snapshot saves the jobs with the snapshotter, if they changed since the last snapshot.
+func (s *JobStore) Snapshot(ctx context.Context) error {
+	if s.Snapshotter == nil {
+		return nil
+	}
+
+	s.mu.Lock()
+	if len(s.changes) == 0 {
+		s.mu.Unlock()
+		return nil
+	}
+
+	jobs := make(map[int64]JobState, len(s.jobs))
+	for id, state := range s.jobs {
+		jobs[id] = state
+	}
+
+	var updated, forgotten []int64
+	for id, isUpdated := range s.changes {
+		if isUpdated {
+			updated = append(updated, id)
+		} else {
+			forgotten = append(forgotten, id)
+		}
+	}
+	changes := s.changes
+	s.changes = map[int64]bool{}
+	s.mu.Unlock()
+
+	if err := s.Snapshotter.Save(ctx, jobs, updated, forgotten); err != nil {
+		s.mu.Lock()
+		// Keep the changes made since for the next snapshot
+		for id, isUpdated := range changes {
+			if _, ok := s.changes[id]; !ok {
+				s.changes[id] = isUpdated
+			}
+		}
+		s.mu.Unlock()
+
+		return err
+	}
+
+	return nil
+}
+
+// Run expires the jobs and snapshots the store every interval until ctx is done, and snapshots it one last time.
+func (s *JobStore) Run(ctx context.Context, interval time.Duration) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-t.C:
+			s.expire()
+
+			if err := s.Snapshot(ctx); err != nil {
+				s.Log.Error(err, "saving workflow job states")
+			}
+		case <-ctx.Done():
+			if err := s.Snapshot(context.Background()); err != nil {
+				s.Log.Error(err, "saving workflow job states")
+			}
+
+			return
+		}
+	}
+}
+
+type jobSnapshot struct {
+	Jobs map[string]JobState `json:"jobs"`
+}
+
+func marshalJobs(jobs map[int64]JobState) ([]byte, error) {
+	snapshot := jobSnapshot{Jobs: make(map[string]JobState, len(jobs))}
+
+	for id, state := range jobs {
+		snapshot.Jobs[strconv.FormatInt(id, 10)] = state
+	}
+
+	return json.Marshal(snapshot)
+}
+
+func unmarshalJobs(data []byte) (map[int64]JobState, error) {
+	var snapshot jobSnapshot
+
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return nil, err
+	}
+
+	jobs := make(map[int64]JobState, len(snapshot.Jobs))
+
+	for k, state := range snapshot.Jobs {
+		id, err := strconv.ParseInt(k, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("parsing job id %q: %w", k, err)
+		}
+
+		jobs[id] = state
+	}
+
+	return jobs, nil
+}
+
+// FileJobSnapshotter saves the jobs to a local file, like one on a persistent volume.
+type FileJobSnapshotter struct {
+	Path string
+}
+
+func (f *FileJobSnapshotter) Load(ctx context.Context) (map[int64]JobState, error) {
+	data, err := os.ReadFile(f.Path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	jobs, err := unmarshalJobs(data)
+	if err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", f.Path, err)
+	}
+
+	return jobs, nil
+}
+
+// Save writes the jobs to a temporary file renamed to Path, so that a crash never leaves a partial snapshot.
+// The file is owned by a single store, and is overwritten with its jobs.
+func (f *FileJobSnapshotter) Save(ctx context.Context, jobs map[int64]JobState, _, _ []int64) error {
+	data, err := marshalJobs(jobs)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(f.Path), filepath.Base(f.Path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), f.Path)
+}
+
+// configMapJobSnapshotKey is the key of the ConfigMap data the jobs are saved to.
+const configMapJobSnapshotKey = "jobs.json"
+
+// ConfigMapJobSnapshotter saves the jobs to a ConfigMap. As ConfigMaps are limited to 1 MiB,
+// it suits up to several thousands of in-flight jobs.
+// The ConfigMap can be shared by the replicas of the actions-metrics-server, as Save merges the jobs into the saved ones.
+type ConfigMapJobSnapshotter struct {
+	Name      string
+	Namespace string
+	Client    client.Client
+
+	// TTL is how long a saved job is kept without any event, so that the jobs of other replicas
+	// whose completed event was lost are dropped on save. Defaults to DefaultJobStateTTL.
+	TTL time.Duration
+}
+
+func (c *ConfigMapJobSnapshotter) Load(ctx context.Context) (map[int64]JobState, error) {
+	var cm corev1.ConfigMap
+
+	if err := c.Client.Get(ctx, types.NamespacedName{Namespace: c.Namespace, Name: c.Name}, &cm); err != nil {
+		if kerrors.IsNotFound(err) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	data, ok := cm.Data[configMapJobSnapshotKey]
+	if !ok {
+		return nil, nil
+	}
+
+	jobs, err := unmarshalJobs([]byte(data))
+	if err != nil {
+		return nil, fmt.Errorf("parsing configmap %s/%s: %w", c.Namespace, c.Name, err)
+	}
+
+	return jobs, nil
+}
+
+// Save merges the jobs into the ones saved to the ConfigMap, creating it if it does not exist.
+// Only the updated and forgotten jobs are changed, so that the jobs saved by the other replicas are kept until
+// they had no event for TTL, and the states of a job whose events were received by several replicas are combined by mergeJobState.
+func (c *ConfigMapJobSnapshotter) Save(ctx context.Context, jobs map[int64]JobState, updated, forgotten []int64) error {
+	conflict := func(err error) bool {
+		return kerrors.IsConflict(err) || kerrors.IsAlreadyExists(err)
+	}
+
+	return retry.OnError(retry.DefaultRetry, conflict, func() error {
+		var cm corev1.ConfigMap
+
+		if err := c.Client.Get(ctx, types.NamespacedName{Namespace: c.Namespace, Name: c.Name}, &cm); err != nil {
+			if !kerrors.IsNotFound(err) {
+				return err
+			}
+
+			data, err := marshalJobs(jobs)
+			if err != nil {
+				return err
+			}
+
+			cm = corev1.ConfigMap{}
+			cm.Name = c.Name
+			cm.Namespace = c.Namespace
+			cm.Data = map[string]string{configMapJobSnapshotKey: string(data)}
+
+			return c.Client.Create(ctx, &cm)
+		}
+
+		merged := map[int64]JobState{}
+
+		if saved, ok := cm.Data[configMapJobSnapshotKey]; ok {
+			var err error
+
+			merged, err = unmarshalJobs([]byte(saved))
+			if err != nil {
+				return fmt.Errorf("parsing configmap %s/%s: %w", c.Namespace, c.Name, err)
+			}
+		}
+
+		for _, id := range forgotten {
+			delete(merged, id)
+		}
+
+		ttl := c.TTL
+		if ttl <= 0 {
+			ttl = DefaultJobStateTTL
+		}
+
+		now := time.Now()
+
+		for id, state := range merged {
+			if now.Sub(state.UpdatedAt) > ttl {
+				delete(merged, id)
+			}
+		}
+
+		for _, id := range updated {
+			if state, ok := jobs[id]; ok {
+				merged[id] = mergeJobState(merged[id], state)
+			}
+		}
+
+		data, err := marshalJobs(merged)
+		if err != nil {
+			return err
+		}
+
+		if cm.Data == nil {
+			cm.Data = map[string]string{}
+		}
+		cm.Data[configMapJobSnapshotKey] = string(data)
+
+		return c.Client.Update(ctx, &cm)
+	})
+}
+
+// mergeJobState combines the saved state of a job with the one of a store, which may have received other events of the job.
+// The times tracked by the store win, and the missing ones are taken from the saved state.
+func mergeJobState(saved, state JobState) JobState {
+	if state.QueuedAt.IsZero() {
+		state.QueuedAt = saved.QueuedAt
+	}
+
+	if state.StartedAt.IsZero() {
+		state.StartedAt = saved.StartedAt
+	}
+
+	if state.CompletedAt.IsZero() {
+		state.CompletedAt = saved.CompletedAt
+	}
+
+	if saved.UpdatedAt.After(state.UpdatedAt) {
+		state.UpdatedAt = saved.UpdatedAt
+	}
+
+	return state
+}
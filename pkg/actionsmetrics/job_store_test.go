@@ -0,0 +1,248 @@
+package actionsmetrics
+
+import (
+	"context"
+	"maps"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	gogithub "github.com/google/go-github/v52/github"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestJobStore(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	now := start
+
+	s := NewJobStore(logr.Discard(), time.Hour, nil)
+	s.now = func() time.Time { return now }
+
+	state := s.Observe(1, "queued", start)
+	_, ok := state.QueueDuration()
+	assert.False(t, ok)
+
+	s.Observe(2, "queued", start)
+
+	state = s.Observe(1, "in_progress", start.Add(time.Minute))
+	d, ok := state.QueueDuration()
+	require.True(t, ok)
+	assert.Equal(t, time.Minute, d)
+
+	// Unknown actions are not tracked
+	s.Observe(3, "waiting", start)
+	assert.Equal(t, 2, s.Len())
+
+	state = s.Observe(1, "completed", start.Add(3*time.Minute))
+	d, ok = state.RunDuration()
+	require.True(t, ok)
+	assert.Equal(t, 2*time.Minute, d)
+	assert.Equal(t, 1, s.Len(), "completed jobs are forgotten")
+
+	// A job restarted after being forgotten has no duration
+	state = s.Observe(1, "completed", start.Add(4*time.Minute))
+	_, ok = state.RunDuration()
+	assert.False(t, ok)
+
+	expired := testutil.ToFloat64(githubWorkflowJobStateStoreExpiredTotal)
+
+	now = start.Add(30 * time.Minute)
+	s.Observe(4, "queued", now)
+
+	now = start.Add(61 * time.Minute)
+	s.expire()
+	assert.Equal(t, 1, s.Len(), "the job that had no event within the TTL is forgotten")
+	assert.Equal(t, expired+1, testutil.ToFloat64(githubWorkflowJobStateStoreExpiredTotal))
+	assert.Equal(t, 1.0, testutil.ToFloat64(githubWorkflowJobStateStoreJobs))
+}
+
+func testJobSnapshotter(t *testing.T, snapshotter JobSnapshotter) {
+	t.Helper()
+
+	ctx := context.Background()
+
+	jobs, err := snapshotter.Load(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, jobs)
+
+	now := time.Now().UTC().Truncate(time.Second)
+
+	s := NewJobStore(logr.Discard(), time.Hour, snapshotter)
+	s.Observe(1, "queued", now.Add(-time.Minute))
+	s.Observe(1, "in_progress", now)
+	s.Observe(2, "queued", now)
+
+	require.NoError(t, s.Snapshot(ctx))
+
+	// Nothing is saved when nothing changed
+	s.Snapshotter = nil
+	require.NoError(t, s.Snapshot(ctx))
+
+	restored := NewJobStore(logr.Discard(), time.Hour, snapshotter)
+	require.NoError(t, restored.Load(ctx))
+	assert.Equal(t, 2, restored.Len())
+
+	state := restored.Observe(1, "completed", now.Add(time.Minute))
+	d, ok := state.RunDuration()
+	require.True(t, ok)
+	assert.Equal(t, time.Minute, d)
+
+	require.NoError(t, restored.Snapshot(ctx))
+
+	jobs, err = snapshotter.Load(ctx)
+	require.NoError(t, err)
+	assert.Len(t, jobs, 1)
+	assert.Equal(t, now, jobs[2].QueuedAt)
+
+	// Expired jobs are dropped on load
+	expiring := NewJobStore(logr.Discard(), time.Hour, snapshotter)
+	expiring.now = func() time.Time { return now.Add(2 * time.Hour) }
+	require.NoError(t, expiring.Load(ctx))
+	assert.Zero(t, expiring.Len())
+}
+
+func TestFileJobSnapshotter(t *testing.T) {
+	testJobSnapshotter(t, &FileJobSnapshotter{Path: filepath.Join(t.TempDir(), "jobs.json")})
+}
+
+func TestConfigMapJobSnapshotter(t *testing.T) {
+	testJobSnapshotter(t, &ConfigMapJobSnapshotter{Name: "jobs", Namespace: "default", Client: fake.NewClientBuilder().Build()})
+}
+
+func TestConfigMapJobSnapshotterReplicas(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now().UTC().Truncate(time.Second)
+
+	snapshotter := &ConfigMapJobSnapshotter{Name: "jobs", Namespace: "default", Client: fake.NewClientBuilder().Build()}
+
+	a := NewJobStore(logr.Discard(), time.Hour, snapshotter)
+	b := NewJobStore(logr.Discard(), time.Hour, snapshotter)
+
+	a.Observe(1, "queued", now)
+	a.Observe(2, "queued", now)
+	a.Observe(3, "queued", now)
+	require.NoError(t, a.Snapshot(ctx))
+
+	// The events of a job can be received by different replicas
+	b.Observe(1, "in_progress", now.Add(time.Minute))
+	b.Observe(4, "queued", now)
+	require.NoError(t, b.Snapshot(ctx))
+
+	a.Observe(2, "completed", now.Add(time.Minute))
+	require.NoError(t, a.Snapshot(ctx))
+
+	jobs, err := snapshotter.Load(ctx)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []int64{1, 3, 4}, slices.Collect(maps.Keys(jobs)))
+	assert.Equal(t, now, jobs[1].QueuedAt)
+	assert.Equal(t, now.Add(time.Minute), jobs[1].StartedAt)
+
+	// A job forgotten by a replica is not saved back by another one that loaded it
+	restored := NewJobStore(logr.Discard(), time.Hour, snapshotter)
+	require.NoError(t, restored.Load(ctx))
+
+	b.Observe(4, "completed", now.Add(time.Minute))
+	require.NoError(t, b.Snapshot(ctx))
+
+	restored.Observe(3, "in_progress", now.Add(time.Minute))
+	require.NoError(t, restored.Snapshot(ctx))
+
+	jobs, err = snapshotter.Load(ctx)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []int64{1, 3}, slices.Collect(maps.Keys(jobs)))
+
+	// The jobs of any replica that had no event for TTL are dropped on save
+	expiring := NewJobStore(logr.Discard(), time.Hour, snapshotter)
+	expiring.now = func() time.Time { return time.Now().Add(-2 * time.Hour) }
+	expiring.Observe(5, "queued", now)
+	require.NoError(t, expiring.Snapshot(ctx))
+
+	snapshotter.TTL = time.Hour
+	restored.Observe(3, "completed", now.Add(time.Minute))
+	require.NoError(t, restored.Snapshot(ctx))
+
+	jobs, err = snapshotter.Load(ctx)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []int64{1}, slices.Collect(maps.Keys(jobs)))
+}
+
+func TestProcessWorkflowJobEventWithJobStore(t *testing.T) {
+	start := time.Now().Add(-time.Hour).Truncate(time.Second)
+
+	reader := &EventReader{
+		Log:      logr.Discard(),
+		JobStore: NewJobStore(logr.Discard(), time.Hour, nil),
+	}
+
+	event := func(action string, at time.Time) *gogithub.WorkflowJobEvent {
+		job := &gogithub.WorkflowJob{
+			ID:         gogithub.Int64(1),
+			Name:       gogithub.String("test-job-store"),
+			Conclusion: gogithub.String("success"),
+		}
+
+		ts := &gogithub.Timestamp{Time: at}
+
+		switch action {
+		case "queued":
+			job.CreatedAt = ts
+		case "in_progress":
+			job.StartedAt = ts
+		case "completed":
+			job.CompletedAt = ts
+		}
+
+		return &gogithub.WorkflowJobEvent{
+			Action:      gogithub.String(action),
+			WorkflowJob: job,
+			Repo: &gogithub.Repository{
+				Name:     gogithub.String("repo"),
+				FullName: gogithub.String("owner/repo"),
+				Owner:    &gogithub.User{Login: gogithub.String("owner")},
+			},
+		}
+	}
+
+	reader.ProcessWorkflowJobEvent(context.Background(), event("queued", start))
+	reader.ProcessWorkflowJobEvent(context.Background(), event("in_progress", start.Add(30*time.Second)))
+	reader.ProcessWorkflowJobEvent(context.Background(), event("completed", start.Add(150*time.Second)))
+
+	labels := prometheus.Labels{
+		"runs_on":              "",
+		"job_name":             "test-job-store",
+		"organization":         "",
+		"repository":           "repo",
+		"repository_full_name": "owner/repo",
+		"owner":                "owner",
+		"workflow_name":        "",
+		"head_branch":          "",
+	}
+
+	queue := githubWorkflowJobQueueDurationSeconds.With(labels).(prometheus.Histogram)
+	assert.Equal(t, 1, testutil.CollectAndCount(queue))
+	assert.Equal(t, 30.0, histogramSum(t, queue))
+
+	run := githubWorkflowJobRunDurationSeconds.With(extraLabel("job_conclusion", "success", labels)).(prometheus.Histogram)
+	assert.Equal(t, 120.0, histogramSum(t, run))
+
+	assert.Zero(t, reader.JobStore.Len())
+}
+
+func histogramSum(t *testing.T, h prometheus.Histogram) float64 {
+	t.Helper()
+
+	reg := prometheus.NewPedanticRegistry()
+	require.NoError(t, reg.Register(h))
+
+	mfs, err := reg.Gather()
+	require.NoError(t, err)
+	require.Len(t, mfs, 1)
+
+	return mfs[0].GetMetric()[0].GetHistogram().GetSampleSum()
+}
@@ -0,0 +1,389 @@
+package actionsmetrics
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	LogRuleTypeRegex = "regex"
+	LogRuleTypeStep  = "step"
+
+	CacheResultHit  = "hit"
+	CacheResultMiss = "miss"
+)
+
+// LogRules configures the extractors that analyze the job logs.
+type LogRules struct {
+	Rules []LogRule `json:"rules"`
+}
+
+// LogRule is an extractor of the job logs.
+//
+// A rule of the "regex" type matches Pattern against every line of the job log,
+// whereas a rule of the "step" type only matches the lines of the steps whose names match Step.
+//
+// A matching line categorizes the failure of the job with FailureCategory, or counts as a cache hit or miss per Cache.
+// A "step" rule with StepDuration records the durations of the matching steps, labeled by Name.
+type LogRule struct {
+	Name            string `json:"name"`
+	Type            string `json:"type"`
+	Step            string `json:"step,omitempty"`
+	Pattern         string `json:"pattern,omitempty"`
+	FailureCategory string `json:"failureCategory,omitempty"`
+	Cache           string `json:"cache,omitempty"`
+	StepDuration    bool   `json:"stepDuration,omitempty"`
+}
+
+// DefaultLogRules are used when no rules file is specified.
+// They categorize the common failures of self-hosted runners, and count the hits and misses of actions/cache
+// and the setup actions that use it.
+var DefaultLogRules = LogRules{
+	Rules: []LogRule{
+		{Name: "oom_killed", Type: LogRuleTypeRegex, Pattern: `(?i)(out of memory|oomkilled|exit code 137\b)`, FailureCategory: "oom_killed"},
+		{Name: "disk_full", Type: LogRuleTypeRegex, Pattern: `(?i)(no space left on device|disk quota exceeded)`, FailureCategory: "disk_full"},
+		{Name: "network_timeout", Type: LogRuleTypeRegex, Pattern: `(?i)(connection timed out|i/o timeout|tls handshake timeout|ETIMEDOUT|could not resolve host)`, FailureCategory: "network_timeout"},
+		{Name: "cache_hit", Type: LogRuleTypeRegex, Pattern: `^Cache restored from key:`, Cache: CacheResultHit},
+		{Name: "cache_miss", Type: LogRuleTypeRegex, Pattern: `^Cache not found for input keys:`, Cache: CacheResultMiss},
+	},
+}
+
+// LogLine is a line of a job log.
+type LogLine struct {
+	Time time.Time
+	Text string
+
+	// Step is the name of the step the line belongs to, like "actions/checkout@v4". Empty before the first step.
+	Step string
+}
+
+// LogStep is the span of a step in a job log.
+type LogStep struct {
+	Name  string
+	Start time.Time
+	End   time.Time
+}
+
+// LogAnalysis is what the extractors found in a job log.
+type LogAnalysis struct {
+	// Steps are the steps of the job in order.
+	Steps []LogStep
+
+	// StepDurations are the durations of the steps matched by the "step" rules with StepDuration, by rule name.
+	StepDurations map[string][]time.Duration
+
+	CacheHits   int
+	CacheMisses int
+
+	// FailureCategory is the category of the first rule with FailureCategory that matched, if any.
+	FailureCategory string
+}
+
+// LogExtractor extracts information from a job log into a LogAnalysis.
+type LogExtractor interface {
+	// Extract is called with each line of the job log, in order.
+	Extract(line LogLine, a *LogAnalysis)
+
+	// Finish is called once all the lines are extracted and a.Steps is complete.
+	Finish(a *LogAnalysis)
+}
+
+// NewLogExtractors validates and compiles the rules into extractors.
+func NewLogExtractors(rules LogRules) ([]LogExtractor, error) {
+	var extractors []LogExtractor
+
+	for i, r := range rules.Rules {
+		e, err := newLogExtractor(r)
+		if err != nil {
+			return nil, fmt.Errorf("rules[%d]: %w", i, err)
+		}
+
+		extractors = append(extractors, e)
+	}
+
+	return extractors, nil
+}
+
+func newLogExtractor(r LogRule) (LogExtractor, error) {
+	if r.Name == "" {
+		return nil, fmt.Errorf("name is required")
+	}
+
+	if r.Cache != "" && r.Cache != CacheResultHit && r.Cache != CacheResultMiss {
+		return nil, fmt.Errorf("cache must be either %q or %q: got %q", CacheResultHit, CacheResultMiss, r.Cache)
+	}
+
+	var (
+		pattern *regexp.Regexp
+		err     error
+	)
+
+	if r.Pattern != "" {
+		pattern, err = regexp.Compile(r.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("pattern: %w", err)
+		}
+	}
+
+	m := lineMatcher{pattern: pattern, failureCategory: r.FailureCategory, cache: r.Cache}
+
+	switch r.Type {
+	case LogRuleTypeRegex:
+		if pattern == nil {
+			return nil, fmt.Errorf("pattern is required for %q rules", LogRuleTypeRegex)
+		}
+
+		if r.StepDuration {
+			return nil, fmt.Errorf("stepDuration is supported only by %q rules", LogRuleTypeStep)
+		}
+
+		return &regexExtractor{lineMatcher: m}, nil
+	case LogRuleTypeStep:
+		if r.Step == "" {
+			return nil, fmt.Errorf("step is required for %q rules", LogRuleTypeStep)
+		}
+
+		step, err := regexp.Compile(r.Step)
+		if err != nil {
+			return nil, fmt.Errorf("step: %w", err)
+		}
+
+		if pattern == nil && !r.StepDuration {
+			return nil, fmt.Errorf("either pattern or stepDuration is required")
+		}
+
+		return &stepExtractor{lineMatcher: m, name: r.Name, step: step, duration: r.StepDuration}, nil
+	default:
+		return nil, fmt.Errorf("type must be either %q or %q: got %q", LogRuleTypeRegex, LogRuleTypeStep, r.Type)
+	}
+}
+
+type lineMatcher struct {
+	pattern         *regexp.Regexp
+	failureCategory string
+	cache           string
+}
+
+func (m lineMatcher) extract(line LogLine, a *LogAnalysis) {
+	if m.pattern == nil || !m.pattern.MatchString(line.Text) {
+		return
+	}
+
+	if m.failureCategory != "" && a.FailureCategory == "" {
+		a.FailureCategory = m.failureCategory
+	}
+
+	switch m.cache {
+	case CacheResultHit:
+		a.CacheHits++
+	case CacheResultMiss:
+		a.CacheMisses++
+	}
+}
+
+// regexExtractor matches every line of the job log.
+type regexExtractor struct {
+	lineMatcher
+}
+
+func (e *regexExtractor) Extract(line LogLine, a *LogAnalysis) {
+	e.extract(line, a)
+}
+
+func (e *regexExtractor) Finish(a *LogAnalysis) {}
+
+// stepExtractor matches the lines of the steps whose names match step, and records their durations.
+type stepExtractor struct {
+	lineMatcher
+
+	name     string
+	step     *regexp.Regexp
+	duration bool
+}
+
+func (e *stepExtractor) Extract(line LogLine, a *LogAnalysis) {
+	if line.Step == "" || !e.step.MatchString(line.Step) {
+		return
+	}
+
+	e.extract(line, a)
+}
+
+func (e *stepExtractor) Finish(a *LogAnalysis) {
+	if !e.duration {
+		return
+	}
+
+	for _, s := range a.Steps {
+		if !e.step.MatchString(s.Name) {
+			continue
+		}
+
+		if a.StepDurations == nil {
+			a.StepDurations = map[string][]time.Duration{}
+		}
+
+		a.StepDurations[e.name] = append(a.StepDurations[e.name], s.End.Sub(s.Start))
+	}
+}
+
+// LogAnalyzer runs the extractors over job logs. The extractors can be replaced while logs are analyzed.
+type LogAnalyzer struct {
+	mu         sync.RWMutex
+	extractors []LogExtractor
+}
+
+// NewLogAnalyzer returns a LogAnalyzer with the rules.
+func NewLogAnalyzer(rules LogRules) (*LogAnalyzer, error) {
+	a := &LogAnalyzer{}
+
+	if err := a.SetRules(rules); err != nil {
+		return nil, err
+	}
+
+	return a, nil
+}
+
+// SetRules replaces the extractors. The current extractors are kept when the rules are invalid.
+func (a *LogAnalyzer) SetRules(rules LogRules) error {
+	extractors, err := NewLogExtractors(rules)
+	if err != nil {
+		return err
+	}
+
+	a.mu.Lock()
+	a.extractors = extractors
+	a.mu.Unlock()
+
+	return nil
+}
+
+func (a *LogAnalyzer) getExtractors() []LogExtractor {
+	if a == nil {
+		return nil
+	}
+
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	return a.extractors
+}
+
+// logAnalysis tracks the steps of a job log while its lines are passed to the extractors.
+type logAnalysis struct {
+	extractors []LogExtractor
+	result     LogAnalysis
+	step       string
+}
+
+func (a *LogAnalyzer) newLogAnalysis() *logAnalysis {
+	return &logAnalysis{extractors: a.getExtractors()}
+}
+
+// stepGroupPrefix starts the log of each step, like "##[group]Run actions/checkout@v4"
+const stepGroupPrefix = "##[group]Run "
+
+func (l *logAnalysis) add(t time.Time, text string) {
+	if name, ok := strings.CutPrefix(text, stepGroupPrefix); ok {
+		l.step = strings.TrimSpace(name)
+		l.result.Steps = append(l.result.Steps, LogStep{Name: l.step, Start: t, End: t})
+	}
+
+	if n := len(l.result.Steps); n > 0 {
+		l.result.Steps[n-1].End = t
+	}
+
+	line := LogLine{Time: t, Text: text, Step: l.step}
+
+	for _, e := range l.extractors {
+		e.Extract(line, &l.result)
+	}
+}
+
+func (l *logAnalysis) finish() *LogAnalysis {
+	// A step ends where the next one starts
+	for i := 0; i+1 < len(l.result.Steps); i++ {
+		l.result.Steps[i].End = l.result.Steps[i+1].Start
+	}
+
+	for _, e := range l.extractors {
+		e.Finish(&l.result)
+	}
+
+	return &l.result
+}
+
+// LoadLogRules reads the rules from the YAML or JSON file.
+func LoadLogRules(path string) (LogRules, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return LogRules{}, err
+	}
+
+	return parseLogRules(path, data)
+}
+
+func parseLogRules(path string, data []byte) (LogRules, error) {
+	var rules LogRules
+
+	if err := yaml.UnmarshalStrict(data, &rules); err != nil {
+		return rules, fmt.Errorf("parsing %s: %w", path, err)
+	}
+
+	return rules, nil
+}
+
+// WatchLogRulesFile reloads the rules of the analyzer from the file whenever its content changes,
+// checking it every interval until ctx is done. Invalid rules are logged and ignored.
+func WatchLogRulesFile(ctx context.Context, log logr.Logger, a *LogAnalyzer, path string, interval time.Duration) {
+	var last []byte
+
+	load := func() {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			log.Error(err, "reading log analysis rules", "path", path)
+			return
+		}
+
+		if last != nil && bytes.Equal(data, last) {
+			return
+		}
+
+		last = data
+
+		rules, err := parseLogRules(path, data)
+		if err == nil {
+			err = a.SetRules(rules)
+		}
+
+		if err != nil {
+			log.Error(err, "loading log analysis rules, keeping the current ones", "path", path)
+			return
+		}
+
+		log.Info("loaded log analysis rules", "path", path, "rules", len(rules.Rules))
+	}
+
+	load()
+
+	t := time.NewTicker(interval)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-t.C:
+			load()
+		case <-ctx.Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,214 @@
+package actionsmetrics
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/actions/actions-runner-controller/github"
+	"github.com/go-logr/logr"
+	gogithub "github.com/google/go-github/v52/github"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testJobLog = `2024-01-01T00:00:00.0000000Z Requested labels: self-hosted
+2024-01-01T00:00:00.0000000Z Waiting for a runner to pick up this job...
+2024-01-01T00:00:10.0000000Z Job is about to start running on the runner: runner-1 (repository)
+2024-01-01T00:00:11.0000000Z ##[group]Run actions/checkout@v4
+2024-01-01T00:00:11.0000000Z with:
+2024-01-01T00:00:15.0000000Z ##[endgroup]
+2024-01-01T00:00:16.0000000Z ##[group]Run actions/cache@v4
+2024-01-01T00:00:17.0000000Z Cache not found for input keys: linux-go-abc
+2024-01-01T00:00:18.0000000Z ##[group]Run actions/setup-go@v5
+2024-01-01T00:00:20.0000000Z Cache restored from key: setup-go-Linux-abc
+2024-01-01T00:00:21.0000000Z ##[group]Run make test
+2024-01-01T00:00:30.0000000Z go: write /tmp/go-build: no space left on device
+2024-01-01T00:00:40.0000000Z fatal: unable to access 'https://github.com/': Connection timed out
+2024-01-01T00:00:41.0000000Z ##[error]Process completed with exit code 1.
+2024-01-01T00:00:45.0000000Z Cleaning up orphan processes
+`
+
+func TestLogAnalysis(t *testing.T) {
+	rules := DefaultLogRules
+	rules.Rules = append(rules.Rules,
+		LogRule{Name: "checkout", Type: LogRuleTypeStep, Step: `^actions/checkout@`, StepDuration: true},
+		LogRule{Name: "tests", Type: LogRuleTypeStep, Step: `^make `, StepDuration: true, Pattern: `Connection timed out`, FailureCategory: "test_network"},
+	)
+
+	analyzer, err := NewLogAnalyzer(rules)
+	require.NoError(t, err)
+
+	l := analyzer.newLogAnalysis()
+
+	for _, line := range strings.Split(strings.TrimSpace(testJobLog), "\n") {
+		m := logLine.FindStringSubmatch(line)
+		require.NotNil(t, m, line)
+
+		ts, err := time.Parse(time.RFC3339, m[1])
+		require.NoError(t, err)
+
+		l.add(ts, m[2])
+	}
+
+	a := l.finish()
+
+	var steps []string
+	for _, s := range a.Steps {
+		steps = append(steps, s.Name)
+	}
+	assert.Equal(t, []string{"actions/checkout@v4", "actions/cache@v4", "actions/setup-go@v5", "make test"}, steps)
+
+	assert.Equal(t, map[string][]time.Duration{
+		"checkout": {5 * time.Second},
+		// The last step ends with the log
+		"tests": {24 * time.Second},
+	}, a.StepDurations)
+	assert.Equal(t, 1, a.CacheHits)
+	assert.Equal(t, 1, a.CacheMisses)
+	assert.Equal(t, "disk_full", a.FailureCategory, "the first matching line categorizes the failure")
+}
+
+func TestNewLogExtractorsErrors(t *testing.T) {
+	testcases := []struct {
+		rule LogRule
+		err  string
+	}{
+		{rule: LogRule{Type: LogRuleTypeRegex, Pattern: "a"}, err: "rules[0]: name is required"},
+		{rule: LogRule{Name: "a", Type: "grep", Pattern: "a"}, err: `rules[0]: type must be either "regex" or "step": got "grep"`},
+		{rule: LogRule{Name: "a", Type: LogRuleTypeRegex}, err: `rules[0]: pattern is required for "regex" rules`},
+		{rule: LogRule{Name: "a", Type: LogRuleTypeRegex, Pattern: "("}, err: "rules[0]: pattern: error parsing regexp: missing closing ): `(`"},
+		{rule: LogRule{Name: "a", Type: LogRuleTypeRegex, Pattern: "a", StepDuration: true}, err: `rules[0]: stepDuration is supported only by "step" rules`},
+		{rule: LogRule{Name: "a", Type: LogRuleTypeRegex, Pattern: "a", Cache: "stale"}, err: `rules[0]: cache must be either "hit" or "miss": got "stale"`},
+		{rule: LogRule{Name: "a", Type: LogRuleTypeStep, Pattern: "a"}, err: `rules[0]: step is required for "step" rules`},
+		{rule: LogRule{Name: "a", Type: LogRuleTypeStep, Step: "a"}, err: "rules[0]: either pattern or stepDuration is required"},
+	}
+
+	for _, tc := range testcases {
+		_, err := NewLogExtractors(LogRules{Rules: []LogRule{tc.rule}})
+		assert.EqualError(t, err, tc.err)
+	}
+}
+
+func TestWatchLogRulesFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rules.yaml")
+
+	require.NoError(t, os.WriteFile(path, []byte(`
+rules:
+- name: oom
+  type: regex
+  pattern: Killed
+  failureCategory: oom_killed
+`), 0o644))
+
+	analyzer, err := NewLogAnalyzer(DefaultLogRules)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		WatchLogRulesFile(ctx, logr.Discard(), analyzer, path, 10*time.Millisecond)
+	}()
+
+	require.Eventually(t, func() bool { return len(analyzer.getExtractors()) == 1 }, time.Second, 10*time.Millisecond)
+
+	// Invalid rules are ignored
+	require.NoError(t, os.WriteFile(path, []byte("rules:\n- name: broken\n  type: regex\n"), 0o644))
+	time.Sleep(50 * time.Millisecond)
+	assert.Len(t, analyzer.getExtractors(), 1)
+
+	require.NoError(t, os.WriteFile(path, []byte(`
+rules:
+- name: a
+  type: regex
+  pattern: a
+  cache: hit
+- name: b
+  type: regex
+  pattern: b
+  cache: miss
+`), 0o644))
+	require.Eventually(t, func() bool { return len(analyzer.getExtractors()) == 2 }, time.Second, 10*time.Millisecond)
+
+	cancel()
+	<-done
+}
+
+func TestProcessWorkflowJobEventWithLogAnalysis(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repos/owner/repo/actions/jobs/1/logs", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "http://"+r.Host+"/logs/1", http.StatusFound)
+	})
+	mux.HandleFunc("/logs/1", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, testJobLog)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	c := github.Config{Token: "token"}
+	client, err := c.NewClient()
+	require.NoError(t, err)
+
+	baseURL, err := url.Parse(server.URL + "/")
+	require.NoError(t, err)
+	client.BaseURL = baseURL
+
+	analyzer, err := NewLogAnalyzer(LogRules{Rules: append(DefaultLogRules.Rules,
+		LogRule{Name: "checkout", Type: LogRuleTypeStep, Step: `^actions/checkout@`, StepDuration: true},
+	)})
+	require.NoError(t, err)
+
+	reader := &EventReader{
+		Log:          logr.Discard(),
+		GitHubClient: client,
+		LogAnalyzer:  analyzer,
+	}
+
+	reader.ProcessWorkflowJobEvent(context.Background(), &gogithub.WorkflowJobEvent{
+		Action: gogithub.String("completed"),
+		WorkflowJob: &gogithub.WorkflowJob{
+			ID:         gogithub.Int64(1),
+			Name:       gogithub.String("test-log-analysis"),
+			Conclusion: gogithub.String("failure"),
+			Steps: []*gogithub.TaskStep{
+				{Conclusion: gogithub.String("success")},
+				{Conclusion: gogithub.String("failure")},
+			},
+		},
+		Repo: &gogithub.Repository{
+			Name:     gogithub.String("repo"),
+			FullName: gogithub.String("owner/repo"),
+			Owner:    &gogithub.User{Login: gogithub.String("owner")},
+		},
+	})
+
+	labels := prometheus.Labels{
+		"runs_on":              "",
+		"job_name":             "test-log-analysis",
+		"organization":         "",
+		"repository":           "repo",
+		"repository_full_name": "owner/repo",
+		"owner":                "owner",
+		"workflow_name":        "",
+		"head_branch":          "",
+	}
+
+	assert.Equal(t, 1.0, testutil.ToFloat64(githubWorkflowJobFailuresTotal.With(
+		extraLabel("failure_category", "disk_full", extraLabel("failed_step", "1", extraLabel("exit_code", "1", labels))),
+	)))
+	assert.Equal(t, 1.0, testutil.ToFloat64(githubWorkflowJobCacheRequestsTotal.With(extraLabel("cache_result", CacheResultHit, labels))))
+	assert.Equal(t, 1.0, testutil.ToFloat64(githubWorkflowJobCacheRequestsTotal.With(extraLabel("cache_result", CacheResultMiss, labels))))
+	assert.Equal(t, 1, testutil.CollectAndCount(githubWorkflowJobStepDurationSeconds, "github_workflow_job_step_duration_seconds"))
+}
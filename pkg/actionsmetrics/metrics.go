@@ -18,6 +18,10 @@ func init() {
 		githubWorkflowJobsStartedTotal,
 		githubWorkflowJobsCompletedTotal,
 		githubWorkflowJobFailuresTotal,
+		githubWorkflowJobStepDurationSeconds,
+		githubWorkflowJobCacheRequestsTotal,
+		githubWorkflowJobStateStoreJobs,
+		githubWorkflowJobStateStoreExpiredTotal,
 	)
 }
 
@@ -124,6 +128,33 @@ var (
 			Name: "github_workflow_job_failures_total",
 			Help: "Conclusions for tracked workflow runs",
 		},
-		metricLabels("failed_step", "exit_code"),
+		metricLabels("failed_step", "exit_code", "failure_category"),
+	)
+	githubWorkflowJobStepDurationSeconds = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "github_workflow_job_step_duration_seconds",
+			Help:    "Run times for the workflow job steps matched by the log analysis rules in seconds",
+			Buckets: runtimeBuckets,
+		},
+		metricLabels("step"),
+	)
+	githubWorkflowJobCacheRequestsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "github_workflow_job_cache_requests_total",
+			Help: "Total count of cache hits and misses found in the workflow job logs",
+		},
+		metricLabels("cache_result"),
+	)
+	githubWorkflowJobStateStoreJobs = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "github_workflow_job_state_store_jobs",
+			Help: "Number of in-flight workflow jobs tracked by the job state store",
+		},
+	)
+	githubWorkflowJobStateStoreExpiredTotal = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "github_workflow_job_state_store_expired_total",
+			Help: "Total count of workflow jobs forgotten by the job state store as they had no event within the TTL",
+		},
 	)
 )
@@ -18,6 +18,7 @@ limitations under the License.
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
@@ -29,10 +30,14 @@ import (
 	ctrl "sigs.k8s.io/controller-runtime"
 
 	"github.com/actions/actions-runner-controller/github"
+	"github.com/actions/actions-runner-controller/pkg/webhooksecret"
 )
 
 type EventHook func(interface{})
 
+// WorkflowJobEventHook is sent a workflow_job event along with the slug of the enterprise it occurred in, which go-github doesn't decode.
+type WorkflowJobEventHook func(e *gogithub.WorkflowJobEvent, enterpriseSlug string)
+
 // WebhookServer is a HTTP server that handles workflow_job events sent from GitHub Actions
 type WebhookServer struct {
 	Log logr.Logger
@@ -41,11 +46,18 @@ type WebhookServer struct {
 	// the administrator is generated and specified in GitHub Web UI.
 	SecretKeyBytes []byte
 
+	// Secrets validates the webhook payloads against one or more secrets tried in order, so that the secret can be rotated.
+	// SecretKeyBytes is ignored when set.
+	Secrets *webhooksecret.Verifier
+
 	// GitHub Client to discover runner groups assigned to a repository
 	GitHubClient *github.Client
 
 	// When HorizontalRunnerAutoscalerGitHubWebhook handles a request, each EventHook is sent the webhook event
 	EventHooks []EventHook
+
+	// Each WorkflowJobEventHook is sent the workflow_job events and the slugs of the enterprises they occurred in
+	WorkflowJobEventHooks []WorkflowJobEventHook
 }
 
 func (autoscaler *WebhookServer) Reconcile(_ context.Context, request reconcile.Request) (reconcile.Result, error) {
@@ -87,7 +99,14 @@ func (autoscaler *WebhookServer) Handle(w http.ResponseWriter, r *http.Request)
 
 	var payload []byte
 
-	if len(autoscaler.SecretKeyBytes) > 0 {
+	if autoscaler.Secrets != nil {
+		payload, err = autoscaler.Secrets.ValidatePayload(r)
+		if err != nil {
+			autoscaler.Log.Error(err, "error validating request body")
+
+			return
+		}
+	} else if len(autoscaler.SecretKeyBytes) > 0 {
 		payload, err = gogithub.ValidatePayload(r, autoscaler.SecretKeyBytes)
 		if err != nil {
 			autoscaler.Log.Error(err, "error validating request body")
@@ -122,7 +141,23 @@ func (autoscaler *WebhookServer) Handle(w http.ResponseWriter, r *http.Request)
 		"delivery", r.Header.Get("X-GitHub-Delivery"),
 	)
 
-	switch event.(type) {
+	var (
+		workflowJobEvent *gogithub.WorkflowJobEvent
+		enterpriseSlug   string
+	)
+
+	switch e := event.(type) {
+	case *gogithub.WorkflowJobEvent:
+		var enterpriseEvent struct {
+			Enterprise struct {
+				Slug string `json:"slug,omitempty"`
+			} `json:"enterprise,omitempty"`
+		}
+		if err := json.Unmarshal(payload, &enterpriseEvent); err != nil {
+			log.Error(err, "could not parse webhook payload for extracting enterprise slug")
+		}
+
+		workflowJobEvent, enterpriseSlug = e, enterpriseEvent.Enterprise.Slug
 	case *gogithub.PingEvent:
 		ok = true
 
@@ -143,6 +178,12 @@ func (autoscaler *WebhookServer) Handle(w http.ResponseWriter, r *http.Request)
 		eventHook(event)
 	}
 
+	if workflowJobEvent != nil {
+		for _, hook := range autoscaler.WorkflowJobEventHooks {
+			hook(workflowJobEvent, enterpriseSlug)
+		}
+	}
+
 	ok = true
 
 	w.WriteHeader(http.StatusOK)
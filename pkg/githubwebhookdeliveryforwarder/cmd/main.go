@@ -14,6 +14,8 @@ import (
 	"github.com/actions/actions-runner-controller/github"
 	"github.com/actions/actions-runner-controller/pkg/githubwebhookdeliveryforwarder"
 	"github.com/kelseyhightower/envconfig"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
 )
 
 func main() {
@@ -21,6 +23,7 @@ func main() {
 		metricsAddr string
 		target      string
 		repo        string
+		secret      string
 	)
 
 	var c github.Config
@@ -32,6 +35,7 @@ func main() {
 	flag.StringVar(&metricsAddr, "metrics-addr", ":8000", "The address the metric endpoint binds to.")
 	flag.StringVar(&repo, "repo", "", "The owner/name of the repository that has the target hook. If specified, the forwarder will use the first hook configured on the repository as the source.")
 	flag.StringVar(&target, "target", "", "The URL of the forwarding target that receives all the forwarded webhooks.")
+	flag.StringVar(&secret, "github-webhook-secret-token", os.Getenv("GITHUB_WEBHOOK_SECRET_TOKEN"), "The secret token used to sign the forwarded payloads in X-Hub-Signature-256, which must match the one configured on the forwarding target. Defaults to the value of GITHUB_WEBHOOK_SECRET_TOKEN. The payloads are forwarded unsigned when empty.")
 	flag.StringVar(&c.Token, "github-token", c.Token, "The personal access token of GitHub.")
 	flag.Int64Var(&c.AppID, "github-app-id", c.AppID, "The application ID of GitHub App.")
 	flag.Int64Var(&c.AppInstallationID, "github-app-installation-id", c.AppInstallationID, "The installation ID of GitHub App.")
@@ -50,9 +54,11 @@ func main() {
 
 	fwd := githubwebhookdeliveryforwarder.New(ghClient, target)
 	fwd.Repo = repo
+	fwd.Secret = []byte(secret)
 
 	mux := http.NewServeMux()
 	mux.HandleFunc("/readyz", fwd.HandleReadyz)
+	mux.Handle("/metrics", promhttp.HandlerFor(metrics.Registry, promhttp.HandlerOpts{}))
 
 	srv := http.Server{
 		Addr:    metricsAddr,
@@ -1,7 +1,6 @@
 package githubwebhookdeliveryforwarder
 
 import (
-	"bytes"
 	"context"
 	"fmt"
 	"net/http"
@@ -11,13 +10,24 @@ import (
 	"time"
 
 	"github.com/actions/actions-runner-controller/github"
+	"github.com/actions/actions-runner-controller/pkg/hookdeliveryforwarder"
 	gogithub "github.com/google/go-github/v52/github"
 )
 
+const (
+	// initialRetryDelay is the delay before a delivery that failed to be forwarded is retried.
+	// It is doubled on every consecutive failure of the delivery, up to maxRetryDelay.
+	initialRetryDelay = 5 * time.Second
+	maxRetryDelay     = 5 * time.Minute
+)
+
 type server struct {
 	target string
 	Repo   string
 	client *github.Client
+
+	// Secret is used to sign the forwarded payloads.
+	Secret []byte
 }
 
 func New(client *github.Client, target string) *server {
@@ -38,6 +48,11 @@ func (s *server) Run(ctx context.Context) error {
 
 	owner, repo := segments[0], segments[1]
 
+	sink, err := hookdeliveryforwarder.NewSink(s.target, hookdeliveryforwarder.SinkOptions{Secret: s.Secret})
+	if err != nil {
+		return err
+	}
+
 	hooks, _, err := s.client.Repositories.ListHooks(ctx, owner, repo, nil)
 	if err != nil {
 		s.Errorf("Failed listing hooks: %v", err)
@@ -57,23 +72,41 @@ func (s *server) Run(ctx context.Context) error {
 	cur.deliveredAt = time.Now()
 
 	for {
-		var (
-			err      error
-			payloads [][]byte
-		)
-
-		payloads, cur, err = s.getUnprocessedDeliveries(ctx, owner, repo, hook.GetID(), *cur)
+		deliveries, next, err := s.getUnprocessedDeliveries(ctx, owner, repo, hook.GetID(), *cur)
 		if err != nil {
 			s.Errorf("failed getting unprocessed deliveries: %v", err)
 		}
 
-		for _, p := range payloads {
-			if _, err := http.Post(s.target, "application/json", bytes.NewReader(p)); err != nil {
-				s.Errorf("failed forwarding delivery: %v", err)
+		for _, d := range deliveries {
+			retryDelay := initialRetryDelay
+
+			for {
+				err := sink.Send(ctx, d)
+				if err == nil {
+					break
+				}
+
+				s.Errorf("failed forwarding delivery: %v. Retrying in %s", err, retryDelay)
+
+				select {
+				case <-time.After(retryDelay):
+				case <-ctx.Done():
+					return ctx.Err()
+				}
+
+				retryDelay = min(retryDelay*2, maxRetryDelay)
 			}
 		}
 
-		time.Sleep(10 * time.Second)
+		if next != nil {
+			cur = next
+		}
+
+		select {
+		case <-time.After(10 * time.Second):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
 	}
 }
 
@@ -82,7 +115,7 @@ type cursor struct {
 	id          int64
 }
 
-func (s *server) getUnprocessedDeliveries(ctx context.Context, owner, repo string, hookID int64, pos cursor) ([][]byte, *cursor, error) {
+func (s *server) getUnprocessedDeliveries(ctx context.Context, owner, repo string, hookID int64, pos cursor) ([]*gogithub.HookDelivery, *cursor, error) {
 	var (
 		opts gogithub.ListCursorOptions
 	)
@@ -91,6 +124,9 @@ func (s *server) getUnprocessedDeliveries(ctx context.Context, owner, repo strin
 
 	var deliveries []*gogithub.HookDelivery
 
+	// pos is advanced while listing, whereas deliveries are compared against the position we started from
+	from := pos
+
 OUTER:
 	for {
 		ds, resp, err := s.client.Repositories.ListHookDeliveries(ctx, owner, repo, hookID, &opts)
@@ -114,15 +150,17 @@ OUTER:
 			id := d.GetID()
 			deliveredAt := d.GetDeliveredAt()
 
-			if !pos.deliveredAt.IsZero() && deliveredAt.Before(pos.deliveredAt) {
-				s.Logf("%s is before %s so skipping all the remaining deliveries", deliveredAt, pos.deliveredAt)
+			if !from.deliveredAt.IsZero() && deliveredAt.Before(from.deliveredAt) {
+				s.Logf("%s is before %s so skipping all the remaining deliveries", deliveredAt, from.deliveredAt)
 				break OUTER
 			}
 
-			if pos.id != 0 && id <= pos.id {
+			if from.id != 0 && id <= from.id {
 				break OUTER
 			}
 
+			deliveries = append(deliveries, d)
+
 			s.Logf("Received %T at %s: %v", payload, deliveredAt, payload)
 
 			if deliveredAt.After(pos.deliveredAt) {
@@ -145,13 +183,7 @@ OUTER:
 		return deliveries[b].GetDeliveredAt().After(deliveries[a].GetDeliveredAt().Time)
 	})
 
-	var payloads [][]byte
-
-	for _, d := range deliveries {
-		payloads = append(payloads, *d.Request.RawPayload)
-	}
-
-	return payloads, &pos, nil
+	return deliveries, &pos, nil
 }
 
 func (s *server) HandleReadyz(w http.ResponseWriter, r *http.Request) {
@@ -1,24 +1,44 @@
 package hookdeliveryforwarder
 
-import "time"
+import (
+	"sync"
+	"time"
+)
 
 type Checkpointer interface {
 	GetOrCreate(hookID int64) (*State, error)
 	Update(hookID int64, pos *State) error
 }
 
+// InMemoryCheckpointer keeps the position of each hook in memory.
+// The position of a hook that hasn't been updated yet is the time the checkpointer was created.
 type InMemoryCheckpointer struct {
-	t  time.Time
-	id int64
+	t time.Time
+
+	mu        sync.Mutex
+	positions map[int64]State
 }
 
 func (p *InMemoryCheckpointer) GetOrCreate(hookID int64) (*State, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if pos, ok := p.positions[hookID]; ok {
+		return &pos, nil
+	}
+
 	return &State{DeliveredAt: p.t}, nil
 }
 
 func (p *InMemoryCheckpointer) Update(hookID int64, pos *State) error {
-	p.t = pos.DeliveredAt
-	p.id = pos.ID
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.positions == nil {
+		p.positions = map[int64]State{}
+	}
+
+	p.positions[hookID] = *pos
 
 	return nil
 }
@@ -49,6 +49,15 @@ func main() {
 
 	flag.Parse()
 
+	if checkpointerConfig.LeaderElection {
+		flag.Visit(func(f *flag.Flag) {
+			if f.Name == "shard-count" || f.Name == "shard-index" {
+				fmt.Fprintf(os.Stderr, "Error: --leader-elect and --%s are mutually exclusive. Either let a single leader forward all the deliveries, or shard them across the replicas.\n", f.Name)
+				os.Exit(1)
+			}
+		})
+	}
+
 	logger := newZapLogger(logLevel)
 
 	checkpointerConfig.Scheme = scheme
@@ -73,6 +82,10 @@ func main() {
 		}
 	}()
 
+	// Elected is closed immediately when leader election is disabled.
+	// A standby replica keeps serving readyz and metrics while it waits to be elected.
+	config.Elected = mgr.Elected()
+
 	hookdeliveryforwarder.Run(ctx, config)
 }
 
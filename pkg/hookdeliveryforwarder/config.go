@@ -8,16 +8,40 @@ import (
 	"net/http"
 	"os"
 	"sync"
+	"time"
 
 	"github.com/actions/actions-runner-controller/github"
 	"github.com/kelseyhightower/envconfig"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
 )
 
+const webhookSecretTokenEnvName = "GITHUB_WEBHOOK_SECRET_TOKEN"
+
 type Config struct {
 	Rules        StringSlice
 	MetricsAddr  string
 	GitHubConfig github.Config
 	Checkpointer Checkpointer
+
+	// WebhookSecretToken is used to sign the forwarded payloads.
+	WebhookSecretToken string
+
+	// Redelivery runs the forwarder in the redelivery mode. See NewRedelivery.
+	Redelivery         bool
+	RedeliveryLookback time.Duration
+	RedeliveryInterval time.Duration
+
+	// DiscoveryInterval is the interval between two discoveries of the repositories of the "org/*" rules.
+	DiscoveryInterval time.Duration
+
+	// ShardCount and ShardIndex shard the repositories across the replicas. See Shard.
+	ShardCount int
+	ShardIndex int
+
+	// Elected delays forwarding until it is closed, like when this replica is elected as the leader.
+	// The readyz and metrics endpoints are served in the meantime. Forwarding starts immediately when nil.
+	Elected <-chan struct{}
 }
 
 func (config *Config) InitFlags(fs *flag.FlagSet) {
@@ -26,7 +50,14 @@ func (config *Config) InitFlags(fs *flag.FlagSet) {
 	}
 
 	flag.StringVar(&config.MetricsAddr, "metrics-addr", ":8000", "The address the metric endpoint binds to.")
-	flag.Var(&config.Rules, "rule", "The rule denotes from where webhook deliveries forwarded and to where they are forwarded. Must be formatted REPO=TARGET where REPO can be just the organization name for a repostory hook or \"owner/repo\" for a repository hook.")
+	flag.Var(&config.Rules, "rule", "The rule denotes from where webhook deliveries forwarded and to where they are forwarded. Must be formatted REPO=TARGET where REPO can be just the organization name for an organization hook, \"owner/repo\" for a repository hook, or \"org/*\" for the repository hooks of all the repositories of the organization. TARGET is an http(s):// URL, a file:// spool, or the URI of a registered message broker, optionally with retry_max_attempts, retry_backoff and retry_max_backoff query parameters.")
+	flag.StringVar(&config.WebhookSecretToken, "github-webhook-secret-token", os.Getenv(webhookSecretTokenEnvName), "The secret token used to sign the forwarded payloads in X-Hub-Signature-256, which must match the one configured on the forwarding target. Defaults to the value of "+webhookSecretTokenEnvName+". The payloads are forwarded unsigned when empty.")
+	flag.BoolVar(&config.Redelivery, "redelivery", false, "Redeliver the deliveries that the hooks of the rules failed to receive, instead of forwarding all the deliveries. Failed deliveries are redelivered by GitHub via the hook deliveries API, or forwarded to the destination of the rule when specified.")
+	flag.DurationVar(&config.RedeliveryLookback, "redelivery-lookback", time.Hour, "How far back the deliveries are scanned for failures in the redelivery mode.")
+	flag.DurationVar(&config.RedeliveryInterval, "redelivery-interval", time.Minute, "The interval between two scans for failed deliveries in the redelivery mode.")
+	flag.DurationVar(&config.DiscoveryInterval, "repo-discovery-interval", 10*time.Minute, "The interval between two discoveries of the repositories of the organizations of the \"org/*\" rules.")
+	flag.IntVar(&config.ShardCount, "shard-count", 1, "The number of replicas the repositories and organizations of the rules are sharded across. Each replica forwards the deliveries of its share only. Mutually exclusive with --leader-elect.")
+	flag.IntVar(&config.ShardIndex, "shard-index", -1, "The index of the shard of this replica, from 0 to shard-count - 1. Defaults to the ordinal in the hostname of the StatefulSet pod.")
 	flag.StringVar(&config.GitHubConfig.Token, "github-token", config.GitHubConfig.Token, "The personal access token of GitHub.")
 	flag.Int64Var(&config.GitHubConfig.AppID, "github-app-id", config.GitHubConfig.AppID, "The application ID of GitHub App.")
 	flag.Int64Var(&config.GitHubConfig.AppInstallationID, "github-app-installation-id", config.GitHubConfig.AppInstallationID, "The installation ID of GitHub App.")
@@ -46,7 +77,12 @@ func Run(ctx context.Context, config *Config) {
 
 	ctx, cancel := context.WithCancel(ctx)
 
-	fwd, err := New(ghClient, []string(config.Rules))
+	newForwarder := New
+	if config.Redelivery {
+		newForwarder = NewRedelivery
+	}
+
+	fwd, err := newForwarder(ghClient, []string(config.Rules))
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "problem initializing forwarder: %v\n", err)
 		os.Exit(1)
@@ -56,8 +92,32 @@ func Run(ctx context.Context, config *Config) {
 		fwd.Checkpointer = config.Checkpointer
 	}
 
+	fwd.Secret = []byte(config.WebhookSecretToken)
+	fwd.RedeliveryLookback = config.RedeliveryLookback
+	fwd.RedeliveryInterval = config.RedeliveryInterval
+	fwd.DiscoveryInterval = config.DiscoveryInterval
+
+	if config.ShardCount > 1 {
+		index := config.ShardIndex
+		if index < 0 {
+			index, err = shardIndexFromHostname()
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "problem determining the shard index: %v\n", err)
+				os.Exit(1)
+			}
+		}
+
+		if index >= config.ShardCount {
+			fmt.Fprintf(os.Stderr, "shard index %d must be less than the shard count %d\n", index, config.ShardCount)
+			os.Exit(1)
+		}
+
+		fwd.Shard = Shard{Index: index, Count: config.ShardCount}
+	}
+
 	mux := http.NewServeMux()
 	mux.HandleFunc("/readyz", fwd.HandleReadyz)
+	mux.Handle("/metrics", promhttp.HandlerFor(metrics.Registry, promhttp.HandlerOpts{}))
 
 	srv := http.Server{
 		Addr:    config.MetricsAddr,
@@ -69,6 +129,14 @@ func Run(ctx context.Context, config *Config) {
 		defer cancel()
 		defer wg.Done()
 
+		if config.Elected != nil {
+			select {
+			case <-config.Elected:
+			case <-ctx.Done():
+				return
+			}
+		}
+
 		if err := fwd.Run(ctx); err != nil {
 			fmt.Fprintf(os.Stderr, "problem running forwarder: %v\n", err)
 		}
@@ -11,6 +11,7 @@ import (
 
 	"github.com/actions/actions-runner-controller/pkg/hookdeliveryforwarder"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/retry"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
@@ -37,7 +38,14 @@ func (p *ConfigMapCheckpointer) GetOrCreate(hookID int64) (*hookdeliveryforwarde
 		cm.Namespace = p.NS
 
 		if err := p.Client.Create(context.Background(), &cm); err != nil {
-			return nil, err
+			if !kerrors.IsAlreadyExists(err) {
+				return nil, err
+			}
+
+			// Another forwarder sharing the configmap has just created it
+			if err := p.Client.Get(context.Background(), types.NamespacedName{Namespace: p.NS, Name: p.Name}, &cm); err != nil {
+				return nil, err
+			}
 		}
 	}
 
@@ -65,36 +73,43 @@ func (p *ConfigMapCheckpointer) GetOrCreate(hookID int64) (*hookdeliveryforwarde
 	return pos, nil
 }
 
+// Update stores the position of the hook with optimistic concurrency on the resourceVersion of the configmap,
+// retrying on conflicts. A position behind the stored one is ignored, so that replicas sharing
+// the configmap can't overwrite each other's progress.
 func (p *ConfigMapCheckpointer) Update(hookID int64, pos *hookdeliveryforwarder.State) error {
-	var cm corev1.ConfigMap
+	idStr := fmt.Sprintf("hook_%d", hookID)
 
-	if err := p.Client.Get(context.Background(), types.NamespacedName{Namespace: p.NS, Name: p.Name}, &cm); err != nil {
+	data, err := json.Marshal(state{
+		DeliveredAt: pos.DeliveredAt,
+		ID:          pos.ID,
+	})
+	if err != nil {
 		return err
 	}
 
-	var posData state
-
-	posData.DeliveredAt = pos.DeliveredAt
-	posData.ID = pos.ID
+	return retry.RetryOnConflict(retry.DefaultBackoff, func() error {
+		var cm corev1.ConfigMap
 
-	idStr := fmt.Sprintf("hook_%d", hookID)
+		if err := p.Client.Get(context.Background(), types.NamespacedName{Namespace: p.NS, Name: p.Name}, &cm); err != nil {
+			return err
+		}
 
-	data, err := json.Marshal(posData)
-	if err != nil {
-		return err
-	}
+		if stored, ok := cm.Data[idStr]; ok {
+			var current state
 
-	copy := cm.DeepCopy()
+			if err := json.Unmarshal([]byte(stored), &current); err == nil && current.ID > pos.ID {
+				return nil
+			}
+		}
 
-	if copy.Data == nil {
-		copy.Data = map[string]string{}
-	}
+		copy := cm.DeepCopy()
 
-	copy.Data[idStr] = string(data)
+		if copy.Data == nil {
+			copy.Data = map[string]string{}
+		}
 
-	if err := p.Client.Patch(context.Background(), copy, client.MergeFrom(&cm)); err != nil {
-		return err
-	}
+		copy.Data[idStr] = string(data)
 
-	return nil
+		return p.Client.Patch(context.Background(), copy, client.MergeFromWithOptions(&cm, client.MergeFromWithOptimisticLock{}))
+	})
 }
@@ -0,0 +1,83 @@
+package configmap
+
+import (
+	"context"
+	"testing"
+
+	"github.com/actions/actions-runner-controller/pkg/hookdeliveryforwarder"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+)
+
+func TestConfigMapCheckpointer(t *testing.T) {
+	c := fake.NewClientBuilder().Build()
+
+	p := &ConfigMapCheckpointer{Name: "forwarder", NS: "default", Client: c}
+
+	pos, err := p.GetOrCreate(1)
+	require.NoError(t, err)
+	assert.Zero(t, pos.ID)
+	assert.False(t, pos.DeliveredAt.IsZero())
+
+	require.NoError(t, p.Update(1, &hookdeliveryforwarder.State{ID: 10}))
+	require.NoError(t, p.Update(2, &hookdeliveryforwarder.State{ID: 20}))
+
+	pos, err = p.GetOrCreate(1)
+	require.NoError(t, err)
+	assert.Equal(t, int64(10), pos.ID)
+
+	pos, err = p.GetOrCreate(2)
+	require.NoError(t, err)
+	assert.Equal(t, int64(20), pos.ID)
+
+	// A position behind the stored one doesn't overwrite it
+	require.NoError(t, p.Update(1, &hookdeliveryforwarder.State{ID: 5}))
+
+	pos, err = p.GetOrCreate(1)
+	require.NoError(t, err)
+	assert.Equal(t, int64(10), pos.ID)
+}
+
+func TestConfigMapCheckpointerConflict(t *testing.T) {
+	base := fake.NewClientBuilder().Build()
+
+	other := &ConfigMapCheckpointer{Name: "forwarder", NS: "default", Client: base}
+
+	_, err := other.GetOrCreate(1)
+	require.NoError(t, err)
+
+	var patches int
+
+	// Another replica updates the configmap between our read and write
+	c := interceptor.NewClient(base.(client.WithWatch), interceptor.Funcs{
+		Patch: func(ctx context.Context, c client.WithWatch, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+			patches++
+
+			if patches == 1 {
+				if err := other.Update(1, &hookdeliveryforwarder.State{ID: 12}); err != nil {
+					return err
+				}
+			}
+
+			return c.Patch(ctx, obj, patch, opts...)
+		},
+	})
+
+	p := &ConfigMapCheckpointer{Name: "forwarder", NS: "default", Client: c}
+
+	require.NoError(t, p.Update(1, &hookdeliveryforwarder.State{ID: 11}))
+	assert.Equal(t, 1, patches, "the conflicting update must be retried, and given up as it is behind the other replica")
+
+	var cm corev1.ConfigMap
+	require.NoError(t, base.Get(context.Background(), types.NamespacedName{Namespace: "default", Name: "forwarder"}, &cm))
+	assert.JSONEq(t, `{"delivered_at":"0001-01-01T00:00:00Z","id":12}`, cm.Data["hook_1"])
+
+	require.NoError(t, p.Update(1, &hookdeliveryforwarder.State{ID: 13}))
+	require.NoError(t, base.Get(context.Background(), types.NamespacedName{Namespace: "default", Name: "forwarder"}, &cm))
+	assert.JSONEq(t, `{"delivered_at":"0001-01-01T00:00:00Z","id":13}`, cm.Data["hook_1"])
+}
@@ -15,20 +15,28 @@ type Config struct {
 	Name      string
 	Namespace string
 	Logger    logr.Logger
-	Scheme    *runtime.Scheme
+
+	// LeaderElection makes only one of the replicas sharing the configmap forward deliveries at a time.
+	LeaderElection bool
+	Scheme         *runtime.Scheme
 }
 
 func (c *Config) InitFlags(fs *flag.FlagSet) {
 	fs.StringVar(&c.Name, "configmap-name", "gh-webhook-forwarder", `The name of the Kubernetes ConfigMap to which store state for check-pointing.`)
 	fs.StringVar(&c.Namespace, "namespace", "default", `The Kubernetes namespace to store configmap for check-pointing.`)
+	fs.BoolVar(&c.LeaderElection, "leader-elect", false, `Elect a leader among the replicas with a Kubernetes Lease in the namespace, so that only the leader forwards deliveries. Mutually exclusive with --shard-count and --shard-index.`)
 }
 
 func New(checkpointerConfig *Config) (*ConfigMapCheckpointer, manager.Manager, error) {
 	ctrl.SetLogger(checkpointerConfig.Logger)
 
 	mgr, err := ctrl.NewManager(ctrl.GetConfigOrDie(), ctrl.Options{
-		Scheme:           checkpointerConfig.Scheme,
-		LeaderElectionID: "hookdeliveryforwarder",
+		Scheme:                  checkpointerConfig.Scheme,
+		LeaderElection:          checkpointerConfig.LeaderElection,
+		LeaderElectionNamespace: checkpointerConfig.Namespace,
+		LeaderElectionID:        "hookdeliveryforwarder",
+		// Let a standby replica take over as soon as the leader shuts down
+		LeaderElectionReleaseOnCancel: true,
 		WebhookServer: webhook.NewServer(webhook.Options{
 			Port: 9443,
 		}),
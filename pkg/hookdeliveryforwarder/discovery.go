@@ -0,0 +1,118 @@
+package hookdeliveryforwarder
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	gogithub "github.com/google/go-github/v52/github"
+)
+
+// runOrg runs the rule for each repository of the organization, discovering new repositories periodically.
+// Archived repositories are skipped, as they don't run any workflow.
+func (f *MultiForwarder) runOrg(ctx context.Context, org string, rule Rule) error {
+	interval := 10 * time.Minute
+	if f.DiscoveryInterval > 0 {
+		interval = f.DiscoveryInterval
+	}
+
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		running = map[string]bool{}
+	)
+
+	defer wg.Wait()
+
+	for {
+		repos, err := listOrgRepos(ctx, f.client.Client, org)
+		if err != nil {
+			f.Errorf("failed discovering repositories of %s: %v", org, err)
+
+			if errors.Is(err, context.Canceled) {
+				return err
+			}
+		}
+
+		for _, repo := range repos {
+			if !f.Shard.Owns(repo) {
+				continue
+			}
+
+			r := rule
+			r.Repo = repo
+
+			mu.Lock()
+			if running[repo] {
+				mu.Unlock()
+				continue
+			}
+			running[repo] = true
+			mu.Unlock()
+
+			f.Logf("Discovered %s", repo)
+
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+
+				err := f.runRepo(ctx, r)
+				if err != nil && !errors.Is(err, context.Canceled) {
+					f.Errorf("failed running the rule for %s: %v", r.Repo, err)
+				}
+
+				var perr persistentError
+				if errors.As(err, &perr) {
+					// Don't retry e.g. a repository the hook can't be created in
+					return
+				}
+
+				mu.Lock()
+				delete(running, r.Repo)
+				mu.Unlock()
+			}()
+		}
+
+		t := time.NewTimer(interval)
+
+		select {
+		case <-t.C:
+			t.Stop()
+		case <-ctx.Done():
+			t.Stop()
+
+			return ctx.Err()
+		}
+	}
+}
+
+// listOrgRepos returns the full names of the repositories of the organization that aren't archived.
+func listOrgRepos(ctx context.Context, client *gogithub.Client, org string) ([]string, error) {
+	opts := &gogithub.RepositoryListByOrgOptions{
+		ListOptions: gogithub.ListOptions{PerPage: 100},
+	}
+
+	var repos []string
+
+	for {
+		rs, resp, err := client.Repositories.ListByOrg(ctx, org, opts)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, r := range rs {
+			if r.GetArchived() {
+				continue
+			}
+
+			repos = append(repos, r.GetFullName())
+		}
+
+		if resp.NextPage == 0 {
+			return repos, nil
+		}
+
+		opts.Page = resp.NextPage
+	}
+}
@@ -1,12 +1,9 @@
 package hookdeliveryforwarder
 
 import (
-	"bytes"
 	"context"
 	"errors"
 	"fmt"
-	"net/http"
-	"os"
 	"sort"
 	"strings"
 	"time"
@@ -16,13 +13,28 @@ import (
 )
 
 type Forwarder struct {
-	Repo   string
+	Repo string
+
+	// Target is the URI of the sink the deliveries are forwarded to. See NewSink for the supported schemes.
 	Target string
 
+	// Sink overrides the sink built from Target.
+	Sink Sink
+
+	// Secret is used to sign the forwarded payloads, so that the target can verify them
+	// the same way as it verifies the webhooks sent by GitHub.
+	Secret []byte
+
 	Hook gogithub.Hook
 
 	PollingDelay time.Duration
 
+	// RetryDelay is the delay before a delivery that the sink gave up on is retried.
+	// It is doubled on every consecutive failure, up to maxRetryDelay.
+	// Deliveries are never skipped, as a 4xx status may come from a target that is still rolling out
+	// or a proxy in front of it.
+	RetryDelay time.Duration
+
 	Client *github.Client
 
 	Checkpointer Checkpointer
@@ -30,6 +42,8 @@ type Forwarder struct {
 	logger
 }
 
+const maxRetryDelay = 5 * time.Minute
+
 type persistentError struct {
 	Err error
 }
@@ -44,6 +58,23 @@ func (f *Forwarder) Run(ctx context.Context) error {
 		pollingDelay = f.PollingDelay
 	}
 
+	initialRetryDelay := 5 * time.Second
+	if f.RetryDelay > 0 {
+		initialRetryDelay = f.RetryDelay
+	}
+
+	retryDelay := initialRetryDelay
+
+	sink := f.Sink
+	if sink == nil {
+		s, err := NewSink(f.Target, SinkOptions{Secret: f.Secret})
+		if err != nil {
+			return persistentError{Err: err}
+		}
+
+		sink = s
+	}
+
 	segments := strings.Split(f.Repo, "/")
 
 	owner := segments[0]
@@ -56,45 +87,24 @@ func (f *Forwarder) Run(ctx context.Context) error {
 
 	hooksAPI := newHooksAPI(f.Client.Client, owner, repo)
 
-	hooks, _, err := hooksAPI.ListHooks(ctx, nil)
+	hooks, err := listAllHooks(ctx, hooksAPI)
 	if err != nil {
 		f.Errorf("Failed listing hooks: %v", err)
 
 		return err
 	}
 
-	var hook *gogithub.Hook
-
-	for i := range hooks {
-		hook = hooks[i]
-		break
-	}
+	hook := selectHook(hooks, f.Hook)
 
 	if hook == nil {
-		hookConfig := &f.Hook
-
-		if _, ok := hookConfig.Config["url"]; !ok {
-			return persistentError{Err: fmt.Errorf("config.url is missing in the hook config")}
-		}
-
-		if _, ok := hookConfig.Config["content_type"]; !ok {
-			hookConfig.Config["content_type"] = "json"
+		if f.Hook.ID != nil {
+			return persistentError{Err: fmt.Errorf("hook %d is not found in %s", f.Hook.GetID(), f.Repo)}
 		}
 
-		if _, ok := hookConfig.Config["insecure_ssl"]; !ok {
-			hookConfig.Config["insecure_ssl"] = 0
-		}
-
-		if _, ok := hookConfig.Config["secret"]; !ok {
-			hookConfig.Config["secret"] = os.Getenv("GITHUB_HOOK_SECRET")
-		}
+		hookConfig := newHookConfig(f.Hook)
 
-		if len(hookConfig.Events) == 0 {
-			hookConfig.Events = []string{"check_run", "push"}
-		}
-
-		if hookConfig.Active == nil {
-			hookConfig.Active = gogithub.Bool(true)
+		if _, ok := hookConfig.Config["url"]; !ok {
+			return persistentError{Err: fmt.Errorf("config.url is missing in the hook config")}
 		}
 
 		h, _, err := hooksAPI.CreateHook(ctx, hookConfig)
@@ -120,12 +130,7 @@ func (f *Forwarder) Run(ctx context.Context) error {
 
 LOOP:
 	for {
-		var (
-			err      error
-			payloads [][]byte
-		)
-
-		payloads, cur, err = f.getUnprocessedDeliveries(ctx, hookDeliveries, *cur)
+		deliveries, next, err := f.getUnprocessedDeliveries(ctx, hookDeliveries, *cur)
 		if err != nil {
 			f.Errorf("failed getting unprocessed deliveries: %v", err)
 
@@ -134,11 +139,16 @@ LOOP:
 			}
 		}
 
-		for _, p := range payloads {
-			if _, err := http.Post(f.Target, "application/json", bytes.NewReader(p)); err != nil {
-				f.Errorf("failed forwarding delivery: %v", err)
+		for _, d := range deliveries {
+			err := sink.Send(ctx, d)
+			if err != nil {
+				f.Errorf("failed forwarding delivery: %v. Retrying in %s", err, retryDelay)
+
+				// Resume from the last forwarded delivery, so that this one is retried
+				if err := f.Checkpointer.Update(hook.GetID(), cur); err != nil {
+					return fmt.Errorf("failed updating checkpoint: %w", err)
+				}
 
-				retryDelay := 5 * time.Second
 				t := time.NewTimer(retryDelay)
 
 				select {
@@ -150,10 +160,20 @@ LOOP:
 					return ctx.Err()
 				}
 
+				retryDelay = min(retryDelay*2, max(maxRetryDelay, initialRetryDelay))
+
 				continue LOOP
-			} else {
-				f.Logf("Successfully POSTed the payload to %s", f.Target)
 			}
+
+			retryDelay = initialRetryDelay
+
+			f.Logf("Successfully forwarded the delivery %d to %s", d.GetID(), f.Target)
+
+			cur = &State{DeliveredAt: d.GetDeliveredAt().Time, ID: d.GetID()}
+		}
+
+		if next != nil {
+			cur = next
 		}
 
 		if err := f.Checkpointer.Update(hook.GetID(), cur); err != nil {
@@ -178,7 +198,7 @@ type State struct {
 	ID          int64
 }
 
-func (f *Forwarder) getUnprocessedDeliveries(ctx context.Context, hookDeliveries *hookDeliveriesAPI, pos State) ([][]byte, *State, error) {
+func (f *Forwarder) getUnprocessedDeliveries(ctx context.Context, hookDeliveries *hookDeliveriesAPI, pos State) ([]*gogithub.HookDelivery, *State, error) {
 	var (
 		opts gogithub.ListCursorOptions
 	)
@@ -187,6 +207,9 @@ func (f *Forwarder) getUnprocessedDeliveries(ctx context.Context, hookDeliveries
 
 	var deliveries []*gogithub.HookDelivery
 
+	// pos is advanced while listing, whereas deliveries are compared against the position we started from
+	from := pos
+
 OUTER:
 	for {
 		ds, resp, err := hookDeliveries.ListHookDeliveries(ctx, &opts)
@@ -210,12 +233,12 @@ OUTER:
 			id := d.GetID()
 			deliveredAt := d.GetDeliveredAt()
 
-			if !pos.DeliveredAt.IsZero() && deliveredAt.Before(pos.DeliveredAt) {
-				f.Logf("%s is before %s so skipping all the remaining deliveries", deliveredAt, pos.DeliveredAt)
+			if !from.DeliveredAt.IsZero() && deliveredAt.Before(from.DeliveredAt) {
+				f.Logf("%s is before %s so skipping all the remaining deliveries", deliveredAt, from.DeliveredAt)
 				break OUTER
 			}
 
-			if pos.ID != 0 && id <= pos.ID {
+			if from.ID != 0 && id <= from.ID {
 				break OUTER
 			}
 
@@ -243,11 +266,5 @@ OUTER:
 		return deliveries[b].GetDeliveredAt().After(deliveries[a].GetDeliveredAt().Time)
 	})
 
-	var payloads [][]byte
-
-	for _, d := range deliveries {
-		payloads = append(payloads, *d.Request.RawPayload)
-	}
-
-	return payloads, &pos, nil
+	return deliveries, &pos, nil
 }
@@ -0,0 +1,188 @@
+package hookdeliveryforwarder
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/actions/actions-runner-controller/github"
+	gogithub "github.com/google/go-github/v52/github"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type recordingCheckpointer struct {
+	mu      sync.Mutex
+	updates []State
+	onID    int64
+	done    func()
+}
+
+func (p *recordingCheckpointer) GetOrCreate(hookID int64) (*State, error) {
+	return &State{}, nil
+}
+
+func (p *recordingCheckpointer) Update(hookID int64, pos *State) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.updates = append(p.updates, *pos)
+
+	if pos.ID == p.onID {
+		p.done()
+	}
+
+	return nil
+}
+
+// newTestGitHubClient returns a client of a stand-in for the GitHub API that has the deliveries 11 and 12 of the hook 1 of owner/repo.
+func newTestGitHubClient(t *testing.T) *github.Client {
+	t.Helper()
+
+	deliveredAt := func(id int64) string {
+		return time.Date(2024, 1, 1, 0, 0, int(id), 0, time.UTC).Format(time.RFC3339)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repos/owner/repo/hooks", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[{"id": 1}]`)
+	})
+	mux.HandleFunc("/repos/owner/repo/hooks/1/deliveries", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `[{"id": 12, "delivered_at": %q}, {"id": 11, "delivered_at": %q}]`, deliveredAt(12), deliveredAt(11))
+	})
+	for _, id := range []int64{11, 12} {
+		id := id
+		mux.HandleFunc(fmt.Sprintf("/repos/owner/repo/hooks/1/deliveries/%d", id), func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprintf(w, `{"id": %d, "guid": "guid-%d", "event": "workflow_job", "delivered_at": %q, "request": {"headers": {"X-GitHub-Event": "workflow_job", "X-GitHub-Delivery": "guid-%d"}, "payload": {"id": %d}}}`, id, id, deliveredAt(id), id, id)
+		})
+	}
+	api := httptest.NewServer(mux)
+	t.Cleanup(api.Close)
+
+	c := github.Config{Token: "token"}
+	client, err := c.NewClient()
+	require.NoError(t, err)
+
+	baseURL, err := url.Parse(api.URL + "/")
+	require.NoError(t, err)
+	client.BaseURL = baseURL
+
+	return client
+}
+
+func TestForwarderRun(t *testing.T) {
+	client := newTestGitHubClient(t)
+
+	var (
+		mu       sync.Mutex
+		received []string
+		failures = 1
+	)
+
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if _, err := gogithub.ValidatePayload(r, []byte("secret")); err != nil {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		received = append(received, r.Header.Get("X-GitHub-Delivery"))
+
+		if failures > 0 {
+			failures--
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer target.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	checkpointer := &recordingCheckpointer{onID: 12, done: cancel}
+
+	f := &Forwarder{
+		Repo:         "owner/repo",
+		Target:       target.URL + "?retry_max_attempts=1",
+		Secret:       []byte("secret"),
+		PollingDelay: 10 * time.Millisecond,
+		RetryDelay:   10 * time.Millisecond,
+		Client:       client,
+		Checkpointer: checkpointer,
+	}
+
+	err := f.Run(ctx)
+	require.ErrorIs(t, err, context.Canceled)
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	// The first attempt of 11 fails, and it is retried before 12 is forwarded
+	assert.Equal(t, []string{"guid-11", "guid-11", "guid-12"}, received)
+
+	checkpointer.mu.Lock()
+	defer checkpointer.mu.Unlock()
+
+	require.GreaterOrEqual(t, len(checkpointer.updates), 2)
+	assert.Equal(t, State{}, checkpointer.updates[0], "the checkpoint must not move past the failed delivery")
+	assert.Equal(t, int64(12), checkpointer.updates[len(checkpointer.updates)-1].ID)
+}
+
+func TestForwarderRunRetries4xx(t *testing.T) {
+	client := newTestGitHubClient(t)
+
+	var (
+		mu       sync.Mutex
+		received []string
+		notFound = 4
+	)
+
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		received = append(received, r.Header.Get("X-GitHub-Delivery"))
+
+		// Like an ingress that is still rolling out
+		if r.Header.Get("X-GitHub-Delivery") == "guid-11" && notFound > 0 {
+			notFound--
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer target.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	checkpointer := &recordingCheckpointer{onID: 12, done: cancel}
+
+	f := &Forwarder{
+		Repo:         "owner/repo",
+		Target:       target.URL + "?retry_max_attempts=3&retry_backoff=1ms",
+		PollingDelay: 10 * time.Millisecond,
+		RetryDelay:   10 * time.Millisecond,
+		Client:       client,
+		Checkpointer: checkpointer,
+	}
+
+	err := f.Run(ctx)
+	require.ErrorIs(t, err, context.Canceled)
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	// 11 is retried 3 times by the sink, and again by the forwarder once the sink gave up
+	assert.Equal(t, []string{"guid-11", "guid-11", "guid-11", "guid-11", "guid-11", "guid-12"}, received)
+}
@@ -2,6 +2,7 @@ package hookdeliveryforwarder
 
 import (
 	"context"
+	"os"
 
 	gogithub "github.com/google/go-github/v52/github"
 )
@@ -44,3 +45,103 @@ func orgHooksAPI(svc *gogithub.OrganizationsService, org string) *hooksAPI {
 		},
 	}
 }
+
+// defaultHookEvents are the events of the hooks created by the forwarder when none are specified.
+// workflow_job is what the webhook-based autoscaling of githubwebhookserver relies on.
+var defaultHookEvents = []string{"workflow_job"}
+
+func listAllHooks(ctx context.Context, api *hooksAPI) ([]*gogithub.Hook, error) {
+	opts := &gogithub.ListOptions{PerPage: 100}
+
+	var hooks []*gogithub.Hook
+
+	for {
+		hs, resp, err := api.ListHooks(ctx, opts)
+		if err != nil {
+			return nil, err
+		}
+
+		hooks = append(hooks, hs...)
+
+		if resp.NextPage == 0 {
+			return hooks, nil
+		}
+
+		opts.Page = resp.NextPage
+	}
+}
+
+// selectHook returns the first hook that matches the ID, config.url and events of the given hook config,
+// ignoring the ones that aren't set. It returns nil when no hook matches.
+//
+// A hook matches the events when it is subscribed to all of them, either explicitly or via the "*" wildcard.
+func selectHook(hooks []*gogithub.Hook, selector gogithub.Hook) *gogithub.Hook {
+	url, _ := selector.Config["url"].(string)
+
+HOOKS:
+	for _, h := range hooks {
+		if selector.ID != nil && h.GetID() != selector.GetID() {
+			continue
+		}
+
+		if url != "" {
+			if u, _ := h.Config["url"].(string); u != url {
+				continue
+			}
+		}
+
+		for _, e := range selector.Events {
+			if !containsEvent(h.Events, e) {
+				continue HOOKS
+			}
+		}
+
+		return h
+	}
+
+	return nil
+}
+
+func containsEvent(events []string, event string) bool {
+	for _, e := range events {
+		if e == event || e == "*" {
+			return true
+		}
+	}
+
+	return false
+}
+
+// newHookConfig returns a copy of the hook config with the defaults applied.
+// The config is copied as it is shared by the forwarders of all the repositories of a rule.
+func newHookConfig(hook gogithub.Hook) *gogithub.Hook {
+	config := map[string]interface{}{}
+
+	for k, v := range hook.Config {
+		config[k] = v
+	}
+
+	if _, ok := config["content_type"]; !ok {
+		config["content_type"] = "json"
+	}
+
+	if _, ok := config["insecure_ssl"]; !ok {
+		config["insecure_ssl"] = 0
+	}
+
+	if _, ok := config["secret"]; !ok {
+		config["secret"] = os.Getenv("GITHUB_HOOK_SECRET")
+	}
+
+	hook.Config = config
+
+	if len(hook.Events) == 0 {
+		hook.Events = defaultHookEvents
+	}
+
+	if hook.Active == nil {
+		hook.Active = gogithub.Bool(true)
+	}
+
+	return &hook
+}
@@ -9,6 +9,8 @@ import (
 type hookDeliveriesAPI struct {
 	GetHookDelivery    func(ctx context.Context, id int64) (*gogithub.HookDelivery, *gogithub.Response, error)
 	ListHookDeliveries func(ctx context.Context, opts *gogithub.ListCursorOptions) ([]*gogithub.HookDelivery, *gogithub.Response, error)
+	// RedeliverHookDelivery asks GitHub to send the delivery to the hook again.
+	RedeliverHookDelivery func(ctx context.Context, id int64) (*gogithub.HookDelivery, *gogithub.Response, error)
 }
 
 func newHookDeliveriesAPI(client *gogithub.Client, org, repo string, hookID int64) *hookDeliveriesAPI {
@@ -31,6 +33,9 @@ func repoHookDeliveriesAPI(svc *gogithub.RepositoriesService, org, repo string,
 		ListHookDeliveries: func(ctx context.Context, opts *gogithub.ListCursorOptions) ([]*gogithub.HookDelivery, *gogithub.Response, error) {
 			return svc.ListHookDeliveries(ctx, org, repo, hookID, opts)
 		},
+		RedeliverHookDelivery: func(ctx context.Context, id int64) (*gogithub.HookDelivery, *gogithub.Response, error) {
+			return svc.RedeliverHookDelivery(ctx, org, repo, hookID, id)
+		},
 	}
 }
 
@@ -42,5 +47,8 @@ func orgHookDeliveriesAPI(svc *gogithub.OrganizationsService, org string, hookID
 		ListHookDeliveries: func(ctx context.Context, opts *gogithub.ListCursorOptions) ([]*gogithub.HookDelivery, *gogithub.Response, error) {
 			return svc.ListHookDeliveries(ctx, org, hookID, opts)
 		},
+		RedeliverHookDelivery: func(ctx context.Context, id int64) (*gogithub.HookDelivery, *gogithub.Response, error) {
+			return svc.RedeliverHookDelivery(ctx, org, hookID, id)
+		},
 	}
 }
@@ -0,0 +1,150 @@
+package hookdeliveryforwarder
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/actions/actions-runner-controller/github"
+	gogithub "github.com/google/go-github/v52/github"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSelectHook(t *testing.T) {
+	hooks := []*gogithub.Hook{
+		{ID: gogithub.Int64(1), Events: []string{"push", "check_run"}, Config: map[string]interface{}{"url": "https://ci.example.com/hook"}},
+		{ID: gogithub.Int64(2), Events: []string{"workflow_job"}, Config: map[string]interface{}{"url": "https://arc.example.com/hook"}},
+		{ID: gogithub.Int64(3), Events: []string{"*"}, Config: map[string]interface{}{"url": "https://all.example.com/hook"}},
+	}
+
+	testcases := []struct {
+		description string
+		selector    gogithub.Hook
+		want        int64
+	}{
+		{
+			description: "the first hook is selected without any selector",
+			want:        1,
+		},
+		{
+			description: "by id",
+			selector:    gogithub.Hook{ID: gogithub.Int64(2)},
+			want:        2,
+		},
+		{
+			description: "by url",
+			selector:    gogithub.Hook{Config: map[string]interface{}{"url": "https://all.example.com/hook"}},
+			want:        3,
+		},
+		{
+			description: "by events",
+			selector:    gogithub.Hook{Events: []string{"workflow_job"}},
+			want:        2,
+		},
+		{
+			description: "by events including a wildcard subscription",
+			selector:    gogithub.Hook{Events: []string{"workflow_job", "push"}},
+			want:        3,
+		},
+		{
+			description: "by id and url that don't match the same hook",
+			selector:    gogithub.Hook{ID: gogithub.Int64(1), Config: map[string]interface{}{"url": "https://arc.example.com/hook"}},
+		},
+		{
+			description: "by unknown id",
+			selector:    gogithub.Hook{ID: gogithub.Int64(4)},
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.description, func(t *testing.T) {
+			got := selectHook(hooks, tc.selector)
+
+			if tc.want == 0 {
+				assert.Nil(t, got)
+				return
+			}
+
+			require.NotNil(t, got)
+			assert.Equal(t, tc.want, got.GetID())
+		})
+	}
+}
+
+func TestNewHookConfig(t *testing.T) {
+	t.Setenv("GITHUB_HOOK_SECRET", "secret")
+
+	shared := gogithub.Hook{Config: map[string]interface{}{"url": "https://arc.example.com/hook"}}
+
+	hook := newHookConfig(shared)
+
+	assert.Equal(t, []string{"workflow_job"}, hook.Events)
+	assert.True(t, hook.GetActive())
+	assert.Equal(t, map[string]interface{}{
+		"url":          "https://arc.example.com/hook",
+		"content_type": "json",
+		"insecure_ssl": 0,
+		"secret":       "secret",
+	}, hook.Config)
+
+	assert.Len(t, shared.Config, 1, "the shared config must not be modified")
+
+	hook = newHookConfig(gogithub.Hook{Events: []string{"check_run"}, Config: map[string]interface{}{"url": "https://arc.example.com/hook", "content_type": "form"}})
+
+	assert.Equal(t, []string{"check_run"}, hook.Events)
+	assert.Equal(t, "form", hook.Config["content_type"])
+}
+
+func TestListOrgRepos(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/orgs/org/repos", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("page") == "2" {
+			fmt.Fprint(w, `[{"full_name": "org/c"}]`)
+			return
+		}
+
+		w.Header().Set("Link", fmt.Sprintf(`<%s/orgs/org/repos?page=2>; rel="next"`, "http://"+r.Host))
+		fmt.Fprint(w, `[{"full_name": "org/a"}, {"full_name": "org/b", "archived": true}]`)
+	})
+	api := httptest.NewServer(mux)
+	defer api.Close()
+
+	c := github.Config{Token: "token"}
+	client, err := c.NewClient()
+	require.NoError(t, err)
+
+	baseURL, err := url.Parse(api.URL + "/")
+	require.NoError(t, err)
+	client.BaseURL = baseURL
+
+	repos, err := listOrgRepos(context.Background(), client.Client, "org")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"org/a", "org/c"}, repos)
+
+	_, err = listOrgRepos(context.Background(), client.Client, "missing")
+	assert.Error(t, err)
+}
+
+func TestInMemoryCheckpointer(t *testing.T) {
+	p := NewInMemoryLogPositionProvider()
+
+	require.NoError(t, p.Update(1, &State{ID: 10}))
+	require.NoError(t, p.Update(2, &State{ID: 20}))
+
+	pos, err := p.GetOrCreate(1)
+	require.NoError(t, err)
+	assert.Equal(t, int64(10), pos.ID)
+
+	pos, err = p.GetOrCreate(2)
+	require.NoError(t, err)
+	assert.Equal(t, int64(20), pos.ID)
+
+	pos, err = p.GetOrCreate(3)
+	require.NoError(t, err)
+	assert.Zero(t, pos.ID)
+	assert.False(t, pos.DeliveredAt.IsZero())
+}
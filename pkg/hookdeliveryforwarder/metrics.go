@@ -0,0 +1,74 @@
+package hookdeliveryforwarder
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+func init() {
+	metrics.Registry.MustRegister(
+		sinkAttemptsTotal,
+		sinkAttemptDurationSeconds,
+		sinkDeliveriesTotal,
+		redeliveriesTotal,
+	)
+}
+
+const (
+	resultSuccess = "success"
+	resultFailure = "failure"
+)
+
+var (
+	sinkAttemptsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "hookdeliveryforwarder_sink_attempts_total",
+			Help: "Total number of attempts to send a hook delivery to a sink, including retries",
+		},
+		[]string{"scheme", "result"},
+	)
+	sinkAttemptDurationSeconds = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "hookdeliveryforwarder_sink_attempt_duration_seconds",
+			Help:    "Time taken by an attempt to send a hook delivery to a sink",
+			Buckets: prometheus.DefBuckets,
+		},
+		[]string{"scheme"},
+	)
+	sinkDeliveriesTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "hookdeliveryforwarder_sink_deliveries_total",
+			Help: "Total number of hook deliveries sent to a sink, or given up after all the retries",
+		},
+		[]string{"scheme", "result"},
+	)
+	redeliveriesTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "hookdeliveryforwarder_redeliveries_total",
+			Help: "Total number of failed hook deliveries redelivered by GitHub or forwarded to a sink",
+		},
+		[]string{"method", "result"},
+	)
+)
+
+func result(err error) string {
+	if err != nil {
+		return resultFailure
+	}
+	return resultSuccess
+}
+
+func observeSinkAttempt(scheme string, err error, d time.Duration) {
+	sinkAttemptsTotal.WithLabelValues(scheme, result(err)).Inc()
+	sinkAttemptDurationSeconds.WithLabelValues(scheme).Observe(d.Seconds())
+}
+
+func observeSinkDelivery(scheme string, err error) {
+	sinkDeliveriesTotal.WithLabelValues(scheme, result(err)).Inc()
+}
+
+func observeRedelivery(method string, err error) {
+	redeliveriesTotal.WithLabelValues(method, result(err)).Inc()
+}
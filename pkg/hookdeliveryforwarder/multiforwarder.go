@@ -5,7 +5,9 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/actions/actions-runner-controller/github"
 	gogithub "github.com/google/go-github/v52/github"
@@ -18,6 +20,23 @@ type MultiForwarder struct {
 
 	Checkpointer Checkpointer
 
+	// Secret is used to sign the forwarded payloads.
+	Secret []byte
+
+	// Redelivery makes the rules redeliver failed deliveries only. See NewRedelivery.
+	Redelivery bool
+
+	// RedeliveryLookback and RedeliveryInterval configure the Redeliverer of each rule.
+	RedeliveryLookback time.Duration
+	RedeliveryInterval time.Duration
+
+	// DiscoveryInterval is the interval between two discoveries of the repositories of the "org/*" rules.
+	// Defaults to 10 minutes.
+	DiscoveryInterval time.Duration
+
+	// Shard limits the rules to the repositories and organizations owned by this replica.
+	Shard Shard
+
 	logger
 }
 
@@ -28,12 +47,32 @@ type RuleConfig struct {
 }
 
 type Rule struct {
+	// Repo is either "owner/repo" for a repository hook, "org" for an organization hook,
+	// or "org/*" for the repository hooks of all the repositories of the organization.
 	Repo   string
 	Target string
 	Hook   gogithub.Hook
 }
 
 func New(client *github.Client, rules []string) (*MultiForwarder, error) {
+	return newMultiForwarder(client, rules, true)
+}
+
+// NewRedelivery returns a MultiForwarder that redelivers the failed deliveries of the hooks of each rule,
+// instead of forwarding all the deliveries. The destination of a rule is optional.
+// When omitted, GitHub is asked to redeliver to the hook itself. See Redeliverer.
+func NewRedelivery(client *github.Client, rules []string) (*MultiForwarder, error) {
+	srv, err := newMultiForwarder(client, rules, false)
+	if err != nil {
+		return nil, err
+	}
+
+	srv.Redelivery = true
+
+	return srv, nil
+}
+
+func newMultiForwarder(client *github.Client, rules []string, targetRequired bool) (*MultiForwarder, error) {
 	var srv MultiForwarder
 
 	for _, r := range rules {
@@ -47,7 +86,7 @@ func New(client *github.Client, rules []string) (*MultiForwarder, error) {
 			return nil, fmt.Errorf("there must be one or more sources configured via `--repo \"from=SOURCE1,SOURCE2,... to=DEST1,DEST2,...\". got %q", r)
 		}
 
-		if rule.Target == "" {
+		if targetRequired && rule.Target == "" {
 			return nil, fmt.Errorf("there must be one destination configured via `--repo \"from=SOURCE to=DEST1,DEST2,...\". got %q", r)
 		}
 
@@ -73,6 +112,12 @@ func (f *MultiForwarder) Run(ctx context.Context) error {
 
 	for _, r := range f.Rules {
 		r := r
+
+		// The repositories of an organization are sharded individually by runOrg
+		if _, ok := strings.CutSuffix(r.Repo, "/*"); !ok && !f.Shard.Owns(r.Repo) {
+			continue
+		}
+
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
@@ -92,10 +137,23 @@ func (f *MultiForwarder) Run(ctx context.Context) error {
 }
 
 func (f *MultiForwarder) run(ctx context.Context, rule Rule) error {
+	if org, ok := strings.CutSuffix(rule.Repo, "/*"); ok {
+		return f.runOrg(ctx, org, rule)
+	}
+
+	return f.runRepo(ctx, rule)
+}
+
+func (f *MultiForwarder) runRepo(ctx context.Context, rule Rule) error {
+	if f.Redelivery {
+		return f.redeliver(ctx, rule)
+	}
+
 	i := &Forwarder{
 		Repo:         rule.Repo,
 		Target:       rule.Target,
 		Hook:         rule.Hook,
+		Secret:       f.Secret,
 		Client:       f.client,
 		Checkpointer: f.Checkpointer,
 	}
@@ -103,6 +161,28 @@ func (f *MultiForwarder) run(ctx context.Context, rule Rule) error {
 	return i.Run(ctx)
 }
 
+func (f *MultiForwarder) redeliver(ctx context.Context, rule Rule) error {
+	r := &Redeliverer{
+		Repo:         rule.Repo,
+		HookID:       rule.Hook.GetID(),
+		Lookback:     f.RedeliveryLookback,
+		Interval:     f.RedeliveryInterval,
+		Client:       f.client,
+		Checkpointer: f.Checkpointer,
+	}
+
+	if rule.Target != "" {
+		sink, err := NewSink(rule.Target, SinkOptions{Secret: f.Secret})
+		if err != nil {
+			return err
+		}
+
+		r.Sink = sink
+	}
+
+	return r.Run(ctx)
+}
+
 func (f *MultiForwarder) HandleReadyz(w http.ResponseWriter, r *http.Request) {
 	var (
 		ok bool
@@ -0,0 +1,260 @@
+package hookdeliveryforwarder
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/actions/actions-runner-controller/github"
+	gogithub "github.com/google/go-github/v52/github"
+)
+
+const (
+	redeliveryMethodGitHub = "github"
+	redeliveryMethodSink   = "sink"
+)
+
+// Redeliverer periodically scans the recent deliveries of the hooks of Repo, and redelivers the ones
+// that the receiver failed to respond to with a 2xx status, like when it was unreachable behind a flaky ingress.
+//
+// A failed delivery is redelivered by GitHub through the hook deliveries API by default,
+// or forwarded by the Redeliverer itself to Sink when set.
+type Redeliverer struct {
+	// Repo is either "owner/repo" for repository hooks or "org" for organization hooks.
+	Repo string
+
+	// HookID limits the redeliveries to the hook. All the hooks of Repo are scanned when zero.
+	HookID int64
+
+	// Lookback is how far back the deliveries are scanned. Defaults to an hour.
+	// Deliveries that failed before the window are never redelivered.
+	Lookback time.Duration
+
+	// Interval is the delay between two scans. Defaults to a minute.
+	Interval time.Duration
+
+	// Sink receives the failed deliveries instead of the hook when set.
+	Sink Sink
+
+	Client *github.Client
+
+	// Checkpointer stores the last delivery handled per hook, so that a delivery is redelivered at most once.
+	Checkpointer Checkpointer
+
+	now func() time.Time
+
+	logger
+}
+
+func (r *Redeliverer) Run(ctx context.Context) error {
+	interval := time.Minute
+	if r.Interval > 0 {
+		interval = r.Interval
+	}
+
+	for {
+		if err := r.reconcile(ctx); err != nil {
+			r.Errorf("failed redelivering failed deliveries: %v", err)
+
+			var perr persistentError
+			if errors.As(err, &perr) || errors.Is(err, context.Canceled) {
+				return err
+			}
+		}
+
+		t := time.NewTimer(interval)
+
+		select {
+		case <-t.C:
+			t.Stop()
+		case <-ctx.Done():
+			t.Stop()
+
+			return ctx.Err()
+		}
+	}
+}
+
+func (r *Redeliverer) reconcile(ctx context.Context) error {
+	segments := strings.Split(r.Repo, "/")
+
+	owner := segments[0]
+
+	var repo string
+
+	if len(segments) > 1 {
+		repo = segments[1]
+	}
+
+	hooks, _, err := newHooksAPI(r.Client.Client, owner, repo).ListHooks(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+
+	for _, hook := range hooks {
+		if r.HookID != 0 && hook.GetID() != r.HookID {
+			continue
+		}
+
+		api := newHookDeliveriesAPI(r.Client.Client, owner, repo, hook.GetID())
+
+		if err := r.reconcileHook(ctx, api, hook.GetID()); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func (r *Redeliverer) reconcileHook(ctx context.Context, api *hookDeliveriesAPI, hookID int64) error {
+	cur, err := r.Checkpointer.GetOrCreate(hookID)
+	if err != nil {
+		return persistentError{Err: err}
+	}
+
+	lookback := time.Hour
+	if r.Lookback > 0 {
+		lookback = r.Lookback
+	}
+
+	now := time.Now
+	if r.now != nil {
+		now = r.now
+	}
+
+	failed, next, err := r.getFailedDeliveries(ctx, api, *cur, now().Add(-lookback))
+	if err != nil {
+		return err
+	}
+
+	for _, d := range failed {
+		if err := r.redeliver(ctx, api, d); err != nil {
+			observeRedelivery(r.method(), err)
+
+			// Resume from the last redelivered delivery, so that this one is retried in the next scan
+			if err := r.Checkpointer.Update(hookID, cur); err != nil {
+				return err
+			}
+
+			return err
+		}
+
+		observeRedelivery(r.method(), nil)
+
+		r.Logf("Redelivered the delivery %d of the hook %d that failed with status %d", d.GetID(), hookID, d.GetStatusCode())
+
+		cur = &State{DeliveredAt: d.GetDeliveredAt().Time, ID: d.GetID()}
+	}
+
+	if next != nil {
+		cur = next
+	}
+
+	return r.Checkpointer.Update(hookID, cur)
+}
+
+func (r *Redeliverer) method() string {
+	if r.Sink != nil {
+		return redeliveryMethodSink
+	}
+	return redeliveryMethodGitHub
+}
+
+func (r *Redeliverer) redeliver(ctx context.Context, api *hookDeliveriesAPI, d *gogithub.HookDelivery) error {
+	if r.Sink == nil {
+		_, _, err := api.RedeliverHookDelivery(ctx, d.GetID())
+
+		// GitHub responds with 202 Accepted as the redelivery is asynchronous
+		var accepted *gogithub.AcceptedError
+		if errors.As(err, &accepted) {
+			return nil
+		}
+
+		return err
+	}
+
+	full, _, err := api.GetHookDelivery(ctx, d.GetID())
+	if err != nil {
+		return err
+	}
+
+	return r.Sink.Send(ctx, full)
+}
+
+// getFailedDeliveries returns the original deliveries after pos and since that failed and haven't been redelivered successfully,
+// oldest first, along with the position of the newest original delivery scanned.
+func (r *Redeliverer) getFailedDeliveries(ctx context.Context, api *hookDeliveriesAPI, pos State, since time.Time) ([]*gogithub.HookDelivery, *State, error) {
+	var opts gogithub.ListCursorOptions
+
+	opts.PerPage = 100
+
+	var (
+		originals []*gogithub.HookDelivery
+
+		// succeeded holds the GUIDs of the deliveries that any attempt succeeded for
+		succeeded = map[string]bool{}
+	)
+
+	from := pos
+
+OUTER:
+	for {
+		ds, resp, err := api.ListHookDeliveries(ctx, &opts)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		opts.Cursor = resp.Cursor
+
+		for _, d := range ds {
+			if d.GetDeliveredAt().Before(since) {
+				break OUTER
+			}
+
+			if isSuccessfulDelivery(d) {
+				succeeded[d.GetGUID()] = true
+			}
+
+			if d.GetRedelivery() {
+				continue
+			}
+
+			if from.ID != 0 && d.GetID() <= from.ID {
+				break OUTER
+			}
+
+			originals = append(originals, d)
+
+			if d.GetID() > pos.ID {
+				pos.ID = d.GetID()
+				pos.DeliveredAt = d.GetDeliveredAt().Time
+			}
+		}
+
+		if opts.Cursor == "" {
+			break
+		}
+	}
+
+	var failed []*gogithub.HookDelivery
+
+	for _, d := range originals {
+		if !succeeded[d.GetGUID()] {
+			failed = append(failed, d)
+		}
+	}
+
+	sort.Slice(failed, func(a, b int) bool {
+		return failed[a].GetID() < failed[b].GetID()
+	})
+
+	return failed, &pos, nil
+}
+
+func isSuccessfulDelivery(d *gogithub.HookDelivery) bool {
+	return d.GetStatusCode() >= 200 && d.GetStatusCode() <= 299
+}
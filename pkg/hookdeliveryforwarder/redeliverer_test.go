@@ -0,0 +1,187 @@
+package hookdeliveryforwarder
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/actions/actions-runner-controller/github"
+	gogithub "github.com/google/go-github/v52/github"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type redeliveryTestDelivery struct {
+	id         int64
+	guid       string
+	redelivery bool
+	statusCode int
+	age        time.Duration
+}
+
+// newRedeliveryTestAPI serves the deliveries of the hook 1 of owner/repo, newest first,
+// and records the redeliveries requested.
+func newRedeliveryTestAPI(t *testing.T, now time.Time, deliveries []redeliveryTestDelivery) (*github.Client, func() []string) {
+	t.Helper()
+
+	var (
+		mu          sync.Mutex
+		redelivered []string
+	)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repos/owner/repo/hooks", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[{"id": 1}, {"id": 2}]`)
+	})
+	mux.HandleFunc("/repos/owner/repo/hooks/2/deliveries", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[]`)
+	})
+	mux.HandleFunc("/repos/owner/repo/hooks/1/deliveries", func(w http.ResponseWriter, r *http.Request) {
+		var items []string
+		for _, d := range deliveries {
+			items = append(items, fmt.Sprintf(`{"id": %d, "guid": %q, "redelivery": %t, "status_code": %d, "event": "workflow_job", "delivered_at": %q}`,
+				d.id, d.guid, d.redelivery, d.statusCode, now.Add(-d.age).Format(time.RFC3339)))
+		}
+		fmt.Fprintf(w, "[%s]", strings.Join(items, ","))
+	})
+	mux.HandleFunc("/repos/owner/repo/hooks/1/deliveries/", func(w http.ResponseWriter, r *http.Request) {
+		rest := strings.TrimPrefix(r.URL.Path, "/repos/owner/repo/hooks/1/deliveries/")
+
+		if id, ok := strings.CutSuffix(rest, "/attempts"); ok && r.Method == http.MethodPost {
+			mu.Lock()
+			redelivered = append(redelivered, id)
+			mu.Unlock()
+
+			w.WriteHeader(http.StatusAccepted)
+			fmt.Fprint(w, `{}`)
+			return
+		}
+
+		fmt.Fprintf(w, `{"id": %s, "guid": "guid-%s", "event": "workflow_job", "request": {"headers": {"X-GitHub-Event": "workflow_job"}, "payload": {"id": %s}}}`, rest, rest, rest)
+	})
+
+	api := httptest.NewServer(mux)
+	t.Cleanup(api.Close)
+
+	c := github.Config{Token: "token"}
+	client, err := c.NewClient()
+	require.NoError(t, err)
+
+	baseURL, err := url.Parse(api.URL + "/")
+	require.NoError(t, err)
+	client.BaseURL = baseURL
+
+	return client, func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string(nil), redelivered...)
+	}
+}
+
+type recordingSink struct {
+	sent []int64
+}
+
+func (s *recordingSink) Send(ctx context.Context, d *gogithub.HookDelivery) error {
+	if d.Request == nil || d.Request.RawPayload == nil {
+		return fmt.Errorf("delivery %d has no request payload", d.GetID())
+	}
+
+	s.sent = append(s.sent, d.GetID())
+
+	return nil
+}
+
+var redeliveryTestDeliveries = []redeliveryTestDelivery{
+	{id: 16, guid: "guid-16", statusCode: 200, age: time.Minute},
+	// A redelivery of 12 that has already succeeded
+	{id: 15, guid: "guid-12", redelivery: true, statusCode: 200, age: 2 * time.Minute},
+	{id: 14, guid: "guid-14", statusCode: 502, age: 3 * time.Minute},
+	{id: 13, guid: "guid-13", statusCode: 0, age: 4 * time.Minute},
+	{id: 12, guid: "guid-12", statusCode: 503, age: 5 * time.Minute},
+	// Outside of the lookback window
+	{id: 11, guid: "guid-11", statusCode: 500, age: 2 * time.Hour},
+}
+
+func TestRedelivererReconcile(t *testing.T) {
+	now := time.Now()
+
+	client, redelivered := newRedeliveryTestAPI(t, now, redeliveryTestDeliveries)
+
+	checkpointer := &InMemoryCheckpointer{}
+
+	r := &Redeliverer{
+		Repo:         "owner/repo",
+		HookID:       1,
+		Lookback:     time.Hour,
+		Client:       client,
+		Checkpointer: checkpointer,
+		now:          func() time.Time { return now },
+	}
+
+	require.NoError(t, r.reconcile(context.Background()))
+	assert.Equal(t, []string{"13", "14"}, redelivered())
+	assert.Equal(t, int64(16), checkpointer.positions[1].ID)
+
+	// Deliveries are redelivered at most once
+	require.NoError(t, r.reconcile(context.Background()))
+	assert.Equal(t, []string{"13", "14"}, redelivered())
+}
+
+func TestRedelivererReconcileToSink(t *testing.T) {
+	now := time.Now()
+
+	client, redelivered := newRedeliveryTestAPI(t, now, redeliveryTestDeliveries)
+
+	sink := &recordingSink{}
+
+	r := &Redeliverer{
+		Repo:         "owner/repo",
+		Lookback:     time.Hour,
+		Sink:         sink,
+		Client:       client,
+		Checkpointer: &InMemoryCheckpointer{},
+		now:          func() time.Time { return now },
+	}
+
+	require.NoError(t, r.reconcile(context.Background()))
+	assert.Equal(t, []int64{13, 14}, sink.sent)
+	assert.Empty(t, redelivered(), "deliveries forwarded to the sink must not be redelivered by GitHub")
+}
+
+func TestRedelivererReconcileFailure(t *testing.T) {
+	now := time.Now()
+
+	client, _ := newRedeliveryTestAPI(t, now, redeliveryTestDeliveries)
+
+	checkpointer := &InMemoryCheckpointer{}
+
+	r := &Redeliverer{
+		Repo:         "owner/repo",
+		HookID:       1,
+		Sink:         &failingSink{failOn: 14},
+		Client:       client,
+		Checkpointer: checkpointer,
+		now:          func() time.Time { return now },
+	}
+
+	require.Error(t, r.reconcile(context.Background()))
+	assert.Equal(t, int64(13), checkpointer.positions[1].ID, "the checkpoint must not move past the delivery that failed to be redelivered")
+}
+
+type failingSink struct {
+	failOn int64
+}
+
+func (s *failingSink) Send(ctx context.Context, d *gogithub.HookDelivery) error {
+	if d.GetID() == s.failOn {
+		return fmt.Errorf("failed sending %d", d.GetID())
+	}
+	return nil
+}
@@ -0,0 +1,145 @@
+package hookdeliveryforwarder
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+
+	gogithub "github.com/google/go-github/v52/github"
+)
+
+const (
+	headerEvent           = "X-GitHub-Event"
+	headerDelivery        = "X-GitHub-Delivery"
+	headerSignatureSHA256 = "X-Hub-Signature-256"
+)
+
+// skippedHeaders are the headers of the original request that are not replayed.
+// They either describe the original connection, or carry signatures that don't match the replayed payload.
+var skippedHeaders = map[string]bool{
+	"Host":                true,
+	"Content-Length":      true,
+	"Content-Type":        true,
+	"Connection":          true,
+	"Keep-Alive":          true,
+	"Transfer-Encoding":   true,
+	"Accept-Encoding":     true,
+	"Te":                  true,
+	"Trailer":             true,
+	"Upgrade":             true,
+	"X-Hub-Signature":     true,
+	"X-Hub-Signature-256": true,
+}
+
+// NewReplayRequest returns a request to target that reproduces the request GitHub sent for the delivery,
+// including the original headers like X-GitHub-Event and X-GitHub-Delivery.
+//
+// The payload is always sent as application/json, and signed with secret in X-Hub-Signature-256 when secret is not empty.
+// The original signatures are dropped, as the payload returned by the API is not byte-for-byte what GitHub signed.
+func NewReplayRequest(ctx context.Context, target string, d *gogithub.HookDelivery, secret []byte) (*http.Request, error) {
+	payload, header, err := replayPayloadAndHeader(d, secret)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target, bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header = header
+
+	return req, nil
+}
+
+// replayPayloadAndHeader returns the payload of the delivery along with the headers to replay it with.
+func replayPayloadAndHeader(d *gogithub.HookDelivery, secret []byte) ([]byte, http.Header, error) {
+	if d.Request == nil || d.Request.RawPayload == nil {
+		return nil, nil, fmt.Errorf("delivery %d has no request payload", d.GetID())
+	}
+
+	payload := []byte(*d.Request.RawPayload)
+
+	header := http.Header{}
+
+	for k, v := range d.Request.Headers {
+		if skippedHeaders[http.CanonicalHeaderKey(k)] {
+			continue
+		}
+		header.Set(k, v)
+	}
+
+	if header.Get(headerEvent) == "" && d.GetEvent() != "" {
+		header.Set(headerEvent, d.GetEvent())
+	}
+
+	if header.Get(headerDelivery) == "" && d.GetGUID() != "" {
+		header.Set(headerDelivery, d.GetGUID())
+	}
+
+	header.Set("Content-Type", "application/json")
+
+	if len(secret) > 0 {
+		header.Set(headerSignatureSHA256, "sha256="+signPayload(secret, payload))
+	}
+
+	return payload, header, nil
+}
+
+// Replay sends the delivery to target. It fails unless the target responds with a 2xx status,
+// so that the caller can retry.
+func Replay(ctx context.Context, c *http.Client, target string, d *gogithub.HookDelivery, secret []byte) error {
+	req, err := NewReplayRequest(ctx, target, d, secret)
+	if err != nil {
+		return err
+	}
+
+	if c == nil {
+		c = http.DefaultClient
+	}
+
+	res, err := c.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		body, _ := io.ReadAll(io.LimitReader(res.Body, 1024))
+
+		return &ReplayError{
+			Target:     target,
+			DeliveryID: d.GetID(),
+			StatusCode: res.StatusCode,
+			Status:     res.Status,
+			Body:       string(body),
+		}
+	}
+
+	return nil
+}
+
+// ReplayError is returned by Replay when the target responds with a non-2xx status.
+type ReplayError struct {
+	Target     string
+	DeliveryID int64
+	StatusCode int
+	Status     string
+	Body       string
+}
+
+func (e *ReplayError) Error() string {
+	return fmt.Sprintf("target %s responded to delivery %d with %s: %s", e.Target, e.DeliveryID, e.Status, e.Body)
+}
+
+func signPayload(secret, payload []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(payload)
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,120 @@
+package hookdeliveryforwarder
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	gogithub "github.com/google/go-github/v52/github"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestDelivery(id int64, payload string, headers map[string]string) *gogithub.HookDelivery {
+	raw := json.RawMessage(payload)
+
+	return &gogithub.HookDelivery{
+		ID:    gogithub.Int64(id),
+		GUID:  gogithub.String("0b989ba4-242f-11e5-81e1-c7b6966d2516"),
+		Event: gogithub.String("workflow_job"),
+		Request: &gogithub.HookRequest{
+			Headers:    headers,
+			RawPayload: &raw,
+		},
+	}
+}
+
+func TestNewReplayRequest(t *testing.T) {
+	payload := `{"action":"queued","workflow_job":{"id":1}}`
+
+	d := newTestDelivery(1, payload, map[string]string{
+		"Accept":                                 "*/*",
+		"User-Agent":                             "GitHub-Hookshot/044aadd",
+		"X-GitHub-Event":                         "workflow_job",
+		"X-GitHub-Delivery":                      "72d3162e-cc78-11e3-81ab-4c9367dc0958",
+		"X-GitHub-Hook-ID":                       "292430182",
+		"X-GitHub-Hook-Installation-Target-ID":   "79929171",
+		"X-GitHub-Hook-Installation-Target-Type": "repository",
+		"X-Hub-Signature":                        "sha1=d03e5b7d9a5d2b1e5e1e1d0d4d1b6f5e6a0e8c8f",
+		"X-Hub-Signature-256":                    "sha256=stale",
+		"Content-Type":                           "application/x-www-form-urlencoded",
+		"Content-Length":                         "42",
+	})
+
+	t.Run("signed", func(t *testing.T) {
+		req, err := NewReplayRequest(context.Background(), "http://example.com/webhook", d, []byte("secret"))
+		require.NoError(t, err)
+
+		assert.Equal(t, http.MethodPost, req.Method)
+		assert.Equal(t, "application/json", req.Header.Get("Content-Type"))
+		assert.Equal(t, "workflow_job", req.Header.Get("X-GitHub-Event"))
+		assert.Equal(t, "72d3162e-cc78-11e3-81ab-4c9367dc0958", req.Header.Get("X-GitHub-Delivery"))
+		assert.Equal(t, "GitHub-Hookshot/044aadd", req.Header.Get("User-Agent"))
+		assert.Equal(t, "292430182", req.Header.Get("X-GitHub-Hook-ID"))
+		assert.Empty(t, req.Header.Get("X-Hub-Signature"))
+
+		// The target validates the payload the same way as githubwebhookserver does
+		body, err := gogithub.ValidatePayload(req, []byte("secret"))
+		require.NoError(t, err)
+		assert.Equal(t, payload, string(body))
+
+		event, err := gogithub.ParseWebHook(gogithub.WebHookType(req), body)
+		require.NoError(t, err)
+		assert.IsType(t, &gogithub.WorkflowJobEvent{}, event)
+	})
+
+	t.Run("unsigned", func(t *testing.T) {
+		req, err := NewReplayRequest(context.Background(), "http://example.com/webhook", d, nil)
+		require.NoError(t, err)
+
+		assert.Empty(t, req.Header.Get("X-Hub-Signature-256"))
+		assert.Empty(t, req.Header.Get("X-Hub-Signature"))
+	})
+
+	t.Run("headers missing from the delivery", func(t *testing.T) {
+		req, err := NewReplayRequest(context.Background(), "http://example.com/webhook", newTestDelivery(2, payload, nil), nil)
+		require.NoError(t, err)
+
+		assert.Equal(t, "workflow_job", req.Header.Get("X-GitHub-Event"))
+		assert.Equal(t, "0b989ba4-242f-11e5-81e1-c7b6966d2516", req.Header.Get("X-GitHub-Delivery"))
+	})
+
+	t.Run("no payload", func(t *testing.T) {
+		_, err := NewReplayRequest(context.Background(), "http://example.com/webhook", &gogithub.HookDelivery{ID: gogithub.Int64(3)}, nil)
+		assert.EqualError(t, err, "delivery 3 has no request payload")
+	})
+}
+
+func TestReplay(t *testing.T) {
+	status := http.StatusInternalServerError
+
+	var received []string
+
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		received = append(received, string(body))
+
+		w.WriteHeader(status)
+		io.WriteString(w, "oops")
+	}))
+	defer target.Close()
+
+	d := newTestDelivery(1, `{"action":"queued"}`, nil)
+
+	err := Replay(context.Background(), nil, target.URL, d, nil)
+	assert.EqualError(t, err, "target "+target.URL+" responded to delivery 1 with 500 Internal Server Error: oops")
+
+	var replayErr *ReplayError
+	require.ErrorAs(t, err, &replayErr)
+	assert.Equal(t, http.StatusInternalServerError, replayErr.StatusCode)
+
+	status = http.StatusAccepted
+
+	err = Replay(context.Background(), nil, target.URL, d, nil)
+	assert.NoError(t, err)
+
+	assert.Equal(t, []string{`{"action":"queued"}`, `{"action":"queued"}`}, received)
+}
@@ -0,0 +1,63 @@
+package hookdeliveryforwarder
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Shard is the share of the repositories and organizations whose deliveries a replica forwards,
+// when the deliveries are sharded across Count replicas instead of being forwarded by an elected leader.
+//
+// Repositories are assigned with rendezvous hashing, so that changing the number of replicas
+// only moves the repositories of the added or removed shards.
+type Shard struct {
+	Index int
+	Count int
+}
+
+// Owns returns true when the deliveries of repo are forwarded by this shard.
+func (s Shard) Owns(repo string) bool {
+	if s.Count <= 1 {
+		return true
+	}
+
+	var (
+		owner int
+		max   uint64
+	)
+
+	for i := 0; i < s.Count; i++ {
+		h := sha256.Sum256([]byte(fmt.Sprintf("%d/%s", i, repo)))
+
+		if sum := binary.BigEndian.Uint64(h[:8]); i == 0 || sum > max {
+			owner, max = i, sum
+		}
+	}
+
+	return owner == s.Index
+}
+
+// shardIndexFromHostname returns the ordinal of the StatefulSet pod the forwarder runs in,
+// like 2 for "hookdeliveryforwarder-2".
+func shardIndexFromHostname() (int, error) {
+	hostname, err := os.Hostname()
+	if err != nil {
+		return 0, err
+	}
+
+	i := strings.LastIndex(hostname, "-")
+	if i < 0 {
+		return 0, fmt.Errorf("hostname %q has no ordinal suffix", hostname)
+	}
+
+	index, err := strconv.Atoi(hostname[i+1:])
+	if err != nil {
+		return 0, fmt.Errorf("hostname %q has no ordinal suffix", hostname)
+	}
+
+	return index, nil
+}
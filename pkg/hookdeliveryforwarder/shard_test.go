@@ -0,0 +1,56 @@
+package hookdeliveryforwarder
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func shardOf(t *testing.T, repo string, count int) int {
+	t.Helper()
+
+	owner := -1
+
+	for i := 0; i < count; i++ {
+		if (Shard{Index: i, Count: count}).Owns(repo) {
+			if owner >= 0 {
+				t.Fatalf("%s is owned by both shard %d and %d", repo, owner, i)
+			}
+			owner = i
+		}
+	}
+
+	if owner < 0 {
+		t.Fatalf("%s is owned by no shard", repo)
+	}
+
+	return owner
+}
+
+func TestShardOwns(t *testing.T) {
+	assert.True(t, Shard{}.Owns("owner/repo"), "everything is owned without sharding")
+
+	counts := map[int]int{}
+	moved := 0
+
+	for n := 0; n < 1000; n++ {
+		repo := fmt.Sprintf("org/repo%d", n)
+
+		before := shardOf(t, repo, 3)
+		after := shardOf(t, repo, 4)
+
+		counts[after]++
+
+		if before != after {
+			assert.Equal(t, 3, after, "%s must only move to the added shard", repo)
+			moved++
+		}
+	}
+
+	for i := 0; i < 4; i++ {
+		assert.InDelta(t, 250, counts[i], 75, "shard %d", i)
+	}
+
+	assert.InDelta(t, 250, moved, 75)
+}
@@ -0,0 +1,350 @@
+package hookdeliveryforwarder
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	gogithub "github.com/google/go-github/v52/github"
+)
+
+// Sink is where a Forwarder sends hook deliveries to.
+type Sink interface {
+	Send(ctx context.Context, d *gogithub.HookDelivery) error
+}
+
+// SinkOptions are the settings shared by all the sinks.
+type SinkOptions struct {
+	// Secret is used to sign the forwarded payloads.
+	Secret []byte
+
+	// HTTPClient is used by the HTTP sink. Defaults to http.DefaultClient.
+	HTTPClient *http.Client
+}
+
+const (
+	queryRetryMaxAttempts = "retry_max_attempts"
+	queryRetryBackoff     = "retry_backoff"
+	queryRetryMaxBackoff  = "retry_max_backoff"
+)
+
+// NewSink returns the sink for the target URI, selected by its scheme:
+//
+//   - http and https POST the deliveries to the URL, see Replay.
+//   - file appends the deliveries to the spool file at the path of the URI, see FileSink.
+//     file:///var/spool/deliveries.jsonl is an absolute path, and file://spool/deliveries.jsonl a relative one.
+//   - Any scheme registered with RegisterBroker publishes the deliveries to the subject
+//     at the path of the URI, see BrokerSink. No broker is registered by default.
+//
+// Every sink retries a failed delivery with an exponential backoff, configured by the
+// retry_max_attempts, retry_backoff and retry_max_backoff query parameters of the URI,
+// which are removed from the URI before it is handed to the sink.
+func NewSink(target string, opts SinkOptions) (Sink, error) {
+	u, err := url.Parse(target)
+	if err != nil {
+		return nil, fmt.Errorf("parsing sink %q: %w", target, err)
+	}
+
+	policy, err := parseRetryPolicy(u)
+	if err != nil {
+		return nil, fmt.Errorf("parsing sink %q: %w", target, err)
+	}
+
+	var sink Sink
+
+	switch u.Scheme {
+	case "http", "https":
+		sink = &HTTPSink{
+			Target: u.String(),
+			Secret: opts.Secret,
+			Client: opts.HTTPClient,
+		}
+	case "file":
+		// The first segment of a relative path like file://spool/deliveries.jsonl is parsed as the host.
+		path := u.Host + u.Path
+		if u.Opaque != "" {
+			path = u.Opaque
+		}
+
+		if path == "" {
+			return nil, fmt.Errorf("parsing sink %q: the path to the spool file is missing", target)
+		}
+
+		sink = &FileSink{Path: path}
+	default:
+		brokersMu.RLock()
+		dial, ok := brokers[u.Scheme]
+		brokersMu.RUnlock()
+
+		if !ok {
+			return nil, fmt.Errorf("parsing sink %q: unsupported scheme %q", target, u.Scheme)
+		}
+
+		publisher, err := dial(u)
+		if err != nil {
+			return nil, fmt.Errorf("connecting to %s broker: %w", u.Scheme, err)
+		}
+
+		sink = &BrokerSink{
+			Publisher: publisher,
+			Subject:   strings.TrimPrefix(u.Path, "/"),
+			Secret:    opts.Secret,
+		}
+	}
+
+	return &retryingSink{
+		sink:   sink,
+		scheme: u.Scheme,
+		policy: policy,
+	}, nil
+}
+
+// HTTPSink POSTs the deliveries to Target, reproducing the original requests.
+type HTTPSink struct {
+	Target string
+	Secret []byte
+	Client *http.Client
+}
+
+func (s *HTTPSink) Send(ctx context.Context, d *gogithub.HookDelivery) error {
+	return Replay(ctx, s.Client, s.Target, d, s.Secret)
+}
+
+// SpooledDelivery is a line of the spool file written by FileSink.
+type SpooledDelivery struct {
+	ID          int64             `json:"id"`
+	GUID        string            `json:"guid,omitempty"`
+	Event       string            `json:"event,omitempty"`
+	Action      string            `json:"action,omitempty"`
+	DeliveredAt time.Time         `json:"delivered_at"`
+	Headers     map[string]string `json:"headers,omitempty"`
+	Payload     json.RawMessage   `json:"payload"`
+}
+
+// FileSink appends each delivery as a line of JSON to the spool file at Path,
+// so that the deliveries can be carried to and replayed in an air-gapped environment with ReadSpool.
+type FileSink struct {
+	Path string
+
+	mu sync.Mutex
+}
+
+func (s *FileSink) Send(ctx context.Context, d *gogithub.HookDelivery) error {
+	if d.Request == nil || d.Request.RawPayload == nil {
+		return fmt.Errorf("delivery %d has no request payload", d.GetID())
+	}
+
+	line, err := json.Marshal(SpooledDelivery{
+		ID:          d.GetID(),
+		GUID:        d.GetGUID(),
+		Event:       d.GetEvent(),
+		Action:      d.GetAction(),
+		DeliveredAt: d.GetDeliveredAt().Time,
+		Headers:     d.Request.Headers,
+		Payload:     *d.Request.RawPayload,
+	})
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	f, err := os.OpenFile(s.Path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+
+	return f.Close()
+}
+
+// ReadSpool calls fn with each delivery of a spool file written by FileSink, in the order they were spooled.
+func ReadSpool(r io.Reader, fn func(*gogithub.HookDelivery) error) error {
+	scanner := bufio.NewScanner(r)
+	// Payloads can be as large as 25 MB
+	scanner.Buffer(make([]byte, 64*1024), 32*1024*1024)
+
+	for n := 1; scanner.Scan(); n++ {
+		if len(strings.TrimSpace(scanner.Text())) == 0 {
+			continue
+		}
+
+		var sd SpooledDelivery
+		if err := json.Unmarshal(scanner.Bytes(), &sd); err != nil {
+			return fmt.Errorf("reading spooled delivery at line %d: %w", n, err)
+		}
+
+		payload := json.RawMessage(append([]byte(nil), sd.Payload...))
+
+		d := &gogithub.HookDelivery{
+			ID:          gogithub.Int64(sd.ID),
+			GUID:        gogithub.String(sd.GUID),
+			Event:       gogithub.String(sd.Event),
+			Action:      gogithub.String(sd.Action),
+			DeliveredAt: &gogithub.Timestamp{Time: sd.DeliveredAt},
+			Request: &gogithub.HookRequest{
+				Headers:    sd.Headers,
+				RawPayload: &payload,
+			},
+		}
+
+		if err := fn(d); err != nil {
+			return err
+		}
+	}
+
+	return scanner.Err()
+}
+
+// Message is a delivery published to a message broker.
+type Message struct {
+	// Header holds the headers the delivery would be POSTed with, like X-GitHub-Event.
+	Header http.Header
+	// Data is the payload of the delivery.
+	Data []byte
+}
+
+// Publisher publishes messages to a message broker, like a NATS subject or a Kafka topic.
+type Publisher interface {
+	Publish(ctx context.Context, subject string, msg *Message) error
+}
+
+var (
+	brokersMu sync.RWMutex
+	brokers   = map[string]func(u *url.URL) (Publisher, error){}
+)
+
+// RegisterBroker makes the message broker reachable through the URI scheme available to NewSink.
+// dial is called with the URI of each sink of the scheme.
+func RegisterBroker(scheme string, dial func(u *url.URL) (Publisher, error)) {
+	brokersMu.Lock()
+	defer brokersMu.Unlock()
+
+	brokers[scheme] = dial
+}
+
+// BrokerSink publishes each delivery to Subject.
+type BrokerSink struct {
+	Publisher Publisher
+	Subject   string
+	Secret    []byte
+}
+
+func (s *BrokerSink) Send(ctx context.Context, d *gogithub.HookDelivery) error {
+	payload, header, err := replayPayloadAndHeader(d, s.Secret)
+	if err != nil {
+		return err
+	}
+
+	return s.Publisher.Publish(ctx, s.Subject, &Message{Header: header, Data: payload})
+}
+
+type retryPolicy struct {
+	maxAttempts int
+	backoff     time.Duration
+	maxBackoff  time.Duration
+}
+
+func parseRetryPolicy(u *url.URL) (retryPolicy, error) {
+	p := retryPolicy{
+		maxAttempts: 3,
+		backoff:     time.Second,
+		maxBackoff:  30 * time.Second,
+	}
+
+	q := u.Query()
+
+	if v := q.Get(queryRetryMaxAttempts); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			return p, fmt.Errorf("%s must be a positive integer: got %q", queryRetryMaxAttempts, v)
+		}
+		p.maxAttempts = n
+	}
+
+	for _, d := range []struct {
+		key string
+		v   *time.Duration
+	}{
+		{queryRetryBackoff, &p.backoff},
+		{queryRetryMaxBackoff, &p.maxBackoff},
+	} {
+		if v := q.Get(d.key); v != "" {
+			dur, err := time.ParseDuration(v)
+			if err != nil || dur < 0 {
+				return p, fmt.Errorf("%s must be a non-negative duration: got %q", d.key, v)
+			}
+			*d.v = dur
+		}
+	}
+
+	q.Del(queryRetryMaxAttempts)
+	q.Del(queryRetryBackoff)
+	q.Del(queryRetryMaxBackoff)
+	u.RawQuery = q.Encode()
+
+	return p, nil
+}
+
+// retryingSink retries the deliveries that failed to be sent, including the ones answered with a non-2xx status,
+// with an exponential backoff.
+type retryingSink struct {
+	sink   Sink
+	scheme string
+	policy retryPolicy
+}
+
+func (s *retryingSink) Send(ctx context.Context, d *gogithub.HookDelivery) error {
+	backoff := s.policy.backoff
+
+	var err error
+
+	for attempt := 1; ; attempt++ {
+		start := time.Now()
+		err = s.sink.Send(ctx, d)
+		observeSinkAttempt(s.scheme, err, time.Since(start))
+
+		if err == nil || attempt >= s.policy.maxAttempts {
+			break
+		}
+
+		t := time.NewTimer(backoff)
+
+		select {
+		case <-t.C:
+		case <-ctx.Done():
+			t.Stop()
+			observeSinkDelivery(s.scheme, ctx.Err())
+
+			return ctx.Err()
+		}
+
+		backoff *= 2
+		if backoff > s.policy.maxBackoff {
+			backoff = s.policy.maxBackoff
+		}
+	}
+
+	observeSinkDelivery(s.scheme, err)
+
+	return err
+}
@@ -0,0 +1,265 @@
+package hookdeliveryforwarder
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	gogithub "github.com/google/go-github/v52/github"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newSinkTestDelivery(id int64) *gogithub.HookDelivery {
+	payload := json.RawMessage(`{"action":"queued","workflow_job":{"id":1}}`)
+
+	return &gogithub.HookDelivery{
+		ID:          gogithub.Int64(id),
+		GUID:        gogithub.String("guid"),
+		Event:       gogithub.String("workflow_job"),
+		Action:      gogithub.String("queued"),
+		DeliveredAt: &gogithub.Timestamp{Time: time.Date(2024, 1, 1, 0, 0, int(id), 0, time.UTC)},
+		Request: &gogithub.HookRequest{
+			Headers:    map[string]string{"X-GitHub-Event": "workflow_job", "X-GitHub-Delivery": "guid"},
+			RawPayload: &payload,
+		},
+	}
+}
+
+func TestFileSink(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "spool.jsonl")
+
+	sink, err := NewSink("file://"+path, SinkOptions{})
+	require.NoError(t, err)
+
+	for _, id := range []int64{1, 2} {
+		require.NoError(t, sink.Send(context.Background(), newSinkTestDelivery(id)))
+	}
+
+	f, err := os.Open(path)
+	require.NoError(t, err)
+	defer f.Close()
+
+	var spooled []*gogithub.HookDelivery
+
+	err = ReadSpool(f, func(d *gogithub.HookDelivery) error {
+		spooled = append(spooled, d)
+		return nil
+	})
+	require.NoError(t, err)
+
+	require.Len(t, spooled, 2)
+
+	for i, d := range spooled {
+		want := newSinkTestDelivery(int64(i + 1))
+
+		assert.Equal(t, want.GetID(), d.GetID())
+		assert.Equal(t, want.GetEvent(), d.GetEvent())
+		assert.Equal(t, want.GetAction(), d.GetAction())
+		assert.True(t, want.GetDeliveredAt().Time.Equal(d.GetDeliveredAt().Time))
+		assert.Equal(t, want.Request.Headers, d.Request.Headers)
+		assert.JSONEq(t, string(*want.Request.RawPayload), string(*d.Request.RawPayload))
+	}
+
+	_, err = NewSink("file://", SinkOptions{})
+	assert.Error(t, err)
+}
+
+func TestFileSinkRelativePath(t *testing.T) {
+	t.Chdir(t.TempDir())
+
+	for _, target := range []string{"file://spool/deliveries.jsonl", "file:spool/deliveries.jsonl"} {
+		require.NoError(t, os.RemoveAll("spool"))
+		require.NoError(t, os.Mkdir("spool", 0o755))
+
+		sink, err := NewSink(target, SinkOptions{})
+		require.NoError(t, err)
+
+		require.NoError(t, sink.Send(context.Background(), newSinkTestDelivery(1)), target)
+		assert.FileExists(t, filepath.Join("spool", "deliveries.jsonl"), target)
+	}
+}
+
+// fileBroker is a stand-in for a message broker that appends the published messages to a file.
+type fileBroker struct {
+	path string
+	mu   sync.Mutex
+}
+
+type brokerMessage struct {
+	Subject string      `json:"subject"`
+	Header  http.Header `json:"header"`
+	Data    string      `json:"data"`
+}
+
+func (b *fileBroker) Publish(ctx context.Context, subject string, msg *Message) error {
+	line, err := json.Marshal(brokerMessage{Subject: subject, Header: msg.Header, Data: string(msg.Data)})
+	if err != nil {
+		return err
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	f, err := os.OpenFile(b.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = f.Write(append(line, '\n'))
+
+	return err
+}
+
+func TestBrokerSink(t *testing.T) {
+	broker := &fileBroker{path: filepath.Join(t.TempDir(), "broker.jsonl")}
+
+	var dialed *url.URL
+
+	RegisterBroker("filebroker", func(u *url.URL) (Publisher, error) {
+		dialed = u
+		return broker, nil
+	})
+
+	sink, err := NewSink("filebroker://broker:4222/github.deliveries?retry_max_attempts=2&durable=arc", SinkOptions{Secret: []byte("secret")})
+	require.NoError(t, err)
+
+	require.Equal(t, "durable=arc", dialed.RawQuery, "retry parameters should not be passed to the broker")
+
+	require.NoError(t, sink.Send(context.Background(), newSinkTestDelivery(1)))
+
+	data, err := os.ReadFile(broker.path)
+	require.NoError(t, err)
+
+	var msg brokerMessage
+	require.NoError(t, json.Unmarshal(data, &msg))
+
+	assert.Equal(t, "github.deliveries", msg.Subject)
+	assert.Equal(t, "workflow_job", msg.Header.Get("X-GitHub-Event"))
+	assert.Equal(t, "guid", msg.Header.Get("X-GitHub-Delivery"))
+	assert.Equal(t, "sha256="+signPayload([]byte("secret"), []byte(msg.Data)), msg.Header.Get("X-Hub-Signature-256"))
+
+	_, err = NewSink("unknown://broker/subject", SinkOptions{})
+	assert.EqualError(t, err, `parsing sink "unknown://broker/subject": unsupported scheme "unknown"`)
+}
+
+type flakySink struct {
+	failures int
+	attempts int
+}
+
+func (s *flakySink) Send(ctx context.Context, d *gogithub.HookDelivery) error {
+	s.attempts++
+
+	if s.failures > 0 {
+		s.failures--
+		return errors.New("unavailable")
+	}
+
+	return nil
+}
+
+func TestRetryingSink(t *testing.T) {
+	sinkAttemptsTotal.DeleteLabelValues("flaky", resultSuccess)
+	sinkAttemptsTotal.DeleteLabelValues("flaky", resultFailure)
+	sinkDeliveriesTotal.DeleteLabelValues("flaky", resultSuccess)
+	sinkDeliveriesTotal.DeleteLabelValues("flaky", resultFailure)
+
+	policy := retryPolicy{maxAttempts: 3, backoff: time.Millisecond, maxBackoff: 2 * time.Millisecond}
+
+	flaky := &flakySink{failures: 2}
+	sink := &retryingSink{sink: flaky, scheme: "flaky", policy: policy}
+
+	require.NoError(t, sink.Send(context.Background(), newSinkTestDelivery(1)))
+	assert.Equal(t, 3, flaky.attempts)
+	assert.Equal(t, 2.0, testutil.ToFloat64(sinkAttemptsTotal.WithLabelValues("flaky", resultFailure)))
+	assert.Equal(t, 1.0, testutil.ToFloat64(sinkAttemptsTotal.WithLabelValues("flaky", resultSuccess)))
+	assert.Equal(t, 1.0, testutil.ToFloat64(sinkDeliveriesTotal.WithLabelValues("flaky", resultSuccess)))
+
+	flaky = &flakySink{failures: 3}
+	sink = &retryingSink{sink: flaky, scheme: "flaky", policy: policy}
+
+	assert.EqualError(t, sink.Send(context.Background(), newSinkTestDelivery(2)), "unavailable")
+	assert.Equal(t, 3, flaky.attempts)
+	assert.Equal(t, 1.0, testutil.ToFloat64(sinkDeliveriesTotal.WithLabelValues("flaky", resultFailure)))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	flaky = &flakySink{failures: 1}
+	sink = &retryingSink{sink: flaky, scheme: "flaky", policy: retryPolicy{maxAttempts: 3, backoff: time.Hour}}
+
+	assert.ErrorIs(t, sink.Send(ctx, newSinkTestDelivery(3)), context.Canceled)
+	assert.Equal(t, 1, flaky.attempts)
+}
+
+type rejectingSink struct {
+	attempts int
+}
+
+func (s *rejectingSink) Send(ctx context.Context, d *gogithub.HookDelivery) error {
+	s.attempts++
+
+	return &ReplayError{StatusCode: http.StatusNotFound, Status: "404 Not Found"}
+}
+
+func TestRetryingSinkRetries4xx(t *testing.T) {
+	sinkDeliveriesTotal.DeleteLabelValues("rejecting", resultFailure)
+
+	rejecting := &rejectingSink{}
+	sink := &retryingSink{sink: rejecting, scheme: "rejecting", policy: retryPolicy{maxAttempts: 3, backoff: time.Millisecond, maxBackoff: time.Millisecond}}
+
+	err := sink.Send(context.Background(), newSinkTestDelivery(1))
+
+	var replayErr *ReplayError
+	require.ErrorAs(t, err, &replayErr)
+	assert.Equal(t, 3, rejecting.attempts, "a delivery answered with a 4xx status must be retried")
+	assert.Equal(t, 1.0, testutil.ToFloat64(sinkDeliveriesTotal.WithLabelValues("rejecting", resultFailure)))
+}
+
+func TestParseRetryPolicy(t *testing.T) {
+	u, err := url.Parse("https://example.com/hook?retry_max_attempts=5&retry_backoff=2s&retry_max_backoff=1m&token=abc")
+	require.NoError(t, err)
+
+	p, err := parseRetryPolicy(u)
+	require.NoError(t, err)
+	assert.Equal(t, retryPolicy{maxAttempts: 5, backoff: 2 * time.Second, maxBackoff: time.Minute}, p)
+	assert.Equal(t, "https://example.com/hook?token=abc", u.String())
+
+	for _, q := range []string{"retry_max_attempts=0", "retry_backoff=soon", "retry_max_backoff=-1s"} {
+		u, err := url.Parse("https://example.com/hook?" + q)
+		require.NoError(t, err)
+
+		_, err = parseRetryPolicy(u)
+		assert.Error(t, err, q)
+	}
+}
+
+func TestHTTPSink(t *testing.T) {
+	var got *http.Request
+
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer target.Close()
+
+	sink, err := NewSink(target.URL+"/hook?retry_max_attempts=1", SinkOptions{HTTPClient: target.Client()})
+	require.NoError(t, err)
+
+	require.NoError(t, sink.Send(context.Background(), newSinkTestDelivery(1)))
+	require.NotNil(t, got)
+	assert.Equal(t, "/hook", got.URL.Path)
+	assert.Empty(t, got.URL.RawQuery)
+	assert.Equal(t, "workflow_job", got.Header.Get("X-GitHub-Event"))
+}
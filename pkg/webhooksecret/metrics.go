@@ -0,0 +1,44 @@
+package webhooksecret
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+func init() {
+	metrics.Registry.MustRegister(
+		webhookSecretMatchesTotal,
+		webhookSignatureRejectionsTotal,
+	)
+}
+
+const (
+	rejectReasonUnsigned         = "unsigned"
+	rejectReasonNoSecret         = "no_secret"
+	rejectReasonInvalidSignature = "invalid_signature"
+)
+
+var (
+	webhookSecretMatchesTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "github_webhook_secret_matches_total",
+			Help: "Total number of webhook payloads whose signature matched the webhook secret. Use it to tell when the old secret is no longer used while rotating it.",
+		},
+		[]string{"server", "secret"},
+	)
+	webhookSignatureRejectionsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "github_webhook_signature_rejections_total",
+			Help: "Total number of webhook payloads rejected as their signature could not be verified",
+		},
+		[]string{"server", "reason"},
+	)
+)
+
+func incMatched(server, secret string) {
+	webhookSecretMatchesTotal.WithLabelValues(server, secret).Inc()
+}
+
+func incRejected(server, reason string) {
+	webhookSignatureRejectionsTotal.WithLabelValues(server, reason).Inc()
+}
@@ -0,0 +1,278 @@
+// Package webhooksecret verifies the signatures of GitHub webhook payloads against a list of secrets,
+// so that the webhook secret can be rotated without downtime by accepting both the old and the new secret for a while.
+package webhooksecret
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+	gogithub "github.com/google/go-github/v52/github"
+)
+
+var (
+	// ErrUnsigned is returned for a payload without signature when unsigned payloads are rejected.
+	ErrUnsigned = errors.New("webhook payload is not signed")
+
+	// ErrNoSecret is returned for a signed payload when unsigned payloads are rejected but no secret is configured to verify it.
+	ErrNoSecret = errors.New("webhook payload cannot be verified as no webhook secret is configured")
+
+	// ErrInvalidSignature is returned when the signature of a payload matches none of the secrets.
+	ErrInvalidSignature = errors.New("webhook payload signature matches none of the webhook secrets")
+)
+
+// Secret is a webhook secret token.
+type Secret struct {
+	// Name identifies the secret in the metrics, without revealing it.
+	Name string
+	Key  []byte
+}
+
+// Verifier validates webhook payloads against its secrets, tried in order.
+// Payloads are accepted without verification when there are no secrets, unless RejectUnsigned is set.
+type Verifier struct {
+	// Server is the name of the webhook server, used to label the metrics.
+	Server string
+
+	// RejectUnsigned rejects the payloads without signature, and fails closed when no secret is configured,
+	// e.g. because the mounted secret file is empty by mistake.
+	RejectUnsigned bool
+
+	mu      sync.RWMutex
+	secrets []Secret
+}
+
+// NewVerifier returns a Verifier for the server with the secrets.
+func NewVerifier(server string, secrets ...Secret) *Verifier {
+	v := &Verifier{Server: server}
+	v.SetSecrets(secrets)
+
+	return v
+}
+
+// SetSecrets replaces the secrets. Secrets with empty keys are ignored.
+func (v *Verifier) SetSecrets(secrets []Secret) {
+	var nonEmpty []Secret
+
+	for _, s := range secrets {
+		if len(s.Key) > 0 {
+			nonEmpty = append(nonEmpty, s)
+		}
+	}
+
+	v.mu.Lock()
+	v.secrets = nonEmpty
+	v.mu.Unlock()
+}
+
+// Secrets returns the names of the current secrets in the order they are tried.
+func (v *Verifier) Secrets() []string {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+
+	var names []string
+	for _, s := range v.secrets {
+		names = append(names, s.Name)
+	}
+
+	return names
+}
+
+// ValidatePayload reads the payload of the webhook request, and validates its signature against the secrets in order.
+// It counts the secret that matched, or the reason the payload was rejected.
+func (v *Verifier) ValidatePayload(r *http.Request) ([]byte, error) {
+	v.mu.RLock()
+	secrets := v.secrets
+	v.mu.RUnlock()
+
+	signature := r.Header.Get(gogithub.SHA256SignatureHeader)
+	if signature == "" {
+		signature = r.Header.Get(gogithub.SHA1SignatureHeader)
+	}
+
+	if v.RejectUnsigned {
+		if signature == "" {
+			incRejected(v.Server, rejectReasonUnsigned)
+			return nil, ErrUnsigned
+		}
+
+		if len(secrets) == 0 {
+			incRejected(v.Server, rejectReasonNoSecret)
+			return nil, ErrNoSecret
+		}
+	}
+
+	if len(secrets) == 0 {
+		return io.ReadAll(r.Body)
+	}
+
+	contentType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, s := range secrets {
+		payload, err := gogithub.ValidatePayloadFromBody(contentType, bytes.NewReader(body), signature, s.Key)
+		if err == nil {
+			incMatched(v.Server, s.Name)
+			return payload, nil
+		}
+	}
+
+	incRejected(v.Server, rejectReasonInvalidSignature)
+
+	return nil, ErrInvalidSignature
+}
+
+// LoadFile reads the secrets from path, which is either a file with a secret per line, or a directory like
+// a mounted Kubernetes Secret with a secret per file.
+//
+// The secrets of a file are named after their line numbers starting at 1, and tried in order.
+// The secrets of a directory are named after the files, and tried in the lexical order of the names.
+// Blank lines and files, and files starting with ".", like the ..data symlink of mounted Secrets, are ignored.
+func LoadFile(path string) ([]Secret, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if !info.IsDir() {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+
+		var secrets []Secret
+
+		scanner := bufio.NewScanner(f)
+		for n := 1; scanner.Scan(); n++ {
+			if key := strings.TrimSpace(scanner.Text()); key != "" {
+				secrets = append(secrets, Secret{Name: strconv.Itoa(n), Key: []byte(key)})
+			}
+		}
+
+		return secrets, scanner.Err()
+	}
+
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name() < entries[j].Name() })
+
+	var secrets []Secret
+
+	for _, e := range entries {
+		if strings.HasPrefix(e.Name(), ".") {
+			continue
+		}
+
+		p := filepath.Join(path, e.Name())
+
+		// Keys of mounted Secrets are symlinks to files
+		info, err := os.Stat(p)
+		if err != nil {
+			return nil, err
+		}
+
+		if info.IsDir() {
+			continue
+		}
+
+		data, err := os.ReadFile(p)
+		if err != nil {
+			return nil, err
+		}
+
+		if key := bytes.TrimSpace(data); len(key) > 0 {
+			secrets = append(secrets, Secret{Name: e.Name(), Key: key})
+		}
+	}
+
+	return secrets, nil
+}
+
+// Watch loads the secrets from path with LoadFile followed by the static ones,
+// and reloads them whenever they change, checking every interval until ctx is done.
+// The current secrets are kept when the file can't be read.
+func (v *Verifier) Watch(ctx context.Context, log logr.Logger, path string, interval time.Duration, static ...Secret) error {
+	var last string
+
+	load := func() error {
+		secrets, err := LoadFile(path)
+		if err != nil {
+			return fmt.Errorf("loading webhook secrets from %s: %w", path, err)
+		}
+
+		secrets = append(secrets, static...)
+
+		fingerprint := fingerprint(secrets)
+		if fingerprint == last {
+			return nil
+		}
+		last = fingerprint
+
+		v.SetSecrets(secrets)
+
+		names := v.Secrets()
+		if len(names) == 0 {
+			log.Info("no webhook secrets are configured", "path", path, "rejectUnsigned", v.RejectUnsigned)
+		} else {
+			log.Info("loaded webhook secrets", "path", path, "secrets", names)
+		}
+
+		return nil
+	}
+
+	if err := load(); err != nil {
+		return err
+	}
+
+	go func() {
+		t := time.NewTicker(interval)
+		defer t.Stop()
+
+		for {
+			select {
+			case <-t.C:
+				if err := load(); err != nil {
+					log.Error(err, "keeping the current webhook secrets")
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return nil
+}
+
+func fingerprint(secrets []Secret) string {
+	var b strings.Builder
+
+	for _, s := range secrets {
+		fmt.Fprintf(&b, "%s=%x;", s.Name, s.Key)
+	}
+
+	return b.String()
+}
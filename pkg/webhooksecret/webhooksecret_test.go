@@ -0,0 +1,157 @@
+package webhooksecret
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testPayload = `{"zen":"Keep it logically awesome."}`
+
+func newTestRequest(secret string) *http.Request {
+	r := httptest.NewRequest(http.MethodPost, "/", bytes.NewBufferString(testPayload))
+	r.Header.Set("Content-Type", "application/json")
+
+	if secret != "" {
+		mac := hmac.New(sha256.New, []byte(secret))
+		mac.Write([]byte(testPayload))
+		r.Header.Set("X-Hub-Signature-256", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	}
+
+	return r
+}
+
+func resetMetrics() {
+	webhookSecretMatchesTotal.Reset()
+	webhookSignatureRejectionsTotal.Reset()
+}
+
+func TestVerifierValidatePayload(t *testing.T) {
+	resetMetrics()
+
+	v := NewVerifier("test-validate", Secret{Name: "new", Key: []byte("new")}, Secret{Name: "old", Key: []byte("old")}, Secret{Name: "empty"})
+
+	assert.Equal(t, []string{"new", "old"}, v.Secrets())
+
+	payload, err := v.ValidatePayload(newTestRequest("old"))
+	require.NoError(t, err)
+	assert.Equal(t, testPayload, string(payload))
+
+	payload, err = v.ValidatePayload(newTestRequest("new"))
+	require.NoError(t, err)
+	assert.Equal(t, testPayload, string(payload))
+
+	_, err = v.ValidatePayload(newTestRequest("other"))
+	assert.ErrorIs(t, err, ErrInvalidSignature)
+
+	_, err = v.ValidatePayload(newTestRequest(""))
+	assert.ErrorIs(t, err, ErrInvalidSignature)
+
+	assert.Equal(t, 1.0, testutil.ToFloat64(webhookSecretMatchesTotal.WithLabelValues("test-validate", "new")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(webhookSecretMatchesTotal.WithLabelValues("test-validate", "old")))
+	assert.Equal(t, 2.0, testutil.ToFloat64(webhookSignatureRejectionsTotal.WithLabelValues("test-validate", rejectReasonInvalidSignature)))
+}
+
+func TestVerifierWithoutSecrets(t *testing.T) {
+	resetMetrics()
+
+	v := NewVerifier("test-without-secrets")
+
+	// Payloads are accepted unverified by default
+	payload, err := v.ValidatePayload(newTestRequest(""))
+	require.NoError(t, err)
+	assert.Equal(t, testPayload, string(payload))
+
+	v.RejectUnsigned = true
+
+	_, err = v.ValidatePayload(newTestRequest(""))
+	assert.ErrorIs(t, err, ErrUnsigned)
+
+	// Signed payloads can't be accepted either when the secret is misconfigured
+	_, err = v.ValidatePayload(newTestRequest("secret"))
+	assert.ErrorIs(t, err, ErrNoSecret)
+
+	v.SetSecrets([]Secret{{Name: "default", Key: []byte("secret")}})
+
+	_, err = v.ValidatePayload(newTestRequest("secret"))
+	require.NoError(t, err)
+
+	_, err = v.ValidatePayload(newTestRequest(""))
+	assert.ErrorIs(t, err, ErrUnsigned)
+
+	assert.Equal(t, 2.0, testutil.ToFloat64(webhookSignatureRejectionsTotal.WithLabelValues("test-without-secrets", rejectReasonUnsigned)))
+	assert.Equal(t, 1.0, testutil.ToFloat64(webhookSignatureRejectionsTotal.WithLabelValues("test-without-secrets", rejectReasonNoSecret)))
+}
+
+func TestLoadFile(t *testing.T) {
+	dir := t.TempDir()
+
+	file := filepath.Join(dir, "secrets")
+	require.NoError(t, os.WriteFile(file, []byte("new\n\n  old  \n"), 0o600))
+
+	secrets, err := LoadFile(file)
+	require.NoError(t, err)
+	assert.Equal(t, []Secret{{Name: "1", Key: []byte("new")}, {Name: "3", Key: []byte("old")}}, secrets)
+
+	// A mounted Secret, whose keys are symlinks into the ..data directory
+	mounted := filepath.Join(dir, "mounted")
+	data := filepath.Join(mounted, "..2024_01_01")
+	require.NoError(t, os.MkdirAll(data, 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(data, "b-old"), []byte("old\n"), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(data, "a-new"), []byte("new"), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(data, "c-empty"), nil, 0o600))
+	require.NoError(t, os.Symlink("..2024_01_01", filepath.Join(mounted, "..data")))
+
+	for _, key := range []string{"a-new", "b-old", "c-empty"} {
+		require.NoError(t, os.Symlink(filepath.Join("..data", key), filepath.Join(mounted, key)))
+	}
+
+	secrets, err = LoadFile(mounted)
+	require.NoError(t, err)
+	assert.Equal(t, []Secret{{Name: "a-new", Key: []byte("new")}, {Name: "b-old", Key: []byte("old")}}, secrets)
+
+	_, err = LoadFile(filepath.Join(dir, "missing"))
+	assert.Error(t, err)
+}
+
+func TestVerifierWatch(t *testing.T) {
+	resetMetrics()
+
+	file := filepath.Join(t.TempDir(), "secrets")
+	require.NoError(t, os.WriteFile(file, []byte("old\n"), 0o600))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	v := NewVerifier("test-watch")
+
+	require.NoError(t, v.Watch(ctx, logr.Discard(), file, 10*time.Millisecond, Secret{Name: "default", Key: []byte("flag")}))
+	assert.Equal(t, []string{"1", "default"}, v.Secrets())
+
+	require.NoError(t, os.WriteFile(file, []byte("new\nold\n"), 0o600))
+	require.Eventually(t, func() bool { return len(v.Secrets()) == 3 }, time.Second, 10*time.Millisecond)
+
+	_, err := v.ValidatePayload(newTestRequest("new"))
+	require.NoError(t, err)
+	assert.Equal(t, 1.0, testutil.ToFloat64(webhookSecretMatchesTotal.WithLabelValues("test-watch", "1")))
+
+	// The current secrets are kept while the file is missing
+	require.NoError(t, os.Remove(file))
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, []string{"1", "2", "default"}, v.Secrets())
+
+	assert.Error(t, NewVerifier("test-watch-missing").Watch(ctx, logr.Discard(), file, time.Second))
+}